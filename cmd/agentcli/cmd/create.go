@@ -32,6 +32,9 @@ BASIC EXAMPLES:
   # Project with specific LLM provider and agent count
   agentcli create myproject --agents 3 --provider azure
 
+  # Self-hosted OpenAI-compatible endpoint (set base_url and model in agentflow.toml)
+  agentcli create myproject --provider openai-compatible
+
   # Interactive mode for guided setup (recommended for beginners)
   agentcli create --interactive
 
@@ -174,7 +177,7 @@ func init() {
 
 	// Basic project flags
 	createCmd.Flags().IntVarP(&numAgents, "agents", "a", 2, "Number of agents to create")
-	createCmd.Flags().StringVarP(&provider, "provider", "p", "azure", "LLM provider (openai, azure, ollama, mock)")
+	createCmd.Flags().StringVarP(&provider, "provider", "p", "azure", "LLM provider (openai, azure, ollama, anthropic, openai-compatible, mock)")
 	createCmd.Flags().BoolVar(&responsibleAI, "responsible-ai", true, "Include responsible AI agent")
 	createCmd.Flags().BoolVar(&errorHandler, "error-handler", true, "Include error handling agents")
 	createCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode for guided setup")
@@ -382,7 +385,7 @@ func validateMCPFlags() error {
 	}
 
 	// Validate provider
-	validProviders := []string{"openai", "azure", "ollama", "mock"}
+	validProviders := []string{"openai", "azure", "ollama", "anthropic", "openai-compatible", "mock"}
 	if !contains(validProviders, provider) {
 		return fmt.Errorf("invalid provider: %s. Valid options: %s", provider, strings.Join(validProviders, ", "))
 	}
@@ -688,12 +691,15 @@ func interactiveSetup() (scaffold.ProjectConfig, error) {
 	fmt.Println("2. Azure OpenAI")
 	fmt.Println("3. Ollama (local)")
 	fmt.Println("4. Mock (testing)")
-	fmt.Print("Choice (1-4): ")
+	fmt.Println("5. Anthropic")
+	fmt.Println("6. OpenAI-compatible (vLLM, LM Studio, llama.cpp server, OpenRouter)")
+	fmt.Print("Choice (1-6): ")
 	var providerChoice string
 	fmt.Scanln(&providerChoice)
 
 	providers := map[string]string{
 		"1": "openai", "2": "azure", "3": "ollama", "4": "mock",
+		"5": "anthropic", "6": "openai-compatible",
 		"": "openai", // default
 	}
 	if p, exists := providers[providerChoice]; exists {
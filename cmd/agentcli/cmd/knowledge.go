@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+	"github.com/kunalkushwaha/agenticgokit/core/loaders"
+	"github.com/spf13/cobra"
+)
+
+// Knowledge command flags
+var (
+	knowledgeConfigPath    string
+	knowledgeExclude       []string
+	knowledgeTags          []string
+	knowledgeIncludeHidden bool
+	knowledgeJSONContent   []string
+	knowledgeJSONTitle     string
+	knowledgeJSONID        string
+	knowledgeJSONMetadata  []string
+	knowledgeDryRun        bool
+)
+
+// knowledgeCmd represents the knowledge command
+var knowledgeCmd = &cobra.Command{
+	Use:   "knowledge",
+	Short: "Manage the knowledge base of the configured memory",
+	Long: `Manage the knowledge base of the memory configured in agentflow.toml.
+
+Use the subcommands to load documents into the knowledge base.`,
+}
+
+// knowledgeIngestCmd loads files into the configured memory
+var knowledgeIngestCmd = &cobra.Command{
+	Use:   "ingest <path>",
+	Short: "Load files or a directory tree into the knowledge base",
+	Long: `Loads a file, or every supported file under a directory, and ingests the documents
+into the memory configured in agentflow.toml.
+
+SUPPORTED FORMATS:
+  txt       .txt .text .log .rst .csv .tsv
+  md        .md .markdown .mdx (YAML or TOML front matter goes to the metadata)
+  web       .html .htm .xhtml (converted to text)
+  json      .json .jsonl .ndjson (see --json-content and related flags)
+  code      source files, detected by extension, file name or "#!" line
+  pdf       .pdf (text extraction; scanned PDFs are not supported)
+
+[agent_memory.documents] limits what is loaded: supported_types selects the types
+(add "json" to the default ["pdf", "txt", "md", "web", "code"] to load JSON) and
+max_file_size the size of each file. Hidden files and directories are skipped.
+Documents get IDs derived from their file paths, so ingesting a file again replaces it.
+With auto_chunk enabled, documents are split into chunks as they are ingested.
+
+EXAMPLES:
+  # Ingest a directory
+  agentcli knowledge ingest ./docs
+
+  # Skip generated files and tag every document
+  agentcli knowledge ingest . --exclude "vendor" --exclude "*.min.js" --tag project-x
+
+  # One document per JSONL record, using its "body" field as content
+  agentcli knowledge ingest faq.jsonl --json-content body --json-title question --json-id id
+
+  # List what would be ingested without touching memory
+  agentcli knowledge ingest ./docs --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runKnowledgeIngestCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(knowledgeCmd)
+	knowledgeCmd.AddCommand(knowledgeIngestCmd)
+
+	knowledgeCmd.PersistentFlags().StringVar(&knowledgeConfigPath, "config-path", "", "Path to agentflow.toml file (default: ./agentflow.toml)")
+
+	knowledgeIngestCmd.Flags().StringSliceVar(&knowledgeExclude, "exclude", nil, "Glob pattern of file or directory names, or relative paths, to skip (repeatable)")
+	knowledgeIngestCmd.Flags().StringSliceVar(&knowledgeTags, "tag", nil, "Tag added to every document (repeatable)")
+	knowledgeIngestCmd.Flags().BoolVar(&knowledgeIncludeHidden, "include-hidden", false, "Also load hidden files and directories")
+	knowledgeIngestCmd.Flags().StringSliceVar(&knowledgeJSONContent, "json-content", nil, "JSON field(s) used as document content (default: the whole record)")
+	knowledgeIngestCmd.Flags().StringVar(&knowledgeJSONTitle, "json-title", "", "JSON field used as document title")
+	knowledgeIngestCmd.Flags().StringVar(&knowledgeJSONID, "json-id", "", "JSON field used as document ID")
+	knowledgeIngestCmd.Flags().StringSliceVar(&knowledgeJSONMetadata, "json-metadata", nil, "JSON field(s) copied to the document metadata")
+	knowledgeIngestCmd.Flags().BoolVar(&knowledgeDryRun, "dry-run", false, "List the documents that would be ingested without ingesting them")
+}
+
+func runKnowledgeIngestCommand(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	path := args[0]
+
+	configPath := "agentflow.toml"
+	if knowledgeConfigPath != "" {
+		configPath = knowledgeConfigPath
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return fmt.Errorf("❌ No agentflow.toml found in current directory\n💡 Run this command from your AgentFlow project root, or specify config:\n   agentcli knowledge ingest %s --config-path /path/to/agentflow.toml", path)
+	}
+	config, err := core.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to load configuration: %v\n💡 Check your agentflow.toml file for syntax errors", err)
+	}
+	if config.AgentMemory.Provider == "" {
+		return fmt.Errorf("❌ Memory system not configured in agentflow.toml\n💡 Add [agent_memory] section to enable memory features")
+	}
+
+	opts, err := loaders.OptionsFromConfig(config.AgentMemory.Documents)
+	if err != nil {
+		return fmt.Errorf("❌ Invalid [agent_memory.documents] configuration: %v", err)
+	}
+	opts.Exclude = knowledgeExclude
+	opts.Tags = knowledgeTags
+	opts.IncludeHidden = knowledgeIncludeHidden
+	opts.JSON = loaders.JSONOptions{
+		ContentFields:  knowledgeJSONContent,
+		TitleField:     knowledgeJSONTitle,
+		IDField:        knowledgeJSONID,
+		MetadataFields: knowledgeJSONMetadata,
+	}
+
+	ctx := context.Background()
+	docs, loadErr := loaders.Load(ctx, path, opts)
+	if loadErr != nil && len(docs) == 0 {
+		return fmt.Errorf("❌ Failed to load %s: %v", path, loadErr)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("❌ No supported documents found in %s", path)
+	}
+
+	if knowledgeDryRun {
+		fmt.Fprintf(out, "📄 %d document(s) would be ingested:\n", len(docs))
+		for _, doc := range docs {
+			fmt.Fprintf(out, "  %-8s %s (%d chars)\n", doc.Type, doc.Source, len(doc.Content))
+		}
+		printLoadWarnings(out, loadErr)
+		return nil
+	}
+
+	memory, err := core.NewMemory(config.AgentMemory)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to connect to memory system: %v", err)
+	}
+	defer memory.Close()
+
+	if err := memory.IngestDocuments(ctx, docs); err != nil {
+		return fmt.Errorf("❌ Failed to ingest documents: %v", err)
+	}
+
+	fmt.Fprintf(out, "✅ Ingested %d document(s) into %s memory\n", len(docs), config.AgentMemory.Provider)
+	counts := make(map[core.DocumentType]int)
+	for _, doc := range docs {
+		counts[doc.Type]++
+	}
+	types := make([]string, 0, len(counts))
+	for docType := range counts {
+		types = append(types, string(docType))
+	}
+	sort.Strings(types)
+	for _, docType := range types {
+		fmt.Fprintf(out, "  %-8s %d\n", docType, counts[core.DocumentType(docType)])
+	}
+	printLoadWarnings(out, loadErr)
+
+	if config.AgentMemory.Provider == "memory" {
+		fmt.Fprintf(out, "💡 The in-memory provider does not persist data; documents are gone when this command exits\n")
+	}
+	return nil
+}
+
+// printLoadWarnings lists the files that failed to load.
+func printLoadWarnings(out io.Writer, err error) {
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	fmt.Fprintf(out, "⚠️  %d file(s) skipped:\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(out, "  %v\n", e)
+	}
+}
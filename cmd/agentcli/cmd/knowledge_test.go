@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKnowledgeFixture(t *testing.T) (configPath, docsDir string) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath = filepath.Join(dir, "agentflow.toml")
+	config := `
+[agent_flow]
+name = "test-project"
+
+[agent_memory]
+provider = "memory"
+connection = "memory"
+dimensions = 768
+
+[agent_memory.embedding]
+provider = "dummy"
+model = "dummy"
+
+[agent_memory.documents]
+supported_types = ["pdf", "txt", "md", "web", "code", "json"]
+auto_chunk = true
+chunk_size = 100
+chunk_overlap = 10
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	docsDir = filepath.Join(dir, "docs")
+	files := map[string]string{
+		"guide.md":     "---\ntitle: Guide\n---\n# Guide\n\nHow to set things up.\n",
+		"notes.txt":    "Some notes.",
+		"src/main.go":  "package main\n",
+		"image.png":    "\x89PNG",
+		"broken.jsonl": "{broken\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(docsDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return configPath, docsDir
+}
+
+func TestKnowledgeIngestCommand_IngestsDirectory(t *testing.T) {
+	configPath, docsDir := writeKnowledgeFixture(t)
+	knowledgeConfigPath = configPath
+	defer func() { knowledgeConfigPath = "" }()
+
+	var out bytes.Buffer
+	knowledgeIngestCmd.SetOut(&out)
+	defer knowledgeIngestCmd.SetOut(nil)
+
+	if err := runKnowledgeIngestCommand(knowledgeIngestCmd, []string{docsDir}); err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"Ingested 3 document(s) into memory memory", "md       1", "code     1", "txt      1", "1 file(s) skipped", "broken.jsonl"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestKnowledgeIngestCommand_DryRun(t *testing.T) {
+	configPath, docsDir := writeKnowledgeFixture(t)
+	knowledgeConfigPath = configPath
+	knowledgeDryRun = true
+	knowledgeExclude = []string{"src"}
+	defer func() {
+		knowledgeConfigPath = ""
+		knowledgeDryRun = false
+		knowledgeExclude = nil
+	}()
+
+	var out bytes.Buffer
+	knowledgeIngestCmd.SetOut(&out)
+	defer knowledgeIngestCmd.SetOut(nil)
+
+	if err := runKnowledgeIngestCommand(knowledgeIngestCmd, []string{docsDir}); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "2 document(s) would be ingested") || !strings.Contains(output, "guide.md") {
+		t.Errorf("unexpected dry run output:\n%s", output)
+	}
+	if strings.Contains(output, "main.go") {
+		t.Errorf("excluded file listed:\n%s", output)
+	}
+}
+
+func TestKnowledgeIngestCommand_Errors(t *testing.T) {
+	knowledgeConfigPath = filepath.Join(t.TempDir(), "missing.toml")
+	defer func() { knowledgeConfigPath = "" }()
+	if err := runKnowledgeIngestCommand(knowledgeIngestCmd, []string{"."}); err == nil || !strings.Contains(err.Error(), "No agentflow.toml") {
+		t.Errorf("expected a missing config error, got %v", err)
+	}
+
+	configPath, docsDir := writeKnowledgeFixture(t)
+	knowledgeConfigPath = configPath
+	err := runKnowledgeIngestCommand(knowledgeIngestCmd, []string{filepath.Join(docsDir, "image.png")})
+	if err == nil || !strings.Contains(err.Error(), "unsupported document format") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
@@ -908,7 +908,13 @@ func (m *MemoryDebugger) ShowConfig() error {
 	if m.config.AgentMemory.Search.HybridSearch {
 		fmt.Printf("   Keyword Weight: %.1f\n", m.config.AgentMemory.Search.KeywordWeight)
 		fmt.Printf("   Semantic Weight: %.1f\n", m.config.AgentMemory.Search.SemanticWeight)
+		if m.config.AgentMemory.Search.FusionMethod != "" {
+			fmt.Printf("   Fusion Method: %s\n", m.config.AgentMemory.Search.FusionMethod)
+		}
 		fmt.Printf("   Enable Reranking: %t\n", m.config.AgentMemory.Search.EnableReranking)
+		if m.config.AgentMemory.Search.EnableReranking && m.config.AgentMemory.Search.Reranker != "" {
+			fmt.Printf("   Reranker: %s\n", m.config.AgentMemory.Search.Reranker)
+		}
 		fmt.Printf("   Query Expansion: %t\n", m.config.AgentMemory.Search.EnableQueryExpansion)
 	}
 
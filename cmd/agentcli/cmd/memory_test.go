@@ -3,9 +3,11 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/kunalkushwaha/agenticgokit/core"
+	"github.com/spf13/cobra"
 )
 
 func TestMemoryDebugger_ShowOverview(t *testing.T) {
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+	"github.com/spf13/cobra"
+)
+
+// Resume command flags
+var (
+	resumeDir        string
+	resumeStatusOnly bool
+	resumeBinary     string
+	resumeConfigPath string
+)
+
+// resumeCmd represents the resume command
+var resumeCmd = &cobra.Command{
+	Use:   "resume <sessionID>",
+	Short: "Continue a failed sequential or loop workflow from its last checkpoint",
+	Long: `Shows the checkpoint saved for a session and continues the workflow from the
+last step that completed, so finished agents are not run again.
+
+Checkpoints are written by sequential and loop orchestrations when
+[orchestration] has a checkpoint_dir in agentflow.toml:
+
+  [orchestration]
+  mode = "sequential"
+  sequential_agents = ["researcher", "writer", "editor"]
+  checkpoint_dir = "./checkpoints"
+
+The workflow is continued by running the project with -resume <sessionID>,
+which projects created with 'agentcli create' support.
+
+EXAMPLES:
+  # Continue a session with 'go run .' in the current project
+  agentcli resume 3f2a9c1e
+
+  # Only show how far the session got
+  agentcli resume 3f2a9c1e --status
+
+  # Continue with a built binary instead of 'go run .'
+  agentcli resume 3f2a9c1e --binary ./myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: runResumeCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+
+	resumeCmd.Flags().StringVar(&resumeDir, "dir", "", "Directory containing checkpoints (default: [orchestration].checkpoint_dir from agentflow.toml)")
+	resumeCmd.Flags().BoolVar(&resumeStatusOnly, "status", false, "Show the checkpoint without resuming")
+	resumeCmd.Flags().StringVar(&resumeBinary, "binary", "", "Project binary to run with -resume (default: go run .)")
+	resumeCmd.Flags().StringVar(&resumeConfigPath, "config-path", "", "Path to agentflow.toml file (default: ./agentflow.toml)")
+}
+
+func runResumeCommand(cmd *cobra.Command, args []string) error {
+	sessionID := args[0]
+	dir, err := resolveCheckpointDir()
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := core.NewFileCheckpointStore(dir).Load(context.Background(), sessionID)
+	if errors.Is(err, core.ErrCheckpointNotFound) {
+		return fmt.Errorf("❌ No checkpoint for session %s in %s", sessionID, dir)
+	}
+	if err != nil {
+		return fmt.Errorf("❌ Failed to read checkpoint: %v", err)
+	}
+
+	out := cmd.OutOrStdout()
+	printCheckpoint(out, checkpoint)
+	if checkpoint.Completed {
+		fmt.Fprintf(out, "\n✅ Workflow already completed, nothing to resume\n")
+		return nil
+	}
+	if resumeStatusOnly {
+		return nil
+	}
+
+	name, cmdArgs := "go", []string{"run", ".", "-resume", sessionID}
+	if resumeBinary != "" {
+		name, cmdArgs = resumeBinary, []string{"-resume", sessionID}
+	}
+	fmt.Fprintf(out, "\n▶️  Resuming from step %d...\n\n", checkpoint.Step+1)
+	run := exec.Command(name, cmdArgs...)
+	run.Stdin = os.Stdin
+	run.Stdout = out
+	run.Stderr = cmd.ErrOrStderr()
+	if err := run.Run(); err != nil {
+		return fmt.Errorf("❌ Resuming session %s failed: %v", sessionID, err)
+	}
+	return nil
+}
+
+// resolveCheckpointDir returns --dir, or [orchestration].checkpoint_dir from agentflow.toml.
+func resolveCheckpointDir() (string, error) {
+	if resumeDir != "" {
+		return resumeDir, nil
+	}
+	configPath := "agentflow.toml"
+	if resumeConfigPath != "" {
+		configPath = resumeConfigPath
+	}
+	config, err := core.LoadConfig(configPath)
+	if err != nil {
+		return "", fmt.Errorf("❌ Failed to load %s: %v\n💡 Run from the project directory or pass --dir", configPath, err)
+	}
+	if config.Orchestration.CheckpointDir == "" {
+		return "", fmt.Errorf("❌ Checkpointing is not enabled\n💡 Set checkpoint_dir under [orchestration] in %s", configPath)
+	}
+	return config.Orchestration.CheckpointDir, nil
+}
+
+func printCheckpoint(out io.Writer, checkpoint *core.Checkpoint) {
+	fmt.Fprintf(out, "Checkpoint for session %s:\n\n", checkpoint.SessionID)
+	fmt.Fprintf(out, "  Workflow:        %s\n", checkpoint.Workflow)
+	fmt.Fprintf(out, "  Completed steps: %d\n", checkpoint.Step)
+	if checkpoint.Agent != "" {
+		fmt.Fprintf(out, "  Last agent:      %s\n", checkpoint.Agent)
+	}
+	if checkpoint.Error != "" {
+		fmt.Fprintf(out, "  Error:           %s\n", checkpoint.Error)
+	}
+	fmt.Fprintf(out, "  Updated:         %s\n", checkpoint.UpdatedAt.Format("2006-01-02 15:04:05"))
+}
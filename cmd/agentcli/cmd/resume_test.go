@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+func TestResumeCommand_ShowsCheckpointStatus(t *testing.T) {
+	dir := t.TempDir()
+	err := core.NewFileCheckpointStore(dir).Save(context.Background(), &core.Checkpoint{
+		SessionID: "s1",
+		Workflow:  core.OrchestrationSequential,
+		Step:      3,
+		Agent:     "editor",
+		Error:     "rate limited",
+		State:     core.NewState(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	resumeDir, resumeStatusOnly = dir, true
+	defer func() { resumeDir, resumeStatusOnly = "", false }()
+
+	var out bytes.Buffer
+	resumeCmd.SetOut(&out)
+	defer resumeCmd.SetOut(nil)
+	if err := runResumeCommand(resumeCmd, []string{"s1"}); err != nil {
+		t.Fatalf("runResumeCommand failed: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"sequential", "Completed steps: 3", "editor", "rate limited"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, output)
+		}
+	}
+
+	if err := runResumeCommand(resumeCmd, []string{"missing"}); err == nil {
+		t.Error("expected an error for a session without a checkpoint")
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+	"github.com/spf13/cobra"
+)
+
+// Usage command flags
+var (
+	usageDir        string
+	usageJSON       bool
+	usageRecords    bool
+	usageConfigPath string
+)
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage <sessionID>",
+	Short: "Show token usage and estimated cost for a session",
+	Long: `Reads the usage records written for a session (<sessionID>.usage.jsonl) and shows
+token usage and estimated cost, broken down by agent and by model.
+
+Usage records are written when [usage] is enabled in agentflow.toml with a log_dir:
+
+  [usage]
+  enabled = true
+  log_dir = "./usage"
+
+  [usage.prices."gpt-4o"]
+  input_per_million = 2.5
+  output_per_million = 10.0
+
+EXAMPLES:
+  # Summarize a session, reading usage files from [usage].log_dir
+  agentcli usage 3f2a9c1e
+
+  # Read usage files from another directory
+  agentcli usage 3f2a9c1e --dir ./logs
+
+  # List every recorded call
+  agentcli usage 3f2a9c1e --records
+
+  # Machine-readable summary
+  agentcli usage 3f2a9c1e --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUsageCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().StringVar(&usageDir, "dir", "", "Directory containing usage files (default: [usage].log_dir from agentflow.toml, or the current directory)")
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Print the summary as JSON")
+	usageCmd.Flags().BoolVar(&usageRecords, "records", false, "List every recorded call")
+	usageCmd.Flags().StringVar(&usageConfigPath, "config-path", "", "Path to agentflow.toml file (default: ./agentflow.toml)")
+}
+
+func runUsageCommand(cmd *cobra.Command, args []string) error {
+	path, err := resolveUsagePath(args[0])
+	if err != nil {
+		return fmt.Errorf("❌ %v", err)
+	}
+	records, err := core.LoadUsageRecords(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("❌ No usage file found at %s\n💡 Enable [usage] with a log_dir in agentflow.toml, or point --dir at the usage files", path)
+		}
+		return fmt.Errorf("❌ Failed to read usage file: %v", err)
+	}
+
+	sessionID := strings.TrimSuffix(filepath.Base(path), ".usage.jsonl")
+	summary := core.SummarizeUsage(sessionID, records)
+	if usageJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+
+	printUsageSummary(cmd.OutOrStdout(), summary)
+	if usageRecords {
+		printUsageRecords(cmd.OutOrStdout(), records)
+	}
+	return nil
+}
+
+// resolveUsagePath accepts a session ID or a path to a usage file.
+func resolveUsagePath(session string) (string, error) {
+	if strings.HasSuffix(session, ".usage.jsonl") {
+		return session, nil
+	}
+
+	dir := usageDir
+	if dir == "" {
+		configPath := "agentflow.toml"
+		if usageConfigPath != "" {
+			configPath = usageConfigPath
+		}
+		if _, err := os.Stat(configPath); err == nil {
+			if config, err := core.LoadConfig(configPath); err == nil {
+				dir = config.Usage.LogDir
+			}
+		}
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return core.UsageLogPath(dir, session)
+}
+
+func printUsageSummary(out io.Writer, summary core.UsageSummary) {
+	fmt.Fprintf(out, "Usage for session %s:\n\n", summary.SessionID)
+	fmt.Fprintf(out, "  Calls:             %d\n", summary.Total.Calls)
+	fmt.Fprintf(out, "  Prompt tokens:     %d\n", summary.Total.PromptTokens)
+	fmt.Fprintf(out, "  Completion tokens: %d\n", summary.Total.CompletionTokens)
+	fmt.Fprintf(out, "  Total tokens:      %d\n", summary.Total.TotalTokens)
+	fmt.Fprintf(out, "  Estimated cost:    %.4f\n", summary.Total.Cost)
+
+	printUsageTable(out, "AGENT", summary.ByAgent)
+	printUsageTable(out, "MODEL", summary.ByModel)
+}
+
+// printUsageTable prints totals grouped by key, most expensive first.
+func printUsageTable(out io.Writer, label string, totals map[string]core.UsageTotals) {
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := totals[keys[i]], totals[keys[j]]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.TotalTokens != b.TotalTokens {
+			return a.TotalTokens > b.TotalTokens
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tCALLS\tPROMPT\tCOMPLETION\tTOTAL\tCOST\n", label)
+	for _, key := range keys {
+		t := totals[key]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.4f\n", key, t.Calls, t.PromptTokens, t.CompletionTokens, t.TotalTokens, t.Cost)
+	}
+	w.Flush()
+}
+
+func printUsageRecords(out io.Writer, records []core.UsageRecord) {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tAGENT\tPROVIDER\tMODEL\tPROMPT\tCOMPLETION\tCOST")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%.4f\n",
+			r.Timestamp.Format("15:04:05.000"), r.AgentID, r.Provider, r.Model, r.PromptTokens, r.CompletionTokens, r.Cost)
+	}
+	w.Flush()
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+func TestUsageCommand_SummarizesSession(t *testing.T) {
+	dir := t.TempDir()
+	ledger := core.NewUsageLedger(core.UsageLedgerConfig{
+		Prices: core.PriceTable{"gpt-4o": {InputPerMillion: 2.5, OutputPerMillion: 10}},
+		LogDir: dir,
+	})
+	ledger.Record(core.UsageRecord{SessionID: "s1", AgentID: "researcher", Provider: "openai", Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 200})
+	ledger.Record(core.UsageRecord{SessionID: "s1", AgentID: "writer", Provider: "ollama", Model: "llama3.2", PromptTokens: 400, CompletionTokens: 100})
+
+	usageDir = dir
+	defer func() { usageDir = "" }()
+
+	var out bytes.Buffer
+	usageCmd.SetOut(&out)
+	defer usageCmd.SetOut(nil)
+	if err := runUsageCommand(usageCmd, []string{"s1"}); err != nil {
+		t.Fatalf("runUsageCommand failed: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"Total tokens:      1700", "researcher", "writer", "openai/gpt-4o", "0.0045"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, output)
+		}
+	}
+	// The most expensive agent is listed first
+	if strings.Index(output, "researcher") > strings.Index(output, "writer") {
+		t.Errorf("expected agents to be ordered by cost:\n%s", output)
+	}
+}
+
+func TestUsageCommand_MissingFile(t *testing.T) {
+	usageDir = t.TempDir()
+	defer func() { usageDir = "" }()
+
+	if err := runUsageCommand(usageCmd, []string{"missing"}); err == nil {
+		t.Error("expected an error for a session without a usage file")
+	}
+}
+
+func TestUsageCommand_RejectsPathInSessionID(t *testing.T) {
+	usageDir = t.TempDir()
+	defer func() { usageDir = "" }()
+
+	err := runUsageCommand(usageCmd, []string{"../s1"})
+	if err == nil || !strings.Contains(err.Error(), "invalid session ID") {
+		t.Errorf("expected a session ID with a path to be rejected, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package core
 
 import (
 	"context"
+	"strings"
 )
 
 // Agent defines the interface for any component that can process a State.
@@ -26,4 +27,71 @@ func (f AgentHandlerFunc) Run(ctx context.Context, event Event, state State) (Ag
 	return f(ctx, event, state)
 }
 
-// ... other agent related code ...
+// TokenEmitter receives partial output from a streaming agent while it runs.
+type TokenEmitter func(token Token)
+
+// StreamingAgentHandler is implemented by handlers that can surface partial output
+// before their final AgentResult is ready. Orchestrators call RunStream instead of Run
+// when a Runner is listening for HookAgentToken callbacks; emit is never nil. Tokens reach
+// the callbacks whole, so the final one carries the finish reason, tool calls and usage.
+type StreamingAgentHandler interface {
+	AgentHandler
+	RunStream(ctx context.Context, event Event, state State, emit TokenEmitter) (AgentResult, error)
+}
+
+// ForwardTokens drains an LLM token stream into emit and returns the response it adds up
+// to: the accumulated content plus the finish reason, tool calls and usage reported on the
+// final token. Every token is emitted whole. It stops at the first token error.
+func ForwardTokens(tokens <-chan Token, emit TokenEmitter) (Response, error) {
+	var content strings.Builder
+	var response Response
+	for token := range tokens {
+		if token.Error != nil {
+			response.Content = content.String()
+			return response, token.Error
+		}
+		content.WriteString(token.Content)
+		if token.FinishReason != "" {
+			response.FinishReason = token.FinishReason
+		}
+		if len(token.ToolCalls) > 0 {
+			response.ToolCalls = append(response.ToolCalls, token.ToolCalls...)
+		}
+		if token.Usage != nil {
+			response.Usage = *token.Usage
+		}
+		if emit != nil {
+			emit(token)
+		}
+	}
+	response.Content = content.String()
+	return response, nil
+}
+
+// agentTokenSink delivers tokens emitted by an agent to whoever started the run.
+type agentTokenSink func(ctx context.Context, event Event, state State, agentID string, token Token)
+
+type agentTokenSinkKey struct{}
+
+// withAgentTokenSink attaches a token sink to ctx so orchestrators can stream agent output.
+func withAgentTokenSink(ctx context.Context, sink agentTokenSink) context.Context {
+	return context.WithValue(ctx, agentTokenSinkKey{}, sink)
+}
+
+// runAgentHandler executes a handler on behalf of an orchestrator, attributing its model usage
+// to agentID. Streaming handlers are run through RunStream when ctx carries a token sink; all
+// others fall back to Run.
+func runAgentHandler(ctx context.Context, agentID string, handler AgentHandler, event Event, state State) (AgentResult, error) {
+	ctx = WithUsageAgent(ctx, agentID)
+	streaming, ok := handler.(StreamingAgentHandler)
+	if !ok {
+		return handler.Run(ctx, event, state)
+	}
+	sink, ok := ctx.Value(agentTokenSinkKey{}).(agentTokenSink)
+	if !ok || sink == nil {
+		return handler.Run(ctx, event, state)
+	}
+	return streaming.RunStream(ctx, event, state, func(token Token) {
+		sink(ctx, event, state, agentID, token)
+	})
+}
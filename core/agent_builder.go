@@ -105,10 +105,17 @@ func (b *AgentBuilder) WithLoopAgent(agent Agent, maxIterations int, condition f
 	b.loopConfig = LoopConfig{
 		MaxIterations: maxIterations,
 		Condition:     condition,
+		Conditions:    b.loopConfig.Conditions,
 	}
 	return b
 }
 
+// WithLoopConditions adds termination conditions to a loop agent, checked after each iteration
+func (b *AgentBuilder) WithLoopConditions(conditions ...LoopCondition) *AgentBuilder {
+	b.loopConfig.Conditions = append(b.loopConfig.Conditions, conditions...)
+	return b
+}
+
 // WithMultiAgentConfig sets the configuration for multi-agent composition
 func (b *AgentBuilder) WithMultiAgentConfig(config MultiAgentConfig) *AgentBuilder {
 	b.multiConfig = config
@@ -655,8 +662,7 @@ func (b *AgentBuilder) buildCompositeAgent() (Agent, error) {
 			Int("max_iterations", b.loopConfig.MaxIterations).
 			Dur("timeout", config.Timeout).
 			Msg("Building loop composite agent")
-		return NewLoopAgent(b.name, b.loopConfig.MaxIterations, config.Timeout,
-			b.loopConfig.Condition, b.subAgents[0]), nil
+		return NewLoopAgentWithConditions(b.name, b.loopConfig, config.Timeout, b.subAgents[0]), nil
 
 	default:
 		return nil, fmt.Errorf("unknown composition mode '%s' for agent '%s'", b.compositionMode, b.name)
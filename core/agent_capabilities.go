@@ -191,6 +191,9 @@ func (c *LLMCapability) Configure(agent CapabilityConfigurable) error {
 		return NewCapabilityError(c.Name(), "configuration",
 			fmt.Errorf("LLM provider is required"))
 	}
+	if _, err := c.Config.ModelParameters(); err != nil {
+		return NewCapabilityError(c.Name(), "configuration", err)
+	}
 
 	agent.SetLLMProvider(c.Provider, c.Config)
 
@@ -286,6 +289,66 @@ type LLMConfig struct {
 	MaxTokens      int     `toml:"max_tokens"`
 	SystemPrompt   string  `toml:"system_prompt"`
 	TimeoutSeconds int     `toml:"timeout_seconds"`
+
+	// Optional sampling and output controls; zero values use the provider's defaults
+	TopP             float64                `toml:"top_p"`
+	StopSequences    []string               `toml:"stop_sequences"`
+	PresencePenalty  float64                `toml:"presence_penalty"`
+	FrequencyPenalty float64                `toml:"frequency_penalty"`
+	Seed             *int64                 `toml:"seed"`            // Fixed seed for reproducible sampling
+	ResponseFormat   string                 `toml:"response_format"` // "text", "json_object" or "json_schema"
+	ResponseSchema   map[string]interface{} `toml:"response_schema"` // Schema for "json_schema"
+}
+
+// ModelParameters converts the configuration to the parameters of a model call. It fails
+// for an unknown response format, or "json_schema" without a response schema.
+func (c LLMConfig) ModelParameters() (ModelParameters, error) {
+	params := ModelParameters{
+		StopSequences: c.StopSequences,
+		Seed:          c.Seed,
+	}
+	if c.Temperature > 0 {
+		params.Temperature = FloatPtr(float32(c.Temperature))
+	}
+	if c.MaxTokens > 0 {
+		params.MaxTokens = Int32Ptr(int32(c.MaxTokens))
+	}
+	if c.TopP > 0 {
+		params.TopP = FloatPtr(float32(c.TopP))
+	}
+	if c.PresencePenalty != 0 {
+		params.PresencePenalty = FloatPtr(float32(c.PresencePenalty))
+	}
+	if c.FrequencyPenalty != 0 {
+		params.FrequencyPenalty = FloatPtr(float32(c.FrequencyPenalty))
+	}
+	switch ResponseFormatType(c.ResponseFormat) {
+	case "", ResponseFormatText:
+	case ResponseFormatJSONObject:
+		params.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONObject}
+	case ResponseFormatJSONSchema:
+		if c.ResponseSchema == nil {
+			return ModelParameters{}, fmt.Errorf("llm config: response_format %q requires a response_schema", c.ResponseFormat)
+		}
+		params.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONSchema, Schema: c.ResponseSchema, Strict: true}
+	default:
+		return ModelParameters{}, fmt.Errorf("llm config: unknown response_format %q (expected %q, %q or %q)",
+			c.ResponseFormat, ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema)
+	}
+	return params, nil
+}
+
+// Prompt builds a prompt for user input with the configured system prompt and parameters.
+func (c LLMConfig) Prompt(user string) (Prompt, error) {
+	params, err := c.ModelParameters()
+	if err != nil {
+		return Prompt{}, err
+	}
+	return Prompt{
+		System:     c.SystemPrompt,
+		User:       user,
+		Parameters: params,
+	}, nil
 }
 
 // DefaultLLMConfig returns sensible defaults for LLM configuration
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamingTestAgent emits its words one at a time before returning the full text.
+type streamingTestAgent struct {
+	words  []string
+	memory Memory // memory found in the context of the last RunStream
+}
+
+func (a *streamingTestAgent) Run(ctx context.Context, event Event, state State) (AgentResult, error) {
+	return a.RunStream(ctx, event, state, func(Token) {})
+}
+
+func (a *streamingTestAgent) RunStream(ctx context.Context, event Event, state State, emit TokenEmitter) (AgentResult, error) {
+	a.memory = GetMemory(ctx)
+	tokens := make(chan Token, len(a.words))
+	for i, word := range a.words {
+		tokens <- Token{Content: word, Index: i}
+	}
+	close(tokens)
+
+	response, err := ForwardTokens(tokens, emit)
+	if err != nil {
+		return AgentResult{}, err
+	}
+	out := state.Clone()
+	out.Set("response", response.Content)
+	return AgentResult{OutputState: out}, nil
+}
+
+func TestRunnerStreamsAgentTokens(t *testing.T) {
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents: map[string]AgentHandler{
+				"writer": &streamingTestAgent{words: []string{"Hello", ", ", "world"}},
+			},
+			Memory:    QuickMemory(),
+			SessionID: "stream-session",
+		},
+		OrchestrationMode: OrchestrationSequential,
+		SequentialAgents:  []string{"writer"},
+	})
+
+	var mu sync.Mutex
+	var received []string
+	var agents []string
+	done := make(chan struct{})
+	runner.RegisterCallback(HookAgentToken, "collect", func(ctx context.Context, args CallbackArgs) (State, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, args.Token.Content)
+		agents = append(agents, args.AgentID)
+		return nil, nil
+	})
+	runner.RegisterCallback(HookAfterEventHandling, "done", func(ctx context.Context, args CallbackArgs) (State, error) {
+		close(done)
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	if err := runner.Emit(NewEvent("writer", EventData{}, map[string]string{SessionIDKey: "stream-session"})); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event to finish")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 || received[0] != "Hello" || received[2] != "world" {
+		t.Fatalf("unexpected tokens: %v", received)
+	}
+	for _, agent := range agents {
+		if agent != "writer" {
+			t.Errorf("expected tokens from writer, got %q", agent)
+		}
+	}
+}
+
+func TestRunnerWithConfigStreamsAgentTokens(t *testing.T) {
+	agent := &streamingTestAgent{words: []string{"Hello", ", ", "world"}}
+	memory := QuickMemory()
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents:    map[string]AgentHandler{"writer": agent},
+		Memory:    memory,
+		SessionID: "stream-session",
+	})
+
+	var mu sync.Mutex
+	var received []string
+	done := make(chan struct{})
+	var once sync.Once
+	runner.RegisterCallback(HookAgentToken, "collect", func(ctx context.Context, args CallbackArgs) (State, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, args.Token.Content)
+		return nil, nil
+	})
+	runner.RegisterCallback(HookAfterEventHandling, "done", func(ctx context.Context, args CallbackArgs) (State, error) {
+		once.Do(func() { close(done) })
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	event := NewEvent("writer", EventData{}, map[string]string{SessionIDKey: "stream-session", RouteMetadataKey: "writer"})
+	if err := runner.Emit(event); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event to finish")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 || received[0] != "Hello" || received[2] != "world" {
+		t.Fatalf("expected the wrapped agent to stream, got %v", received)
+	}
+	if agent.memory != memory {
+		t.Error("expected memory in the streaming agent's context")
+	}
+}
+
+func TestRunAgentHandlerWithoutSinkUsesRun(t *testing.T) {
+	agent := &streamingTestAgent{words: []string{"a", "b"}}
+	result, err := runAgentHandler(context.Background(), "writer", agent, NewEvent("writer", nil, nil), NewState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := result.OutputState.Get("response"); got != "ab" {
+		t.Errorf("expected response 'ab', got %v", got)
+	}
+}
+
+func TestForwardTokensStopsOnError(t *testing.T) {
+	tokens := make(chan Token, 3)
+	tokens <- Token{Content: "partial"}
+	tokens <- Token{Error: errors.New("stream failed")}
+	tokens <- Token{Content: "ignored"}
+	close(tokens)
+
+	var emitted int
+	response, err := ForwardTokens(tokens, func(Token) { emitted++ })
+	if err == nil {
+		t.Fatal("expected stream error")
+	}
+	if response.Content != "partial" || emitted != 1 {
+		t.Errorf("expected only the first token to be forwarded, got %q (%d emitted)", response.Content, emitted)
+	}
+}
+
+func TestForwardTokensKeepsFinalTokenFields(t *testing.T) {
+	call := ToolCall{ID: "call_1", Name: "search", Arguments: map[string]interface{}{"q": "go"}}
+	tokens := make(chan Token, 2)
+	tokens <- Token{Content: "Searching"}
+	tokens <- Token{Index: 1, FinishReason: "tool_calls", ToolCalls: []ToolCall{call}, Usage: &UsageStats{TotalTokens: 12}}
+	close(tokens)
+
+	var emitted []Token
+	response, err := ForwardTokens(tokens, func(token Token) { emitted = append(emitted, token) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Content != "Searching" || response.FinishReason != "tool_calls" || response.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected response: %+v", response)
+	}
+	if len(response.ToolCalls) != 1 || response.ToolCalls[0].Name != "search" {
+		t.Errorf("expected the tool call in the response, got %+v", response.ToolCalls)
+	}
+	if len(emitted) != 2 || len(emitted[1].ToolCalls) != 1 || emitted[1].FinishReason != "tool_calls" || emitted[1].Usage == nil {
+		t.Errorf("expected the final token to be emitted whole, got %+v", emitted)
+	}
+}
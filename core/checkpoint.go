@@ -0,0 +1,315 @@
+// Package core provides workflow checkpointing and resume for AgentFlow orchestrators.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResumeMetadataKey marks an event that continues the checkpointed workflow of its session
+// instead of starting it over. Runner.Resume sets it.
+const ResumeMetadataKey = "resume_from_checkpoint"
+
+var (
+	// ErrCheckpointNotFound is returned when a session has no checkpoint.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	// ErrCheckpointCompleted is returned when resuming a workflow that already finished.
+	ErrCheckpointCompleted = errors.New("workflow already completed")
+)
+
+// Checkpoint records the progress of a sequential or loop workflow for one session.
+type Checkpoint struct {
+	SessionID string            `json:"session_id"`
+	Workflow  OrchestrationMode `json:"workflow"`
+	// Step is the number of completed steps: agents for sequential workflows,
+	// iterations for loops. A resumed workflow continues with this step.
+	Step int `json:"step"`
+	// Agent is the agent that ran last; Error is set when it failed.
+	Agent     string       `json:"agent,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Completed bool         `json:"completed"`
+	State     *SimpleState `json:"state"` // State after the last completed step
+	Event     *SimpleEvent `json:"event"` // Event that started the workflow
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CheckpointStore persists workflow checkpoints, one per session.
+type CheckpointStore interface {
+	// Save stores the checkpoint, replacing any previous one for the session.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+	// Load returns the checkpoint for a session, or ErrCheckpointNotFound.
+	Load(ctx context.Context, sessionID string) (*Checkpoint, error)
+	// Delete removes the checkpoint for a session. Deleting a missing checkpoint is not an error.
+	Delete(ctx context.Context, sessionID string) error
+	// List returns all checkpoints, most recently updated first.
+	List(ctx context.Context) ([]*Checkpoint, error)
+}
+
+// CheckpointingOrchestrator is implemented by orchestrators that save their progress after
+// each step and can resume a session from its last checkpoint.
+type CheckpointingOrchestrator interface {
+	Orchestrator
+	SetCheckpointStore(store CheckpointStore)
+	CheckpointStore() CheckpointStore
+}
+
+// =============================================================================
+// CHECKPOINT STORES
+// =============================================================================
+
+// MemoryCheckpointStore keeps checkpoints in memory. It is useful for tests and for
+// retrying failed workflows within one process.
+type MemoryCheckpointStore struct {
+	mu          sync.RWMutex
+	checkpoints map[string][]byte
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory checkpoint store.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string][]byte)}
+}
+
+// Save implements CheckpointStore.
+func (s *MemoryCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	// Stored encoded, so later changes to the state do not leak into the checkpoint
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[checkpoint.SessionID] = data
+	return nil
+}
+
+// Load implements CheckpointStore.
+func (s *MemoryCheckpointStore) Load(ctx context.Context, sessionID string) (*Checkpoint, error) {
+	s.mu.RLock()
+	data, ok := s.checkpoints[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	return decodeCheckpoint(data)
+}
+
+// Delete implements CheckpointStore.
+func (s *MemoryCheckpointStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkpoints, sessionID)
+	return nil
+}
+
+// List implements CheckpointStore.
+func (s *MemoryCheckpointStore) List(ctx context.Context) ([]*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	checkpoints := make([]*Checkpoint, 0, len(s.checkpoints))
+	for _, data := range s.checkpoints {
+		checkpoint, err := decodeCheckpoint(data)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	sortCheckpoints(checkpoints)
+	return checkpoints, nil
+}
+
+// FileCheckpointStore keeps one JSON file per session in a directory, so workflows can be
+// resumed after the process exits.
+type FileCheckpointStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileCheckpointStore creates a store writing checkpoints under dir. The directory is
+// created on the first Save.
+func NewFileCheckpointStore(dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{dir: dir}
+}
+
+// CheckpointPath returns the checkpoint file written for a session under dir.
+func CheckpointPath(dir, sessionID string) string {
+	return filepath.Join(dir, sessionID+".checkpoint.json")
+}
+
+func (s *FileCheckpointStore) path(sessionID string) (string, error) {
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\`) || sessionID == "." || sessionID == ".." {
+		return "", fmt.Errorf("invalid session ID for checkpoint: %q", sessionID)
+	}
+	return CheckpointPath(s.dir, sessionID), nil
+}
+
+// Save implements CheckpointStore. The file is replaced atomically.
+func (s *FileCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	path, err := s.path(checkpoint.SessionID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load implements CheckpointStore.
+func (s *FileCheckpointStore) Load(ctx context.Context, sessionID string) (*Checkpoint, error) {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return decodeCheckpoint(data)
+}
+
+// Delete implements CheckpointStore.
+func (s *FileCheckpointStore) Delete(ctx context.Context, sessionID string) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// List implements CheckpointStore. Unreadable files are skipped.
+func (s *FileCheckpointStore) List(ctx context.Context) ([]*Checkpoint, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.checkpoint.json"))
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]*Checkpoint, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		checkpoint, err := decodeCheckpoint(data)
+		if err != nil {
+			Logger().Warn().Str("path", path).Err(err).Msg("FileCheckpointStore: Skipping unreadable checkpoint")
+			continue
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	sortCheckpoints(checkpoints)
+	return checkpoints, nil
+}
+
+func decodeCheckpoint(data []byte) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if checkpoint.State == nil {
+		checkpoint.State = NewState()
+	}
+	return &checkpoint, nil
+}
+
+func sortCheckpoints(checkpoints []*Checkpoint) {
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].UpdatedAt.After(checkpoints[j].UpdatedAt)
+	})
+}
+
+// =============================================================================
+// ORCHESTRATOR HELPERS
+// =============================================================================
+
+// resumePoint returns the state and step a workflow starts from. Events marked with
+// ResumeMetadataKey continue from their session's checkpoint; all others start at step 0
+// with the initial state.
+func resumePoint(ctx context.Context, store CheckpointStore, workflow OrchestrationMode, event Event, initial State) (State, int, error) {
+	if resume, _ := event.GetMetadataValue(ResumeMetadataKey); resume != "true" {
+		return initial, 0, nil
+	}
+	if store == nil {
+		return nil, 0, fmt.Errorf("cannot resume %s workflow: no checkpoint store configured", workflow)
+	}
+
+	sessionID, _ := event.GetMetadataValue(SessionIDKey)
+	checkpoint, err := store.Load(ctx, sessionID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot resume session %s: %w", sessionID, err)
+	}
+	if checkpoint.Workflow != workflow {
+		return nil, 0, fmt.Errorf("cannot resume session %s: checkpoint is for a %s workflow, not %s", sessionID, checkpoint.Workflow, workflow)
+	}
+	if checkpoint.Completed {
+		return nil, 0, fmt.Errorf("cannot resume session %s: %w", sessionID, ErrCheckpointCompleted)
+	}
+
+	Logger().Info().
+		Str("session_id", sessionID).
+		Str("workflow", string(workflow)).
+		Int("step", checkpoint.Step).
+		Msg("Resuming workflow from checkpoint")
+	return checkpoint.State, checkpoint.Step, nil
+}
+
+// saveCheckpoint records a workflow's progress. Store failures are logged rather than
+// returned, so a broken store does not fail the workflow itself.
+func saveCheckpoint(ctx context.Context, store CheckpointStore, workflow OrchestrationMode, event Event, step int, agentName string, state State, completed bool, agentErr error) {
+	sessionID, _ := event.GetMetadataValue(SessionIDKey)
+	if store == nil || sessionID == "" {
+		return
+	}
+
+	snapshot := NewState()
+	snapshot.Merge(state)
+	metadata := event.GetMetadata()
+	delete(metadata, ResumeMetadataKey)
+	original := NewEvent(event.GetTargetAgentID(), event.GetData(), metadata)
+	original.SetID(event.GetID())
+	original.Timestamp = event.GetTimestamp()
+	original.SetSourceAgentID(event.GetSourceAgentID())
+
+	checkpoint := &Checkpoint{
+		SessionID: sessionID,
+		Workflow:  workflow,
+		Step:      step,
+		Agent:     agentName,
+		Completed: completed,
+		State:     snapshot,
+		Event:     original,
+		UpdatedAt: time.Now(),
+	}
+	if agentErr != nil {
+		checkpoint.Error = agentErr.Error()
+	}
+	if err := store.Save(ctx, checkpoint); err != nil {
+		Logger().Warn().Str("session_id", sessionID).Int("step", step).Err(err).Msg("Failed to save workflow checkpoint")
+	}
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// checkpointedRunner is a started runner that can resume checkpointed workflows.
+type checkpointedRunner interface {
+	Runner
+	Resumer
+}
+
+// startCheckpointedRunner starts a sequential or loop runner that checkpoints into store.
+func startCheckpointedRunner(t *testing.T, mode OrchestrationMode, sequence []string, agents map[string]AgentHandler, store CheckpointStore) checkpointedRunner {
+	t.Helper()
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents:    agents,
+			Memory:    QuickMemory(),
+			SessionID: "checkpoint-test",
+			Config:    &Config{},
+		},
+		OrchestrationMode: mode,
+		SequentialAgents:  sequence,
+		CheckpointStore:   store,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := runner.Start(ctx); err != nil {
+		cancel()
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	t.Cleanup(func() {
+		runner.Stop()
+		cancel()
+	})
+	resumable, ok := runner.(checkpointedRunner)
+	if !ok {
+		t.Fatalf("expected the runner to implement Resumer, got %T", runner)
+	}
+	return resumable
+}
+
+func TestSequentialOrchestrator_ResumesAfterLastCompletedStep(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	failPublish := true
+	step := func(name string) AgentHandler {
+		return AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			mu.Lock()
+			calls[name]++
+			fail := name == "publish" && failPublish
+			mu.Unlock()
+			if fail {
+				return AgentResult{}, errors.New("publisher unavailable")
+			}
+			output := state.Clone()
+			output.Set(name, "done")
+			return AgentResult{OutputState: output}, nil
+		})
+	}
+	sequence := []string{"research", "draft", "publish"}
+	agents := map[string]AgentHandler{}
+	for _, name := range sequence {
+		agents[name] = step(name)
+	}
+	store := NewMemoryCheckpointStore()
+	runner := startCheckpointedRunner(t, OrchestrationSequential, sequence, agents, store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	event := NewEvent("research", EventData{"topic": "solar"}, map[string]string{SessionIDKey: "report", RouteMetadataKey: "research"})
+	if _, err := runner.Run(ctx, event); err == nil {
+		t.Fatal("expected the publish step to fail")
+	}
+
+	checkpoint, err := store.Load(ctx, "report")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if checkpoint.Step != 2 || checkpoint.Agent != "publish" || checkpoint.Error == "" || checkpoint.Completed {
+		t.Errorf("unexpected checkpoint after failure: %+v", checkpoint)
+	}
+
+	mu.Lock()
+	failPublish = false
+	mu.Unlock()
+	result, err := runner.Resume(ctx, "report")
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if published, _ := result.OutputState.Get("publish"); published != "done" {
+		t.Errorf("expected the workflow to finish, got %v", result.OutputState)
+	}
+	if draft, _ := result.OutputState.Get("draft"); draft != "done" {
+		t.Errorf("expected state from completed steps to be restored, got %v", result.OutputState)
+	}
+
+	mu.Lock()
+	if calls["research"] != 1 || calls["draft"] != 1 || calls["publish"] != 2 {
+		t.Errorf("expected only the failed step to run again, got %v", calls)
+	}
+	mu.Unlock()
+
+	if _, err := runner.Resume(ctx, "report"); !errors.Is(err, ErrCheckpointCompleted) {
+		t.Errorf("expected ErrCheckpointCompleted for a finished workflow, got %v", err)
+	}
+	if _, err := runner.Resume(ctx, "unknown"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("expected ErrCheckpointNotFound, got %v", err)
+	}
+}
+
+func TestLoopOrchestrator_ResumesFromFileCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var seen []float64
+	crashAt := 3.0
+	refine := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		count := 0.0
+		if value, ok := state.Get("count"); ok {
+			count, _ = value.(float64)
+		}
+		count++
+		mu.Lock()
+		seen = append(seen, count)
+		crash := count == crashAt
+		mu.Unlock()
+		if crash {
+			return AgentResult{}, errors.New("process killed")
+		}
+		output := state.Clone()
+		output.Set("count", count)
+		return AgentResult{OutputState: output}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	first := startCheckpointedRunner(t, OrchestrationLoop, []string{"refine"}, map[string]AgentHandler{"refine": refine}, NewFileCheckpointStore(dir))
+	first.Run(ctx, NewEvent("refine", EventData{}, map[string]string{SessionIDKey: "essay", RouteMetadataKey: "refine"}))
+	first.Stop()
+
+	// A new process resumes from the checkpoint on disk
+	mu.Lock()
+	crashAt = -1
+	mu.Unlock()
+	second := startCheckpointedRunner(t, OrchestrationLoop, []string{"refine"}, map[string]AgentHandler{"refine": refine}, NewFileCheckpointStore(dir))
+	result, err := second.Resume(ctx, "essay")
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if count, _ := result.OutputState.Get("count"); count != 5.0 {
+		t.Errorf("expected the loop to finish its 5 iterations, got count %v", count)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []float64{1, 2, 3, 3, 4, 5}
+	if len(seen) != len(want) {
+		t.Fatalf("expected iterations %v, got %v", want, seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("expected iterations %v, got %v", want, seen)
+		}
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(t.TempDir())
+
+	older := &Checkpoint{SessionID: "a", Workflow: OrchestrationLoop, Step: 1, State: NewState(), UpdatedAt: time.Now().Add(-time.Minute)}
+	newer := &Checkpoint{SessionID: "b", Workflow: OrchestrationSequential, Step: 2, State: NewState(), UpdatedAt: time.Now()}
+	newer.State.Set("summary", "ok")
+	for _, checkpoint := range []*Checkpoint{older, newer} {
+		if err := store.Save(ctx, checkpoint); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	loaded, err := store.Load(ctx, "b")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if summary, _ := loaded.State.Get("summary"); summary != "ok" || loaded.Step != 2 {
+		t.Errorf("unexpected checkpoint: %+v", loaded)
+	}
+
+	checkpoints, err := store.List(ctx)
+	if err != nil || len(checkpoints) != 2 || checkpoints[0].SessionID != "b" {
+		t.Errorf("expected the newest checkpoint first, got %v (%v)", checkpoints, err)
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load(ctx, "a"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("expected ErrCheckpointNotFound after Delete, got %v", err)
+	}
+	if err := store.Save(ctx, &Checkpoint{SessionID: "../escape"}); err == nil {
+		t.Error("expected session IDs with path separators to be rejected")
+	}
+}
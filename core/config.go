@@ -24,8 +24,12 @@ type Config struct {
 	} `toml:"logging"`
 
 	Runtime struct {
-		MaxConcurrentAgents int `toml:"max_concurrent_agents"`
-		TimeoutSeconds      int `toml:"timeout_seconds"`
+		MaxConcurrentAgents int    `toml:"max_concurrent_agents"`
+		TimeoutSeconds      int    `toml:"timeout_seconds"`
+		QueueType           string `toml:"queue_type"`             // "memory" (default, priority-aware), "fifo" or "file"
+		QueuePath           string `toml:"queue_path"`             // Event log used by the "file" queue
+		ScheduleStatePath   string `toml:"schedule_state_path"`    // Last run times of [[schedules]], to catch up runs missed while down
+		MaxQueuedPerSession int    `toml:"max_queued_per_session"` // Events one session may have queued or in flight; 0 means no limit
 	} `toml:"runtime"`
 
 	// Breaking change: Agent memory configuration added
@@ -45,11 +49,20 @@ type Config struct {
 	} `toml:"error_routing"`
 	Providers map[string]map[string]interface{} `toml:"providers"`
 
+	// Provider routing configuration (fallback chains, tiers, per-agent models)
+	ProviderRouting ProviderRoutingConfigToml `toml:"provider_routing"`
+
+	// Token usage accounting, cost estimation and budgets
+	Usage UsageConfigToml `toml:"usage"`
+
 	// MCP configuration
 	MCP MCPConfigToml `toml:"mcp"`
 
 	// Orchestration configuration
 	Orchestration OrchestrationConfigToml `toml:"orchestration"`
+
+	// Events the runner emits on a cron expression or interval
+	Schedules []ScheduleConfigToml `toml:"schedules"`
 }
 
 // MemoryConfig represents memory configuration in TOML
@@ -78,6 +91,42 @@ type RetryConfigToml struct {
 	EnableJitter  bool    `toml:"enable_jitter"`
 }
 
+// ProviderRoutingConfigToml represents provider routing configuration in TOML format.
+// The chain starts with agent_flow.provider followed by Fallback, unless DefaultTier is set.
+type ProviderRoutingConfigToml struct {
+	Fallback       []string                 `toml:"fallback"`        // Providers tried after agent_flow.provider
+	DefaultTier    string                   `toml:"default_tier"`    // Tier used when no agent or tier is selected
+	Tiers          map[string][]string      `toml:"tiers"`           // Tier name -> ordered provider names
+	Agents         map[string]string        `toml:"agents"`          // Agent name -> tier or provider name
+	CircuitBreaker CircuitBreakerConfigToml `toml:"circuit_breaker"` // Per-provider circuit breaker settings
+}
+
+// enabled reports whether any routing beyond a single provider has been configured.
+func (r ProviderRoutingConfigToml) enabled() bool {
+	return len(r.Fallback) > 0 || len(r.Tiers) > 0 || len(r.Agents) > 0
+}
+
+// UsageConfigToml represents usage accounting configuration in TOML format
+type UsageConfigToml struct {
+	Enabled             bool                  `toml:"enabled"`
+	LogDir              string                `toml:"log_dir"`                // Writes <session>.usage.jsonl files for `agentcli usage`
+	MaxTokensPerSession int                   `toml:"max_tokens_per_session"` // 0 means no limit
+	MaxCostPerSession   float64               `toml:"max_cost_per_session"`   // 0 means no limit
+	Prices              map[string]ModelPrice `toml:"prices"`                 // Model, "prefix*" or provider name -> price
+}
+
+// ledgerConfig converts the TOML settings to a UsageLedgerConfig.
+func (u UsageConfigToml) ledgerConfig() UsageLedgerConfig {
+	return UsageLedgerConfig{
+		Prices: PriceTable(u.Prices),
+		Budget: UsageBudget{
+			MaxTokensPerSession: u.MaxTokensPerSession,
+			MaxCostPerSession:   u.MaxCostPerSession,
+		},
+		LogDir: u.LogDir,
+	}
+}
+
 // MCPConfigToml represents MCP configuration in TOML format
 type MCPConfigToml struct {
 	Enabled           bool                  `toml:"enabled"`
@@ -105,12 +154,37 @@ type MCPServerConfigToml struct {
 
 // OrchestrationConfigToml represents orchestration configuration in TOML format
 type OrchestrationConfigToml struct {
-	Mode                string   `toml:"mode"`                 // route, collaborative, sequential, loop, mixed
+	Mode                string   `toml:"mode"`                 // route, collaborative, sequential, loop, mixed, graph, supervisor, map-reduce
 	TimeoutSeconds      int      `toml:"timeout_seconds"`      // Overall timeout for orchestration operations
 	MaxIterations       int      `toml:"max_iterations"`       // For loop mode: maximum iterations
 	SequentialAgents    []string `toml:"sequential_agents"`    // For sequential mode: ordered list of agent names
 	CollaborativeAgents []string `toml:"collaborative_agents"` // For mixed mode: agents that run collaboratively
 	LoopAgent           string   `toml:"loop_agent"`           // For loop mode: agent to run in loop
+	CheckpointDir       string   `toml:"checkpoint_dir"`       // For sequential and loop modes: save progress here so failed workflows can be resumed
+
+	// For graph mode: agents as nodes, connected by optionally conditional edges
+	Graph OrchestrationGraphConfig `toml:"graph"`
+
+	// For supervisor mode: an LLM picks the next agent until it finishes or max_steps is reached
+	SupervisorAgents       []string          `toml:"supervisor_agents"`       // Agents the supervisor may choose (default: all registered)
+	SupervisorProvider     string            `toml:"supervisor_provider"`     // Provider from [providers] making the decisions (default: agent_flow.provider)
+	SupervisorInstructions string            `toml:"supervisor_instructions"` // Added to the supervisor's system prompt
+	MaxSteps               int               `toml:"max_steps"`               // Maximum agent runs per event (default: 10)
+	AgentDescriptions      map[string]string `toml:"agent_descriptions"`      // Agent name -> description shown to the supervisor
+
+	// For loop mode: stop before max_iterations when one of these conditions holds
+	Termination LoopTerminationConfig `toml:"termination"`
+
+	// For map-reduce mode: map_agent runs once per item of the list under items_key, then
+	// reduce_agent receives the outputs
+	MapAgent       string `toml:"map_agent"`       // Agent run on each item
+	ReduceAgent    string `toml:"reduce_agent"`    // Agent combining the outputs (optional)
+	ItemsKey       string `toml:"items_key"`       // State key holding the list
+	ItemKey        string `toml:"item_key"`        // State key the mapper finds its item under (default: map_item)
+	OutputKey      string `toml:"output_key"`      // Key collected from each mapper's output (default: the keys it changed)
+	ResultsKey     string `toml:"results_key"`     // State key the reducer finds the outputs under (default: map_results)
+	MaxConcurrency int    `toml:"max_concurrency"` // Mappers running at once (default: 10)
+	ErrorStrategy  string `toml:"error_strategy"`  // fail_fast, collect_all (default) or continue
 }
 
 // LoadConfig loads configuration from the specified TOML file path
@@ -128,7 +202,8 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Parse TOML
 	var config Config
-	if err := toml.Unmarshal(data, &config); err != nil {
+	meta, err := toml.Decode(string(data), &config)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse TOML configuration: %w", err)
 	}
 
@@ -261,7 +336,7 @@ func LoadConfig(path string) (*Config, error) {
 	if !config.AgentMemory.Embedding.CacheEmbeddings {
 		config.AgentMemory.Embedding.CacheEmbeddings = true
 	}
-	if !config.AgentMemory.Search.HybridSearch {
+	if !meta.IsDefined("agent_memory", "search", "hybrid_search") {
 		config.AgentMemory.Search.HybridSearch = true
 	}
 
@@ -289,6 +364,10 @@ func LoadConfigFromWorkingDir() (*Config, error) {
 
 // InitializeProvider creates a ModelProvider based on the configuration
 func (c *Config) InitializeProvider() (ModelProvider, error) {
+	if c.ProviderRouting.enabled() {
+		return c.InitializeProviderRouter()
+	}
+
 	provider := c.AgentFlow.Provider
 	if provider == "" {
 		return nil, fmt.Errorf("no provider specified in configuration")
@@ -300,15 +379,177 @@ func (c *Config) InitializeProvider() (ModelProvider, error) {
 		return nil, fmt.Errorf("no configuration found for provider: %s", provider)
 	}
 
-	switch provider {
+	return c.initializeProviderFromConfig(provider, providerConfig)
+}
+
+// initializeNamedProvider creates the provider configured under [providers.<name>], or the
+// default provider when name is empty.
+func (c *Config) initializeNamedProvider(name string) (ModelProvider, error) {
+	if name == "" {
+		return c.InitializeProvider()
+	}
+	providerConfig, exists := c.Providers[name]
+	if !exists {
+		return nil, fmt.Errorf("no configuration found for provider: %s", name)
+	}
+	return c.initializeProviderFromConfig(name, providerConfig)
+}
+
+// InitializeProviderRouter creates a ProviderRouter from [provider_routing], initializing every
+// provider it references. Providers that cannot be initialized (for example because an API key
+// is missing) are left out of the chains with a warning, as long as at least one remains.
+func (c *Config) InitializeProviderRouter() (*ProviderRouter, error) {
+	routing := c.ProviderRouting
+
+	fallback := uniqueNames(append([]string{c.AgentFlow.Provider}, routing.Fallback...))
+	if routing.DefaultTier != "" {
+		tierChain, ok := routing.Tiers[routing.DefaultTier]
+		if !ok {
+			return nil, fmt.Errorf("default tier %s is not defined in provider_routing.tiers", routing.DefaultTier)
+		}
+		fallback = uniqueNames(tierChain)
+	}
+
+	referenced := append([]string{}, fallback...)
+	for _, chain := range routing.Tiers {
+		referenced = append(referenced, chain...)
+	}
+	for _, target := range routing.Agents {
+		if _, isTier := routing.Tiers[target]; !isTier {
+			referenced = append(referenced, target)
+		}
+	}
+
+	providers := make(map[string]ModelProvider)
+	for _, name := range uniqueNames(referenced) {
+		providerConfig, exists := c.Providers[name]
+		if !exists {
+			return nil, fmt.Errorf("no configuration found for provider: %s", name)
+		}
+		provider, err := c.initializeProviderFromConfig(name, providerConfig)
+		if err != nil {
+			Logger().Warn().Str("provider", name).Err(err).Msg("Provider routing: skipping provider that failed to initialize")
+			continue
+		}
+		providers[name] = provider
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider routing: no provider could be initialized")
+	}
+
+	available := func(chain []string) []string {
+		var result []string
+		for _, name := range uniqueNames(chain) {
+			if _, ok := providers[name]; ok {
+				result = append(result, name)
+			}
+		}
+		return result
+	}
+
+	routerConfig := ProviderRouterConfig{
+		Providers: providers,
+		Fallback:  available(fallback),
+		Tiers:     make(map[string][]string),
+		Agents:    make(map[string]string),
+	}
+	if len(routerConfig.Fallback) == 0 {
+		return nil, fmt.Errorf("provider routing: no provider in the default chain could be initialized")
+	}
+	for tier, chain := range routing.Tiers {
+		if tierChain := available(chain); len(tierChain) > 0 {
+			routerConfig.Tiers[tier] = tierChain
+		} else {
+			Logger().Warn().Str("tier", tier).Msg("Provider routing: no provider in tier could be initialized, tier will use the default chain")
+		}
+	}
+	for agent, target := range routing.Agents {
+		_, isTier := routerConfig.Tiers[target]
+		_, isProvider := providers[target]
+		if isTier || isProvider {
+			routerConfig.Agents[agent] = target
+		}
+	}
+
+	cb := routing.CircuitBreaker
+	if cb.FailureThreshold > 0 || cb.SuccessThreshold > 0 || cb.TimeoutMs > 0 || cb.HalfOpenMaxCalls > 0 {
+		breakerConfig := DefaultCircuitBreakerConfig()
+		if cb.FailureThreshold > 0 {
+			breakerConfig.FailureThreshold = cb.FailureThreshold
+		}
+		if cb.SuccessThreshold > 0 {
+			breakerConfig.SuccessThreshold = cb.SuccessThreshold
+		}
+		if cb.TimeoutMs > 0 {
+			breakerConfig.Timeout = time.Duration(cb.TimeoutMs) * time.Millisecond
+		}
+		if cb.HalfOpenMaxCalls > 0 {
+			breakerConfig.MaxConcurrentCalls = cb.HalfOpenMaxCalls
+		}
+		routerConfig.CircuitBreaker = breakerConfig
+	}
+
+	return NewProviderRouter(routerConfig)
+}
+
+// uniqueNames drops empty and repeated names while preserving order.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// initializeProviderFromConfig creates a ModelProvider from a [providers.<name>] section,
+// wrapped so that its calls are charged to the usage ledger of the calling Runner.
+func (c *Config) initializeProviderFromConfig(name string, providerConfig map[string]interface{}) (ModelProvider, error) {
+	provider, err := c.createProviderFromConfig(name, providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewUsageTrackingProvider(provider, name, c.providerModelName(providerConfig)), nil
+}
+
+// providerModelName returns the model a provider section is configured with, for usage accounting.
+func (c *Config) providerModelName(providerConfig map[string]interface{}) string {
+	for _, key := range []string{"model", "chat_deployment", "deployment"} {
+		if model := c.getStringValue(providerConfig, key); model != "" {
+			return model
+		}
+	}
+	return ""
+}
+
+// createProviderFromConfig creates the provider described by a [providers.<name>] section.
+// The section's "type" key selects the implementation, so several endpoints of the same
+// kind can be configured side by side; without it the section name is used.
+func (c *Config) createProviderFromConfig(name string, providerConfig map[string]interface{}) (ModelProvider, error) {
+	providerType := c.getStringValue(providerConfig, "type")
+	if providerType == "" {
+		providerType = name
+	}
+
+	switch providerType {
 	case "openai":
 		return c.initializeOpenAIProvider(providerConfig)
 	case "azure":
 		return c.initializeAzureProvider(providerConfig)
 	case "ollama":
 		return c.initializeOllamaProvider(providerConfig)
+	case "anthropic":
+		return c.initializeAnthropicProvider(providerConfig)
+	case "openai-compatible":
+		return c.initializeOpenAICompatibleProvider(providerConfig)
+	case "mock":
+		return c.initializeMockProvider(name, providerConfig)
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
+		return nil, fmt.Errorf("unsupported provider: %s", providerType)
 	}
 }
 
@@ -338,9 +579,145 @@ func (c *Config) initializeOpenAIProvider(config map[string]interface{}) (ModelP
 		temperature = 0.7
 	}
 
+	// A custom base URL turns the openai provider into an OpenAI-compatible client
+	if baseURL := c.getStringValue(config, "base_url"); baseURL != "" {
+		return NewOpenAICompatibleAdapter(OpenAICompatibleAdapterOptions{
+			BaseURL:     baseURL,
+			APIKey:      apiKey,
+			Model:       model,
+			Headers:     c.getStringMapValue(config, "headers"),
+			MaxTokens:   maxTokens,
+			Temperature: float32(temperature),
+		})
+	}
+
 	return NewOpenAIAdapter(apiKey, model, maxTokens, float32(temperature))
 }
 
+// initializeAnthropicProvider creates an Anthropic provider from configuration
+func (c *Config) initializeAnthropicProvider(config map[string]interface{}) (ModelProvider, error) {
+	apiKey := c.getStringValue(config, "api_key")
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("Anthropic API key not found in configuration or ANTHROPIC_API_KEY environment variable")
+		}
+	}
+
+	model := c.getStringValue(config, "model")
+	if model == "" {
+		model = os.Getenv("ANTHROPIC_MODEL")
+	}
+
+	maxTokens := c.getIntValue(config, "max_tokens")
+	if maxTokens == 0 {
+		maxTokens = 1000
+	}
+
+	temperature := c.getFloatValue(config, "temperature")
+	if temperature == 0 {
+		temperature = 0.7
+	}
+
+	return NewAnthropicAdapter(apiKey, model, maxTokens, float32(temperature))
+}
+
+// initializeOpenAICompatibleProvider creates a provider for any server implementing the
+// OpenAI Chat Completions API (vLLM, LM Studio, llama.cpp server, OpenRouter, ...)
+func (c *Config) initializeOpenAICompatibleProvider(config map[string]interface{}) (ModelProvider, error) {
+	baseURL := c.getStringValue(config, "base_url")
+	if baseURL == "" {
+		baseURL = c.getStringValue(config, "endpoint") // alias support
+	}
+	if baseURL == "" {
+		baseURL = os.Getenv("OPENAI_COMPATIBLE_BASE_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("OpenAI-compatible base_url not found in configuration or OPENAI_COMPATIBLE_BASE_URL environment variable")
+		}
+	}
+
+	model := c.getStringValue(config, "model")
+	if model == "" {
+		model = os.Getenv("OPENAI_COMPATIBLE_MODEL")
+		if model == "" {
+			return nil, fmt.Errorf("OpenAI-compatible model not found in configuration or OPENAI_COMPATIBLE_MODEL environment variable")
+		}
+	}
+
+	// Many self-hosted servers need no key, so a missing one is not an error
+	apiKey := c.getStringValue(config, "api_key")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_COMPATIBLE_API_KEY")
+	}
+
+	maxTokens := c.getIntValue(config, "max_tokens")
+	if maxTokens == 0 {
+		maxTokens = 1000
+	}
+
+	temperature := c.getFloatValue(config, "temperature")
+	if temperature == 0 {
+		temperature = 0.7
+	}
+
+	return NewOpenAICompatibleAdapter(OpenAICompatibleAdapterOptions{
+		BaseURL:        baseURL,
+		APIKey:         apiKey,
+		Model:          model,
+		EmbeddingModel: c.getStringValue(config, "embedding_model"),
+		Headers:        c.getStringMapValue(config, "headers"),
+		MaxTokens:      maxTokens,
+		Temperature:    float32(temperature),
+	})
+}
+
+// initializeMockProvider creates a provider for offline tests. With a cassette it either
+// replays recorded interactions (mode = "replay", the default) or records the interactions
+// of record_provider into the cassette (mode = "record"). Without a cassette it answers every
+// prompt with the configured response.
+func (c *Config) initializeMockProvider(name string, config map[string]interface{}) (ModelProvider, error) {
+	cassette := c.getStringValue(config, "cassette")
+	if cassette == "" {
+		mock := NewMockProvider()
+		if response := c.getStringValue(config, "response"); response != "" {
+			mock.SetDefaultResponse(Response{Content: response, FinishReason: "stop"})
+		}
+		if dimensions := c.getIntValue(config, "embedding_dimensions"); dimensions > 0 {
+			mock.SetEmbeddingDimensions(dimensions)
+		}
+		return mock, nil
+	}
+
+	mode := c.getStringValue(config, "mode")
+	switch mode {
+	case "", "replay":
+		return NewReplayProvider(cassette)
+	case "record":
+		recordName := c.getStringValue(config, "record_provider")
+		if recordName == "" || recordName == name {
+			return nil, fmt.Errorf("mock provider %s in record mode requires record_provider to name another provider", name)
+		}
+		recordConfig, exists := c.Providers[recordName]
+		if !exists {
+			return nil, fmt.Errorf("no configuration found for record_provider: %s", recordName)
+		}
+		recordType := c.getStringValue(recordConfig, "type")
+		if recordType == "" {
+			recordType = recordName
+		}
+		if recordType == "mock" {
+			return nil, fmt.Errorf("record_provider %s cannot be a mock provider", recordName)
+		}
+		inner, err := c.createProviderFromConfig(recordName, recordConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize record_provider %s: %w", recordName, err)
+		}
+		return NewRecordingProvider(inner, cassette)
+	default:
+		return nil, fmt.Errorf("unsupported mock provider mode: %s (expected replay or record)", mode)
+	}
+}
+
 // initializeAzureProvider creates an Azure OpenAI provider from configuration
 func (c *Config) initializeAzureProvider(config map[string]interface{}) (ModelProvider, error) {
 	// Try to get from config, then fall back to environment variables
@@ -428,6 +805,21 @@ func (c *Config) getStringValue(config map[string]interface{}, key string) strin
 	return ""
 }
 
+// getStringMapValue reads an inline table such as headers = { "X-Title" = "my-app" }
+func (c *Config) getStringMapValue(config map[string]interface{}, key string) map[string]string {
+	values, ok := config[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+	return result
+}
+
 func (c *Config) getIntValue(config map[string]interface{}, key string) int {
 	if val, exists := config[key]; exists {
 		switch v := val.(type) {
@@ -610,7 +1002,7 @@ func (c *Config) ValidateOrchestrationConfig() error {
 	orch := &c.Orchestration
 
 	// Validate orchestration mode
-	validModes := []string{"route", "collaborative", "sequential", "loop", "mixed"}
+	validModes := []string{"route", "collaborative", "sequential", "loop", "mixed", "graph", "supervisor", "map-reduce"}
 	if orch.Mode == "" {
 		return fmt.Errorf("orchestration mode is required. Valid options: %v", validModes)
 	}
@@ -636,10 +1028,35 @@ func (c *Config) ValidateOrchestrationConfig() error {
 		if orch.LoopAgent == "" {
 			return fmt.Errorf("loop orchestration requires 'loop_agent' string in configuration")
 		}
+		if orch.Termination.StopWhen != "" {
+			if _, err := ParseEdgeCondition(orch.Termination.StopWhen); err != nil {
+				return fmt.Errorf("invalid termination stop_when: %w", err)
+			}
+		}
 	case "mixed":
 		if len(orch.CollaborativeAgents) == 0 && len(orch.SequentialAgents) == 0 {
 			return fmt.Errorf("mixed orchestration requires either 'collaborative_agents' or 'sequential_agents' (or both)")
 		}
+	case "graph":
+		if len(orch.Graph.Edges) == 0 && len(orch.Graph.Nodes) == 0 {
+			return fmt.Errorf("graph orchestration requires '[[orchestration.graph.nodes]]' or '[[orchestration.graph.edges]]' in configuration")
+		}
+	case "supervisor":
+		if orch.MaxSteps < 0 {
+			return fmt.Errorf("orchestration max_steps must not be negative, got %d", orch.MaxSteps)
+		}
+	case "map-reduce":
+		if orch.MapAgent == "" || orch.ItemsKey == "" {
+			return fmt.Errorf("map-reduce orchestration requires 'map_agent' and 'items_key' strings in configuration")
+		}
+		if orch.MaxConcurrency < 0 {
+			return fmt.Errorf("orchestration max_concurrency must not be negative, got %d", orch.MaxConcurrency)
+		}
+		switch ErrorHandlingStrategy(orch.ErrorStrategy) {
+		case "", ErrorStrategyFailFast, ErrorStrategyCollectAll, ErrorStrategyContinue:
+		default:
+			return fmt.Errorf("invalid orchestration error_strategy '%s': valid options are fail_fast, collect_all and continue", orch.ErrorStrategy)
+		}
 	}
 
 	// Validate timeout
@@ -66,3 +66,57 @@ func TestInitializeProvider_UnknownProvider(t *testing.T) {
 		t.Error("Expected error for unknown provider, got nil")
 	}
 }
+
+func TestInitializeProvider_OpenAICompatibleSection(t *testing.T) {
+	file := "test_compatible_agentflow.toml"
+	content := `[agent_flow]
+name = "TestAgent"
+provider = "local-vllm"
+
+[providers.local-vllm]
+type = "openai-compatible"
+base_url = "http://localhost:8000/v1"
+model = "meta-llama/Llama-3.1-8B-Instruct"
+headers = { "X-Title" = "agentflow" }
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	defer os.Remove(file)
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if headers := cfg.getStringMapValue(cfg.Providers["local-vllm"], "headers"); headers["X-Title"] != "agentflow" {
+		t.Errorf("headers not parsed: %v", headers)
+	}
+	provider, err := cfg.InitializeProvider()
+	if err != nil {
+		t.Fatalf("InitializeProvider failed: %v", err)
+	}
+	if provider == nil {
+		t.Fatal("InitializeProvider returned nil provider")
+	}
+}
+
+func TestInitializeProvider_OpenAICompatibleRequiresModel(t *testing.T) {
+	t.Setenv("OPENAI_COMPATIBLE_MODEL", "")
+	cfg := &Config{Providers: map[string]map[string]interface{}{
+		"openai-compatible": {"base_url": "http://localhost:1234/v1"},
+	}}
+	cfg.AgentFlow.Provider = "openai-compatible"
+	if _, err := cfg.InitializeProvider(); err == nil {
+		t.Error("Expected error for missing model, got nil")
+	}
+}
+
+func TestInitializeProvider_Anthropic(t *testing.T) {
+	cfg := &Config{Providers: map[string]map[string]interface{}{
+		"anthropic": {"api_key": "test-key"},
+	}}
+	cfg.AgentFlow.Provider = "anthropic"
+	if _, err := cfg.InitializeProvider(); err != nil {
+		t.Fatalf("InitializeProvider failed: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Package core provides event priorities and a fair, priority-aware event queue for AgentFlow.
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PriorityKey is the metadata key holding an event's priority: "low", "normal", "high",
+// "critical" or an integer. Events without it have PriorityNormal.
+const PriorityKey = "priority"
+
+// ErrSessionQueueFull is returned by Emit when a session already has the maximum number of
+// queued events allowed by RunnerImpl.SetMaxQueuedPerSession.
+var ErrSessionQueueFull = errors.New("session has too many queued events")
+
+// EventPriority orders events waiting in a PriorityEventQueue. Higher values go first.
+type EventPriority int
+
+const (
+	PriorityLow      EventPriority = -10
+	PriorityNormal   EventPriority = 0
+	PriorityHigh     EventPriority = 10
+	PriorityCritical EventPriority = 20
+)
+
+var priorityNames = map[string]EventPriority{
+	"low":      PriorityLow,
+	"normal":   PriorityNormal,
+	"high":     PriorityHigh,
+	"critical": PriorityCritical,
+}
+
+// String returns the priority's name, or its number when it has none
+func (p EventPriority) String() string {
+	for name, priority := range priorityNames {
+		if priority == p {
+			return name
+		}
+	}
+	return strconv.Itoa(int(p))
+}
+
+// ParseEventPriority parses a priority name or integer
+func ParseEventPriority(s string) (EventPriority, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if priority, ok := priorityNames[s]; ok {
+		return priority, true
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return EventPriority(n), true
+	}
+	return PriorityNormal, false
+}
+
+// GetEventPriority returns the priority in an event's metadata, or PriorityNormal
+func GetEventPriority(event Event) EventPriority {
+	value, ok := event.GetMetadataValue(PriorityKey)
+	if !ok {
+		return PriorityNormal
+	}
+	priority, _ := ParseEventPriority(value)
+	return priority
+}
+
+// SetEventPriority sets an event's priority. Follow-up events the runner emits for its
+// session inherit it.
+func SetEventPriority(event Event, priority EventPriority) {
+	event.SetMetadata(PriorityKey, priority.String())
+}
+
+// PriorityEventQueue is a bounded, in-memory EventQueue that is fair between sessions.
+// Each session's events stay in order; Pop takes the session whose next event has the
+// highest priority, and rotates between sessions of equal priority, so a burst of events
+// in one session does not hold back the others. It is the Runner's default queue.
+type PriorityEventQueue struct {
+	mu       sync.Mutex
+	capacity int
+	size     int
+	sessions map[string][]Event
+	order    []string // Sessions with waiting events, least recently served first
+	notEmpty chan struct{}
+	notFull  chan struct{}
+	closed   bool
+	done     chan struct{}
+}
+
+// NewPriorityEventQueue creates a queue holding at most capacity events.
+func NewPriorityEventQueue(capacity int) *PriorityEventQueue {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	return &PriorityEventQueue{
+		capacity: capacity,
+		sessions: make(map[string][]Event),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Push implements EventQueue.
+func (q *PriorityEventQueue) Push(ctx context.Context, event Event) error {
+	sessionID, _ := event.GetMetadataValue(SessionIDKey)
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return ErrQueueClosed
+		}
+		if q.size < q.capacity {
+			if _, waiting := q.sessions[sessionID]; !waiting {
+				q.order = append(q.order, sessionID)
+			}
+			q.sessions[sessionID] = append(q.sessions[sessionID], event)
+			q.size++
+			q.signal(q.notEmpty, q.size > 0)
+			q.mu.Unlock()
+			return nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notFull:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.done:
+			return ErrQueueClosed
+		}
+	}
+}
+
+// Pop implements EventQueue.
+func (q *PriorityEventQueue) Pop(ctx context.Context) (Event, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+		if q.size > 0 {
+			event := q.popLocked()
+			q.signal(q.notEmpty, q.size > 0)
+			q.signal(q.notFull, true)
+			q.mu.Unlock()
+			return event, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notEmpty:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.done:
+			return nil, ErrQueueClosed
+		}
+	}
+}
+
+// popLocked removes the next event. It must be called with q.mu held and q.size > 0.
+func (q *PriorityEventQueue) popLocked() Event {
+	best := 0
+	bestPriority := GetEventPriority(q.sessions[q.order[0]][0])
+	for i := 1; i < len(q.order); i++ {
+		if priority := GetEventPriority(q.sessions[q.order[i]][0]); priority > bestPriority {
+			best, bestPriority = i, priority
+		}
+	}
+
+	sessionID := q.order[best]
+	events := q.sessions[sessionID]
+	event := events[0]
+	events[0] = nil
+	q.size--
+
+	// The session goes to the back of the rotation
+	q.order = append(q.order[:best], q.order[best+1:]...)
+	if len(events) > 1 {
+		q.sessions[sessionID] = events[1:]
+		q.order = append(q.order, sessionID)
+	} else {
+		delete(q.sessions, sessionID)
+	}
+	return event
+}
+
+// signal wakes one waiter on ch when cond holds. It must be called with q.mu held.
+func (q *PriorityEventQueue) signal(ch chan struct{}, cond bool) {
+	if !cond {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Ack implements EventQueue. Memory queues keep nothing to acknowledge.
+func (q *PriorityEventQueue) Ack(Event) error { return nil }
+
+// Recover implements EventQueue. Memory queues have nothing to recover.
+func (q *PriorityEventQueue) Recover() ([]Event, error) { return nil, nil }
+
+// Len returns the number of events waiting to be popped.
+func (q *PriorityEventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Close implements EventQueue.
+func (q *PriorityEventQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	return nil
+}
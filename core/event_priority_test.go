@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func priorityEvent(sessionID string, seq int, priority EventPriority) Event {
+	event := NewEvent("worker", EventData{"seq": seq}, map[string]string{SessionIDKey: sessionID, RouteMetadataKey: "worker"})
+	SetEventPriority(event, priority)
+	return event
+}
+
+func TestParseEventPriority(t *testing.T) {
+	cases := map[string]EventPriority{"high": PriorityHigh, " Critical ": PriorityCritical, "low": PriorityLow, "5": 5, "-3": -3}
+	for input, want := range cases {
+		if got, ok := ParseEventPriority(input); !ok || got != want {
+			t.Errorf("ParseEventPriority(%q) = %v, %v; want %v", input, got, ok, want)
+		}
+	}
+	if _, ok := ParseEventPriority("urgent"); ok {
+		t.Error("expected an unknown priority name to be rejected")
+	}
+	if got := GetEventPriority(NewEvent("a", nil, nil)); got != PriorityNormal {
+		t.Errorf("expected events without a priority to be normal, got %v", got)
+	}
+}
+
+func TestPriorityEventQueue_HighestPriorityFirst(t *testing.T) {
+	queue := NewPriorityEventQueue(10)
+	ctx := context.Background()
+	queue.Push(ctx, priorityEvent("a", 0, PriorityLow))
+	queue.Push(ctx, priorityEvent("b", 0, PriorityNormal))
+	queue.Push(ctx, priorityEvent("c", 0, PriorityCritical))
+	queue.Push(ctx, priorityEvent("d", 0, PriorityHigh))
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		sessionID, _ := popWithTimeout(t, queue).GetMetadataValue(SessionIDKey)
+		got = append(got, sessionID)
+	}
+	if want := "c d b a"; strings.Join(got, " ") != want {
+		t.Errorf("expected order %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestPriorityEventQueue_RotatesBetweenSessions(t *testing.T) {
+	queue := NewPriorityEventQueue(20)
+	ctx := context.Background()
+	// A burst in one session followed by a single event in another
+	for seq := 0; seq < 5; seq++ {
+		queue.Push(ctx, priorityEvent("burst", seq, PriorityNormal))
+	}
+	queue.Push(ctx, priorityEvent("user", 0, PriorityNormal))
+
+	var sessions []string
+	lastSeq := -1
+	for i := 0; i < 6; i++ {
+		event := popWithTimeout(t, queue)
+		sessionID, _ := event.GetMetadataValue(SessionIDKey)
+		sessions = append(sessions, sessionID)
+		if sessionID == "burst" {
+			seq := event.GetData()["seq"].(int)
+			if seq != lastSeq+1 {
+				t.Errorf("burst session events out of order: %d after %d", seq, lastSeq)
+			}
+			lastSeq = seq
+		}
+	}
+	if sessions[1] != "user" {
+		t.Errorf("expected the other session to be served second, got %v", sessions)
+	}
+}
+
+func TestPriorityEventQueue_KeepsSessionOrder(t *testing.T) {
+	queue := NewPriorityEventQueue(10)
+	ctx := context.Background()
+	// A high priority event does not overtake earlier events of its own session
+	queue.Push(ctx, priorityEvent("s", 0, PriorityNormal))
+	queue.Push(ctx, priorityEvent("s", 1, PriorityHigh))
+	queue.Push(ctx, priorityEvent("t", 0, PriorityNormal))
+
+	first := popWithTimeout(t, queue)
+	if sessionID, _ := first.GetMetadataValue(SessionIDKey); sessionID != "s" || first.GetData()["seq"] != 0 {
+		t.Fatalf("unexpected first event: %v %v", sessionID, first.GetData())
+	}
+	second := popWithTimeout(t, queue)
+	if second.GetData()["seq"] != 1 || GetEventPriority(second) != PriorityHigh {
+		t.Errorf("expected the session's high priority event next, got %v", second.GetData())
+	}
+}
+
+func TestPriorityEventQueue_BoundedPush(t *testing.T) {
+	queue := NewPriorityEventQueue(1)
+	if err := queue.Push(context.Background(), NewEvent("a", nil, nil)); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := queue.Push(ctx, NewEvent("b", nil, nil)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a full queue to block until the deadline, got %v", err)
+	}
+
+	pushed := make(chan error, 1)
+	go func() { pushed <- queue.Push(context.Background(), NewEvent("c", nil, nil)) }()
+	popWithTimeout(t, queue)
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Errorf("Push failed after Pop made room: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Push stayed blocked after Pop made room")
+	}
+
+	queue.Close()
+	if _, err := queue.Pop(context.Background()); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestRunner_ProcessesHigherPriorityEventsFirst(t *testing.T) {
+	release := make(chan struct{})
+	order := make(chan string, 4)
+	runner := startTestRunner(t, 1, map[string]AgentHandler{"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		sessionID, _ := event.GetMetadataValue(SessionIDKey)
+		if sessionID == "blocker" {
+			<-release
+		}
+		order <- sessionID
+		return AgentResult{OutputState: NewState()}, nil
+	})})
+
+	emitToWorker(t, runner, "blocker", EventData{})
+	time.Sleep(20 * time.Millisecond) // Let the only worker pick up the blocker
+	for _, event := range []Event{
+		priorityEvent("low", 0, PriorityLow),
+		priorityEvent("normal", 0, PriorityNormal),
+		priorityEvent("high", 0, PriorityHigh),
+	} {
+		if err := runner.Emit(event); err != nil {
+			t.Fatalf("Emit failed: %v", err)
+		}
+	}
+	close(release)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		select {
+		case sessionID := <-order:
+			got = append(got, sessionID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	if want := "blocker high normal low"; strings.Join(got, " ") != want {
+		t.Errorf("expected order %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestRunner_MaxQueuedPerSession(t *testing.T) {
+	release := make(chan struct{})
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents: map[string]AgentHandler{"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			<-release
+			return AgentResult{OutputState: NewState()}, nil
+		})},
+		Memory:              QuickMemory(),
+		SessionID:           "runner-test",
+		MaxQueuedPerSession: 2,
+		Config:              &Config{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+	defer close(release)
+
+	emitToWorker(t, runner, "busy", EventData{})
+	emitToWorker(t, runner, "busy", EventData{})
+	err := runner.Emit(NewEvent("worker", EventData{}, map[string]string{SessionIDKey: "busy"}))
+	if !errors.Is(err, ErrSessionQueueFull) {
+		t.Errorf("expected ErrSessionQueueFull, got %v", err)
+	}
+	// Other sessions are not affected
+	emitToWorker(t, runner, "other", EventData{})
+}
+
+func TestRunner_FollowUpEventsInheritPriority(t *testing.T) {
+	priorities := make(chan EventPriority, 2)
+	runner := startTestRunner(t, 1, map[string]AgentHandler{
+		"first": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			out := NewState()
+			out.SetMeta(RouteMetadataKey, "second")
+			return AgentResult{OutputState: out}, nil
+		}),
+		"second": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			priorities <- GetEventPriority(event)
+			return AgentResult{OutputState: NewState()}, nil
+		}),
+	})
+
+	event := NewEvent("first", EventData{}, map[string]string{SessionIDKey: "s", RouteMetadataKey: "first"})
+	SetEventPriority(event, PriorityCritical)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	runner.Run(ctx, event)
+
+	select {
+	case got := <-priorities:
+		if got != PriorityCritical {
+			t.Errorf("expected the success event to keep the critical priority, got %v", got)
+		}
+	default:
+		t.Fatal("the follow-up event was not processed")
+	}
+}
@@ -0,0 +1,464 @@
+// Package core provides pluggable event queues, including a durable file-backed queue, for AgentFlow.
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQueueClosed is returned by queue operations after Close.
+var ErrQueueClosed = errors.New("event queue closed")
+
+// EventQueue holds the events a Runner has accepted but not yet processed.
+// Implementations must be safe for concurrent use.
+type EventQueue interface {
+	// Push adds an event to the back of the queue. It blocks while a bounded queue is
+	// full, until ctx is done. Durable queues persist the event before returning.
+	Push(ctx context.Context, event Event) error
+
+	// Pop removes and returns the event at the front of the queue, blocking until one
+	// is available or ctx is done.
+	Pop(ctx context.Context) (Event, error)
+
+	// Ack marks an event as processed. Durable queues stop replaying it.
+	Ack(event Event) error
+
+	// Recover requeues events that were pushed earlier, e.g. before a crash or restart,
+	// and never acknowledged, returning them in their original order. The Runner calls
+	// it on Start.
+	Recover() ([]Event, error)
+
+	// Close releases the queue's resources.
+	Close() error
+}
+
+// MemoryEventQueue is a bounded, in-memory FIFO EventQueue. Events are lost when the process
+// exits. Unlike PriorityEventQueue it ignores priorities and sessions.
+type MemoryEventQueue struct {
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryEventQueue creates an in-memory queue holding at most size events.
+func NewMemoryEventQueue(size int) *MemoryEventQueue {
+	if size <= 0 {
+		size = 100
+	}
+	return &MemoryEventQueue{
+		events: make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+}
+
+// Push implements EventQueue.
+func (q *MemoryEventQueue) Push(ctx context.Context, event Event) error {
+	select {
+	case <-q.done:
+		return ErrQueueClosed
+	default:
+	}
+	select {
+	case q.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-q.done:
+		return ErrQueueClosed
+	}
+}
+
+// Pop implements EventQueue.
+func (q *MemoryEventQueue) Pop(ctx context.Context) (Event, error) {
+	select {
+	case event := <-q.events:
+		return event, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.done:
+		return nil, ErrQueueClosed
+	}
+}
+
+// Ack implements EventQueue. Memory queues keep nothing to acknowledge.
+func (q *MemoryEventQueue) Ack(Event) error { return nil }
+
+// Recover implements EventQueue. Memory queues have nothing to recover.
+func (q *MemoryEventQueue) Recover() ([]Event, error) { return nil, nil }
+
+// Close implements EventQueue.
+func (q *MemoryEventQueue) Close() error {
+	q.once.Do(func() { close(q.done) })
+	return nil
+}
+
+// FileEventQueueOptions configures a FileEventQueue.
+type FileEventQueueOptions struct {
+	// SyncWrites calls fsync after every write, so events also survive power loss and
+	// not just process crashes. It is slower.
+	SyncWrites bool
+	// CompactAfter rewrites the log once this many acknowledgements have been appended,
+	// dropping processed events. Defaults to 1000.
+	CompactAfter int
+}
+
+// FileEventQueue is a durable EventQueue backed by an append-only log of JSON lines.
+// Every pushed event and every acknowledgement is appended to the log; events that were
+// pushed but not acknowledged when the process stopped are replayed by Recover. The queue
+// is unbounded, so Push never blocks.
+//
+// Event data round-trips through JSON: numbers come back as float64 and structs as maps.
+type FileEventQueue struct {
+	mu      sync.Mutex
+	path    string
+	options FileEventQueueOptions
+	file    *os.File
+
+	ready   []Event          // Events waiting to be popped, in order
+	unacked map[string]Event // Events persisted but not acknowledged, by ID
+	order   []string         // IDs of unacked events in push order
+	acks    int              // Acknowledgements appended since the last compaction
+	notify  chan struct{}
+	closed  bool
+}
+
+// fileQueueRecord is one line of a FileEventQueue log.
+type fileQueueRecord struct {
+	Op    string           `json:"op"` // "push" or "ack"
+	ID    string           `json:"id"`
+	Event *persistentEvent `json:"event,omitempty"`
+}
+
+// persistentEvent is the stored form of an Event.
+type persistentEvent struct {
+	ID            string            `json:"id"`
+	Timestamp     time.Time         `json:"timestamp"`
+	TargetAgentID string            `json:"target_agent_id,omitempty"`
+	SourceAgentID string            `json:"source_agent_id,omitempty"`
+	Data          EventData         `json:"data,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+func toPersistentEvent(event Event) *persistentEvent {
+	return &persistentEvent{
+		ID:            event.GetID(),
+		Timestamp:     event.GetTimestamp(),
+		TargetAgentID: event.GetTargetAgentID(),
+		SourceAgentID: event.GetSourceAgentID(),
+		Data:          event.GetData(),
+		Metadata:      event.GetMetadata(),
+	}
+}
+
+func (p *persistentEvent) toEvent() Event {
+	event := NewEvent(p.TargetAgentID, p.Data, p.Metadata)
+	event.ID = p.ID
+	event.Timestamp = p.Timestamp
+	event.SourceAgentID = p.SourceAgentID
+	return event
+}
+
+// NewFileEventQueue opens the queue log at path, creating it if needed. Events left
+// unacknowledged in an existing log are returned by the first call to Recover.
+func NewFileEventQueue(path string, options FileEventQueueOptions) (*FileEventQueue, error) {
+	if options.CompactAfter <= 0 {
+		options.CompactAfter = 1000
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	q := &FileEventQueue{
+		path:    path,
+		options: options,
+		unacked: make(map[string]Event),
+		notify:  make(chan struct{}, 1),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	// Rewrite the log with just the unacknowledged events before appending to it
+	if err := q.compact(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load reads the log into unacked and order. A truncated last line, left by a crash
+// mid-write, is ignored.
+func (q *FileEventQueue) load() error {
+	file, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open queue log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record fileQueueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			Logger().Warn().Str("path", q.path).Int("line", line).Err(err).Msg("FileEventQueue: Skipping unreadable record")
+			continue
+		}
+		switch record.Op {
+		case "push":
+			if record.Event == nil {
+				continue
+			}
+			if _, exists := q.unacked[record.ID]; !exists {
+				q.order = append(q.order, record.ID)
+			}
+			q.unacked[record.ID] = record.Event.toEvent()
+		case "ack":
+			delete(q.unacked, record.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read queue log: %w", err)
+	}
+
+	return nil
+}
+
+// compact rewrites the log with only the unacknowledged events and reopens it for
+// appending. It must be called with q.mu held, or before the queue is shared.
+func (q *FileEventQueue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to compact queue log: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	order := q.order[:0]
+	seen := make(map[string]bool, len(q.unacked))
+	for _, id := range q.order {
+		event, ok := q.unacked[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		order = append(order, id)
+		data, err := json.Marshal(fileQueueRecord{Op: "push", ID: id, Event: toPersistentEvent(event)})
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode event %s: %w", id, err)
+		}
+		writer.Write(append(data, '\n'))
+	}
+	q.order = order
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact queue log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact queue log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to compact queue log: %w", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to compact queue log: %w", err)
+	}
+
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file, err = os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen queue log: %w", err)
+	}
+	q.acks = 0
+	return nil
+}
+
+// appendRecord writes a record to the log. It must be called with q.mu held.
+func (q *FileEventQueue) appendRecord(record fileQueueRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue record: %w", err)
+	}
+	if _, err := q.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write queue log: %w", err)
+	}
+	if q.options.SyncWrites {
+		if err := q.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync queue log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Push implements EventQueue. The event is written to the log before it can be popped.
+func (q *FileEventQueue) Push(ctx context.Context, event Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if err := q.appendRecord(fileQueueRecord{Op: "push", ID: event.GetID(), Event: toPersistentEvent(event)}); err != nil {
+		return err
+	}
+	if _, exists := q.unacked[event.GetID()]; !exists {
+		q.order = append(q.order, event.GetID())
+	}
+	q.unacked[event.GetID()] = event
+	q.enqueueLocked(event)
+	return nil
+}
+
+// enqueueLocked makes an event available to Pop. It must be called with q.mu held.
+func (q *FileEventQueue) enqueueLocked(event Event) {
+	q.ready = append(q.ready, event)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Pop implements EventQueue.
+func (q *FileEventQueue) Pop(ctx context.Context) (Event, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+		if len(q.ready) > 0 {
+			event := q.ready[0]
+			q.ready[0] = nil
+			q.ready = q.ready[1:]
+			if len(q.ready) > 0 {
+				// Wake another waiting Pop
+				select {
+				case q.notify <- struct{}{}:
+				default:
+				}
+			}
+			q.mu.Unlock()
+			return event, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack implements EventQueue.
+func (q *FileEventQueue) Ack(event Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if _, ok := q.unacked[event.GetID()]; !ok {
+		return nil
+	}
+	if err := q.appendRecord(fileQueueRecord{Op: "ack", ID: event.GetID()}); err != nil {
+		return err
+	}
+	delete(q.unacked, event.GetID())
+
+	q.acks++
+	if q.acks >= q.options.CompactAfter {
+		return q.compact()
+	}
+	return nil
+}
+
+// Recover implements EventQueue. It requeues every unacknowledged event that is not already
+// waiting: those left in the log when it was opened, and those popped in this process but
+// never acknowledged, such as events dropped when a Runner stopped mid-session. It must not
+// be called while events are being processed.
+func (q *FileEventQueue) Recover() ([]Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+
+	queued := make(map[string]bool, len(q.ready))
+	for _, event := range q.ready {
+		queued[event.GetID()] = true
+	}
+	var recovered []Event
+	for _, id := range q.order {
+		event, ok := q.unacked[id]
+		if !ok || queued[id] {
+			continue
+		}
+		queued[id] = true
+		recovered = append(recovered, event)
+	}
+
+	// Recovered events go first, in their original order
+	q.ready = append(recovered, q.ready...)
+	if len(q.ready) > 0 {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+	return recovered, nil
+}
+
+// Len returns the number of events waiting to be popped.
+func (q *FileEventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// Close implements EventQueue. Unacknowledged events stay in the log for the next open.
+func (q *FileEventQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.notify)
+	if q.file == nil {
+		return nil
+	}
+	return q.file.Close()
+}
+
+// newEventQueueFromConfig builds the queue selected by [runtime].queue_type. It returns
+// nil for the default in-memory priority queue, which NewRunner creates itself.
+func newEventQueueFromConfig(config *Config, queueSize int) (EventQueue, error) {
+	switch strings.ToLower(config.Runtime.QueueType) {
+	case "", "memory", "priority":
+		return nil, nil
+	case "fifo":
+		return NewMemoryEventQueue(queueSize), nil
+	case "file":
+		path := config.Runtime.QueuePath
+		if path == "" {
+			path = "agentflow-events.jsonl"
+		}
+		queue, err := NewFileEventQueue(path, FileEventQueueOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return queue, nil
+	default:
+		return nil, fmt.Errorf("unknown queue type %q", config.Runtime.QueueType)
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func popWithTimeout(t *testing.T, queue EventQueue) Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, err := queue.Pop(ctx)
+	if err != nil {
+		t.Fatalf("Pop failed: %v", err)
+	}
+	return event
+}
+
+func TestMemoryEventQueue_BoundedPush(t *testing.T) {
+	queue := NewMemoryEventQueue(1)
+	if err := queue.Push(context.Background(), NewEvent("a", nil, nil)); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := queue.Push(ctx, NewEvent("b", nil, nil)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a full queue to block until the deadline, got %v", err)
+	}
+
+	if event := popWithTimeout(t, queue); event.GetTargetAgentID() != "a" {
+		t.Errorf("unexpected event: %s", event.GetTargetAgentID())
+	}
+	queue.Close()
+	if err := queue.Push(context.Background(), NewEvent("c", nil, nil)); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestFileEventQueue_ReplaysUnackedEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	queue, err := NewFileEventQueue(path, FileEventQueueOptions{})
+	if err != nil {
+		t.Fatalf("NewFileEventQueue failed: %v", err)
+	}
+
+	first := NewEvent("worker", EventData{"n": 1}, map[string]string{SessionIDKey: "s1"})
+	second := NewEvent("worker", EventData{"n": 2}, map[string]string{SessionIDKey: "s1"})
+	third := NewEvent("worker", EventData{"n": 3}, map[string]string{SessionIDKey: "s2"})
+	for _, event := range []Event{first, second, third} {
+		if err := queue.Push(context.Background(), event); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+
+	// first is processed; second is in flight when the process dies; third was never popped
+	if err := queue.Ack(popWithTimeout(t, queue)); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	popWithTimeout(t, queue)
+	queue.Close()
+
+	reopened, err := NewFileEventQueue(path, FileEventQueueOptions{})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	recovered, err := reopened.Recover()
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if len(recovered) != 2 || recovered[0].GetID() != second.GetID() || recovered[1].GetID() != third.GetID() {
+		t.Fatalf("expected the two unacked events in order, got %v", recovered)
+	}
+
+	event := popWithTimeout(t, reopened)
+	if event.GetID() != second.GetID() || event.GetData()["n"] != float64(2) {
+		t.Errorf("unexpected replayed event: %s %v", event.GetID(), event.GetData())
+	}
+	if sessionID, _ := event.GetMetadataValue(SessionIDKey); sessionID != "s1" {
+		t.Errorf("expected metadata to survive a restart, got %q", sessionID)
+	}
+
+	// A second Recover does not duplicate events that are still waiting
+	reopened.Ack(event)
+	if again, _ := reopened.Recover(); len(again) != 0 || reopened.Len() != 1 {
+		t.Errorf("expected no duplicates, recovered %d with %d waiting", len(again), reopened.Len())
+	}
+}
+
+func TestFileEventQueue_CompactsAndToleratesTruncatedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	queue, err := NewFileEventQueue(path, FileEventQueueOptions{CompactAfter: 2})
+	if err != nil {
+		t.Fatalf("NewFileEventQueue failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		queue.Push(context.Background(), NewEvent("worker", EventData{"n": i}, nil))
+	}
+	queue.Ack(popWithTimeout(t, queue))
+	queue.Ack(popWithTimeout(t, queue))
+	queue.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("expected the log to be compacted to one pending event, got %d lines", lines)
+	}
+
+	// Simulate a crash halfway through writing a record
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"op":"push","id":"partial","event":{"id":"par`)
+	file.Close()
+
+	reopened, err := NewFileEventQueue(path, FileEventQueueOptions{})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	recovered, _ := reopened.Recover()
+	if len(recovered) != 1 || recovered[0].GetData()["n"] != float64(2) {
+		t.Errorf("expected only the intact pending event, got %v", recovered)
+	}
+}
+
+func TestRunner_ReplaysDurableQueueOnStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	queue, err := NewFileEventQueue(path, FileEventQueueOptions{})
+	if err != nil {
+		t.Fatalf("NewFileEventQueue failed: %v", err)
+	}
+	// Left over from a previous run that crashed before processing it
+	queue.Push(context.Background(), NewEvent("worker", EventData{"task": "resume me"}, map[string]string{SessionIDKey: "crashed", RouteMetadataKey: "worker"}))
+	queue.Close()
+
+	queue, err = NewFileEventQueue(path, FileEventQueueOptions{})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer queue.Close()
+
+	processed := make(chan string, 1)
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents: map[string]AgentHandler{"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			processed <- event.GetData()["task"].(string)
+			return AgentResult{OutputState: NewState()}, nil
+		})},
+		Memory:     QuickMemory(),
+		SessionID:  "durable",
+		EventQueue: queue,
+		Config:     &Config{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	select {
+	case task := <-processed:
+		if task != "resume me" {
+			t.Errorf("unexpected task: %s", task)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the unacknowledged event was not replayed")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		queue.mu.Lock()
+		pending := len(queue.unacked)
+		queue.mu.Unlock()
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the replayed event to be acknowledged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -38,6 +38,7 @@ const (
 	HookBeforeAgentRun      HookPoint = "BeforeAgentRun"
 	HookAfterAgentRun       HookPoint = "AfterAgentRun"
 	HookAgentError          HookPoint = "AgentError"
+	HookAgentToken          HookPoint = "AgentToken" // Partial output from a StreamingAgentHandler
 	HookAll                 HookPoint = "AllHooks"
 )
 
@@ -49,6 +50,7 @@ type CallbackArgs struct {
 	State       State
 	AgentID     string
 	AgentResult AgentResult
+	Token       Token // Set for HookAgentToken only
 	Error       error
 }
 
@@ -254,6 +256,7 @@ type TraceEntry struct {
 	TargetAgentID string       `json:"target_agent_id,omitempty"`
 	SourceAgentID string       `json:"source_agent_id,omitempty"`
 	AgentResult   *AgentResult `json:"agent_result,omitempty"`
+	Usage         *UsageRecord `json:"usage,omitempty"` // Set for "llm_usage" entries
 }
 
 // TraceLogger defines the interface for storing and retrieving trace entries.
@@ -0,0 +1,738 @@
+// Package core provides graph (DAG) orchestration with conditional edges for multi-agent systems
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OrchestrationGraph runs agents as the nodes of a directed acyclic graph
+const OrchestrationGraph OrchestrationMode = "graph"
+
+// JoinMode controls when a node with several incoming edges runs
+type JoinMode string
+
+const (
+	// JoinAll waits until every incoming edge is resolved, then runs if at least one of them
+	// was taken. Branches skipped by a condition do not block the join.
+	JoinAll JoinMode = "all"
+	// JoinAny runs as soon as the first incoming edge is taken; later arrivals are ignored.
+	JoinAny JoinMode = "any"
+)
+
+// EdgeCondition decides whether an edge is followed, given the output state of its source node
+type EdgeCondition func(state State) bool
+
+// GraphEdge connects two nodes of a WorkflowGraph
+type GraphEdge struct {
+	From      string
+	To        string
+	Condition EdgeCondition // nil means the edge is always followed
+	Label     string        // Describes the condition in diagrams
+}
+
+// GraphNode is an agent in a WorkflowGraph
+type GraphNode struct {
+	Name string
+	Join JoinMode
+}
+
+// WorkflowGraph describes a DAG workflow: nodes are agents and edges carry optional conditions
+// over the State produced by their source. Nodes without incoming edges run first; a node
+// runs once its incoming edges are resolved (see JoinMode) with the merged output of the
+// predecessors whose edges were taken. Independent nodes run in parallel.
+type WorkflowGraph struct {
+	nodes []*GraphNode
+	index map[string]*GraphNode
+	edges []*GraphEdge
+	errs  []error
+}
+
+// NewWorkflowGraph creates an empty workflow graph
+func NewWorkflowGraph() *WorkflowGraph {
+	return &WorkflowGraph{index: make(map[string]*GraphNode)}
+}
+
+// AddNode adds an agent node. Nodes are also added implicitly by edges.
+func (g *WorkflowGraph) AddNode(name string) *WorkflowGraph {
+	g.node(name)
+	return g
+}
+
+// SetJoin sets how a node with several incoming edges waits for them (JoinAll by default)
+func (g *WorkflowGraph) SetJoin(name string, mode JoinMode) *WorkflowGraph {
+	if mode != JoinAll && mode != JoinAny {
+		g.errs = append(g.errs, fmt.Errorf("node '%s': invalid join mode '%s'", name, mode))
+		return g
+	}
+	g.node(name).Join = mode
+	return g
+}
+
+// AddEdge adds an edge that is always followed
+func (g *WorkflowGraph) AddEdge(from, to string) *WorkflowGraph {
+	return g.AddConditionalEdge(from, to, "", nil)
+}
+
+// AddConditionalEdge adds an edge that is followed when condition returns true for the
+// output state of from. label describes the condition in diagrams.
+func (g *WorkflowGraph) AddConditionalEdge(from, to, label string, condition EdgeCondition) *WorkflowGraph {
+	g.node(from)
+	g.node(to)
+	g.edges = append(g.edges, &GraphEdge{From: from, To: to, Condition: condition, Label: label})
+	return g
+}
+
+// AddEdgeWhen adds an edge with a condition expression, as used in agentflow.toml.
+// See ParseEdgeCondition for the syntax.
+func (g *WorkflowGraph) AddEdgeWhen(from, to, expression string) *WorkflowGraph {
+	if strings.TrimSpace(expression) == "" {
+		return g.AddEdge(from, to)
+	}
+	condition, err := ParseEdgeCondition(expression)
+	if err != nil {
+		g.errs = append(g.errs, fmt.Errorf("edge %s -> %s: %w", from, to, err))
+		return g
+	}
+	return g.AddConditionalEdge(from, to, expression, condition)
+}
+
+// Nodes returns the graph's nodes in the order they were added
+func (g *WorkflowGraph) Nodes() []GraphNode {
+	nodes := make([]GraphNode, len(g.nodes))
+	for i, node := range g.nodes {
+		nodes[i] = *node
+	}
+	return nodes
+}
+
+// Edges returns the graph's edges in the order they were added
+func (g *WorkflowGraph) Edges() []GraphEdge {
+	edges := make([]GraphEdge, len(g.edges))
+	for i, edge := range g.edges {
+		edges[i] = *edge
+	}
+	return edges
+}
+
+// EntryNodes returns the nodes without incoming edges, which run first
+func (g *WorkflowGraph) EntryNodes() []string {
+	incoming := make(map[string]bool)
+	for _, edge := range g.edges {
+		incoming[edge.To] = true
+	}
+	var entries []string
+	for _, node := range g.nodes {
+		if !incoming[node.Name] {
+			entries = append(entries, node.Name)
+		}
+	}
+	return entries
+}
+
+// Validate checks the graph with a WorkflowValidator: it must have nodes and entry points,
+// no cycles, and every node must be reachable. Invalid join modes and condition
+// expressions are reported as invalid routing.
+func (g *WorkflowGraph) Validate() []*WorkflowValidationError {
+	var errs []*WorkflowValidationError
+	for _, err := range g.errs {
+		errs = append(errs, &WorkflowValidationError{Type: ValidationErrorInvalidRouting, Message: err.Error()})
+	}
+	if len(g.nodes) == 0 {
+		return append(errs, &WorkflowValidationError{Type: ValidationErrorNoEntryPoint, Message: "graph has no nodes"})
+	}
+
+	validator := g.validator()
+	return append(errs, validator.ValidateWorkflow()...)
+}
+
+// validator builds a WorkflowValidator describing the graph
+func (g *WorkflowGraph) validator() *WorkflowValidator {
+	validator := NewWorkflowValidator(false)
+	outgoing := make(map[string]bool)
+	for _, node := range g.nodes {
+		validator.AddAgent(node.Name, AgentTypeStandard)
+	}
+	for _, edge := range g.edges {
+		validator.AddRoute(edge.From, edge.To)
+		outgoing[edge.From] = true
+	}
+	for _, entry := range g.EntryNodes() {
+		validator.SetEntryPoint(entry)
+	}
+	for _, node := range g.nodes {
+		if !outgoing[node.Name] {
+			validator.SetEndpoint(node.Name)
+		}
+	}
+	return validator
+}
+
+func (g *WorkflowGraph) node(name string) *GraphNode {
+	if node, ok := g.index[name]; ok {
+		return node
+	}
+	node := &GraphNode{Name: name, Join: JoinAll}
+	g.index[name] = node
+	g.nodes = append(g.nodes, node)
+	return node
+}
+
+// graphValidationError combines validation errors into one error, or returns nil
+func graphValidationError(errs []*WorkflowValidationError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("invalid workflow graph: %s", strings.Join(messages, "; "))
+}
+
+// =============================================================================
+// GRAPH CONFIGURATION
+// =============================================================================
+
+// OrchestrationGraphConfig describes a graph workflow in [orchestration.graph]
+type OrchestrationGraphConfig struct {
+	Nodes []GraphNodeConfig `toml:"nodes"`
+	Edges []GraphEdgeConfig `toml:"edges"`
+}
+
+// GraphNodeConfig configures a node in [[orchestration.graph.nodes]]
+type GraphNodeConfig struct {
+	Name string `toml:"name"`
+	Join string `toml:"join"` // "all" (default) or "any"
+}
+
+// GraphEdgeConfig configures an edge in [[orchestration.graph.edges]]
+type GraphEdgeConfig struct {
+	From string `toml:"from"`
+	To   string `toml:"to"`
+	When string `toml:"when"` // Optional condition, e.g. "flagged == true"
+}
+
+// NewWorkflowGraphFromConfig builds a graph from [orchestration.graph] and validates it
+func NewWorkflowGraphFromConfig(config OrchestrationGraphConfig) (*WorkflowGraph, error) {
+	graph := NewWorkflowGraph()
+	for _, node := range config.Nodes {
+		graph.AddNode(node.Name)
+		if node.Join != "" {
+			graph.SetJoin(node.Name, JoinMode(strings.ToLower(node.Join)))
+		}
+	}
+	for _, edge := range config.Edges {
+		if edge.From == "" || edge.To == "" {
+			return nil, fmt.Errorf("graph edge requires 'from' and 'to', got from=%q to=%q", edge.From, edge.To)
+		}
+		graph.AddEdgeWhen(edge.From, edge.To, edge.When)
+	}
+	if err := graphValidationError(graph.Validate()); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// =============================================================================
+// EDGE CONDITION EXPRESSIONS
+// =============================================================================
+
+// ParseEdgeCondition parses a condition expression over State. Supported forms:
+//
+//	flagged                  value is truthy (true, non-zero, non-empty)
+//	!flagged                 value is missing or falsy
+//	score >= 0.8             numeric comparison: ==, !=, >, >=, <, <=
+//	status == "approved"     equality with a string, number or true/false
+//	meta.priority == high    "meta." reads State metadata instead of data
+//	a && b || c              && binds tighter than ||
+func ParseEdgeCondition(expression string) (EdgeCondition, error) {
+	var alternatives []EdgeCondition
+	for _, part := range splitOutsideQuotes(expression, "||") {
+		var terms []EdgeCondition
+		for _, termExpr := range splitOutsideQuotes(part, "&&") {
+			term, err := parseConditionTerm(strings.TrimSpace(termExpr))
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, term)
+		}
+		alternatives = append(alternatives, func(state State) bool {
+			for _, term := range terms {
+				if !term(state) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	return func(state State) bool {
+		for _, alternative := range alternatives {
+			if alternative(state) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// splitOutsideQuotes splits an expression around sep, ignoring separators inside single or
+// double quoted values.
+func splitOutsideQuotes(expression, sep string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(expression[i:], sep):
+			parts = append(parts, expression[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, expression[start:])
+}
+
+var conditionOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+func parseConditionTerm(term string) (EdgeCondition, error) {
+	if term == "" {
+		return nil, errors.New("empty condition")
+	}
+	// Split on the leftmost operator, so quoted values may contain operators
+	i, op := -1, ""
+	for _, candidate := range conditionOperators {
+		if j := strings.Index(term, candidate); j >= 0 && (i < 0 || j < i) {
+			i, op = j, candidate
+		}
+	}
+	if i >= 0 {
+		key := strings.TrimSpace(term[:i])
+		if !isConditionKey(key) {
+			return nil, fmt.Errorf("invalid condition %q: expected a state key before %s", term, op)
+		}
+		rawLiteral := strings.TrimSpace(term[i+len(op):])
+		if rawLiteral == "" {
+			return nil, fmt.Errorf("invalid condition %q: expected a value after %s", term, op)
+		}
+		literal := parseConditionLiteral(rawLiteral)
+		return func(state State) bool {
+			value, ok := conditionValue(state, key)
+			return ok && compareConditionValues(value, op, literal)
+		}, nil
+	}
+
+	negate := strings.HasPrefix(term, "!")
+	key := strings.TrimSpace(strings.TrimPrefix(term, "!"))
+	if !isConditionKey(key) {
+		return nil, fmt.Errorf("invalid condition %q", term)
+	}
+	return func(state State) bool {
+		value, ok := conditionValue(state, key)
+		return (ok && isTruthy(value)) != negate
+	}, nil
+}
+
+func isConditionKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func parseConditionLiteral(literal string) interface{} {
+	if unquoted, err := strconv.Unquote(literal); err == nil {
+		return unquoted
+	}
+	if strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'") && len(literal) >= 2 {
+		return literal[1 : len(literal)-1]
+	}
+	if b, err := strconv.ParseBool(literal); err == nil {
+		return b
+	}
+	if f, err := strconv.ParseFloat(literal, 64); err == nil {
+		return f
+	}
+	return literal
+}
+
+func conditionValue(state State, key string) (interface{}, bool) {
+	if state == nil {
+		return nil, false
+	}
+	if metaKey := strings.TrimPrefix(key, "meta."); metaKey != key {
+		return state.GetMeta(metaKey)
+	}
+	return state.Get(key)
+}
+
+func compareConditionValues(value interface{}, op string, literal interface{}) bool {
+	if a, ok := toConditionNumber(value); ok {
+		if b, ok := toConditionNumber(literal); ok {
+			switch op {
+			case "==":
+				return a == b
+			case "!=":
+				return a != b
+			case ">":
+				return a > b
+			case ">=":
+				return a >= b
+			case "<":
+				return a < b
+			case "<=":
+				return a <= b
+			}
+		}
+	}
+
+	equal := fmt.Sprint(value) == fmt.Sprint(literal)
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	}
+	return false
+}
+
+func toConditionNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && !strings.EqualFold(v, "false")
+	}
+	if f, ok := toConditionNumber(value); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+// =============================================================================
+// GRAPH ORCHESTRATOR IMPLEMENTATION
+// =============================================================================
+
+// graphOrchestrator runs the agents of a WorkflowGraph
+type graphOrchestrator struct {
+	handlers         map[string]AgentHandler
+	graph            *WorkflowGraph
+	invalid          error
+	callbackRegistry *CallbackRegistry
+	mu               sync.RWMutex
+}
+
+// NewGraphOrchestrator creates an orchestrator that runs agents as the nodes of graph.
+// The graph is validated here; an invalid graph makes every Dispatch fail with the
+// validation errors.
+func NewGraphOrchestrator(registry *CallbackRegistry, graph *WorkflowGraph) Orchestrator {
+	if graph == nil {
+		graph = NewWorkflowGraph()
+	}
+	invalid := graphValidationError(graph.Validate())
+	if invalid != nil {
+		Logger().Error().Err(invalid).Msg("GraphOrchestrator: Workflow graph is invalid")
+	}
+	return &graphOrchestrator{
+		handlers:         make(map[string]AgentHandler),
+		graph:            graph,
+		invalid:          invalid,
+		callbackRegistry: registry,
+	}
+}
+
+// RegisterAgent adds an agent handler to the graph orchestrator
+func (o *graphOrchestrator) RegisterAgent(name string, handler AgentHandler) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil for agent %s", name)
+	}
+
+	o.handlers[name] = handler
+	Logger().Debug().Str("agent", name).Msg("GraphOrchestrator: Agent registered")
+	return nil
+}
+
+// Dispatch runs the graph for the event
+func (o *graphOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResult, error) {
+	if event == nil {
+		err := errors.New("cannot dispatch nil event")
+		return AgentResult{Error: err.Error()}, err
+	}
+
+	o.mu.RLock()
+	handlers := make(map[string]AgentHandler, len(o.handlers))
+	for name, handler := range o.handlers {
+		handlers[name] = handler
+	}
+	o.mu.RUnlock()
+
+	if o.invalid != nil {
+		return AgentResult{Error: o.invalid.Error()}, o.invalid
+	}
+
+	// Initialize state from event data
+	currentState := NewState()
+	for key, value := range event.GetData() {
+		currentState.Set(key, value)
+	}
+	for key, value := range event.GetMetadata() {
+		currentState.SetMeta(key, value)
+	}
+
+	nodeNames := make([]string, len(o.graph.nodes))
+	for i, node := range o.graph.nodes {
+		nodeNames[i] = node.Name
+	}
+	if result, handled, err := dispatchOutsideWorkflow(ctx, handlers, nodeNames, event, currentState); handled {
+		return result, err
+	}
+
+	var missing []string
+	for _, name := range nodeNames {
+		if _, ok := handlers[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("graph nodes have no registered agent: %s", strings.Join(missing, ", "))
+		return AgentResult{Error: err.Error()}, err
+	}
+
+	run := newGraphRun(o.graph, handlers, event, currentState)
+	state, err := run.execute(ctx)
+	if err != nil {
+		return AgentResult{Error: err.Error()}, err
+	}
+	return AgentResult{OutputState: finishWorkflow(state)}, nil
+}
+
+// GetCallbackRegistry returns the callback registry
+func (o *graphOrchestrator) GetCallbackRegistry() *CallbackRegistry {
+	return o.callbackRegistry
+}
+
+// Stop halts the graph orchestrator
+func (o *graphOrchestrator) Stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handlers = make(map[string]AgentHandler)
+	Logger().Debug().Msg("GraphOrchestrator: Stopped")
+}
+
+// graphNodeStatus tracks a node during one graph run
+type graphNodeStatus int
+
+const (
+	graphNodeWaiting graphNodeStatus = iota
+	graphNodeRunning
+	graphNodeDone
+	graphNodeSkipped
+)
+
+// graphNodeResult is sent by a finished node
+type graphNodeResult struct {
+	name   string
+	output State
+	err    error
+}
+
+// graphRun holds the state of one execution of a WorkflowGraph. Only the goroutine calling
+// execute touches it; agents run in their own goroutines and report on results.
+type graphRun struct {
+	graph    *WorkflowGraph
+	handlers map[string]AgentHandler
+	event    Event
+	initial  State
+
+	incoming  map[string][]int // Edge indexes into each node
+	outgoing  map[string][]int // Edge indexes out of each node
+	pending   map[string]int   // Unresolved incoming edges per node
+	taken     map[string][]int // Taken incoming edges per node
+	forwarded map[string]bool  // Nodes with at least one taken outgoing edge
+	status    map[string]graphNodeStatus
+	outputs   map[string]State
+
+	results chan graphNodeResult
+	running int
+}
+
+func newGraphRun(graph *WorkflowGraph, handlers map[string]AgentHandler, event Event, initial State) *graphRun {
+	run := &graphRun{
+		graph:     graph,
+		handlers:  handlers,
+		event:     event,
+		initial:   initial,
+		incoming:  make(map[string][]int),
+		outgoing:  make(map[string][]int),
+		pending:   make(map[string]int),
+		taken:     make(map[string][]int),
+		forwarded: make(map[string]bool),
+		status:    make(map[string]graphNodeStatus),
+		outputs:   make(map[string]State),
+		// Every node runs at most once, so finished nodes never block on send
+		results: make(chan graphNodeResult, len(graph.nodes)),
+	}
+	for i, edge := range graph.edges {
+		run.incoming[edge.To] = append(run.incoming[edge.To], i)
+		run.outgoing[edge.From] = append(run.outgoing[edge.From], i)
+		run.pending[edge.To]++
+	}
+	return run
+}
+
+// execute runs the graph and returns the merged output of the nodes where execution ended.
+// The first node error cancels the nodes still running.
+func (r *graphRun) execute(ctx context.Context) (State, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, entry := range r.graph.EntryNodes() {
+		r.start(ctx, entry)
+	}
+
+	for r.running > 0 {
+		var result graphNodeResult
+		select {
+		case result = <-r.results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		r.running--
+		if result.err != nil {
+			return nil, fmt.Errorf("graph node %s failed: %w", result.name, result.err)
+		}
+		r.complete(ctx, result.name, result.output)
+	}
+
+	// The result merges the outputs of executed nodes that did not pass their state on
+	final := r.initial.Clone()
+	for _, node := range r.graph.nodes {
+		if r.status[node.Name] == graphNodeDone && !r.forwarded[node.Name] {
+			final.Merge(r.outputs[node.Name])
+		}
+	}
+	return final, nil
+}
+
+// start runs a node with the initial state merged with the outputs of the predecessors
+// whose edges were taken, in edge order
+func (r *graphRun) start(ctx context.Context, name string) {
+	input := r.initial.Clone()
+	taken := append([]int(nil), r.taken[name]...)
+	sort.Ints(taken)
+	for _, i := range taken {
+		input.Merge(r.outputs[r.graph.edges[i].From])
+	}
+
+	r.status[name] = graphNodeRunning
+	r.running++
+	handler := r.handlers[name]
+	Logger().Debug().Str("agent", name).Msg("GraphOrchestrator: Executing node")
+	go func() {
+		result, err := runAgentHandler(ctx, name, handler, r.event, input)
+		output := result.OutputState
+		if output == nil {
+			output = input
+		}
+		r.results <- graphNodeResult{name: name, output: output, err: err}
+	}()
+}
+
+// complete records a node's output and resolves its outgoing edges
+func (r *graphRun) complete(ctx context.Context, name string, output State) {
+	r.status[name] = graphNodeDone
+	r.outputs[name] = output
+	for _, i := range r.outgoing[name] {
+		edge := r.graph.edges[i]
+		take := edge.Condition == nil || edge.Condition(output)
+		if take {
+			r.forwarded[name] = true
+		}
+		r.resolve(ctx, i, take)
+	}
+}
+
+// skip marks a node that none of its incoming edges reached, and skips its outgoing edges
+func (r *graphRun) skip(ctx context.Context, name string) {
+	r.status[name] = graphNodeSkipped
+	Logger().Debug().Str("agent", name).Msg("GraphOrchestrator: Skipping node, no incoming edge was taken")
+	for _, i := range r.outgoing[name] {
+		r.resolve(ctx, i, false)
+	}
+}
+
+// resolve records the outcome of an edge and starts or skips its target when it is ready
+func (r *graphRun) resolve(ctx context.Context, edgeIndex int, taken bool) {
+	to := r.graph.edges[edgeIndex].To
+	r.pending[to]--
+	if taken {
+		r.taken[to] = append(r.taken[to], edgeIndex)
+	}
+	if r.status[to] != graphNodeWaiting {
+		return
+	}
+
+	switch {
+	case taken && r.graph.index[to].Join == JoinAny:
+		r.start(ctx, to)
+	case r.pending[to] == 0 && len(r.taken[to]) > 0:
+		r.start(ctx, to)
+	case r.pending[to] == 0:
+		r.skip(ctx, to)
+	}
+}
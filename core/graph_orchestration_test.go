@@ -0,0 +1,345 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// graphStep returns a handler that records its call and sets name=true in its output
+func graphStep(name string, mu *sync.Mutex, calls *[]string, extra map[string]interface{}) AgentHandler {
+	return AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		mu.Lock()
+		*calls = append(*calls, name)
+		mu.Unlock()
+		output := state.Clone()
+		output.Set(name, true)
+		for key, value := range extra {
+			output.Set(key, value)
+		}
+		return AgentResult{OutputState: output}, nil
+	})
+}
+
+func dispatchGraph(t *testing.T, graph *WorkflowGraph, agents map[string]AgentHandler) (AgentResult, error) {
+	t.Helper()
+	orch := NewGraphOrchestrator(NewCallbackRegistry(), graph)
+	for name, handler := range agents {
+		if err := orch.RegisterAgent(name, handler); err != nil {
+			t.Fatalf("RegisterAgent failed: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return orch.Dispatch(ctx, NewEvent("", EventData{"topic": "solar"}, nil))
+}
+
+func TestGraphOrchestrator_FanOutAndJoin(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	agents := map[string]AgentHandler{}
+	for _, name := range []string{"plan", "research", "outline", "write"} {
+		agents[name] = graphStep(name, &mu, &calls, nil)
+	}
+
+	// research and outline both need plan; write joins them
+	graph := NewWorkflowGraph().
+		AddEdge("plan", "research").
+		AddEdge("plan", "outline").
+		AddEdge("research", "write").
+		AddEdge("outline", "write")
+
+	result, err := dispatchGraph(t, graph, agents)
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	for _, key := range []string{"topic", "plan", "research", "outline", "write"} {
+		if _, ok := result.OutputState.Get(key); !ok {
+			t.Errorf("expected %q in the final state, got keys %v", key, result.OutputState.Keys())
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 4 || calls[0] != "plan" || calls[3] != "write" {
+		t.Errorf("expected plan first, write last and each node once, got %v", calls)
+	}
+}
+
+func TestGraphOrchestrator_ConditionalEdges(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	agents := map[string]AgentHandler{
+		"review":  graphStep("review", &mu, &calls, map[string]interface{}{"score": 0.4}),
+		"publish": graphStep("publish", &mu, &calls, nil),
+		"revise":  graphStep("revise", &mu, &calls, nil),
+		"notify":  graphStep("notify", &mu, &calls, nil),
+	}
+
+	graph := NewWorkflowGraph().
+		AddEdgeWhen("review", "publish", "score >= 0.8").
+		AddEdgeWhen("review", "revise", "score < 0.8").
+		AddEdge("publish", "notify")
+
+	result, err := dispatchGraph(t, graph, agents)
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if _, ok := result.OutputState.Get("revise"); !ok {
+		t.Errorf("expected the revise branch to run, got keys %v", result.OutputState.Keys())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, call := range calls {
+		if call == "publish" || call == "notify" {
+			t.Errorf("expected the publish branch to be skipped, got calls %v", calls)
+		}
+	}
+}
+
+func TestGraphOrchestrator_JoinModes(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	release := make(chan struct{})
+	agents := map[string]AgentHandler{
+		"start": graphStep("start", &mu, &calls, map[string]interface{}{"cached": true}),
+		"fast":  graphStep("fast", &mu, &calls, nil),
+		"slow": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			<-release
+			return AgentResult{OutputState: state.Clone()}, nil
+		}),
+		"first": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			// Runs while slow is still blocked
+			close(release)
+			output := state.Clone()
+			output.Set("first", true)
+			return AgentResult{OutputState: output}, nil
+		}),
+		"skipped": graphStep("skipped", &mu, &calls, nil),
+		"all":     graphStep("all", &mu, &calls, nil),
+	}
+
+	graph := NewWorkflowGraph().
+		AddEdge("start", "fast").
+		AddEdge("start", "slow").
+		AddEdgeWhen("start", "skipped", "!cached").
+		AddEdge("fast", "first").
+		AddEdge("slow", "first").
+		SetJoin("first", JoinAny).
+		AddEdge("first", "all").
+		AddEdge("skipped", "all")
+
+	result, err := dispatchGraph(t, graph, agents)
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if _, ok := result.OutputState.Get("all"); !ok {
+		t.Errorf("expected a JoinAll node to run when its other branch was skipped, got keys %v", result.OutputState.Keys())
+	}
+	if _, ok := result.OutputState.Get("skipped"); ok {
+		t.Error("expected the !cached branch to be skipped")
+	}
+}
+
+func TestGraphOrchestrator_NodeErrorStopsGraph(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	agents := map[string]AgentHandler{
+		"fetch": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			return AgentResult{}, errors.New("source unavailable")
+		}),
+		"summarize": graphStep("summarize", &mu, &calls, nil),
+	}
+
+	_, err := dispatchGraph(t, NewWorkflowGraph().AddEdge("fetch", "summarize"), agents)
+	if err == nil || !strings.Contains(err.Error(), "graph node fetch failed") {
+		t.Fatalf("expected the node error to fail the graph, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected downstream nodes not to run, got %v", calls)
+	}
+}
+
+func TestWorkflowGraph_Validate(t *testing.T) {
+	cyclic := NewWorkflowGraph().
+		AddEdge("plan", "write").
+		AddEdge("write", "review").
+		AddEdge("review", "write")
+	errs := cyclic.Validate()
+	if len(errs) == 0 {
+		t.Fatal("expected a cycle to be reported")
+	}
+	found := false
+	for _, err := range errs {
+		if err.Type == ValidationErrorCircularDependency {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a circular dependency error, got %v", errs)
+	}
+
+	badCondition := NewWorkflowGraph().AddEdgeWhen("a", "b", "score >")
+	if errs := badCondition.Validate(); len(errs) == 0 {
+		t.Error("expected an invalid condition to be reported")
+	}
+
+	if _, err := dispatchGraph(t, cyclic, nil); err == nil {
+		t.Error("expected Dispatch to refuse an invalid graph")
+	}
+	if _, err := dispatchGraph(t, NewWorkflowGraph().AddEdge("a", "b"), nil); err == nil {
+		t.Error("expected Dispatch to refuse nodes without registered agents")
+	}
+}
+
+func TestParseEdgeCondition(t *testing.T) {
+	state := NewState()
+	state.Set("score", 0.9)
+	state.Set("status", "approved")
+	state.Set("label", "a || b && c")
+	state.Set("flagged", false)
+	state.Set("tags", []string{"urgent"})
+	state.SetMeta("priority", "high")
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{"score >= 0.8", true},
+		{"score < 0.5", false},
+		{`status == "approved"`, true},
+		{"status != approved", false},
+		{"flagged", false},
+		{"!flagged", true},
+		{"missing", false},
+		{"!missing", true},
+		{"tags", true},
+		{"meta.priority == high", true},
+		{"flagged || score > 0.5", true},
+		{"score > 0.5 && flagged == true", false},
+		{`label == "a || b && c"`, true},
+		{`label == 'a || b && c' && score > 0.5`, true},
+		{`label == "a \" || b" || status == approved`, true},
+	}
+	for _, tt := range tests {
+		condition, err := ParseEdgeCondition(tt.expression)
+		if err != nil {
+			t.Errorf("ParseEdgeCondition(%q) failed: %v", tt.expression, err)
+			continue
+		}
+		if got := condition(state); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+
+	for _, expression := range []string{"", "== 1", "score >= 1 &&", "bad key"} {
+		if _, err := ParseEdgeCondition(expression); err == nil {
+			t.Errorf("expected ParseEdgeCondition(%q) to fail", expression)
+		}
+	}
+}
+
+func TestGraphOrchestration_FromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentflow.toml")
+	content := `[orchestration]
+mode = "graph"
+timeout_seconds = 30
+
+[[orchestration.graph.nodes]]
+name = "summary"
+join = "any"
+
+[[orchestration.graph.edges]]
+from = "classify"
+to = "escalate"
+when = "urgent == true"
+
+[[orchestration.graph.edges]]
+from = "classify"
+to = "answer"
+when = "!urgent"
+
+[[orchestration.graph.edges]]
+from = "escalate"
+to = "summary"
+
+[[orchestration.graph.edges]]
+from = "answer"
+to = "summary"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	runner, err := createRunnerWithOrchestration(config, QuickMemory(), "graph-test")
+	if err != nil {
+		t.Fatalf("createRunnerWithOrchestration failed: %v", err)
+	}
+	var mu sync.Mutex
+	var calls []string
+	runner.RegisterAgent("classify", graphStep("classify", &mu, &calls, map[string]interface{}{"urgent": true}))
+	for _, name := range []string{"escalate", "answer", "summary"} {
+		runner.RegisterAgent(name, graphStep(name, &mu, &calls, nil))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	event := NewEvent("classify", EventData{"ticket": "T-1"}, map[string]string{SessionIDKey: "graph-test", RouteMetadataKey: "classify"})
+	result, err := runner.Run(ctx, event)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, ok := result.OutputState.Get("escalate"); !ok {
+		t.Errorf("expected the urgent branch to run, got keys %v", result.OutputState.Keys())
+	}
+	if _, ok := result.OutputState.Get("answer"); ok {
+		t.Error("expected the non-urgent branch to be skipped")
+	}
+
+	config.Orchestration.Graph.Edges = append(config.Orchestration.Graph.Edges, GraphEdgeConfig{From: "summary", To: "classify"})
+	if _, err := createRunnerWithOrchestration(config, QuickMemory(), "graph-test"); err == nil {
+		t.Error("expected a cyclic graph in configuration to be rejected")
+	}
+}
+
+func TestGenerateGraphMermaidDiagram(t *testing.T) {
+	graph := NewWorkflowGraph().
+		AddEdge("plan", "research").
+		AddEdgeWhen("research", "fact-check", "confidence < 0.7").
+		AddEdge("research", "write").
+		AddEdge("fact-check", "write").
+		SetJoin("write", JoinAny)
+
+	diagram := GenerateGraphMermaidDiagram(graph)
+	for _, want := range []string{
+		"EVENT --> N_plan",
+		"N_plan --> N_research",
+		`N_research -.->|"confidence < 0.7"| N_fact_check`,
+		`N_write["write (any)"]`,
+		"N_write --> RESULT",
+	} {
+		if !strings.Contains(diagram, want) {
+			t.Errorf("expected diagram to contain %q:\n%s", want, diagram)
+		}
+	}
+
+	builderDiagram := NewOrchestrationBuilder(OrchestrationGraph).WithGraph(graph).GenerateMermaidDiagram()
+	if !strings.Contains(builderDiagram, "N_plan --> N_research") {
+		t.Errorf("expected the builder to draw the graph:\n%s", builderDiagram)
+	}
+}
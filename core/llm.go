@@ -5,9 +5,35 @@ import "context"
 
 // ModelParameters holds common configuration options for language model calls.
 type ModelParameters struct {
-	Temperature *float32 // Sampling temperature. nil uses the provider's default.
-	MaxTokens   *int32   // Max tokens to generate. nil uses the provider's default.
-	// TODO: Add TopP, StopSequences, PresencePenalty, FrequencyPenalty etc.
+	Temperature      *float32        // Sampling temperature. nil uses the provider's default.
+	MaxTokens        *int32          // Max tokens to generate. nil uses the provider's default.
+	TopP             *float32        // Nucleus sampling probability mass. nil uses the provider's default.
+	StopSequences    []string        // Generation stops before any of these sequences.
+	PresencePenalty  *float32        // Penalizes tokens that already appeared at all. Ignored by Anthropic.
+	FrequencyPenalty *float32        // Penalizes tokens by how often they appeared. Ignored by Anthropic.
+	Seed             *int64          // Requests deterministic sampling where supported. Ignored by Anthropic.
+	ResponseFormat   *ResponseFormat // Constrains the output format, e.g. to JSON. nil means free text.
+}
+
+// ResponseFormatType selects the kind of output a model must produce.
+type ResponseFormatType string
+
+const (
+	// ResponseFormatText is free-form text, the default.
+	ResponseFormatText ResponseFormatType = "text"
+	// ResponseFormatJSONObject requires the output to be a single JSON object.
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	// ResponseFormatJSONSchema requires the output to be JSON matching Schema.
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat constrains the format of a model's output. Providers without a native
+// JSON mode (Anthropic) are instructed through the system prompt instead.
+type ResponseFormat struct {
+	Type   ResponseFormatType
+	Name   string                 // Schema name, required by some providers for ResponseFormatJSONSchema
+	Schema map[string]interface{} // JSON schema for ResponseFormatJSONSchema
+	Strict bool                   // Asks the provider to enforce Schema exactly where supported
 }
 
 // Prompt represents the input to a language model call.
@@ -16,9 +42,54 @@ type Prompt struct {
 	System string
 	// User message is the primary input or question.
 	User string
+	// Messages holds prior conversation turns, in order. They are sent after System
+	// and before User, so User (when set) becomes the final user turn.
+	Messages []ChatMessage
 	// Parameters specify model configuration for this call.
 	Parameters ModelParameters
-	// TODO: Add fields for message history, function calls/definitions
+	// Tools lists the functions the model may call. Providers that support native
+	// tool calling send these in their API's tools format.
+	Tools []ToolDefinition
+}
+
+// Chat message roles understood by all providers.
+const (
+	ChatRoleSystem    = "system"
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+	ChatRoleTool      = "tool"
+)
+
+// ChatMessage is a single turn in a multi-turn conversation.
+type ChatMessage struct {
+	// Role is one of ChatRoleSystem, ChatRoleUser, ChatRoleAssistant or ChatRoleTool.
+	Role string `json:"role"`
+	// Content is the text of the message. For tool messages it holds the tool result.
+	Content string `json:"content"`
+	// ToolCalls holds the tool calls made by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a tool message to the ToolCall it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ToolDefinition describes a function the model may call.
+type ToolDefinition struct {
+	// Name is the function name the model uses to reference the tool.
+	Name string `json:"name"`
+	// Description tells the model what the tool does and when to use it.
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema describing the tool's arguments.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolCall represents a function call requested by the model.
+type ToolCall struct {
+	// ID identifies the call so its result can be matched back to it.
+	ID string `json:"id"`
+	// Name is the name of the tool to invoke.
+	Name string `json:"name"`
+	// Arguments holds the decoded arguments for the tool.
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
 }
 
 // UsageStats contains token usage information for a model call.
@@ -36,17 +107,27 @@ type Response struct {
 	Usage UsageStats
 	// FinishReason indicates why the model stopped generating tokens (e.g., "stop", "length", "content_filter").
 	FinishReason string
-	// TODO: Add fields for function call results, log probabilities, etc.
+	// ToolCalls holds the tool calls requested by the model, if any.
+	ToolCalls []ToolCall
+	// TODO: Add fields for log probabilities, etc.
 }
 
 // Token represents a single token streamed from a language model.
 type Token struct {
-	// Content is the text chunk of the token.
+	// Content is the text chunk of the token. The final token may carry no content.
 	Content string
+	// Index is the position of this chunk in the stream, starting at 0.
+	Index int
+	// FinishReason is set on the final token and reports why generation stopped.
+	FinishReason string
+	// ToolCalls is set on the final token when the model requested tool calls.
+	ToolCalls []ToolCall
+	// Usage is set on the final token when the provider reports token counts.
+	Usage *UsageStats
 	// Error holds any error that occurred during streaming for this token or subsequent ones.
 	// If non-nil, the stream should be considered terminated.
 	Error error
-	// TODO: Add fields for token index, log probabilities, finish reason (on last token), usage (on last token) if available.
+	// TODO: Add fields for log probabilities.
 }
 
 // ModelProvider defines the interface for interacting with different language model backends.
@@ -81,3 +162,7 @@ func FloatPtr(f float32) *float32 {
 func Int32Ptr(i int32) *int32 {
 	return &i
 }
+
+func Int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -60,6 +60,46 @@ func NewOllamaAdapter(baseURL, model string, maxTokens int, temperature float32)
 	return &modelProviderWrapper{internal: adapter}, nil
 }
 
+// NewAnthropicAdapter creates a new Anthropic Messages API adapter
+func NewAnthropicAdapter(apiKey, model string, maxTokens int, temperature float32) (ModelProvider, error) {
+	adapter, err := llm.NewAnthropicAdapter(apiKey, model, maxTokens, temperature)
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelProviderWrapper{internal: adapter}, nil
+}
+
+// OpenAICompatibleAdapterOptions holds configuration for servers implementing the OpenAI
+// Chat Completions API, such as vLLM, LM Studio, llama.cpp server or OpenRouter
+type OpenAICompatibleAdapterOptions struct {
+	BaseURL        string            // API root including any version path, e.g. http://localhost:8000/v1
+	APIKey         string            // Optional bearer token
+	Model          string            // Model name as the server knows it
+	EmbeddingModel string            // Optional, defaults to text-embedding-3-small
+	Headers        map[string]string // Extra headers sent with every request
+	MaxTokens      int
+	Temperature    float32
+}
+
+// NewOpenAICompatibleAdapter creates an adapter for an OpenAI-compatible endpoint
+func NewOpenAICompatibleAdapter(options OpenAICompatibleAdapterOptions) (ModelProvider, error) {
+	adapter, err := llm.NewOpenAICompatibleAdapter(llm.OpenAICompatibleAdapterOptions{
+		BaseURL:        options.BaseURL,
+		APIKey:         options.APIKey,
+		Model:          options.Model,
+		EmbeddingModel: options.EmbeddingModel,
+		Headers:        options.Headers,
+		MaxTokens:      options.MaxTokens,
+		Temperature:    options.Temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelProviderWrapper{internal: adapter}, nil
+}
+
 // NewModelProviderAdapter creates an LLMAdapter from a ModelProvider
 func NewModelProviderAdapter(provider ModelProvider) LLMAdapter {
 	// If it's our wrapper, use the internal provider directly
@@ -77,42 +117,16 @@ type modelProviderWrapper struct {
 }
 
 func (w *modelProviderWrapper) Call(ctx context.Context, prompt Prompt) (Response, error) {
-	internalPrompt := llm.Prompt{
-		System: prompt.System,
-		User:   prompt.User,
-		Parameters: llm.ModelParameters{
-			Temperature: prompt.Parameters.Temperature,
-			MaxTokens:   prompt.Parameters.MaxTokens,
-		},
-	}
-
-	resp, err := w.internal.Call(ctx, internalPrompt)
+	resp, err := w.internal.Call(ctx, toInternalPrompt(prompt))
 	if err != nil {
 		return Response{}, err
 	}
 
-	return Response{
-		Content: resp.Content,
-		Usage: UsageStats{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
-		FinishReason: resp.FinishReason,
-	}, nil
+	return fromInternalResponse(resp), nil
 }
 
 func (w *modelProviderWrapper) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
-	internalPrompt := llm.Prompt{
-		System: prompt.System,
-		User:   prompt.User,
-		Parameters: llm.ModelParameters{
-			Temperature: prompt.Parameters.Temperature,
-			MaxTokens:   prompt.Parameters.MaxTokens,
-		},
-	}
-
-	internalChan, err := w.internal.Stream(ctx, internalPrompt)
+	internalChan, err := w.internal.Stream(ctx, toInternalPrompt(prompt))
 	if err != nil {
 		return nil, err
 	}
@@ -121,9 +135,27 @@ func (w *modelProviderWrapper) Stream(ctx context.Context, prompt Prompt) (<-cha
 	go func() {
 		defer close(publicChan)
 		for token := range internalChan {
-			publicChan <- Token{
-				Content: token.Content,
-				Error:   token.Error,
+			publicToken := Token{
+				Content:      token.Content,
+				Index:        token.Index,
+				FinishReason: token.FinishReason,
+				ToolCalls:    fromInternalToolCalls(token.ToolCalls),
+				Error:        token.Error,
+			}
+			if token.Usage != nil {
+				publicToken.Usage = &UsageStats{
+					PromptTokens:     token.Usage.PromptTokens,
+					CompletionTokens: token.Usage.CompletionTokens,
+					TotalTokens:      token.Usage.TotalTokens,
+				}
+			}
+			select {
+			case publicChan <- publicToken:
+			case <-ctx.Done():
+				// Keep draining so the provider goroutine can exit
+				for range internalChan {
+				}
+				return
 			}
 		}
 	}()
@@ -135,6 +167,95 @@ func (w *modelProviderWrapper) Embeddings(ctx context.Context, texts []string) (
 	return w.internal.Embeddings(ctx, texts)
 }
 
+// toInternalPrompt maps a public Prompt to the internal llm.Prompt.
+func toInternalPrompt(prompt Prompt) llm.Prompt {
+	internalPrompt := llm.Prompt{
+		System: prompt.System,
+		User:   prompt.User,
+		Parameters: llm.ModelParameters{
+			Temperature:      prompt.Parameters.Temperature,
+			MaxTokens:        prompt.Parameters.MaxTokens,
+			TopP:             prompt.Parameters.TopP,
+			StopSequences:    prompt.Parameters.StopSequences,
+			PresencePenalty:  prompt.Parameters.PresencePenalty,
+			FrequencyPenalty: prompt.Parameters.FrequencyPenalty,
+			Seed:             prompt.Parameters.Seed,
+		},
+	}
+
+	if format := prompt.Parameters.ResponseFormat; format != nil {
+		internalPrompt.Parameters.ResponseFormat = &llm.ResponseFormat{
+			Type:   llm.ResponseFormatType(format.Type),
+			Name:   format.Name,
+			Schema: format.Schema,
+			Strict: format.Strict,
+		}
+	}
+
+	if len(prompt.Messages) > 0 {
+		internalPrompt.Messages = make([]llm.ChatMessage, len(prompt.Messages))
+		for i, msg := range prompt.Messages {
+			internalPrompt.Messages[i] = llm.ChatMessage{
+				Role:       msg.Role,
+				Content:    msg.Content,
+				ToolCalls:  toInternalToolCalls(msg.ToolCalls),
+				ToolCallID: msg.ToolCallID,
+			}
+		}
+	}
+
+	if len(prompt.Tools) > 0 {
+		internalPrompt.Tools = make([]llm.ToolDefinition, len(prompt.Tools))
+		for i, tool := range prompt.Tools {
+			internalPrompt.Tools[i] = llm.ToolDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			}
+		}
+	}
+
+	return internalPrompt
+}
+
+// fromInternalResponse maps an internal llm.Response to the public Response.
+func fromInternalResponse(resp llm.Response) Response {
+	return Response{
+		Content: resp.Content,
+		Usage: UsageStats{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+		FinishReason: resp.FinishReason,
+		ToolCalls:    fromInternalToolCalls(resp.ToolCalls),
+	}
+}
+
+// toInternalToolCalls maps public ToolCalls to internal llm.ToolCalls.
+func toInternalToolCalls(calls []ToolCall) []llm.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	internalCalls := make([]llm.ToolCall, len(calls))
+	for i, call := range calls {
+		internalCalls[i] = llm.ToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+	}
+	return internalCalls
+}
+
+// fromInternalToolCalls maps internal llm.ToolCalls to public ToolCalls.
+func fromInternalToolCalls(calls []llm.ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	publicCalls := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		publicCalls[i] = ToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+	}
+	return publicCalls
+}
+
 // llmAdapterWrapper adapts public ModelProvider to LLMAdapter
 type llmAdapterWrapper struct {
 	provider ModelProvider
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kunalkushwaha/agenticgokit/internal/llm"
+)
+
+func TestLLMConfig_ModelParameters(t *testing.T) {
+	seed := int64(7)
+	config := LLMConfig{
+		Temperature:      0.2,
+		MaxTokens:        300,
+		SystemPrompt:     "Reply in JSON.",
+		TopP:             0.8,
+		StopSequences:    []string{"###"},
+		PresencePenalty:  0.1,
+		FrequencyPenalty: -0.3,
+		Seed:             &seed,
+		ResponseFormat:   "json_schema",
+		ResponseSchema:   map[string]interface{}{"type": "object"},
+	}
+
+	prompt, err := config.Prompt("hello")
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	params := prompt.Parameters
+	if prompt.System != "Reply in JSON." || prompt.User != "hello" {
+		t.Errorf("unexpected prompt: %+v", prompt)
+	}
+	if *params.Temperature != 0.2 || *params.MaxTokens != 300 || *params.TopP != 0.8 {
+		t.Errorf("unexpected sampling parameters: %+v", params)
+	}
+	if *params.PresencePenalty != 0.1 || *params.FrequencyPenalty != -0.3 || *params.Seed != 7 {
+		t.Errorf("unexpected penalties or seed: %+v", params)
+	}
+	if !reflect.DeepEqual(params.StopSequences, []string{"###"}) {
+		t.Errorf("unexpected stop sequences: %v", params.StopSequences)
+	}
+	if params.ResponseFormat == nil || params.ResponseFormat.Type != ResponseFormatJSONSchema || params.ResponseFormat.Schema["type"] != "object" {
+		t.Errorf("unexpected response format: %+v", params.ResponseFormat)
+	}
+
+	// Zero values leave every parameter to the provider
+	if empty, err := (LLMConfig{ResponseFormat: "text"}).ModelParameters(); err != nil || !reflect.DeepEqual(empty, ModelParameters{}) {
+		t.Errorf("expected empty parameters, got %+v (%v)", empty, err)
+	}
+
+	// Unknown formats and a json_schema without its schema are configuration errors
+	for _, bad := range []LLMConfig{{ResponseFormat: "json_schema"}, {ResponseFormat: "yaml"}, {ResponseFormat: "JSON"}} {
+		if _, err := bad.ModelParameters(); err == nil {
+			t.Errorf("expected response_format %q to be rejected", bad.ResponseFormat)
+		}
+		if _, err := bad.Prompt("hello"); err == nil {
+			t.Errorf("expected Prompt to fail for response_format %q", bad.ResponseFormat)
+		}
+	}
+	if err := NewLLMCapability(NewMockModelProvider(), LLMConfig{ResponseFormat: "json_schema"}).Configure(nil); err == nil {
+		t.Error("expected the LLM capability to reject a json_schema format without a schema")
+	}
+}
+
+func TestToInternalPrompt_Parameters(t *testing.T) {
+	prompt := Prompt{
+		User: "hi",
+		Parameters: ModelParameters{
+			TopP:             FloatPtr(0.5),
+			StopSequences:    []string{"STOP"},
+			PresencePenalty:  FloatPtr(0.4),
+			FrequencyPenalty: FloatPtr(0.6),
+			Seed:             Int64Ptr(99),
+			ResponseFormat:   &ResponseFormat{Type: ResponseFormatJSONSchema, Name: "reply", Strict: true},
+		},
+	}
+
+	params := toInternalPrompt(prompt).Parameters
+	if *params.TopP != 0.5 || *params.PresencePenalty != 0.4 || *params.FrequencyPenalty != 0.6 || *params.Seed != 99 {
+		t.Errorf("parameters were not mapped: %+v", params)
+	}
+	if !reflect.DeepEqual(params.StopSequences, []string{"STOP"}) {
+		t.Errorf("stop sequences were not mapped: %v", params.StopSequences)
+	}
+	want := &llm.ResponseFormat{Type: llm.ResponseFormatJSONSchema, Name: "reply", Strict: true}
+	if !reflect.DeepEqual(params.ResponseFormat, want) {
+		t.Errorf("response format = %+v, want %+v", params.ResponseFormat, want)
+	}
+}
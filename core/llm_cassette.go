@@ -0,0 +1,392 @@
+// Package core provides record/replay ModelProviders backed by cassette files for AgentFlow.
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// cassetteVersion is written to every cassette so the format can evolve.
+const cassetteVersion = 1
+
+// ErrCassetteMiss is returned by a ReplayProvider when a request was not recorded.
+var ErrCassetteMiss = errors.New("cassette: no recorded interaction for request")
+
+// Cassette is the on-disk record of model interactions, stored as JSON.
+type Cassette struct {
+	Version      int                   `json:"version"`
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction is one recorded request and its outcome. Completion requests
+// (Call and Stream) set Prompt and Response; embedding requests set Texts and Embeddings.
+type CassetteInteraction struct {
+	// Key identifies the request; replay matches on it.
+	Key        string            `json:"key"`
+	Prompt     *CassettePrompt   `json:"prompt,omitempty"`
+	Response   *CassetteResponse `json:"response,omitempty"`
+	Texts      []string          `json:"texts,omitempty"`
+	Embeddings [][]float64       `json:"embeddings,omitempty"`
+	// Error is the recorded error message, replayed as an error.
+	Error string `json:"error,omitempty"`
+}
+
+// CassettePrompt is the recorded form of a Prompt. Model parameters are not recorded,
+// so tuning temperature or max tokens does not invalidate a cassette.
+type CassettePrompt struct {
+	System   string           `json:"system,omitempty"`
+	Messages []ChatMessage    `json:"messages,omitempty"`
+	User     string           `json:"user,omitempty"`
+	Tools    []ToolDefinition `json:"tools,omitempty"`
+}
+
+// CassetteResponse is the recorded form of a Response.
+type CassetteResponse struct {
+	Content          string     `json:"content"`
+	FinishReason     string     `json:"finish_reason,omitempty"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+	PromptTokens     int        `json:"prompt_tokens,omitempty"`
+	CompletionTokens int        `json:"completion_tokens,omitempty"`
+	TotalTokens      int        `json:"total_tokens,omitempty"`
+}
+
+func newCassettePrompt(prompt Prompt) *CassettePrompt {
+	return &CassettePrompt{
+		System:   prompt.System,
+		Messages: prompt.Messages,
+		User:     prompt.User,
+		Tools:    prompt.Tools,
+	}
+}
+
+func newCassetteResponse(response Response) *CassetteResponse {
+	return &CassetteResponse{
+		Content:          response.Content,
+		FinishReason:     response.FinishReason,
+		ToolCalls:        response.ToolCalls,
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+		TotalTokens:      response.Usage.TotalTokens,
+	}
+}
+
+func (r *CassetteResponse) toResponse() Response {
+	return Response{
+		Content:      r.Content,
+		FinishReason: r.FinishReason,
+		ToolCalls:    r.ToolCalls,
+		Usage: UsageStats{
+			PromptTokens:     r.PromptTokens,
+			CompletionTokens: r.CompletionTokens,
+			TotalTokens:      r.TotalTokens,
+		},
+	}
+}
+
+// promptKey hashes the canonical JSON of a recorded prompt.
+func promptKey(prompt *CassettePrompt) (string, error) {
+	return cassetteKey("prompt", prompt)
+}
+
+// embeddingsKey hashes the texts of an embeddings request.
+func embeddingsKey(texts []string) (string, error) {
+	return cassetteKey("embeddings", texts)
+}
+
+func cassetteKey(kind string, v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("cassette: failed to encode %s: %w", kind, err)
+	}
+	sum := sha256.Sum256(append([]byte(kind+":"), data...))
+	return kind + ":" + hex.EncodeToString(sum[:16]), nil
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read %s: %w", path, err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("cassette: failed to parse %s: %w", path, err)
+	}
+	if cassette.Version > cassetteVersion {
+		return nil, fmt.Errorf("cassette: %s has unsupported version %d", path, cassette.Version)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path atomically, creating parent directories as needed.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette: failed to encode: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("cassette: failed to create directory %s: %w", dir, err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("cassette: failed to write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// RecordingProvider wraps a live ModelProvider and records every interaction to a
+// cassette file. The file is rewritten after each interaction, so a crashed run still
+// leaves a usable cassette behind.
+type RecordingProvider struct {
+	inner    ModelProvider
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingProvider creates a provider that records inner's interactions to path.
+// Any existing cassette at path is replaced once the first interaction is recorded.
+func NewRecordingProvider(inner ModelProvider, path string) (*RecordingProvider, error) {
+	if inner == nil {
+		return nil, errors.New("recording provider requires a provider to record")
+	}
+	if path == "" {
+		return nil, errors.New("recording provider requires a cassette path")
+	}
+	return &RecordingProvider{
+		inner:    inner,
+		path:     path,
+		cassette: Cassette{Version: cassetteVersion},
+	}, nil
+}
+
+// record appends an interaction and flushes the cassette. Write failures are logged
+// rather than returned so recording never changes the behaviour of the wrapped call.
+func (p *RecordingProvider) record(interaction CassetteInteraction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cassette.Interactions = append(p.cassette.Interactions, interaction)
+	if err := p.cassette.Save(p.path); err != nil {
+		Logger().Error().Str("cassette", p.path).Err(err).Msg("RecordingProvider: Failed to save cassette")
+	}
+}
+
+// Call implements ModelProvider.
+func (p *RecordingProvider) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	response, err := p.inner.Call(ctx, prompt)
+	if ctx.Err() != nil {
+		return response, err // Cancelled calls are not reproducible; don't record them
+	}
+
+	recorded := newCassettePrompt(prompt)
+	key, keyErr := promptKey(recorded)
+	if keyErr != nil {
+		Logger().Error().Err(keyErr).Msg("RecordingProvider: Failed to key prompt")
+		return response, err
+	}
+	interaction := CassetteInteraction{Key: key, Prompt: recorded}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Response = newCassetteResponse(response)
+	}
+	p.record(interaction)
+	return response, err
+}
+
+// Stream implements ModelProvider. Tokens are forwarded as they arrive and the
+// assembled response is recorded once the stream completes.
+func (p *RecordingProvider) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	recorded := newCassettePrompt(prompt)
+	key, keyErr := promptKey(recorded)
+
+	tokens, err := p.inner.Stream(ctx, prompt)
+	if err != nil {
+		if keyErr == nil && ctx.Err() == nil {
+			p.record(CassetteInteraction{Key: key, Prompt: recorded, Error: err.Error()})
+		}
+		return nil, err
+	}
+	if keyErr != nil {
+		Logger().Error().Err(keyErr).Msg("RecordingProvider: Failed to key prompt")
+		return tokens, nil
+	}
+
+	out := make(chan Token)
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		var response Response
+		var streamErr error
+		for token := range tokens {
+			content.WriteString(token.Content)
+			if token.FinishReason != "" {
+				response.FinishReason = token.FinishReason
+			}
+			if token.ToolCalls != nil {
+				response.ToolCalls = token.ToolCalls
+			}
+			if token.Usage != nil {
+				response.Usage = *token.Usage
+			}
+			if token.Error != nil {
+				streamErr = token.Error
+			}
+			select {
+			case out <- token:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		interaction := CassetteInteraction{Key: key, Prompt: recorded}
+		if streamErr != nil {
+			interaction.Error = streamErr.Error()
+		} else {
+			response.Content = content.String()
+			interaction.Response = newCassetteResponse(response)
+		}
+		p.record(interaction)
+	}()
+	return out, nil
+}
+
+// Embeddings implements ModelProvider.
+func (p *RecordingProvider) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, err := p.inner.Embeddings(ctx, texts)
+	if ctx.Err() != nil {
+		return embeddings, err
+	}
+
+	key, keyErr := embeddingsKey(texts)
+	if keyErr != nil {
+		Logger().Error().Err(keyErr).Msg("RecordingProvider: Failed to key embeddings request")
+		return embeddings, err
+	}
+	interaction := CassetteInteraction{Key: key, Texts: texts}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Embeddings = embeddings
+	}
+	p.record(interaction)
+	return embeddings, err
+}
+
+// ReplayProvider serves interactions from a cassette without any network access.
+// When the same request was recorded several times the recordings are returned in
+// order, and the last one is repeated once they run out. Requests that were never
+// recorded fail with ErrCassetteMiss.
+type ReplayProvider struct {
+	path         string
+	mu           sync.Mutex
+	interactions map[string][]CassetteInteraction
+	next         map[string]int
+}
+
+// NewReplayProvider loads the cassette at path for replay.
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayProviderFromCassette(path, cassette), nil
+}
+
+// NewReplayProviderFromCassette replays an in-memory cassette. name is only used in error messages.
+func NewReplayProviderFromCassette(name string, cassette *Cassette) *ReplayProvider {
+	p := &ReplayProvider{
+		path:         name,
+		interactions: make(map[string][]CassetteInteraction),
+		next:         make(map[string]int),
+	}
+	for _, interaction := range cassette.Interactions {
+		p.interactions[interaction.Key] = append(p.interactions[interaction.Key], interaction)
+	}
+	return p
+}
+
+// lookup returns the next recorded interaction for key.
+func (p *ReplayProvider) lookup(key, description string) (CassetteInteraction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recorded := p.interactions[key]
+	if len(recorded) == 0 {
+		return CassetteInteraction{}, fmt.Errorf("%w in %s: %s", ErrCassetteMiss, p.path, description)
+	}
+	i := p.next[key]
+	if i < len(recorded)-1 {
+		p.next[key] = i + 1
+	}
+	return recorded[i], nil
+}
+
+func (p *ReplayProvider) lookupPrompt(prompt Prompt) (Response, error) {
+	key, err := promptKey(newCassettePrompt(prompt))
+	if err != nil {
+		return Response{}, err
+	}
+	interaction, err := p.lookup(key, fmt.Sprintf("prompt %q", lastUserInput(prompt)))
+	if err != nil {
+		return Response{}, err
+	}
+	if interaction.Error != "" {
+		return Response{}, errors.New(interaction.Error)
+	}
+	if interaction.Response == nil {
+		return Response{}, fmt.Errorf("cassette: interaction %s has no response", key)
+	}
+	return interaction.Response.toResponse(), nil
+}
+
+// Call implements ModelProvider.
+func (p *ReplayProvider) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	return p.lookupPrompt(prompt)
+}
+
+// Stream implements ModelProvider, replaying the recorded response as word-sized tokens.
+func (p *ReplayProvider) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	response, err := p.lookupPrompt(prompt)
+	if err != nil {
+		return nil, err
+	}
+	return streamResponse(ctx, response), nil
+}
+
+// Embeddings implements ModelProvider.
+func (p *ReplayProvider) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key, err := embeddingsKey(texts)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := p.lookup(key, fmt.Sprintf("embeddings for %d texts", len(texts)))
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	return interaction.Embeddings, nil
+}
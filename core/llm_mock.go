@@ -0,0 +1,260 @@
+// Package core provides a deterministic, scriptable ModelProvider for tests in AgentFlow.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// defaultMockResponse is the content returned by NewMockProvider.
+const defaultMockResponse = "This is a mock response."
+
+// ErrNoMockResponse is returned when no rule matches a prompt and no default response is set.
+var ErrNoMockResponse = errors.New("mock provider: no response configured for prompt")
+
+// PromptMatcher reports whether a canned response applies to a prompt.
+type PromptMatcher func(prompt Prompt) bool
+
+// MatchAny matches every prompt.
+func MatchAny() PromptMatcher {
+	return func(Prompt) bool { return true }
+}
+
+// MatchUserContains matches prompts whose latest user input contains substr.
+func MatchUserContains(substr string) PromptMatcher {
+	return func(prompt Prompt) bool {
+		return strings.Contains(lastUserInput(prompt), substr)
+	}
+}
+
+// MatchUserExact matches prompts whose latest user input equals text.
+func MatchUserExact(text string) PromptMatcher {
+	return func(prompt Prompt) bool {
+		return lastUserInput(prompt) == text
+	}
+}
+
+// MatchSystemContains matches prompts whose system message contains substr.
+func MatchSystemContains(substr string) PromptMatcher {
+	return func(prompt Prompt) bool {
+		return strings.Contains(prompt.System, substr)
+	}
+}
+
+// MatchUserRegexp matches prompts whose latest user input matches pattern.
+// It panics if pattern does not compile, like regexp.MustCompile.
+func MatchUserRegexp(pattern string) PromptMatcher {
+	re := regexp.MustCompile(pattern)
+	return func(prompt Prompt) bool {
+		return re.MatchString(lastUserInput(prompt))
+	}
+}
+
+// lastUserInput returns Prompt.User, or the last user turn in Messages when User is empty.
+func lastUserInput(prompt Prompt) string {
+	if prompt.User != "" {
+		return prompt.User
+	}
+	for i := len(prompt.Messages) - 1; i >= 0; i-- {
+		if prompt.Messages[i].Role == ChatRoleUser {
+			return prompt.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// mockRule pairs a matcher with the response or error it produces.
+type mockRule struct {
+	match    PromptMatcher
+	response Response
+	err      error
+}
+
+// MockModelProvider is a deterministic ModelProvider for tests. Rules are checked in the
+// order they were added and the first match wins; unmatched prompts get the default
+// response, or ErrNoMockResponse when none is set. Embeddings are derived from a hash of
+// each text, so equal texts always map to equal vectors.
+type MockModelProvider struct {
+	mu                  sync.Mutex
+	rules               []mockRule
+	defaultResponse     *Response
+	embeddingDimensions int
+	calls               []Prompt
+}
+
+// NewMockModelProvider creates a mock provider with no rules and 8-dimensional embeddings.
+func NewMockModelProvider() *MockModelProvider {
+	return &MockModelProvider{embeddingDimensions: 8}
+}
+
+// NewMockProvider creates a mock provider that answers every prompt with a fixed
+// response, for demos and smoke tests that only need a working provider.
+func NewMockProvider() *MockModelProvider {
+	return NewMockModelProvider().SetDefaultResponse(Response{Content: defaultMockResponse, FinishReason: "stop"})
+}
+
+// Respond adds a rule returning response for prompts accepted by match.
+func (m *MockModelProvider) Respond(match PromptMatcher, response Response) *MockModelProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, mockRule{match: match, response: response})
+	return m
+}
+
+// RespondText adds a rule returning content for prompts accepted by match.
+func (m *MockModelProvider) RespondText(match PromptMatcher, content string) *MockModelProvider {
+	return m.Respond(match, Response{Content: content, FinishReason: "stop"})
+}
+
+// RespondError adds a rule failing with err for prompts accepted by match.
+func (m *MockModelProvider) RespondError(match PromptMatcher, err error) *MockModelProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, mockRule{match: match, err: err})
+	return m
+}
+
+// SetDefaultResponse sets the response used when no rule matches.
+func (m *MockModelProvider) SetDefaultResponse(response Response) *MockModelProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultResponse = &response
+	return m
+}
+
+// SetEmbeddingDimensions sets the length of the vectors returned by Embeddings.
+func (m *MockModelProvider) SetEmbeddingDimensions(dimensions int) *MockModelProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dimensions > 0 {
+		m.embeddingDimensions = dimensions
+	}
+	return m
+}
+
+// Calls returns the prompts received so far, in order.
+func (m *MockModelProvider) Calls() []Prompt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]Prompt, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// Call implements ModelProvider.
+func (m *MockModelProvider) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, prompt)
+
+	for _, rule := range m.rules {
+		if rule.match(prompt) {
+			if rule.err != nil {
+				return Response{}, rule.err
+			}
+			return withEstimatedUsage(prompt, rule.response), nil
+		}
+	}
+	if m.defaultResponse != nil {
+		return withEstimatedUsage(prompt, *m.defaultResponse), nil
+	}
+	return Response{}, fmt.Errorf("%w: %q", ErrNoMockResponse, lastUserInput(prompt))
+}
+
+// Stream implements ModelProvider by splitting the matched response into word-sized tokens.
+func (m *MockModelProvider) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	response, err := m.Call(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return streamResponse(ctx, response), nil
+}
+
+// Embeddings implements ModelProvider with deterministic, hash-derived unit vectors.
+func (m *MockModelProvider) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	m.mu.Lock()
+	dimensions := m.embeddingDimensions
+	m.mu.Unlock()
+
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i] = hashEmbedding(text, dimensions)
+	}
+	return embeddings, nil
+}
+
+// withEstimatedUsage fills in usage from a rough token estimate when the canned response has none.
+func withEstimatedUsage(prompt Prompt, response Response) Response {
+	if response.Usage.TotalTokens > 0 {
+		return response
+	}
+	promptTokens := estimateTokenCount(prompt.System + prompt.User)
+	for _, msg := range prompt.Messages {
+		promptTokens += estimateTokenCount(msg.Content)
+	}
+	completionTokens := estimateTokenCount(response.Content)
+	response.Usage = UsageStats{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+	return response
+}
+
+// streamResponse replays a complete response as a token stream: one token per word,
+// followed by a final token carrying the finish reason and usage.
+func streamResponse(ctx context.Context, response Response) <-chan Token {
+	ch := make(chan Token)
+	go func() {
+		defer close(ch)
+		index := 0
+		for _, chunk := range strings.SplitAfter(response.Content, " ") {
+			if chunk == "" {
+				continue
+			}
+			select {
+			case ch <- Token{Content: chunk, Index: index}:
+				index++
+			case <-ctx.Done():
+				return
+			}
+		}
+		usage := response.Usage
+		select {
+		case ch <- Token{Index: index, FinishReason: response.FinishReason, ToolCalls: response.ToolCalls, Usage: &usage}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
+
+// hashEmbedding derives a deterministic unit vector from text.
+func hashEmbedding(text string, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+	var norm float64
+	for i := range vector {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%d:%s", i, text)
+		// Map the hash onto [-1, 1)
+		vector[i] = float64(h.Sum64()%2000)/1000 - 1
+		norm += vector[i] * vector[i]
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMockModelProvider_MatchesRulesInOrder(t *testing.T) {
+	mock := NewMockModelProvider().
+		RespondText(MatchUserContains("weather"), "It is sunny.").
+		RespondText(MatchSystemContains("pirate"), "Arr!").
+		RespondError(MatchUserExact("fail"), errors.New("boom"))
+
+	ctx := context.Background()
+	resp, err := mock.Call(ctx, Prompt{System: "You are a pirate", User: "What is the weather?"})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if resp.Content != "It is sunny." {
+		t.Errorf("expected first matching rule to win, got %q", resp.Content)
+	}
+	if resp.Usage.TotalTokens == 0 {
+		t.Error("expected estimated usage to be filled in")
+	}
+
+	resp, err = mock.Call(ctx, Prompt{System: "You are a pirate", User: "Hello"})
+	if err != nil || resp.Content != "Arr!" {
+		t.Errorf("expected system rule to match, got %q, %v", resp.Content, err)
+	}
+
+	if _, err := mock.Call(ctx, Prompt{User: "fail"}); err == nil || err.Error() != "boom" {
+		t.Errorf("expected scripted error, got %v", err)
+	}
+
+	if _, err := mock.Call(ctx, Prompt{User: "unmatched"}); !errors.Is(err, ErrNoMockResponse) {
+		t.Errorf("expected ErrNoMockResponse, got %v", err)
+	}
+
+	mock.SetDefaultResponse(Response{Content: "default"})
+	if resp, err := mock.Call(ctx, Prompt{User: "unmatched"}); err != nil || resp.Content != "default" {
+		t.Errorf("expected default response, got %q, %v", resp.Content, err)
+	}
+
+	if calls := mock.Calls(); len(calls) != 5 || calls[3].User != "unmatched" {
+		t.Errorf("unexpected recorded calls: %+v", calls)
+	}
+}
+
+func TestMockModelProvider_MatchesMessageHistory(t *testing.T) {
+	mock := NewMockModelProvider().RespondText(MatchUserRegexp(`^order #\d+$`), "shipped")
+	prompt := Prompt{Messages: []ChatMessage{
+		{Role: ChatRoleUser, Content: "order #42"},
+	}}
+	resp, err := mock.Call(context.Background(), prompt)
+	if err != nil || resp.Content != "shipped" {
+		t.Errorf("expected match on last user message, got %q, %v", resp.Content, err)
+	}
+}
+
+func TestMockModelProvider_Stream(t *testing.T) {
+	mock := NewMockModelProvider().RespondText(MatchAny(), "one two three")
+	tokens, err := mock.Stream(context.Background(), Prompt{User: "count"})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	var content strings.Builder
+	var last Token
+	for token := range tokens {
+		content.WriteString(token.Content)
+		last = token
+	}
+	if content.String() != "one two three" {
+		t.Errorf("unexpected streamed content %q", content.String())
+	}
+	if last.FinishReason != "stop" || last.Usage == nil || last.Index != 3 {
+		t.Errorf("unexpected final token: %+v", last)
+	}
+}
+
+func TestMockModelProvider_EmbeddingsAreDeterministic(t *testing.T) {
+	mock := NewMockModelProvider().SetEmbeddingDimensions(4)
+	first, err := mock.Embeddings(context.Background(), []string{"alpha", "beta"})
+	if err != nil {
+		t.Fatalf("Embeddings failed: %v", err)
+	}
+	second, _ := mock.Embeddings(context.Background(), []string{"alpha"})
+	if len(first[0]) != 4 {
+		t.Fatalf("expected 4 dimensions, got %d", len(first[0]))
+	}
+	if !reflect.DeepEqual(first[0], second[0]) {
+		t.Error("expected equal texts to produce equal vectors")
+	}
+	if reflect.DeepEqual(first[0], first[1]) {
+		t.Error("expected different texts to produce different vectors")
+	}
+}
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "agent.json")
+	live := NewMockModelProvider().
+		RespondText(MatchUserExact("first"), "answer one").
+		RespondText(MatchUserExact("streamed"), "streamed answer").
+		RespondError(MatchUserExact("broken"), errors.New("rate limited"))
+
+	recorder, err := NewRecordingProvider(live, path)
+	if err != nil {
+		t.Fatalf("NewRecordingProvider failed: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := recorder.Call(ctx, Prompt{User: "first"}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if _, err := recorder.Call(ctx, Prompt{User: "broken"}); err == nil {
+		t.Fatal("expected recorded call to fail")
+	}
+	tokens, err := recorder.Stream(ctx, Prompt{User: "streamed"})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	for range tokens {
+	}
+	if _, err := recorder.Embeddings(ctx, []string{"doc"}); err != nil {
+		t.Fatalf("Embeddings failed: %v", err)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette failed: %v", err)
+	}
+	if len(cassette.Interactions) != 4 {
+		t.Fatalf("expected 4 recorded interactions, got %d", len(cassette.Interactions))
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatalf("NewReplayProvider failed: %v", err)
+	}
+	// Parameters are not part of the match, so changing them still replays
+	temperature := float32(0.1)
+	resp, err := replay.Call(ctx, Prompt{User: "first", Parameters: ModelParameters{Temperature: &temperature}})
+	if err != nil || resp.Content != "answer one" {
+		t.Errorf("expected replayed response, got %q, %v", resp.Content, err)
+	}
+	if _, err := replay.Call(ctx, Prompt{User: "broken"}); err == nil || err.Error() != "rate limited" {
+		t.Errorf("expected replayed error, got %v", err)
+	}
+	if resp, err := replay.Call(ctx, Prompt{User: "streamed"}); err != nil || resp.Content != "streamed answer" {
+		t.Errorf("expected recorded stream to replay as a call, got %q, %v", resp.Content, err)
+	}
+	embeddings, err := replay.Embeddings(ctx, []string{"doc"})
+	if err != nil || len(embeddings) != 1 || len(embeddings[0]) != 8 {
+		t.Errorf("unexpected replayed embeddings: %v, %v", embeddings, err)
+	}
+	if _, err := replay.Call(ctx, Prompt{User: "never recorded"}); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("expected ErrCassetteMiss, got %v", err)
+	}
+}
+
+func TestReplayProvider_RepeatedPromptsReplayInOrder(t *testing.T) {
+	key, err := promptKey(newCassettePrompt(Prompt{User: "roll"}))
+	if err != nil {
+		t.Fatalf("promptKey failed: %v", err)
+	}
+	replay := NewReplayProviderFromCassette("inline", &Cassette{Version: cassetteVersion, Interactions: []CassetteInteraction{
+		{Key: key, Response: &CassetteResponse{Content: "1"}},
+		{Key: key, Response: &CassetteResponse{Content: "2"}},
+	}})
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		resp, err := replay.Call(context.Background(), Prompt{User: "roll"})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		got = append(got, resp.Content)
+	}
+	if want := []string{"1", "2", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestInitializeProvider_Mock(t *testing.T) {
+	cfg := &Config{Providers: map[string]map[string]interface{}{
+		"mock": {"response": "canned"},
+	}}
+	cfg.AgentFlow.Provider = "mock"
+	provider, err := cfg.InitializeProvider()
+	if err != nil {
+		t.Fatalf("InitializeProvider failed: %v", err)
+	}
+	resp, err := provider.Call(context.Background(), Prompt{User: "anything"})
+	if err != nil || resp.Content != "canned" {
+		t.Errorf("expected canned response, got %q, %v", resp.Content, err)
+	}
+}
+
+func TestInitializeProvider_MockRecordRequiresOtherProvider(t *testing.T) {
+	cfg := &Config{Providers: map[string]map[string]interface{}{
+		"mock": {"cassette": filepath.Join(t.TempDir(), "c.json"), "mode": "record", "record_provider": "mock"},
+	}}
+	cfg.AgentFlow.Provider = "mock"
+	if _, err := cfg.InitializeProvider(); err == nil {
+		t.Error("expected error when record_provider refers to the mock provider itself")
+	}
+}
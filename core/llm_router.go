@@ -0,0 +1,269 @@
+// Package core provides a routing ModelProvider with fallback chains and model tiers for AgentFlow.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProviderRouterConfig configures a ProviderRouter.
+type ProviderRouterConfig struct {
+	// Providers maps provider names to their implementations.
+	Providers map[string]ModelProvider
+	// Fallback is the default chain: provider names tried in order until one succeeds.
+	Fallback []string
+	// Tiers maps a tier name (e.g. "fast", "cheap", "quality") to its own ordered chain.
+	Tiers map[string][]string
+	// Agents maps an agent name to a tier name or a provider name. An agent mapped to a
+	// provider tries that provider first and then the default chain.
+	Agents map[string]string
+	// CircuitBreaker configures the breaker kept for each provider. nil uses the defaults.
+	CircuitBreaker *CircuitBreakerConfig
+}
+
+// ProviderRouter is a ModelProvider that routes calls across several providers.
+// A provider whose call fails, or whose circuit breaker is open, is skipped in favour
+// of the next one in the chain, so one outage does not take down every agent.
+type ProviderRouter struct {
+	providers map[string]*routedProvider
+	fallback  []string
+	tiers     map[string][]string
+	agents    map[string]string
+}
+
+// routedProvider pairs a provider with the circuit breaker guarding it.
+type routedProvider struct {
+	name     string
+	provider ModelProvider
+	breaker  *CircuitBreaker
+}
+
+// NewProviderRouter creates a router and validates that every chain refers to known providers.
+func NewProviderRouter(config ProviderRouterConfig) (*ProviderRouter, error) {
+	if len(config.Providers) == 0 {
+		return nil, errors.New("provider router requires at least one provider")
+	}
+	if len(config.Fallback) == 0 {
+		return nil, errors.New("provider router requires a fallback chain")
+	}
+
+	router := &ProviderRouter{
+		providers: make(map[string]*routedProvider, len(config.Providers)),
+		fallback:  config.Fallback,
+		tiers:     make(map[string][]string, len(config.Tiers)),
+		agents:    make(map[string]string, len(config.Agents)),
+	}
+	for name, provider := range config.Providers {
+		if provider == nil {
+			return nil, fmt.Errorf("provider %s is nil", name)
+		}
+		breakerConfig := DefaultCircuitBreakerConfig()
+		if config.CircuitBreaker != nil {
+			copied := *config.CircuitBreaker
+			breakerConfig = &copied
+		}
+		router.providers[name] = &routedProvider{name: name, provider: provider, breaker: NewCircuitBreaker(breakerConfig)}
+	}
+
+	if err := router.validateChain("fallback", config.Fallback); err != nil {
+		return nil, err
+	}
+	for tier, chain := range config.Tiers {
+		if err := router.validateChain("tier "+tier, chain); err != nil {
+			return nil, err
+		}
+		router.tiers[tier] = chain
+	}
+	for agent, target := range config.Agents {
+		_, isTier := router.tiers[target]
+		_, isProvider := router.providers[target]
+		if !isTier && !isProvider {
+			return nil, fmt.Errorf("agent %s is mapped to unknown tier or provider %s", agent, target)
+		}
+		router.agents[agent] = target
+	}
+
+	return router, nil
+}
+
+// validateChain checks that a chain is non-empty and only names registered providers.
+func (r *ProviderRouter) validateChain(label string, chain []string) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("%s chain is empty", label)
+	}
+	for _, name := range chain {
+		if _, ok := r.providers[name]; !ok {
+			return fmt.Errorf("%s chain refers to unknown provider %s", label, name)
+		}
+	}
+	return nil
+}
+
+type modelTierKey struct{}
+
+// WithModelTier selects a router tier for calls made with the returned context.
+func WithModelTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, modelTierKey{}, tier)
+}
+
+// ForTier returns a ModelProvider that always uses the given tier's chain.
+// Unknown tiers fall back to the default chain.
+func (r *ProviderRouter) ForTier(tier string) ModelProvider {
+	chain, ok := r.tiers[tier]
+	if !ok {
+		Logger().Warn().Str("tier", tier).Msg("ProviderRouter: Unknown tier, using default fallback chain")
+		chain = r.fallback
+	}
+	return &routedChain{router: r, chain: chain}
+}
+
+// ForAgent returns the ModelProvider configured for an agent. Agents without an entry
+// use the default chain.
+func (r *ProviderRouter) ForAgent(agentName string) ModelProvider {
+	chain, ok := r.agentChain(agentName)
+	if !ok {
+		chain = r.fallback
+	}
+	return &routedChain{router: r, chain: chain}
+}
+
+// agentChain resolves the chain configured for an agent, if it has an entry.
+func (r *ProviderRouter) agentChain(agentName string) ([]string, bool) {
+	target, ok := r.agents[agentName]
+	if !ok {
+		return nil, false
+	}
+	if chain, isTier := r.tiers[target]; isTier {
+		return chain, true
+	}
+	chain := []string{target}
+	for _, name := range r.fallback {
+		if name != target {
+			chain = append(chain, name)
+		}
+	}
+	return chain, true
+}
+
+// CircuitBreakerStates reports the breaker state of every provider, keyed by provider name.
+func (r *ProviderRouter) CircuitBreakerStates() map[string]CircuitBreakerState {
+	states := make(map[string]CircuitBreakerState, len(r.providers))
+	for name, provider := range r.providers {
+		states[name] = provider.breaker.GetState()
+	}
+	return states
+}
+
+// chainFor resolves the chain for a call. A tier set with WithModelTier wins, then the
+// entry of the agent making the call, which orchestrators set on ctx before running it.
+func (r *ProviderRouter) chainFor(ctx context.Context) []string {
+	if tier, ok := ctx.Value(modelTierKey{}).(string); ok && tier != "" {
+		if chain, exists := r.tiers[tier]; exists {
+			return chain
+		}
+		Logger().Warn().Str("tier", tier).Msg("ProviderRouter: Unknown tier, using default fallback chain")
+	}
+	if agentID, ok := ctx.Value(usageAgentKey{}).(string); ok && agentID != "" {
+		if chain, exists := r.agentChain(agentID); exists {
+			return chain
+		}
+	}
+	return r.fallback
+}
+
+// Call implements ModelProvider using the chain of the tier or agent found on ctx, or
+// the default chain.
+func (r *ProviderRouter) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	return r.call(ctx, r.chainFor(ctx), prompt)
+}
+
+// Stream implements ModelProvider. Fallback only applies while opening the stream;
+// errors reported on the token channel are passed through to the caller.
+func (r *ProviderRouter) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	return r.stream(ctx, r.chainFor(ctx), prompt)
+}
+
+// Embeddings implements ModelProvider using only the first provider of the chain.
+// Falling back would silently mix vectors from different embedding spaces.
+func (r *ProviderRouter) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return r.embeddings(ctx, r.chainFor(ctx), texts)
+}
+
+func (r *ProviderRouter) call(ctx context.Context, chain []string, prompt Prompt) (Response, error) {
+	var response Response
+	err := r.tryChain(ctx, chain, func(provider ModelProvider) error {
+		var callErr error
+		response, callErr = provider.Call(ctx, prompt)
+		return callErr
+	})
+	return response, err
+}
+
+func (r *ProviderRouter) stream(ctx context.Context, chain []string, prompt Prompt) (<-chan Token, error) {
+	var tokens <-chan Token
+	err := r.tryChain(ctx, chain, func(provider ModelProvider) error {
+		var streamErr error
+		tokens, streamErr = provider.Stream(ctx, prompt)
+		return streamErr
+	})
+	return tokens, err
+}
+
+func (r *ProviderRouter) embeddings(ctx context.Context, chain []string, texts []string) ([][]float64, error) {
+	primary := r.providers[chain[0]]
+	var embeddings [][]float64
+	err := primary.breaker.Call(func() error {
+		var embedErr error
+		embeddings, embedErr = primary.provider.Embeddings(ctx, texts)
+		return embedErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", primary.name, err)
+	}
+	return embeddings, nil
+}
+
+// tryChain runs fn against each provider in order until one succeeds. Cancellation of
+// ctx stops the chain immediately instead of falling through to the next provider.
+func (r *ProviderRouter) tryChain(ctx context.Context, chain []string, fn func(ModelProvider) error) error {
+	var failures []string
+	for i, name := range chain {
+		routed := r.providers[name]
+		err := routed.breaker.Call(func() error {
+			return fn(routed.provider)
+		})
+		if err == nil {
+			if i > 0 {
+				Logger().Info().Str("provider", name).Int("position", i).Msg("ProviderRouter: Served by fallback provider")
+			}
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		Logger().Warn().Str("provider", name).Err(err).Msg("ProviderRouter: Provider failed, trying next in chain")
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	return fmt.Errorf("all providers failed: %s", strings.Join(failures, "; "))
+}
+
+// routedChain is a ModelProvider bound to one chain of a ProviderRouter.
+type routedChain struct {
+	router *ProviderRouter
+	chain  []string
+}
+
+func (c *routedChain) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	return c.router.call(ctx, c.chain, prompt)
+}
+
+func (c *routedChain) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	return c.router.stream(ctx, c.chain, prompt)
+}
+
+func (c *routedChain) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.router.embeddings(ctx, c.chain, texts)
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// routerTestProvider answers with its own name, or fails with err when set.
+type routerTestProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *routerTestProvider) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	p.calls++
+	if p.err != nil {
+		return Response{}, p.err
+	}
+	return Response{Content: p.name}, nil
+}
+
+func (p *routerTestProvider) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	ch := make(chan Token, 1)
+	ch <- Token{Content: p.name}
+	close(ch)
+	return ch, nil
+}
+
+func (p *routerTestProvider) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return [][]float64{{1}}, nil
+}
+
+func TestProviderRouter_FallsBackOnError(t *testing.T) {
+	azure := &routerTestProvider{name: "azure", err: errors.New("503 service unavailable")}
+	ollama := &routerTestProvider{name: "ollama"}
+	router, err := NewProviderRouter(ProviderRouterConfig{
+		Providers: map[string]ModelProvider{"azure": azure, "ollama": ollama},
+		Fallback:  []string{"azure", "ollama"},
+	})
+	if err != nil {
+		t.Fatalf("NewProviderRouter failed: %v", err)
+	}
+
+	resp, err := router.Call(context.Background(), Prompt{User: "hi"})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if resp.Content != "ollama" {
+		t.Errorf("expected fallback to ollama, got %q", resp.Content)
+	}
+
+	tokens, err := router.Stream(context.Background(), Prompt{User: "hi"})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if token := <-tokens; token.Content != "ollama" {
+		t.Errorf("expected stream from ollama, got %q", token.Content)
+	}
+}
+
+func TestProviderRouter_SkipsOpenCircuit(t *testing.T) {
+	azure := &routerTestProvider{name: "azure", err: errors.New("timeout")}
+	ollama := &routerTestProvider{name: "ollama"}
+	router, err := NewProviderRouter(ProviderRouterConfig{
+		Providers:      map[string]ModelProvider{"azure": azure, "ollama": ollama},
+		Fallback:       []string{"azure", "ollama"},
+		CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 2, SuccessThreshold: 1, Timeout: time.Minute, MaxConcurrentCalls: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewProviderRouter failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := router.Call(context.Background(), Prompt{User: "hi"}); err != nil {
+			t.Fatalf("Call %d failed: %v", i, err)
+		}
+	}
+	if azure.calls != 2 {
+		t.Errorf("expected azure to be called until its breaker opened (2 calls), got %d", azure.calls)
+	}
+	if state := router.CircuitBreakerStates()["azure"]; state != CircuitBreakerOpen {
+		t.Errorf("expected azure breaker to be open, got %s", state)
+	}
+}
+
+func TestProviderRouter_AllProvidersFail(t *testing.T) {
+	router, err := NewProviderRouter(ProviderRouterConfig{
+		Providers: map[string]ModelProvider{
+			"a": &routerTestProvider{name: "a", err: errors.New("down")},
+			"b": &routerTestProvider{name: "b", err: errors.New("down")},
+		},
+		Fallback: []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewProviderRouter failed: %v", err)
+	}
+	if _, err := router.Call(context.Background(), Prompt{User: "hi"}); err == nil {
+		t.Error("expected an error when every provider fails")
+	}
+}
+
+func TestProviderRouter_TiersAndAgents(t *testing.T) {
+	providers := map[string]ModelProvider{
+		"azure":  &routerTestProvider{name: "azure"},
+		"openai": &routerTestProvider{name: "openai"},
+		"ollama": &routerTestProvider{name: "ollama"},
+	}
+	router, err := NewProviderRouter(ProviderRouterConfig{
+		Providers: providers,
+		Fallback:  []string{"azure", "ollama"},
+		Tiers:     map[string][]string{"fast": {"ollama"}},
+		Agents:    map[string]string{"summarizer": "fast", "writer": "openai"},
+	})
+	if err != nil {
+		t.Fatalf("NewProviderRouter failed: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		provider ModelProvider
+		ctx      context.Context
+		want     string
+	}{
+		{"default chain", router, context.Background(), "azure"},
+		{"tier from context", router, WithModelTier(context.Background(), "fast"), "ollama"},
+		{"agent mapped to tier", router.ForAgent("summarizer"), context.Background(), "ollama"},
+		{"agent from context", router, WithUsageAgent(context.Background(), "writer"), "openai"},
+		{"tier overrides agent", router, WithModelTier(WithUsageAgent(context.Background(), "writer"), "fast"), "ollama"},
+		{"agent mapped to provider", router.ForAgent("writer"), context.Background(), "openai"},
+		{"unmapped agent", router.ForAgent("other"), context.Background(), "azure"},
+		{"explicit tier", router.ForTier("fast"), context.Background(), "ollama"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.provider.Call(tc.ctx, Prompt{User: "hi"})
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			if resp.Content != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, resp.Content)
+			}
+		})
+	}
+}
+
+func TestProviderRouter_RoutesRunnerAgents(t *testing.T) {
+	router, err := NewProviderRouter(ProviderRouterConfig{
+		Providers: map[string]ModelProvider{
+			"azure":  &routerTestProvider{name: "azure"},
+			"ollama": &routerTestProvider{name: "ollama"},
+		},
+		Fallback: []string{"azure", "ollama"},
+		Tiers:    map[string][]string{"fast": {"ollama"}},
+		Agents:   map[string]string{"summarizer": "fast"},
+	})
+	if err != nil {
+		t.Fatalf("NewProviderRouter failed: %v", err)
+	}
+
+	// Each agent calls the shared router directly and records who answered
+	agent := func(name string) AgentHandlerFunc {
+		return func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			resp, err := router.Call(ctx, Prompt{User: "hi"})
+			if err != nil {
+				return AgentResult{}, err
+			}
+			output := state.Clone()
+			output.Set(name, resp.Content)
+			return AgentResult{OutputState: output}, nil
+		}
+	}
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents:    map[string]AgentHandler{"writer": agent("writer"), "summarizer": agent("summarizer")},
+			Memory:    QuickMemory(),
+			SessionID: "router-test",
+		},
+		OrchestrationMode: OrchestrationSequential,
+		SequentialAgents:  []string{"writer", "summarizer"},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	result, err := runner.Run(ctx, NewEvent("writer", EventData{}, map[string]string{SessionIDKey: "router-test"}))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	writer, _ := result.OutputState.Get("writer")
+	summarizer, _ := result.OutputState.Get("summarizer")
+	if writer != "azure" || summarizer != "ollama" {
+		t.Errorf("expected writer on azure and summarizer on ollama, got %v and %v", writer, summarizer)
+	}
+}
+
+func TestNewProviderRouter_Validation(t *testing.T) {
+	providers := map[string]ModelProvider{"a": &routerTestProvider{name: "a"}}
+	if _, err := NewProviderRouter(ProviderRouterConfig{Providers: providers, Fallback: []string{"missing"}}); err == nil {
+		t.Error("expected error for unknown provider in fallback chain")
+	}
+	if _, err := NewProviderRouter(ProviderRouterConfig{Providers: providers, Fallback: []string{"a"}, Agents: map[string]string{"x": "nope"}}); err == nil {
+		t.Error("expected error for agent mapped to unknown target")
+	}
+}
+
+func TestInitializeProvider_Routing(t *testing.T) {
+	file := "test_routing_agentflow.toml"
+	content := `[agent_flow]
+name = "TestAgent"
+provider = "azure"
+
+[providers.azure]
+# No credentials: this provider is skipped during initialization
+
+[providers.ollama]
+base_url = "http://localhost:11434"
+
+[providers.local]
+type = "openai-compatible"
+base_url = "http://localhost:8000/v1"
+model = "test-model"
+
+[provider_routing]
+fallback = ["ollama"]
+
+[provider_routing.tiers]
+fast = ["local", "ollama"]
+
+[provider_routing.agents]
+summarizer = "fast"
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	defer os.Remove(file)
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	router, err := cfg.InitializeProviderRouter()
+	if err != nil {
+		t.Fatalf("InitializeProviderRouter failed: %v", err)
+	}
+	if len(router.fallback) != 1 || router.fallback[0] != "ollama" {
+		t.Errorf("expected unavailable azure to be dropped from the chain, got %v", router.fallback)
+	}
+	if target := router.agents["summarizer"]; target != "fast" {
+		t.Errorf("expected summarizer to use the fast tier, got %q", target)
+	}
+
+	provider, err := cfg.InitializeProvider()
+	if err != nil {
+		t.Fatalf("InitializeProvider failed: %v", err)
+	}
+	if _, ok := provider.(*ProviderRouter); !ok {
+		t.Errorf("expected InitializeProvider to return a *ProviderRouter, got %T", provider)
+	}
+}
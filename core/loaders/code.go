@@ -0,0 +1,79 @@
+package loaders
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+// codeLanguages maps source file extensions to language names.
+var codeLanguages = map[string]string{
+	".go": "go", ".py": "python", ".pyi": "python", ".js": "javascript", ".mjs": "javascript",
+	".cjs": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".rs": "rust",
+	".rb": "ruby", ".php": "php", ".cs": "csharp", ".fs": "fsharp", ".swift": "swift",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp",
+	".m": "objective-c", ".lua": "lua", ".pl": "perl", ".r": "r", ".jl": "julia",
+	".dart": "dart", ".ex": "elixir", ".exs": "elixir", ".erl": "erlang", ".hs": "haskell",
+	".clj": "clojure", ".sh": "shell", ".bash": "shell", ".zsh": "shell", ".ps1": "powershell",
+	".sql": "sql", ".proto": "protobuf", ".graphql": "graphql", ".tf": "terraform",
+	".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".xml": "xml", ".css": "css",
+	".scss": "scss", ".vue": "vue", ".svelte": "svelte",
+}
+
+// codeFileNames maps well-known file names without a language extension.
+var codeFileNames = map[string]string{
+	"dockerfile": "dockerfile", "makefile": "make", "gnumakefile": "make",
+	"rakefile": "ruby", "gemfile": "ruby", "jenkinsfile": "groovy", "vagrantfile": "ruby",
+}
+
+// shebangInterpreters maps "#!" interpreters to language names.
+var shebangInterpreters = map[string]string{
+	"sh": "shell", "bash": "shell", "zsh": "shell", "python": "python", "python3": "python",
+	"node": "javascript", "ruby": "ruby", "perl": "perl", "php": "php", "lua": "lua",
+}
+
+// DetectLanguage returns the programming language of a source file from its extension,
+// its name (Dockerfile, Makefile, ...) or its "#!" line, or "" when it is not code.
+func DetectLanguage(path string, content []byte) string {
+	if language, ok := codeLanguages[strings.ToLower(filepath.Ext(path))]; ok {
+		return language
+	}
+	if language, ok := codeFileNames[strings.ToLower(filepath.Base(path))]; ok {
+		return language
+	}
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line := string(content[2:])
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+	return shebangInterpreters[interpreter]
+}
+
+// loadCode loads a source file with its language and line count in the metadata.
+func loadCode(path string, content []byte, opts Options) ([]core.Document, error) {
+	text, err := decodeText(content)
+	if err != nil {
+		return nil, err
+	}
+	return []core.Document{{
+		Title:   filepath.Base(path),
+		Content: text,
+		Metadata: map[string]any{
+			"language": DetectLanguage(path, content),
+			"lines":    strings.Count(text, "\n") + 1,
+		},
+	}}, nil
+}
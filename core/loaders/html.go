@@ -0,0 +1,195 @@
+package loaders
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// loadHTML loads an HTML page as text. Scripts, styles and other non-content elements are
+// dropped, block elements become paragraphs and headings keep Markdown markers so the
+// structure survives. The <title>, or else the first <h1>, becomes the title, and the
+// description meta tag and the page language go to the metadata.
+func loadHTML(path string, content []byte, opts Options) ([]core.Document, error) {
+	root, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := core.Document{Metadata: map[string]any{}}
+	var text htmlText
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Iframe, atom.Head:
+				collectHead(n, &doc)
+				return
+			case atom.Html:
+				if lang := attr(n, "lang"); lang != "" {
+					doc.Metadata["language"] = lang
+				}
+			}
+			if doc.Title == "" && n.DataAtom == atom.H1 {
+				doc.Title = strings.TrimSpace(nodeText(n))
+			}
+			text.open(n)
+		}
+		if n.Type == html.TextNode {
+			text.write(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode {
+			text.close(n)
+		}
+	}
+	walk(root)
+
+	doc.Content = text.String()
+	return []core.Document{doc}, nil
+}
+
+// collectHead reads the title and description from the <head> element.
+func collectHead(n *html.Node, doc *core.Document) {
+	if n.DataAtom != atom.Head {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Title:
+			if title := strings.TrimSpace(nodeText(child)); title != "" {
+				doc.Title = title
+			}
+		case atom.Meta:
+			if strings.EqualFold(attr(child, "name"), "description") {
+				doc.Metadata["description"] = attr(child, "content")
+			}
+		}
+	}
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// htmlText accumulates the text of a page, collapsing whitespace as a browser would.
+type htmlText struct {
+	b          strings.Builder
+	pendingGap string // Separator owed before the next text: " ", "\n" or "\n\n"
+	pre        int    // Depth of <pre> elements
+}
+
+var htmlBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Nav: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Ul: true, atom.Ol: true, atom.Table: true, atom.Pre: true, atom.Figure: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Dl: true, atom.Form: true, atom.Hr: true,
+}
+
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+func (t *htmlText) open(n *html.Node) {
+	switch {
+	case htmlBlocks[n.DataAtom]:
+		t.gap("\n\n")
+		if level := htmlHeadingLevels[n.DataAtom]; level > 0 {
+			t.emit(strings.Repeat("#", level))
+			t.gap(" ")
+		}
+		if n.DataAtom == atom.Pre {
+			t.pre++
+		}
+	case n.DataAtom == atom.Li:
+		t.gap("\n")
+		t.emit("-")
+		t.gap(" ")
+	case n.DataAtom == atom.Br, n.DataAtom == atom.Tr, n.DataAtom == atom.Dt, n.DataAtom == atom.Dd:
+		t.gap("\n")
+	case n.DataAtom == atom.Td, n.DataAtom == atom.Th:
+		t.gap(" ")
+	}
+}
+
+func (t *htmlText) close(n *html.Node) {
+	if htmlBlocks[n.DataAtom] {
+		if n.DataAtom == atom.Pre {
+			t.pre--
+		}
+		t.gap("\n\n")
+	}
+}
+
+func (t *htmlText) write(s string) {
+	if t.pre > 0 {
+		t.emit(s)
+		return
+	}
+	if len(s) > 0 && isHTMLSpace(s[0]) {
+		t.gap(" ")
+	}
+	words := strings.Fields(s)
+	for i, word := range words {
+		if i > 0 {
+			t.gap(" ")
+		}
+		t.emit(word)
+	}
+	if len(words) > 0 && isHTMLSpace(s[len(s)-1]) {
+		t.gap(" ")
+	}
+}
+
+// gap records a separator, keeping the strongest one owed.
+func (t *htmlText) gap(separator string) {
+	if len(separator) > len(t.pendingGap) {
+		t.pendingGap = separator
+	}
+}
+
+// emit writes text after the separator it owes, dropped at the start of the page.
+func (t *htmlText) emit(s string) {
+	if t.b.Len() > 0 {
+		t.b.WriteString(t.pendingGap)
+	}
+	t.pendingGap = ""
+	t.b.WriteString(s)
+}
+
+func (t *htmlText) String() string {
+	return strings.TrimSpace(t.b.String())
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
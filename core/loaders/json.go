@@ -0,0 +1,148 @@
+package loaders
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+// JSONOptions select the fields of JSON records that make up documents. Fields are
+// dot-separated paths such as "body.text".
+type JSONOptions struct {
+	ContentFields  []string // Joined, in order, as the content; the whole record when empty
+	TitleField     string   // Field holding the title
+	IDField        string   // Field holding the document ID; derived from the file otherwise
+	MetadataFields []string // Fields copied to the metadata, keyed by their path
+}
+
+// loadJSON loads a JSON file: an array yields one document per element, any other value
+// a single document.
+func loadJSON(path string, content []byte, opts Options) ([]core.Document, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if records, ok := value.([]any); ok {
+		return jsonDocuments(path, records, opts.JSON)
+	}
+	return jsonDocuments(path, []any{value}, opts.JSON)
+}
+
+// loadJSONL loads a JSON Lines file, one document per non-empty line.
+func loadJSONL(path string, content []byte, opts Options) ([]core.Document, error) {
+	var records []any
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var value any
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		records = append(records, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return jsonDocuments(path, records, opts.JSON)
+}
+
+// jsonDocuments turns records into documents. Records whose content is empty are skipped.
+// Without an ID field, a file with several records numbers them "<file id>_<index>".
+func jsonDocuments(path string, records []any, opts JSONOptions) ([]core.Document, error) {
+	baseID := fileID(path)
+	docs := make([]core.Document, 0, len(records))
+	for i, record := range records {
+		content, err := jsonContent(record, opts.ContentFields)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		doc := core.Document{Content: content, Metadata: map[string]any{}}
+		if opts.IDField != "" {
+			if id, ok := jsonField(record, opts.IDField); ok {
+				doc.ID = jsonString(id)
+			}
+		}
+		if doc.ID == "" && len(records) > 1 {
+			doc.ID = fmt.Sprintf("%s_%d", baseID, i)
+		}
+		if opts.TitleField != "" {
+			if title, ok := jsonField(record, opts.TitleField); ok {
+				doc.Title = jsonString(title)
+			}
+		}
+		for _, field := range opts.MetadataFields {
+			if value, ok := jsonField(record, field); ok {
+				doc.Metadata[field] = value
+			}
+		}
+		if len(records) > 1 {
+			doc.Metadata["record_index"] = i
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// jsonContent joins the selected fields of a record, or renders the whole record.
+func jsonContent(record any, fields []string) (string, error) {
+	if len(fields) == 0 {
+		if s, ok := record.(string); ok {
+			return s, nil
+		}
+		out, err := json.MarshalIndent(record, "", "  ")
+		return string(out), err
+	}
+
+	var parts []string
+	for _, field := range fields {
+		if value, ok := jsonField(record, field); ok {
+			if s := jsonString(value); s != "" {
+				parts = append(parts, s)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// jsonField follows a dot-separated path through nested objects.
+func jsonField(record any, path string) (any, bool) {
+	value := record
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+// jsonString renders a field value as text: strings as they are, anything else as JSON.
+func jsonString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
@@ -0,0 +1,322 @@
+// Package loaders turns files and directory trees into core.Document values ready for
+// Memory.IngestDocuments: plain text, Markdown, HTML, JSON and JSONL, source code and PDF.
+package loaders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+// ErrUnsupportedFormat is returned by LoadFile for files no loader handles.
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// ErrFileTooLarge is returned for files larger than Options.MaxFileSize.
+var ErrFileTooLarge = errors.New("file exceeds maximum size")
+
+// Loader turns the content of one file into documents. The documents it returns get the
+// file's ID, source, type and file metadata wherever they leave them unset.
+type Loader interface {
+	Load(path string, content []byte, opts Options) ([]core.Document, error)
+}
+
+// LoaderFunc adapts a function to the Loader interface.
+type LoaderFunc func(path string, content []byte, opts Options) ([]core.Document, error)
+
+// Load implements Loader.
+func (f LoaderFunc) Load(path string, content []byte, opts Options) ([]core.Document, error) {
+	return f(path, content, opts)
+}
+
+// Options control which files are loaded and how.
+type Options struct {
+	Types         []core.DocumentType // Only load files of these types; all types when empty
+	MaxFileSize   int64               // Bytes; 0 means no limit
+	Exclude       []string            // Glob patterns matched against file names and slash-separated paths relative to the directory
+	IncludeHidden bool                // Load files and directories whose name starts with "."
+	Tags          []string            // Added to every document
+	JSON          JSONOptions         // How JSON and JSONL records become documents
+}
+
+// format is a registered file format.
+type format struct {
+	docType core.DocumentType
+	loader  Loader
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]format{}
+)
+
+func init() {
+	for _, ext := range []string{".txt", ".text", ".log", ".rst", ".csv", ".tsv"} {
+		Register(ext, core.DocumentTypeText, LoaderFunc(loadText))
+	}
+	for _, ext := range []string{".md", ".markdown", ".mdx"} {
+		Register(ext, core.DocumentTypeMarkdown, LoaderFunc(loadMarkdown))
+	}
+	for _, ext := range []string{".html", ".htm", ".xhtml"} {
+		Register(ext, core.DocumentTypeWeb, LoaderFunc(loadHTML))
+	}
+	Register(".json", core.DocumentTypeJSON, LoaderFunc(loadJSON))
+	for _, ext := range []string{".jsonl", ".ndjson"} {
+		Register(ext, core.DocumentTypeJSON, LoaderFunc(loadJSONL))
+	}
+	for ext := range codeLanguages {
+		Register(ext, core.DocumentTypeCode, LoaderFunc(loadCode))
+	}
+	Register(".pdf", core.DocumentTypePDF, LoaderFunc(loadPDF))
+}
+
+// Register adds or replaces the loader for a file extension such as ".rst".
+func Register(ext string, docType core.DocumentType, loader Loader) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(ext)] = format{docType: docType, loader: loader}
+}
+
+// formatFor finds the format of a file from its extension, or for files without one from
+// well-known names and a "#!" line.
+func formatFor(path string, content []byte) (format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if f, ok := formats[strings.ToLower(filepath.Ext(path))]; ok {
+		return f, true
+	}
+	if DetectLanguage(path, content) != "" {
+		return format{docType: core.DocumentTypeCode, loader: LoaderFunc(loadCode)}, true
+	}
+	return format{}, false
+}
+
+// Load loads a file, or every supported file under a directory.
+func Load(ctx context.Context, path string, opts Options) ([]core.Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return LoadDirectory(ctx, path, opts)
+	}
+	return LoadFile(ctx, path, opts)
+}
+
+// LoadFile loads one file. Its documents have IDs derived from the file's absolute path,
+// so loading the same file again yields the same IDs and replaces it when ingested.
+func LoadFile(ctx context.Context, path string, opts Options) ([]core.Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+		return nil, fmt.Errorf("%s: %w (%d > %d bytes)", path, ErrFileTooLarge, info.Size(), opts.MaxFileSize)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := formatFor(path, content)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedFormat)
+	}
+	if !opts.allows(f.docType) {
+		return nil, fmt.Errorf("%s: %w: type %s is not enabled", path, ErrUnsupportedFormat, f.docType)
+	}
+
+	docs, err := f.loader.Load(path, content, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	baseID := fileID(path)
+	for i := range docs {
+		fillFileDefaults(&docs[i], path, info, f.docType, baseID, opts)
+	}
+	return docs, nil
+}
+
+// LoadDirectory loads every supported file under root. Files of unsupported or disabled
+// types are skipped; other failures are returned together, joined, after the documents
+// of the files that loaded.
+func LoadDirectory(ctx context.Context, root string, opts Options) ([]core.Document, error) {
+	var docs []core.Document
+	var errs []error
+	walkErr := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		if opts.skips(entry.Name(), filepath.ToSlash(rel)) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		fileDocs, err := LoadFile(ctx, path, opts)
+		if errors.Is(err, ErrUnsupportedFormat) {
+			return nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		docs = append(docs, fileDocs...)
+		return nil
+	})
+	if walkErr != nil {
+		return docs, walkErr
+	}
+	return docs, errors.Join(errs...)
+}
+
+// allows reports whether documents of docType may be loaded.
+func (o Options) allows(docType core.DocumentType) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, t := range o.Types {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// skips reports whether a file or directory is hidden or excluded.
+func (o Options) skips(name, rel string) bool {
+	if !o.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, pattern := range o.Exclude {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// fileID derives a stable document ID from a file's absolute path.
+func fileID(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(filepath.ToSlash(path)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fillFileDefaults sets what a loader left unset from the file.
+func fillFileDefaults(doc *core.Document, path string, info os.FileInfo, docType core.DocumentType, baseID string, opts Options) {
+	if doc.ID == "" {
+		doc.ID = baseID
+	}
+	if doc.Source == "" {
+		doc.Source = filepath.ToSlash(path)
+	}
+	if doc.Type == "" {
+		doc.Type = docType
+	}
+	if doc.Title == "" {
+		doc.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = info.ModTime()
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]any)
+	}
+	setDefault(doc.Metadata, "file_name", filepath.Base(path))
+	setDefault(doc.Metadata, "extension", strings.ToLower(filepath.Ext(path)))
+	setDefault(doc.Metadata, "size_bytes", info.Size())
+	setDefault(doc.Metadata, "modified_at", info.ModTime().UTC().Format(time.RFC3339))
+	doc.Tags = appendUnique(doc.Tags, opts.Tags...)
+}
+
+func setDefault(metadata map[string]any, key string, value any) {
+	if _, ok := metadata[key]; !ok {
+		metadata[key] = value
+	}
+}
+
+func appendUnique(values []string, more ...string) []string {
+	for _, v := range more {
+		found := false
+		for _, existing := range values {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// OptionsFromConfig builds options from [agent_memory.documents]: supported_types limits
+// the types loaded ("web" covers HTML) and max_file_size the size of each file.
+func OptionsFromConfig(config core.DocumentConfig) (Options, error) {
+	var opts Options
+	for _, t := range config.SupportedTypes {
+		opts.Types = append(opts.Types, core.DocumentType(strings.ToLower(strings.TrimSpace(t))))
+	}
+	if config.MaxFileSize != "" {
+		size, err := ParseFileSize(config.MaxFileSize)
+		if err != nil {
+			return Options{}, err
+		}
+		opts.MaxFileSize = size
+	}
+	return opts, nil
+}
+
+// ParseFileSize parses sizes such as "10MB", "512KB", "1.5GB" or a number of bytes.
+// Units are powers of 1024.
+func ParseFileSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid file size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
@@ -0,0 +1,387 @@
+package loaders
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadOne(t *testing.T, path string, opts Options) core.Document {
+	t.Helper()
+	docs, err := LoadFile(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("LoadFile(%s) failed: %v", path, err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("LoadFile(%s) returned %d documents, want 1", path, len(docs))
+	}
+	return docs[0]
+}
+
+func TestLoadText(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "notes.txt", "\xef\xbb\xbfline one\r\nline two\r\n")
+
+	doc := loadOne(t, path, Options{Tags: []string{"notes"}})
+	if doc.Content != "line one\nline two\n" {
+		t.Errorf("Content = %q", doc.Content)
+	}
+	if doc.Type != core.DocumentTypeText || doc.Title != "notes" || doc.Source != filepath.ToSlash(path) {
+		t.Errorf("unexpected defaults: type %q, title %q, source %q", doc.Type, doc.Title, doc.Source)
+	}
+	if doc.Metadata["file_name"] != "notes.txt" || doc.Metadata["extension"] != ".txt" {
+		t.Errorf("unexpected file metadata: %v", doc.Metadata)
+	}
+	if len(doc.Tags) != 1 || doc.Tags[0] != "notes" {
+		t.Errorf("Tags = %v", doc.Tags)
+	}
+
+	again := loadOne(t, path, Options{})
+	if again.ID != doc.ID || doc.ID == "" {
+		t.Errorf("IDs are not stable: %q and %q", doc.ID, again.ID)
+	}
+
+	binary := writeFile(t, dir, "data.txt", "abc\x00def")
+	if _, err := LoadFile(context.Background(), binary, Options{}); err == nil {
+		t.Error("expected an error for binary content")
+	}
+}
+
+func TestLoadMarkdownFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	yamlDoc := loadOne(t, writeFile(t, dir, "guide.md",
+		"---\ntitle: Setup Guide\ntags: [setup, install]\nauthor: Ada\n---\n\n# Heading\n\nBody text.\n"), Options{Tags: []string{"docs"}})
+	if yamlDoc.Title != "Setup Guide" {
+		t.Errorf("Title = %q", yamlDoc.Title)
+	}
+	if yamlDoc.Metadata["author"] != "Ada" {
+		t.Errorf("author metadata = %v", yamlDoc.Metadata["author"])
+	}
+	if got := strings.Join(yamlDoc.Tags, ","); got != "setup,install,docs" {
+		t.Errorf("Tags = %q", got)
+	}
+	if !strings.HasPrefix(yamlDoc.Content, "# Heading") || strings.Contains(yamlDoc.Content, "author") {
+		t.Errorf("front matter not stripped from content: %q", yamlDoc.Content)
+	}
+
+	tomlDoc := loadOne(t, writeFile(t, dir, "post.md", "+++\nkeywords = \"go, agents\"\ndraft = true\n+++\n# Post Title\n"), Options{})
+	if tomlDoc.Title != "Post Title" {
+		t.Errorf("Title = %q, want first heading", tomlDoc.Title)
+	}
+	if tomlDoc.Metadata["draft"] != true {
+		t.Errorf("draft metadata = %v", tomlDoc.Metadata["draft"])
+	}
+	if got := strings.Join(tomlDoc.Tags, ","); got != "go,agents" {
+		t.Errorf("Tags = %q", got)
+	}
+
+	plain := loadOne(t, writeFile(t, dir, "plain.md", "---\nnot front matter\n"), Options{})
+	if !strings.HasPrefix(plain.Content, "---") {
+		t.Errorf("unterminated front matter should be kept: %q", plain.Content)
+	}
+
+	if _, err := LoadFile(context.Background(), writeFile(t, dir, "bad.md", "---\n: [\n---\nbody\n"), Options{}); err == nil {
+		t.Error("expected an error for invalid front matter")
+	}
+}
+
+func TestLoadHTML(t *testing.T) {
+	dir := t.TempDir()
+	page := `<!DOCTYPE html>
+<html lang="en">
+<head><title> Release Notes </title><meta name="description" content="What changed"><style>p{color:red}</style></head>
+<body>
+<nav>Home</nav>
+<h2>New   features</h2>
+<p>Faster <b>runner</b>
+and better memory.</p>
+<script>alert("hi")</script>
+<ul><li>One</li><li>Two</li></ul>
+<pre>x := 1
+y := 2</pre>
+</body></html>`
+	doc := loadOne(t, writeFile(t, dir, "notes.html", page), Options{})
+
+	if doc.Type != core.DocumentTypeWeb || doc.Title != "Release Notes" {
+		t.Errorf("type %q, title %q", doc.Type, doc.Title)
+	}
+	if doc.Metadata["description"] != "What changed" || doc.Metadata["language"] != "en" {
+		t.Errorf("unexpected metadata: %v", doc.Metadata)
+	}
+	want := "Home\n\n## New features\n\nFaster runner and better memory.\n\n- One\n- Two\n\nx := 1\ny := 2"
+	if doc.Content != want {
+		t.Errorf("Content =\n%q\nwant\n%q", doc.Content, want)
+	}
+
+	untitled := loadOne(t, writeFile(t, dir, "page.htm", "<h1>Main <i>Title</i></h1><p>text</p>"), Options{})
+	if untitled.Title != "Main Title" {
+		t.Errorf("Title = %q, want first h1", untitled.Title)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	records := `[
+		{"id": "a1", "title": "First", "body": {"text": "Alpha body"}, "lang": "en"},
+		{"id": "a2", "title": "Second", "body": {"text": ""}},
+		{"id": 3, "title": "Third", "body": {"text": "Gamma body"}, "lang": "fr"}
+	]`
+	path := writeFile(t, dir, "records.json", records)
+	opts := Options{JSON: JSONOptions{
+		ContentFields:  []string{"body.text"},
+		TitleField:     "title",
+		IDField:        "id",
+		MetadataFields: []string{"lang"},
+	}}
+	docs, err := LoadFile(context.Background(), path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2 (empty content skipped)", len(docs))
+	}
+	if docs[0].ID != "a1" || docs[0].Title != "First" || docs[0].Content != "Alpha body" || docs[0].Metadata["lang"] != "en" {
+		t.Errorf("unexpected first document: %+v", docs[0])
+	}
+	if docs[1].ID != "3" || docs[1].Metadata["record_index"] != 2 {
+		t.Errorf("unexpected second document: ID %q, metadata %v", docs[1].ID, docs[1].Metadata)
+	}
+
+	lines := writeFile(t, dir, "events.jsonl", "{\"msg\": \"started\"}\n\n{\"msg\": \"stopped\"}\n")
+	docs, err = LoadFile(context.Background(), lines, Options{JSON: JSONOptions{ContentFields: []string{"msg"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Content != "started" || docs[1].Content != "stopped" {
+		t.Fatalf("unexpected JSONL documents: %+v", docs)
+	}
+	base := fileID(lines)
+	if docs[0].ID != base+"_0" || docs[1].ID != base+"_1" {
+		t.Errorf("IDs = %q, %q", docs[0].ID, docs[1].ID)
+	}
+
+	whole := loadOne(t, writeFile(t, dir, "config.json", `{"name": "demo", "size": 12}`), Options{})
+	if !strings.Contains(whole.Content, `"name": "demo"`) || whole.ID != fileID(filepath.Join(dir, "config.json")) {
+		t.Errorf("unexpected whole-record document: %+v", whole)
+	}
+
+	bad := writeFile(t, dir, "bad.jsonl", "{\"ok\": 1}\n{broken\n")
+	if _, err := LoadFile(context.Background(), bad, Options{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a line 2 error, got %v", err)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"main.go", "package main", "go"},
+		{"lib/App.TSX", "", "typescript"},
+		{"Dockerfile", "FROM alpine", "dockerfile"},
+		{"bin/deploy", "#!/usr/bin/env python3\nprint()", "python"},
+		{"bin/build", "#!/bin/bash -e\n", "shell"},
+		{"README", "plain text", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.path, []byte(tt.content)); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCode(t *testing.T) {
+	dir := t.TempDir()
+	doc := loadOne(t, writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n"), Options{})
+	if doc.Type != core.DocumentTypeCode || doc.Title != "main.go" {
+		t.Errorf("type %q, title %q", doc.Type, doc.Title)
+	}
+	if doc.Metadata["language"] != "go" || doc.Metadata["lines"] != 4 {
+		t.Errorf("unexpected metadata: %v", doc.Metadata)
+	}
+
+	script := loadOne(t, writeFile(t, dir, "run", "#!/bin/sh\necho hi\n"), Options{})
+	if script.Type != core.DocumentTypeCode || script.Metadata["language"] != "shell" {
+		t.Errorf("shebang script: type %q, metadata %v", script.Type, script.Metadata)
+	}
+}
+
+// buildPDF assembles a minimal PDF with one page per content stream.
+func buildPDF(title string, compress bool, contents ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(&b, "1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Count %d >>\nendobj\n", len(contents))
+	for i, content := range contents {
+		page, stream := 3+2*i, 4+2*i
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>\nendobj\n", page, stream)
+		data := []byte(content)
+		filter := ""
+		if compress {
+			var z bytes.Buffer
+			w := zlib.NewWriter(&z)
+			w.Write(data)
+			w.Close()
+			data, filter = z.Bytes(), " /Filter /FlateDecode"
+		}
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d%s >>\nstream\n", stream, len(data), filter)
+		b.Write(data)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	if title != "" {
+		fmt.Fprintf(&b, "9 0 obj\n<< /Title (%s) >>\nendobj\n", title)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestLoadPDF(t *testing.T) {
+	page1 := "BT /F1 12 Tf 72 720 Td (Quarterly \\(Q3\\) report) Tj 0 -14 Td [(Rev) -50 (enue) -300 (grew)] TJ ET"
+	page2 := "BT /F1 12 Tf 72 720 Td (Second page) Tj T* (caf\\351) Tj ET"
+
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%v", compress), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.pdf")
+			if err := os.WriteFile(path, buildPDF("Q3 Report", compress, page1, page2), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			doc := loadOne(t, path, Options{})
+			want := "Quarterly (Q3) report\nRevenue grew\n\nSecond page\ncafé"
+			if doc.Content != want {
+				t.Errorf("Content = %q, want %q", doc.Content, want)
+			}
+			if doc.Title != "Q3 Report" || doc.Type != core.DocumentTypePDF {
+				t.Errorf("title %q, type %q", doc.Title, doc.Type)
+			}
+			if doc.Metadata["pages"] != 2 {
+				t.Errorf("pages = %v", doc.Metadata["pages"])
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	if _, err := LoadFile(context.Background(), writeFile(t, dir, "fake.pdf", "hello"), Options{}); err == nil {
+		t.Error("expected an error for a file without a PDF header")
+	}
+	if _, err := LoadFile(context.Background(), writeFile(t, dir, "empty.pdf", string(buildPDF("", false, "q 1 0 0 1 0 0 cm Q"))), Options{}); err == nil {
+		t.Error("expected an error for a PDF without text")
+	}
+
+	// A small file whose stream decompresses past the size limit
+	bomb := buildPDF("", true, "BT (x) Tj ET "+strings.Repeat(" ", 1<<20))
+	path := writeFile(t, dir, "bomb.pdf", string(bomb))
+	if _, err := LoadFile(context.Background(), path, Options{MaxFileSize: 64 << 10}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge for %d bytes expanding to 1MB, got %v", len(bomb), err)
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "readme.md", "# Readme\n")
+	writeFile(t, dir, "docs/guide.txt", "guide")
+	writeFile(t, dir, "docs/big.txt", strings.Repeat("x", 2048))
+	writeFile(t, dir, "src/main.go", "package main\n")
+	writeFile(t, dir, "vendor/lib.go", "package lib\n")
+	writeFile(t, dir, ".git/config", "[core]")
+	writeFile(t, dir, ".env.md", "# secret")
+	writeFile(t, dir, "image.png", "\x89PNG")
+	writeFile(t, dir, "broken.json", "{")
+
+	sources := func(docs []core.Document) string {
+		var out []string
+		for _, doc := range docs {
+			rel, _ := filepath.Rel(dir, filepath.FromSlash(doc.Source))
+			out = append(out, filepath.ToSlash(rel))
+		}
+		sort.Strings(out)
+		return strings.Join(out, ",")
+	}
+
+	docs, err := LoadDirectory(context.Background(), dir, Options{Exclude: []string{"vendor"}, MaxFileSize: 1024})
+	if err == nil || !strings.Contains(err.Error(), "broken.json") || !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected joined errors for broken.json and big.txt, got %v", err)
+	}
+	if got := sources(docs); got != "docs/guide.txt,readme.md,src/main.go" {
+		t.Errorf("loaded %s", got)
+	}
+
+	docs, err = Load(context.Background(), dir, Options{
+		Types:         []core.DocumentType{core.DocumentTypeMarkdown},
+		IncludeHidden: true,
+		Exclude:       []string{".git"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sources(docs); got != ".env.md,readme.md" {
+		t.Errorf("loaded %s", got)
+	}
+
+	docs, err = LoadDirectory(context.Background(), dir, Options{Exclude: []string{"docs/*", "*.json", "vendor"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sources(docs); got != "readme.md,src/main.go" {
+		t.Errorf("loaded %s", got)
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":   1024,
+		"10MB":   10 << 20,
+		"512 kb": 512 << 10,
+		"1.5GB":  3 << 29,
+		"2m":     2 << 20,
+		"100B":   100,
+	}
+	for input, want := range tests {
+		got, err := ParseFileSize(input)
+		if err != nil || got != want {
+			t.Errorf("ParseFileSize(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "MB", "-1KB", "ten"} {
+		if _, err := ParseFileSize(input); err == nil {
+			t.Errorf("ParseFileSize(%q) should fail", input)
+		}
+	}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(core.DocumentConfig{SupportedTypes: []string{"PDF", " md "}, MaxFileSize: "1KB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Types) != 2 || opts.Types[0] != core.DocumentTypePDF || opts.Types[1] != core.DocumentTypeMarkdown {
+		t.Errorf("Types = %v", opts.Types)
+	}
+	if opts.MaxFileSize != 1024 {
+		t.Errorf("MaxFileSize = %d", opts.MaxFileSize)
+	}
+	if _, err := OptionsFromConfig(core.DocumentConfig{MaxFileSize: "lots"}); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
@@ -0,0 +1,460 @@
+package loaders
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/kunalkushwaha/agenticgokit/core"
+)
+
+// defaultMaxPDFDecodedSize bounds the decompressed content of a PDF when Options.MaxFileSize
+// sets no limit.
+const defaultMaxPDFDecodedSize = 256 << 20
+
+var (
+	pdfLength    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	pdfFilter    = regexp.MustCompile(`/Filter\s*(\[[^\]]*\]|/\w+)`)
+	pdfPageType  = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	pdfSkipTypes = regexp.MustCompile(`/Subtype\s*/Image|/Type\s*/(XRef|ObjStm|Metadata|EmbeddedFile)|/Length1|/Length2|/Length3`)
+	pdfSpaces    = regexp.MustCompile(`[ \t]+`)
+	pdfBlank     = regexp.MustCompile(`\n{3,}`)
+)
+
+// loadPDF extracts the text of a PDF: the text drawn by its content streams, uncompressed
+// or Flate-compressed, in the order they appear in the file. Fonts with single-byte
+// encodings are read as Latin-1 with the Windows quote and dash characters; text in
+// composite (CID) fonts without a usable encoding, scanned pages and encrypted files yield
+// no text and an error. Decompressed streams may add up to at most Options.MaxFileSize
+// bytes, so a small file cannot expand without bound.
+func loadPDF(path string, content []byte, opts Options) ([]core.Document, error) {
+	header := content
+	if len(header) > 1024 {
+		header = header[:1024]
+	}
+	if !bytes.Contains(header, []byte("%PDF-")) {
+		return nil, errors.New("not a PDF file")
+	}
+	if bytes.Contains(content, []byte("/Encrypt")) {
+		return nil, errors.New("encrypted PDFs are not supported")
+	}
+
+	remaining := opts.MaxFileSize
+	if remaining <= 0 {
+		remaining = defaultMaxPDFDecodedSize
+	}
+	var texts []string
+	for _, stream := range pdfStreams(content) {
+		if pdfSkipTypes.Match(stream.dict) {
+			continue
+		}
+		data, ok, err := stream.decode(remaining)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Contains(stream.dict, []byte("/Filter")) {
+			remaining -= int64(len(data))
+		}
+		if !ok || !bytes.Contains(data, []byte("BT")) {
+			continue
+		}
+		if text := pdfContentText(data); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, errors.New("PDF has no extractable text (scanned pages or unsupported fonts)")
+	}
+
+	doc := core.Document{
+		Title:    pdfTitle(content),
+		Content:  strings.Join(texts, "\n\n"),
+		Metadata: map[string]any{"pages": len(pdfPageType.FindAll(content, -1))},
+	}
+	return []core.Document{doc}, nil
+}
+
+// pdfStream is a stream object: its dictionary and raw data.
+type pdfStream struct {
+	dict []byte
+	data []byte
+}
+
+// pdfStreams finds the stream objects of a PDF.
+func pdfStreams(content []byte) []pdfStream {
+	var streams []pdfStream
+	pos := 0
+	for {
+		i := bytes.Index(content[pos:], []byte("stream"))
+		if i < 0 {
+			return streams
+		}
+		start := pos + i
+		pos = start + len("stream")
+		if start >= 3 && string(content[start-3:start]) == "end" {
+			continue
+		}
+
+		// The data starts after the end of line following the keyword
+		dataStart := pos
+		if dataStart < len(content) && content[dataStart] == '\r' {
+			dataStart++
+		}
+		if dataStart < len(content) && content[dataStart] == '\n' {
+			dataStart++
+		}
+		if dataStart == pos {
+			continue
+		}
+
+		dictStart := bytes.LastIndex(content[:start], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := content[dictStart:start]
+
+		dataEnd := -1
+		if m := pdfLength.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && dataStart+n <= len(content) &&
+				bytes.HasPrefix(bytes.TrimLeft(content[dataStart+n:], "\r\n \t"), []byte("endstream")) {
+				dataEnd = dataStart + n
+			}
+		}
+		if dataEnd < 0 {
+			end := bytes.Index(content[dataStart:], []byte("endstream"))
+			if end < 0 {
+				return streams
+			}
+			dataEnd = dataStart + end
+			for dataEnd > dataStart && (content[dataEnd-1] == '\n' || content[dataEnd-1] == '\r') {
+				dataEnd--
+			}
+		}
+
+		streams = append(streams, pdfStream{dict: dict, data: content[dataStart:dataEnd]})
+		pos = dataEnd
+	}
+}
+
+// decode applies the stream's filter. Only FlateDecode is supported. Streams that
+// decompress to more than maxSize bytes fail with ErrFileTooLarge.
+func (s pdfStream) decode(maxSize int64) ([]byte, bool, error) {
+	if !bytes.Contains(s.dict, []byte("/Filter")) {
+		return s.data, true, nil
+	}
+	m := pdfFilter.FindSubmatch(s.dict)
+	if m == nil || bytes.Count(m[1], []byte("/")) != 1 || !bytes.Contains(m[1], []byte("/FlateDecode")) {
+		return nil, false, nil
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(s.data))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer reader.Close()
+	// Keep what was decoded from streams with a damaged end
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if int64(len(data)) > maxSize {
+		return nil, false, fmt.Errorf("%w: compressed PDF content expands past %d bytes", ErrFileTooLarge, maxSize)
+	}
+	return data, err == nil || len(data) > 0, nil
+}
+
+// pdfToken is an operand in a content stream.
+type pdfToken struct {
+	kind   byte // 's' string, 'n' number, '[' array start, 'o' other
+	text   string
+	number float64
+}
+
+// pdfContentText returns the text drawn by a content stream.
+func pdfContentText(data []byte) string {
+	var out strings.Builder
+	var operands []pdfToken
+	lastY, haveY := 0.0, false
+
+	for l := (pdfLexer{data: data}); ; {
+		token, operator, ok := l.next()
+		if !ok {
+			break
+		}
+		if operator == "" {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch operator {
+		case "Tj":
+			writeLastString(&out, operands)
+		case "'", "\"":
+			out.WriteString("\n")
+			writeLastString(&out, operands)
+		case "TJ":
+			for _, operand := range operands {
+				switch {
+				case operand.kind == 's':
+					out.WriteString(operand.text)
+				case operand.kind == 'n' && operand.number < -200:
+					out.WriteString(" ")
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+				out.WriteString("\n")
+			} else {
+				out.WriteString(" ")
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y := operands[len(operands)-1].number
+				if haveY && y != lastY {
+					out.WriteString("\n")
+				} else {
+					out.WriteString(" ")
+				}
+				lastY, haveY = y, true
+			}
+		case "T*", "ET":
+			out.WriteString("\n")
+		case "BI":
+			l.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+
+	text := pdfSpaces.ReplaceAllString(out.String(), " ")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(pdfBlank.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func writeLastString(out *strings.Builder, operands []pdfToken) {
+	for i := len(operands) - 1; i >= 0; i-- {
+		if operands[i].kind == 's' {
+			out.WriteString(operands[i].text)
+			return
+		}
+	}
+}
+
+// pdfLexer splits a content stream into operands and operators.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next operand, or the next operator's name.
+func (l *pdfLexer) next() (pdfToken, string, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: 's', text: pdfDecodeString(l.literalString())}, "", true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+		case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+		case c == '<':
+			return pdfToken{kind: 's', text: pdfDecodeString(l.hexString())}, "", true
+		case c == '[':
+			l.pos++
+			return pdfToken{kind: '['}, "", true
+		case c == ']' || c == '{' || c == '}' || c == '>':
+			l.pos++
+		case c == '/':
+			l.pos++
+			l.word()
+			return pdfToken{kind: 'o'}, "", true
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			n, _ := strconv.ParseFloat(l.word(), 64)
+			return pdfToken{kind: 'n', number: n}, "", true
+		default:
+			word := l.word()
+			if word == "" {
+				l.pos++
+				continue
+			}
+			return pdfToken{}, word, true
+		}
+	}
+	return pdfToken{}, "", false
+}
+
+// word reads up to the next delimiter.
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !strings.ContainsRune("()<>[]{}/%", rune(l.data[l.pos])) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literalString reads a (...) string, with nested parentheses and escapes.
+func (l *pdfLexer) literalString() []byte {
+	var out []byte
+	depth := 0
+	for l.pos++; l.pos < len(l.data); l.pos++ {
+		c := l.data[l.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				l.pos++
+				return out
+			}
+			depth--
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.data) {
+				return out
+			}
+			switch e := l.data[l.pos]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos+1 < len(l.data) && l.data[l.pos+1] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for i := 0; i < 3 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					l.pos--
+					out = append(out, byte(n))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hexString reads a <...> string.
+func (l *pdfLexer) hexString() []byte {
+	var digits []byte
+	for l.pos++; l.pos < len(l.data) && l.data[l.pos] != '>'; l.pos++ {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		n, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return nil
+		}
+		out = append(out, byte(n))
+	}
+	return out
+}
+
+// skipInlineImage skips the data of an inline image up to its EI operator.
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if isPDFSpace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+			(l.pos+3 == len(l.data) || isPDFSpace(l.data[l.pos+3])) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == 0
+}
+
+// winAnsiSpecials are the printable Windows-1252 characters in 0x80-0x9f.
+var winAnsiSpecials = map[byte]rune{
+	0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x80: '€',
+}
+
+// pdfDecodeString turns string bytes into text: UTF-16 with a byte order mark, otherwise
+// single-byte characters. Strings that are mostly control characters, typically glyph
+// IDs of composite fonts, are dropped.
+func pdfDecodeString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xfe && raw[1] == 0xff {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	control := 0
+	var b strings.Builder
+	for _, c := range raw {
+		switch {
+		case c == '\n' || c == '\r' || c == '\t':
+			b.WriteByte(' ')
+		case c < 0x20:
+			control++
+		case c >= 0x80 && c < 0xa0:
+			if r, ok := winAnsiSpecials[c]; ok {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	if control*2 > len(raw) {
+		return ""
+	}
+	return b.String()
+}
+
+// pdfTitle reads the /Title of the document information dictionary.
+func pdfTitle(content []byte) string {
+	i := bytes.LastIndex(content, []byte("/Title"))
+	if i < 0 {
+		return ""
+	}
+	l := pdfLexer{data: content, pos: i + len("/Title")}
+	for l.pos < len(l.data) && isPDFSpace(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos >= len(l.data) {
+		return ""
+	}
+	switch l.data[l.pos] {
+	case '(':
+		return strings.TrimSpace(pdfDecodeString(l.literalString()))
+	case '<':
+		return strings.TrimSpace(pdfDecodeString(l.hexString()))
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package loaders
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kunalkushwaha/agenticgokit/core"
+	"gopkg.in/yaml.v3"
+)
+
+// loadText loads a plain text file as one document.
+func loadText(path string, content []byte, opts Options) ([]core.Document, error) {
+	text, err := decodeText(content)
+	if err != nil {
+		return nil, err
+	}
+	return []core.Document{{Content: text}}, nil
+}
+
+// decodeText checks that content is text and normalizes line endings.
+func decodeText(content []byte) (string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+		return "", fmt.Errorf("content is not UTF-8 text")
+	}
+	return strings.ReplaceAll(string(content), "\r\n", "\n"), nil
+}
+
+// loadMarkdown loads a Markdown file. YAML ("---") or TOML ("+++") front matter goes to
+// the document's metadata; its title and tags fields set the title and tags. Without a
+// title in the front matter, the first level-one heading is used.
+func loadMarkdown(path string, content []byte, opts Options) ([]core.Document, error) {
+	text, err := decodeText(content)
+	if err != nil {
+		return nil, err
+	}
+	frontMatter, body, err := splitFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := core.Document{Content: strings.TrimLeft(body, "\n"), Metadata: map[string]any{}}
+	for key, value := range frontMatter {
+		switch strings.ToLower(key) {
+		case "title":
+			doc.Title = fmt.Sprint(value)
+		case "tags", "keywords":
+			doc.Tags = appendUnique(doc.Tags, stringList(value)...)
+		default:
+			doc.Metadata[key] = value
+		}
+	}
+	if doc.Title == "" {
+		doc.Title = firstHeading(body)
+	}
+	return []core.Document{doc}, nil
+}
+
+// splitFrontMatter separates YAML or TOML front matter from a Markdown body.
+func splitFrontMatter(text string) (map[string]any, string, error) {
+	var delimiter string
+	switch {
+	case strings.HasPrefix(text, "---\n"):
+		delimiter = "---"
+	case strings.HasPrefix(text, "+++\n"):
+		delimiter = "+++"
+	default:
+		return nil, text, nil
+	}
+
+	rest := "\n" + text[len(delimiter)+1:]
+	closing := "\n" + delimiter
+	var body string
+	end := strings.Index(rest, closing+"\n")
+	switch {
+	case end >= 0:
+		body = rest[end+len(closing)+1:]
+	case strings.HasSuffix(rest, closing):
+		end = len(rest) - len(closing)
+	default:
+		// No closing delimiter: not front matter
+		return nil, text, nil
+	}
+	raw := strings.TrimPrefix(rest[:end], "\n")
+
+	frontMatter := map[string]any{}
+	var err error
+	if delimiter == "---" {
+		err = yaml.Unmarshal([]byte(raw), &frontMatter)
+	} else {
+		_, err = toml.Decode(raw, &frontMatter)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid front matter: %w", err)
+	}
+	return frontMatter, body, nil
+}
+
+// firstHeading returns the text of the first level-one heading outside code fences.
+func firstHeading(markdown string) string {
+	inFence := false
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return ""
+}
+
+// stringList turns a front matter value into a list of strings.
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	case []string:
+		return v
+	case string:
+		var out []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+		return out
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,305 @@
+// Package core provides pluggable termination conditions for loop orchestration and loop agents
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// LoopStopReasonKey is the metadata key recording why a loop stopped: "max_iterations",
+	// "loop_completed" or the description of the condition that ended it
+	LoopStopReasonKey = "loop_stop_reason"
+	// LoopIterationsKey is the metadata key recording how many iterations a loop ran
+	LoopIterationsKey = "loop_iterations"
+	// LoopFeedbackKey holds the judge's feedback for the next iteration when StopWhenJudged
+	// decides the output is not good enough yet
+	LoopFeedbackKey = "loop_feedback"
+)
+
+// LoopIteration describes a completed loop iteration to termination conditions
+type LoopIteration struct {
+	Iteration int           // Number of completed iterations, starting at 1
+	Previous  State         // State the iteration started with
+	State     State         // State the iteration produced; conditions may annotate it for the next iteration
+	Elapsed   time.Duration // Time since the loop started
+	Usage     UsageTotals   // Model usage of the loop so far, when the context carries a UsageLedger
+}
+
+// LoopCondition decides after each iteration whether a loop is done
+type LoopCondition interface {
+	ShouldStop(ctx context.Context, iteration LoopIteration) (bool, error)
+}
+
+// LoopConditionFunc allows using a function as a LoopCondition
+type LoopConditionFunc func(ctx context.Context, iteration LoopIteration) (bool, error)
+
+func (f LoopConditionFunc) ShouldStop(ctx context.Context, iteration LoopIteration) (bool, error) {
+	return f(ctx, iteration)
+}
+
+// namedLoopCondition gives a condition the description recorded as the stop reason
+type namedLoopCondition struct {
+	LoopCondition
+	name string
+}
+
+func (c namedLoopCondition) String() string {
+	return c.name
+}
+
+// loopConditionName describes a condition for LoopStopReasonKey and logs
+func loopConditionName(condition LoopCondition) string {
+	if named, ok := condition.(fmt.Stringer); ok {
+		return named.String()
+	}
+	return "condition"
+}
+
+// StopWhen stops the loop once predicate returns true for an iteration's output. It accepts
+// the condition functions used with CompositionBuilder.AsLoop.
+func StopWhen(predicate func(State) bool) LoopCondition {
+	return namedLoopCondition{
+		name: "condition",
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			return predicate(iteration.State), nil
+		}),
+	}
+}
+
+// StopWhenState stops the loop once an expression over the output state holds, e.g.
+// "score >= 0.8" or "approved". See ParseEdgeCondition for the syntax.
+func StopWhenState(expression string) (LoopCondition, error) {
+	predicate, err := ParseEdgeCondition(expression)
+	if err != nil {
+		return nil, err
+	}
+	return namedLoopCondition{
+		name: "stop_when: " + expression,
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			return predicate(iteration.State), nil
+		}),
+	}, nil
+}
+
+// StopOnConvergence stops the loop when an iteration leaves the given state keys unchanged,
+// or all state data when no keys are given
+func StopOnConvergence(keys ...string) LoopCondition {
+	return namedLoopCondition{
+		name: "convergence",
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			if iteration.Previous == nil || iteration.State == nil {
+				return false, nil
+			}
+			compare := keys
+			if len(compare) == 0 {
+				compare = uniqueNames(append(iteration.Previous.Keys(), iteration.State.Keys()...))
+			}
+			for _, key := range compare {
+				before, hadBefore := iteration.Previous.Get(key)
+				after, hasAfter := iteration.State.Get(key)
+				if hadBefore != hasAfter || !reflect.DeepEqual(before, after) {
+					return false, nil
+				}
+			}
+			return true, nil
+		}),
+	}
+}
+
+// StopAfterDuration stops the loop once it has run for at least budget. The budget is
+// checked between iterations, so a running iteration is not interrupted.
+func StopAfterDuration(budget time.Duration) LoopCondition {
+	return namedLoopCondition{
+		name: fmt.Sprintf("time budget %s", budget),
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			return iteration.Elapsed >= budget, nil
+		}),
+	}
+}
+
+// StopAfterTokens stops the loop once its model calls have used at least maxTokens. Usage is
+// read from the UsageLedger on the context, which the Runner attaches when [usage] is enabled.
+func StopAfterTokens(maxTokens int) LoopCondition {
+	return namedLoopCondition{
+		name: fmt.Sprintf("token budget %d", maxTokens),
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			return iteration.Usage.TotalTokens >= maxTokens, nil
+		}),
+	}
+}
+
+// loopJudgement is the reply expected from the judge model
+type loopJudgement struct {
+	Done     bool   `json:"done"`
+	Feedback string `json:"feedback"`
+}
+
+// StopWhenJudged asks a model whether the output meets criteria, and stops the loop when it
+// does. Otherwise the judge's feedback is stored under LoopFeedbackKey for the next iteration.
+// keys limits the state data shown to the judge; by default all data is shown.
+func StopWhenJudged(provider ModelProvider, criteria string, keys ...string) LoopCondition {
+	return namedLoopCondition{
+		name: "judge",
+		LoopCondition: LoopConditionFunc(func(ctx context.Context, iteration LoopIteration) (bool, error) {
+			shown := iteration.State
+			if len(keys) > 0 {
+				shown = NewState()
+				for _, key := range keys {
+					if value, ok := iteration.State.Get(key); ok {
+						shown.Set(key, value)
+					}
+				}
+			}
+
+			prompt := Prompt{
+				System: "You review the output of an iterative process. Decide whether it meets the criteria. " +
+					"If it does not, give concrete feedback for the next attempt. " +
+					`Reply with a JSON object: {"done": <true or false>, "feedback": <what to improve>}.`,
+				User: fmt.Sprintf("Criteria:\n%s\n\nIteration %d output:\n%s", criteria, iteration.Iteration, stateDataJSON(shown, LoopFeedbackKey)),
+			}
+			var judgement loopJudgement
+			if _, err := CallStructured(ctx, provider, prompt, &judgement, StructuredOutputOptions{Name: "loop_judgement"}); err != nil {
+				return false, fmt.Errorf("loop judge failed: %w", err)
+			}
+			if !judgement.Done && judgement.Feedback != "" {
+				iteration.State.Set(LoopFeedbackKey, judgement.Feedback)
+			}
+			return judgement.Done, nil
+		}),
+	}
+}
+
+// stateDataJSON renders a state's data for a prompt, leaving out the skipped keys
+func stateDataJSON(state State, skip ...string) string {
+	data := make(map[string]interface{})
+	for _, key := range state.Keys() {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || key == s
+		}
+		if value, ok := state.Get(key); ok && !skipped {
+			data[key] = value
+		}
+	}
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", data)
+	}
+	return string(encoded)
+}
+
+// =============================================================================
+// LOOP CONDITION EVALUATION
+// =============================================================================
+
+// loopTracker evaluates termination conditions over the iterations of one loop run
+type loopTracker struct {
+	conditions  []LoopCondition
+	started     time.Time
+	usage       usageScope
+	hasUsage    bool
+	usageOffset int
+}
+
+func newLoopTracker(ctx context.Context, conditions []LoopCondition) *loopTracker {
+	tracker := &loopTracker{conditions: conditions, started: time.Now()}
+	if scope, _, ok := usageScopeFromContext(ctx); ok {
+		tracker.usage, tracker.hasUsage = scope, true
+		tracker.usageOffset = len(scope.ledger.Records(scope.sessionID))
+	}
+	return tracker
+}
+
+// check returns the reason the loop should stop after an iteration, or "" to continue
+func (t *loopTracker) check(ctx context.Context, iteration int, previous, current State) (string, error) {
+	if len(t.conditions) == 0 {
+		return "", nil
+	}
+
+	info := LoopIteration{
+		Iteration: iteration,
+		Previous:  previous,
+		State:     current,
+		Elapsed:   time.Since(t.started),
+	}
+	if t.hasUsage {
+		records := t.usage.ledger.Records(t.usage.sessionID)
+		if t.usageOffset <= len(records) {
+			for _, record := range records[t.usageOffset:] {
+				info.Usage.add(record)
+			}
+		}
+	}
+
+	for _, condition := range t.conditions {
+		stop, err := condition.ShouldStop(ctx, info)
+		if err != nil {
+			return "", err
+		}
+		if stop {
+			return loopConditionName(condition), nil
+		}
+	}
+	return "", nil
+}
+
+// finishLoop records how the loop ended on its final state
+func finishLoop(state State, iterations int, reason string) State {
+	state = finishWorkflow(state).Clone()
+	state.SetMeta(LoopIterationsKey, fmt.Sprint(iterations))
+	state.SetMeta(LoopStopReasonKey, reason)
+	return state
+}
+
+// =============================================================================
+// LOOP CONDITION CONFIGURATION
+// =============================================================================
+
+// LoopTerminationConfig configures loop termination in [orchestration.termination].
+// The loop stops at the first condition that holds, or after max_iterations.
+type LoopTerminationConfig struct {
+	StopWhen           string   `toml:"stop_when"`            // Expression over the state, e.g. "score >= 0.8"
+	Convergence        bool     `toml:"convergence"`          // Stop when an iteration changes nothing
+	ConvergenceKeys    []string `toml:"convergence_keys"`     // Keys compared for convergence (default: all)
+	MaxDurationSeconds int      `toml:"max_duration_seconds"` // Time budget for the whole loop
+	MaxTokens          int      `toml:"max_tokens"`           // Token budget for the whole loop (needs [usage])
+	JudgeCriteria      string   `toml:"judge_criteria"`       // Ask a model whether the output meets these criteria
+	JudgeProvider      string   `toml:"judge_provider"`       // Provider for the judge (default: agent_flow.provider)
+	JudgeKeys          []string `toml:"judge_keys"`           // State keys shown to the judge (default: all)
+}
+
+// loopConditionsFromConfig builds the termination conditions configured for a loop
+func loopConditionsFromConfig(config *Config) ([]LoopCondition, error) {
+	termination := config.Orchestration.Termination
+	var conditions []LoopCondition
+
+	if termination.StopWhen != "" {
+		condition, err := StopWhenState(termination.StopWhen)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stop_when: %w", err)
+		}
+		conditions = append(conditions, condition)
+	}
+	if termination.Convergence || len(termination.ConvergenceKeys) > 0 {
+		conditions = append(conditions, StopOnConvergence(termination.ConvergenceKeys...))
+	}
+	if termination.MaxDurationSeconds > 0 {
+		conditions = append(conditions, StopAfterDuration(time.Duration(termination.MaxDurationSeconds)*time.Second))
+	}
+	if termination.MaxTokens > 0 {
+		conditions = append(conditions, StopAfterTokens(termination.MaxTokens))
+	}
+	if strings.TrimSpace(termination.JudgeCriteria) != "" {
+		provider, err := config.initializeNamedProvider(termination.JudgeProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize loop judge provider: %w", err)
+		}
+		conditions = append(conditions, StopWhenJudged(provider, termination.JudgeCriteria, termination.JudgeKeys...))
+	}
+	return conditions, nil
+}
@@ -0,0 +1,258 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dispatchLoop runs handler in a loop orchestrator with the given conditions
+func dispatchLoop(t *testing.T, ctx context.Context, maxIterations int, handler AgentHandler, conditions ...LoopCondition) State {
+	t.Helper()
+	orch := NewLoopOrchestratorWithConditions(NewCallbackRegistry(), []string{"refine"}, maxIterations, conditions...)
+	if err := orch.RegisterAgent("refine", handler); err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	result, err := orch.Dispatch(ctx, NewEvent("refine", EventData{}, nil))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	return result.OutputState
+}
+
+// counter returns a handler that increments "count" and sets "value" from next
+func counter(next func(count int) interface{}) AgentHandler {
+	return AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		count := 0
+		if value, ok := state.Get("count"); ok {
+			count = value.(int)
+		}
+		count++
+		output := state.Clone()
+		output.Set("count", count)
+		output.Set("value", next(count))
+		return AgentResult{OutputState: output}, nil
+	})
+}
+
+func TestLoopOrchestrator_StopWhenState(t *testing.T) {
+	condition, err := StopWhenState("value >= 0.8")
+	if err != nil {
+		t.Fatalf("StopWhenState failed: %v", err)
+	}
+	state := dispatchLoop(t, context.Background(), 10, counter(func(count int) interface{} { return float64(count) * 0.3 }), condition)
+
+	if count, _ := state.Get("count"); count != 3 {
+		t.Errorf("expected the loop to stop after 3 iterations, got %v", count)
+	}
+	if reason, _ := state.GetMeta(LoopStopReasonKey); reason != "stop_when: value >= 0.8" {
+		t.Errorf("unexpected stop reason %q", reason)
+	}
+	if iterations, _ := state.GetMeta(LoopIterationsKey); iterations != "3" {
+		t.Errorf("expected 3 iterations recorded, got %q", iterations)
+	}
+
+	if _, err := StopWhenState("value >="); err == nil {
+		t.Error("expected an invalid expression to be rejected")
+	}
+}
+
+func TestLoopOrchestrator_StopOnConvergence(t *testing.T) {
+	capped := counter(func(count int) interface{} {
+		if count > 2 {
+			return 2
+		}
+		return count
+	})
+	state := dispatchLoop(t, context.Background(), 10, capped, StopOnConvergence("value"))
+	if count, _ := state.Get("count"); count != 3 {
+		t.Errorf("expected the loop to stop once value stopped changing, got %v iterations", count)
+	}
+
+	state = dispatchLoop(t, context.Background(), 4, capped)
+	if reason, _ := state.GetMeta(LoopStopReasonKey); reason != "max_iterations" {
+		t.Errorf("expected max_iterations without conditions, got %q", reason)
+	}
+	if count, _ := state.Get("count"); count != 4 {
+		t.Errorf("expected the configured 4 iterations, got %v", count)
+	}
+}
+
+func TestLoopOrchestrator_StopOnConvergence_InPlaceHandler(t *testing.T) {
+	inPlace := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		count, _ := state.Get("count")
+		next, _ := count.(int)
+		state.Set("count", next+1)
+		return AgentResult{OutputState: state}, nil
+	})
+	state := dispatchLoop(t, context.Background(), 4, inPlace, StopOnConvergence("count"))
+	if count, _ := state.Get("count"); count != 4 {
+		t.Errorf("expected a changing count to run all 4 iterations, got %v", count)
+	}
+	if reason, _ := state.GetMeta(LoopStopReasonKey); reason != "max_iterations" {
+		t.Errorf("expected max_iterations, got %q", reason)
+	}
+
+	agent := &inPlaceAgent{}
+	loop, err := NewComposition("refiner").
+		WithAgents(agent).
+		WithLoopConditions(StopOnConvergence("count")).
+		AsLoop(4, nil).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if _, err := loop.Run(context.Background(), NewState()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if agent.runs != 4 {
+		t.Errorf("expected a changing count to run all 4 iterations, got %d", agent.runs)
+	}
+}
+
+func TestLoopOrchestrator_StopWhenJudged(t *testing.T) {
+	judge := NewMockModelProvider().
+		RespondText(MatchUserContains("draft v2"), `{"done": true, "feedback": ""}`).
+		SetDefaultResponse(Response{Content: `{"done": false, "feedback": "add an example"}`})
+
+	var feedbackSeen []string
+	writer := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		version := 1
+		if value, ok := state.Get("version"); ok {
+			version = value.(int) + 1
+		}
+		feedback, _ := state.Get(LoopFeedbackKey)
+		feedbackSeen = append(feedbackSeen, feedbackString(feedback))
+		output := state.Clone()
+		output.Set("version", version)
+		output.Set("essay", fmt.Sprintf("draft v%d", version))
+		return AgentResult{OutputState: output}, nil
+	})
+
+	state := dispatchLoop(t, context.Background(), 5, writer, StopWhenJudged(judge, "The essay includes an example.", "essay"))
+	if version, _ := state.Get("version"); version != 2 {
+		t.Errorf("expected the judge to accept the second draft, got version %v", version)
+	}
+	if reason, _ := state.GetMeta(LoopStopReasonKey); reason != "judge" {
+		t.Errorf("unexpected stop reason %q", reason)
+	}
+	if len(feedbackSeen) != 2 || feedbackSeen[0] != "" || feedbackSeen[1] != "add an example" {
+		t.Errorf("expected the judge's feedback to reach the next iteration, got %v", feedbackSeen)
+	}
+	if prompt := judge.Calls()[0].User; !strings.Contains(prompt, "The essay includes an example.") || strings.Contains(prompt, "version") {
+		t.Errorf("expected the judge to see the criteria and only the judged keys:\n%s", prompt)
+	}
+}
+
+func TestLoopOrchestrator_StopAfterTokens(t *testing.T) {
+	provider := NewUsageTrackingProvider(NewMockModelProvider().SetDefaultResponse(Response{
+		Content: "ok",
+		Usage:   UsageStats{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40},
+	}), "mock", "mock-model")
+	caller := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		if _, err := provider.Call(ctx, Prompt{User: "improve"}); err != nil {
+			return AgentResult{}, err
+		}
+		return counter(func(count int) interface{} { return count }).Run(ctx, event, state)
+	})
+
+	ctx := WithUsageLedger(context.Background(), NewUsageLedger(UsageLedgerConfig{}), "tokens")
+	state := dispatchLoop(t, ctx, 10, caller, StopAfterTokens(100))
+	if count, _ := state.Get("count"); count != 3 {
+		t.Errorf("expected the token budget to stop the loop after 3 calls of 40 tokens, got %v", count)
+	}
+}
+
+func TestLoopComposition_WithLoopConditions(t *testing.T) {
+	agent := &countingAgent{}
+	loop, err := NewComposition("refiner").
+		WithAgents(agent).
+		WithLoopConditions(StopOnConvergence()).
+		AsLoop(10, nil).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if _, err := loop.Run(context.Background(), NewState()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if agent.runs != 1 {
+		t.Errorf("expected an unchanged state to end the loop after one iteration, got %d", agent.runs)
+	}
+}
+
+func TestLoopConditionsFromConfig(t *testing.T) {
+	config := &Config{}
+	config.Orchestration.Mode = "loop"
+	config.Orchestration.LoopAgent = "refine"
+	config.Orchestration.MaxIterations = 6
+	config.Orchestration.TimeoutSeconds = 30
+	config.Orchestration.Termination = LoopTerminationConfig{StopWhen: "value >= 4", Convergence: true, MaxDurationSeconds: 60}
+
+	conditions, err := loopConditionsFromConfig(config)
+	if err != nil || len(conditions) != 3 {
+		t.Fatalf("expected 3 conditions, got %d (%v)", len(conditions), err)
+	}
+
+	runner, err := createRunnerWithOrchestration(config, QuickMemory(), "loop-config")
+	if err != nil {
+		t.Fatalf("createRunnerWithOrchestration failed: %v", err)
+	}
+	runner.RegisterAgent("refine", counter(func(count int) interface{} { return count }))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	result, err := runner.Run(ctx, NewEvent("refine", EventData{}, map[string]string{SessionIDKey: "loop-config", RouteMetadataKey: "refine"}))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if count, _ := result.OutputState.Get("count"); count != 4 {
+		t.Errorf("expected stop_when from configuration to end the loop at 4, got %v", count)
+	}
+
+	config.Orchestration.Termination = LoopTerminationConfig{StopWhen: "value >="}
+	if err := config.ValidateOrchestrationConfig(); err == nil {
+		t.Error("expected an invalid stop_when to fail validation")
+	}
+	config.Orchestration.Termination = LoopTerminationConfig{JudgeCriteria: "good", JudgeProvider: "missing"}
+	if _, err := loopConditionsFromConfig(config); err == nil {
+		t.Error("expected an unknown judge provider to be rejected")
+	}
+}
+
+// countingAgent returns its input unchanged and counts its runs
+type countingAgent struct {
+	runs int
+}
+
+func (a *countingAgent) Name() string { return "counting" }
+
+func (a *countingAgent) Run(ctx context.Context, state State) (State, error) {
+	a.runs++
+	return state.Clone(), nil
+}
+
+// inPlaceAgent increments "count" in the state it is given and returns that same state
+type inPlaceAgent struct {
+	runs int
+}
+
+func (a *inPlaceAgent) Name() string { return "in-place" }
+
+func (a *inPlaceAgent) Run(ctx context.Context, state State) (State, error) {
+	a.runs++
+	state.Set("count", a.runs)
+	return state, nil
+}
+
+func feedbackString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,406 @@
+// Package core provides map/reduce orchestration over list-valued state
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrchestrationMapReduce runs a mapper agent over every item of a list in the state and
+// feeds the collected outputs to a reducer agent
+const OrchestrationMapReduce OrchestrationMode = "map-reduce"
+
+const (
+	// DefaultMapItemKey is the state key holding the item a mapper works on
+	DefaultMapItemKey = "map_item"
+	// DefaultMapResultsKey is the state key holding the mapper outputs given to the reducer
+	DefaultMapResultsKey = "map_results"
+	// MapIndexKey is the metadata key holding the position of the item a mapper works on
+	MapIndexKey = "map_index"
+	// MapErrorsKey is the state key listing the items that failed under ErrorStrategyContinue
+	MapErrorsKey = "map_errors"
+)
+
+// MapReduceConfig configures map/reduce orchestration
+type MapReduceConfig struct {
+	// ItemsKey is the state key holding the list to map over. Required.
+	ItemsKey string
+	// Mapper and Reducer name the agents of a map/reduce orchestrator. The reducer is
+	// optional; without one the collected outputs are returned under ResultsKey.
+	Mapper  string
+	Reducer string
+	// ItemKey is the state key the mapper finds its item under. Default DefaultMapItemKey.
+	ItemKey string
+	// OutputKey is the key read from each mapper's output state. When empty, the keys the
+	// mapper added or changed are collected as a map.
+	OutputKey string
+	// ResultsKey is the state key the reducer finds the mapper outputs under, in item
+	// order. Default DefaultMapResultsKey.
+	ResultsKey string
+	// MaxConcurrency bounds the mappers running at once. 0 uses DefaultMultiAgentConfig.
+	MaxConcurrency int
+	// ErrorStrategy decides what failed items do: ErrorStrategyFailFast stops at the first
+	// failure, ErrorStrategyCollectAll maps every item and then fails with all errors, and
+	// ErrorStrategyContinue reduces the successful items and lists failures under
+	// MapErrorsKey. Default ErrorStrategyCollectAll.
+	ErrorStrategy ErrorHandlingStrategy
+	// Timeout bounds the whole run, mapping and reducing. 0 means no limit.
+	Timeout time.Duration
+}
+
+// MapItemError describes an item the mapper failed on
+type MapItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// withDefaults fills in the unset fields of a map/reduce configuration
+func (c MapReduceConfig) withDefaults() MapReduceConfig {
+	defaults := DefaultMultiAgentConfig()
+	if c.ItemKey == "" {
+		c.ItemKey = DefaultMapItemKey
+	}
+	if c.ResultsKey == "" {
+		c.ResultsKey = DefaultMapResultsKey
+	}
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if c.ErrorStrategy == "" {
+		c.ErrorStrategy = defaults.ErrorStrategy
+	}
+	return c
+}
+
+// mapFunc runs the mapper on the input state prepared for one item
+type mapFunc func(ctx context.Context, input State) (State, error)
+
+// runMapPhase maps every item of the list under config.ItemsKey and returns the state for
+// the reducer: the input state with the outputs under config.ResultsKey
+func runMapPhase(ctx context.Context, state State, config MapReduceConfig, mapper mapFunc) (State, error) {
+	if config.ItemsKey == "" {
+		return nil, errors.New("map/reduce requires an items key")
+	}
+	value, ok := state.Get(config.ItemsKey)
+	if !ok {
+		return nil, fmt.Errorf("map/reduce items key %s not found in state", config.ItemsKey)
+	}
+	items, err := mapItems(value)
+	if err != nil {
+		return nil, fmt.Errorf("map/reduce items key %s: %w", config.ItemsKey, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]interface{}, len(items))
+	failures := make([]error, len(items))
+	semaphore := make(chan struct{}, config.MaxConcurrency)
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var firstErr error
+
+schedule:
+	for i, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			break schedule
+		}
+
+		wg.Add(1)
+		go func(index int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			input := mapInputState(state, config, index, item)
+			output, err := mapper(ctx, input)
+			if err != nil {
+				failures[index] = err
+				if config.ErrorStrategy == ErrorStrategyFailFast {
+					failOnce.Do(func() {
+						firstErr = fmt.Errorf("map item %d failed: %w", index, err)
+						cancel()
+					})
+				}
+				return
+			}
+			if output == nil {
+				output = input
+			}
+			outputs[index] = mapOutput(input, output, config.OutputKey)
+		}(i, item)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("map/reduce cancelled: %w", err)
+	}
+
+	results := make([]interface{}, 0, len(items))
+	var itemErrors []MapItemError
+	var messages []string
+	for i := range items {
+		if failures[i] != nil {
+			itemErrors = append(itemErrors, MapItemError{Index: i, Error: failures[i].Error()})
+			messages = append(messages, fmt.Sprintf("item %d: %v", i, failures[i]))
+			continue
+		}
+		results = append(results, outputs[i])
+	}
+	if len(itemErrors) > 0 && config.ErrorStrategy != ErrorStrategyContinue {
+		return nil, fmt.Errorf("map/reduce: %d of %d items failed: %s", len(itemErrors), len(items), strings.Join(messages, "; "))
+	}
+	if len(itemErrors) > 0 {
+		Logger().Warn().Int("failed", len(itemErrors)).Int("items", len(items)).Msg("MapReduce: Continuing without failed items")
+	}
+
+	reduceInput := state.Clone()
+	reduceInput.Set(config.ResultsKey, results)
+	if len(itemErrors) > 0 {
+		reduceInput.Set(MapErrorsKey, itemErrors)
+	}
+	return reduceInput, nil
+}
+
+// mapItems converts a list-valued state entry into its items
+func mapItems(value interface{}) ([]interface{}, error) {
+	if items, ok := value.([]interface{}); ok {
+		return items, nil
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// mapInputState builds a mapper's input: the state without the full list, plus its item.
+// Leaving out the list keeps prompts built from the state to the one item.
+func mapInputState(state State, config MapReduceConfig, index int, item interface{}) State {
+	input := NewState()
+	for _, key := range state.Keys() {
+		if key != config.ItemsKey {
+			value, _ := state.Get(key)
+			input.Set(key, value)
+		}
+	}
+	for _, key := range state.MetaKeys() {
+		value, _ := state.GetMeta(key)
+		input.SetMeta(key, value)
+	}
+	input.Set(config.ItemKey, item)
+	input.SetMeta(MapIndexKey, strconv.Itoa(index))
+	return input
+}
+
+// mapOutput extracts what a mapper produced for the reducer
+func mapOutput(input, output State, outputKey string) interface{} {
+	if outputKey != "" {
+		value, _ := output.Get(outputKey)
+		return value
+	}
+	changed := make(map[string]interface{})
+	for _, key := range output.Keys() {
+		value, _ := output.Get(key)
+		if before, ok := input.Get(key); ok && reflect.DeepEqual(before, value) {
+			continue
+		}
+		changed[key] = value
+	}
+	return changed
+}
+
+// =============================================================================
+// MAP/REDUCE ORCHESTRATOR
+// =============================================================================
+
+// mapReduceOrchestrator maps the registered mapper agent over a list in the event data and
+// reduces the outputs with the reducer agent
+type mapReduceOrchestrator struct {
+	handlers         map[string]AgentHandler
+	config           MapReduceConfig
+	callbackRegistry *CallbackRegistry
+	mu               sync.RWMutex
+}
+
+// NewMapReduceOrchestrator creates an orchestrator that runs config.Mapper once per item of
+// the list under config.ItemsKey, with at most config.MaxConcurrency mappers at a time, and
+// passes the collected outputs to config.Reducer
+func NewMapReduceOrchestrator(registry *CallbackRegistry, config MapReduceConfig) Orchestrator {
+	return &mapReduceOrchestrator{
+		handlers:         make(map[string]AgentHandler),
+		config:           config.withDefaults(),
+		callbackRegistry: registry,
+	}
+}
+
+// RegisterAgent adds an agent handler to the map/reduce orchestrator
+func (o *mapReduceOrchestrator) RegisterAgent(name string, handler AgentHandler) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil for agent %s", name)
+	}
+
+	o.handlers[name] = handler
+	Logger().Debug().Str("agent", name).Msg("MapReduceOrchestrator: Agent registered")
+	return nil
+}
+
+// Dispatch maps the event's items and reduces the results
+func (o *mapReduceOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResult, error) {
+	if event == nil {
+		err := errors.New("cannot dispatch nil event")
+		return AgentResult{Error: err.Error()}, err
+	}
+
+	o.mu.RLock()
+	handlers := make(map[string]AgentHandler, len(o.handlers))
+	for name, handler := range o.handlers {
+		handlers[name] = handler
+	}
+	o.mu.RUnlock()
+
+	// Initialize state from event data
+	var currentState State = NewState()
+	for key, value := range event.GetData() {
+		currentState.Set(key, value)
+	}
+	for key, value := range event.GetMetadata() {
+		currentState.SetMeta(key, value)
+	}
+
+	workflowAgents := []string{o.config.Mapper}
+	if o.config.Reducer != "" {
+		workflowAgents = append(workflowAgents, o.config.Reducer)
+	}
+	if result, handled, err := dispatchOutsideWorkflow(ctx, handlers, workflowAgents, event, currentState); handled {
+		return result, err
+	}
+	if o.config.Mapper == "" {
+		err := errors.New("map/reduce orchestration requires a mapper agent")
+		return AgentResult{Error: err.Error()}, err
+	}
+	for _, name := range workflowAgents {
+		if _, ok := handlers[name]; !ok {
+			err := fmt.Errorf("map/reduce agent %s is not registered", name)
+			return AgentResult{Error: err.Error()}, err
+		}
+	}
+
+	if o.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.Timeout)
+		defer cancel()
+	}
+
+	mapper := handlers[o.config.Mapper]
+	reduceInput, err := runMapPhase(ctx, currentState, o.config, func(ctx context.Context, input State) (State, error) {
+		result, err := runAgentHandler(ctx, o.config.Mapper, mapper, event, input)
+		if err != nil {
+			return nil, err
+		}
+		return result.OutputState, nil
+	})
+	if err != nil {
+		return AgentResult{Error: err.Error()}, err
+	}
+
+	if o.config.Reducer == "" {
+		return AgentResult{OutputState: finishWorkflow(reduceInput)}, nil
+	}
+
+	Logger().Debug().Str("agent", o.config.Reducer).Msg("MapReduceOrchestrator: Reducing mapper outputs")
+	result, err := runAgentHandler(ctx, o.config.Reducer, handlers[o.config.Reducer], event, reduceInput)
+	if err != nil {
+		err = fmt.Errorf("map/reduce reducer %s failed: %w", o.config.Reducer, err)
+		return AgentResult{Error: err.Error()}, err
+	}
+	if result.OutputState == nil {
+		result.OutputState = reduceInput
+	}
+	result.OutputState = finishWorkflow(result.OutputState)
+	return result, nil
+}
+
+// GetCallbackRegistry returns the callback registry
+func (o *mapReduceOrchestrator) GetCallbackRegistry() *CallbackRegistry {
+	return o.callbackRegistry
+}
+
+// Stop halts the map/reduce orchestrator
+func (o *mapReduceOrchestrator) Stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handlers = make(map[string]AgentHandler)
+	Logger().Debug().Msg("MapReduceOrchestrator: Stopped")
+}
+
+// =============================================================================
+// MAP/REDUCE AGENT
+// =============================================================================
+
+// NewMapReduceAgent creates an agent that runs mapper once per item of the list under
+// config.ItemsKey and passes the collected outputs to reducer. reducer may be nil, in which
+// case the outputs are returned under config.ResultsKey. config.Mapper and config.Reducer
+// are not used.
+func NewMapReduceAgent(name string, config MapReduceConfig, mapper, reducer Agent) Agent {
+	return &mapReduceAgent{
+		name:    name,
+		config:  config.withDefaults(),
+		mapper:  mapper,
+		reducer: reducer,
+	}
+}
+
+// mapReduceAgent maps a sub-agent over a list in the state and reduces the outputs
+type mapReduceAgent struct {
+	name    string
+	config  MapReduceConfig
+	mapper  Agent
+	reducer Agent
+}
+
+func (ma *mapReduceAgent) Name() string {
+	return ma.name
+}
+
+func (ma *mapReduceAgent) Run(ctx context.Context, inputState State) (State, error) {
+	if ma.mapper == nil {
+		return inputState, fmt.Errorf("map/reduce agent %s requires a mapper", ma.name)
+	}
+
+	// Create context with timeout if specified
+	if ma.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ma.config.Timeout)
+		defer cancel()
+	}
+
+	reduceInput, err := runMapPhase(ctx, inputState, ma.config, ma.mapper.Run)
+	if err != nil {
+		return inputState, err
+	}
+	if ma.reducer == nil {
+		return reduceInput, nil
+	}
+	return ma.reducer.Run(ctx, reduceInput)
+}
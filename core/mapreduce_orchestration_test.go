@@ -0,0 +1,313 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// summarizer is a mapper that upper-cases its item into "summary", failing on items in fail
+func summarizer(active, peak *int32, fail ...string) AgentHandlerFunc {
+	return func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		if active != nil {
+			current := atomic.AddInt32(active, 1)
+			defer atomic.AddInt32(active, -1)
+			for {
+				seen := atomic.LoadInt32(peak)
+				if current <= seen || atomic.CompareAndSwapInt32(peak, seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		item, _ := state.Get(DefaultMapItemKey)
+		for _, f := range fail {
+			if item == f {
+				return AgentResult{}, fmt.Errorf("cannot summarize %v", item)
+			}
+		}
+		output := state.Clone()
+		output.Set("summary", strings.ToUpper(item.(string)))
+		return AgentResult{OutputState: output}, nil
+	}
+}
+
+// joiner is a reducer that joins the mapper outputs into "report"
+var joiner = AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+	results, _ := state.Get(DefaultMapResultsKey)
+	var parts []string
+	for _, result := range results.([]interface{}) {
+		parts = append(parts, fmt.Sprint(result))
+	}
+	output := state.Clone()
+	output.Set("report", strings.Join(parts, ","))
+	return AgentResult{OutputState: output}, nil
+})
+
+func dispatchMapReduce(config MapReduceConfig, mapper, reducer AgentHandler, items interface{}) (AgentResult, error) {
+	orch := NewMapReduceOrchestrator(NewCallbackRegistry(), config)
+	orch.RegisterAgent("summarize", mapper)
+	if reducer != nil {
+		orch.RegisterAgent("combine", reducer)
+	}
+	return orch.Dispatch(context.Background(), NewEvent("", EventData{"documents": items}, nil))
+}
+
+func TestMapReduceOrchestrator_MapsAndReduces(t *testing.T) {
+	var active, peak int32
+	var mu sync.Mutex
+	indexes := map[string]string{}
+	mapper := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		if _, ok := state.Get("documents"); ok {
+			return AgentResult{}, errors.New("mapper should not see the whole list")
+		}
+		item, _ := state.Get(DefaultMapItemKey)
+		index, _ := state.GetMeta(MapIndexKey)
+		mu.Lock()
+		indexes[item.(string)] = index
+		mu.Unlock()
+		return summarizer(&active, &peak).Run(ctx, event, state)
+	})
+
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents:    map[string]AgentHandler{"summarize": mapper, "combine": joiner},
+			Memory:    QuickMemory(),
+			SessionID: "map-reduce-test",
+			Config:    &Config{},
+		},
+		OrchestrationMode: OrchestrationMapReduce,
+		MapReduce: MapReduceConfig{
+			ItemsKey:       "documents",
+			Mapper:         "summarize",
+			Reducer:        "combine",
+			OutputKey:      "summary",
+			MaxConcurrency: 2,
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	documents := []string{"a", "b", "c", "d", "e", "f"}
+	event := NewEvent("summarize", EventData{"documents": documents}, map[string]string{SessionIDKey: "docs", RouteMetadataKey: "summarize"})
+	result, err := runner.Run(ctx, event)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report, _ := result.OutputState.Get("report"); report != "A,B,C,D,E,F" {
+		t.Errorf("expected the reducer to see the outputs in item order, got %v", report)
+	}
+	if peak := atomic.LoadInt32(&peak); peak > 2 {
+		t.Errorf("expected at most 2 mappers at once, saw %d", peak)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, document := range documents {
+		if indexes[document] != strconv.Itoa(i) {
+			t.Errorf("expected item %s to have index %d, got %q", document, i, indexes[document])
+		}
+	}
+}
+
+func TestMapReduceOrchestrator_ErrorStrategies(t *testing.T) {
+	documents := []string{"a", "bad", "c", "worse"}
+
+	// continue reduces the successful items and lists the failures
+	result, err := dispatchMapReduce(MapReduceConfig{
+		ItemsKey: "documents", Mapper: "summarize", Reducer: "combine", OutputKey: "summary",
+		ErrorStrategy: ErrorStrategyContinue,
+	}, summarizer(nil, nil, "bad", "worse"), joiner, documents)
+	if err != nil {
+		t.Fatalf("expected continue to succeed with partial results: %v", err)
+	}
+	if report, _ := result.OutputState.Get("report"); report != "A,C" {
+		t.Errorf("expected the reducer to see only successful items, got %v", report)
+	}
+	failures, _ := result.OutputState.Get(MapErrorsKey)
+	if itemErrors, ok := failures.([]MapItemError); !ok || len(itemErrors) != 2 || itemErrors[0].Index != 1 || itemErrors[1].Index != 3 {
+		t.Errorf("expected failures for items 1 and 3, got %v", failures)
+	}
+
+	// collect_all maps every item, then fails without reducing
+	reduced := false
+	reducer := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		reduced = true
+		return AgentResult{OutputState: state}, nil
+	})
+	var mapped int32
+	counting := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		atomic.AddInt32(&mapped, 1)
+		return summarizer(nil, nil, "bad", "worse").Run(ctx, event, state)
+	})
+	_, err = dispatchMapReduce(MapReduceConfig{ItemsKey: "documents", Mapper: "summarize", Reducer: "combine"}, counting, reducer, documents)
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 items failed") {
+		t.Errorf("expected collect_all to report both failures, got %v", err)
+	}
+	if mapped != 4 || reduced {
+		t.Errorf("expected all 4 items mapped and no reduce, got %d mapped, reduced=%v", mapped, reduced)
+	}
+
+	// fail_fast stops scheduling items after the first failure
+	atomic.StoreInt32(&mapped, 0)
+	_, err = dispatchMapReduce(MapReduceConfig{
+		ItemsKey: "documents", Mapper: "summarize", Reducer: "combine",
+		MaxConcurrency: 1, ErrorStrategy: ErrorStrategyFailFast,
+	}, counting, reducer, documents)
+	if err == nil || !strings.Contains(err.Error(), "map item 1 failed") {
+		t.Errorf("expected fail_fast to report the first failure, got %v", err)
+	}
+	if mapped != 2 || reduced {
+		t.Errorf("expected mapping to stop after the second item, got %d mapped, reduced=%v", mapped, reduced)
+	}
+}
+
+func TestMapReduceOrchestrator_WithoutReducer(t *testing.T) {
+	result, err := dispatchMapReduce(MapReduceConfig{ItemsKey: "documents", Mapper: "summarize"}, summarizer(nil, nil), nil, []interface{}{"x", "y"})
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	value, _ := result.OutputState.Get(DefaultMapResultsKey)
+	results, ok := value.([]interface{})
+	if !ok || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v", value)
+	}
+	// Without an output key the keys the mapper changed are collected
+	if changed, ok := results[1].(map[string]interface{}); !ok || changed["summary"] != "Y" || len(changed) != 1 {
+		t.Errorf("expected only the mapper's new keys, got %v", results[1])
+	}
+
+	if _, err := dispatchMapReduce(MapReduceConfig{ItemsKey: "documents", Mapper: "summarize"}, summarizer(nil, nil), nil, "not a list"); err == nil {
+		t.Error("expected an error when the items are not a list")
+	}
+	if _, err := dispatchMapReduce(MapReduceConfig{ItemsKey: "missing", Mapper: "summarize"}, summarizer(nil, nil), nil, []string{}); err == nil {
+		t.Error("expected an error when the items key is missing")
+	}
+	if _, err := dispatchMapReduce(MapReduceConfig{ItemsKey: "documents", Mapper: "summarize", Reducer: "unknown"}, summarizer(nil, nil), nil, []string{}); err == nil {
+		t.Error("expected an error for an unregistered reducer")
+	}
+}
+
+func TestMapReduceComposition(t *testing.T) {
+	mapper := &handlerAgent{name: "mapper", handler: summarizer(nil, nil)}
+	reducer := &handlerAgent{name: "reducer", handler: joiner}
+	agent, err := NewComposition("summaries").
+		WithAgents(mapper, reducer).
+		WithMaxConcurrency(3).
+		AsMapReduce("documents").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	state := NewState()
+	state.Set("documents", []string{"p", "q"})
+	output, err := agent.Run(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report, _ := output.Get("report"); report != "map[summary:P],map[summary:Q]" {
+		t.Errorf("unexpected report %v", report)
+	}
+	if diagram := NewComposition("summaries").WithAgents(mapper, reducer).AsMapReduce("documents").GenerateMermaidDiagram(); !strings.Contains(diagram, "REDUCE") {
+		t.Errorf("expected a map-reduce diagram:\n%s", diagram)
+	}
+}
+
+func TestMapReduceCompositionTimeout(t *testing.T) {
+	slow := &handlerAgent{name: "mapper", handler: AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		select {
+		case <-ctx.Done():
+			return AgentResult{}, ctx.Err()
+		case <-time.After(time.Second):
+			return AgentResult{OutputState: state}, nil
+		}
+	})}
+	agent, err := NewComposition("slow").WithAgents(slow).WithTimeout(20 * time.Millisecond).AsMapReduce("documents").Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	state := NewState()
+	state.Set("documents", []string{"p"})
+	start := time.Now()
+	if _, err := agent.Run(context.Background(), state); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the composition timeout to stop the mapper, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timeout was not applied, run took %v", elapsed)
+	}
+}
+
+func TestMapReduceFromConfig(t *testing.T) {
+	config := &Config{}
+	config.Orchestration.Mode = "map-reduce"
+	config.Orchestration.TimeoutSeconds = 30
+	config.Orchestration.MapAgent = "summarize"
+	config.Orchestration.ReduceAgent = "combine"
+	config.Orchestration.ItemsKey = "documents"
+	config.Orchestration.OutputKey = "summary"
+	config.Orchestration.ErrorStrategy = "continue"
+	if err := config.ValidateOrchestrationConfig(); err != nil {
+		t.Fatalf("expected a valid configuration: %v", err)
+	}
+
+	runner, err := createRunnerWithOrchestration(config, QuickMemory(), "map-reduce-config")
+	if err != nil {
+		t.Fatalf("createRunnerWithOrchestration failed: %v", err)
+	}
+	runner.RegisterAgent("summarize", summarizer(nil, nil, "b"))
+	runner.RegisterAgent("combine", joiner)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	event := NewEvent("summarize", EventData{"documents": []string{"a", "b", "c"}}, map[string]string{SessionIDKey: "map-reduce-config", RouteMetadataKey: "summarize"})
+	result, err := runner.Run(ctx, event)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report, _ := result.OutputState.Get("report"); report != "A,C" {
+		t.Errorf("expected the configured error strategy to skip the failed item, got %v", report)
+	}
+
+	config.Orchestration.ErrorStrategy = "ignore"
+	if err := config.ValidateOrchestrationConfig(); err == nil {
+		t.Error("expected an unknown error strategy to fail validation")
+	}
+	config.Orchestration.ErrorStrategy = ""
+	config.Orchestration.ItemsKey = ""
+	if err := config.ValidateOrchestrationConfig(); err == nil {
+		t.Error("expected a missing items_key to fail validation")
+	}
+}
+
+// handlerAgent adapts an AgentHandler to the Agent interface for compositions
+type handlerAgent struct {
+	name    string
+	handler AgentHandler
+}
+
+func (a *handlerAgent) Name() string { return a.name }
+
+func (a *handlerAgent) Run(ctx context.Context, state State) (State, error) {
+	result, err := a.handler.Run(ctx, NewEvent(a.name, EventData{}, nil), state)
+	if err != nil {
+		return nil, err
+	}
+	return result.OutputState, nil
+}
@@ -2148,6 +2148,52 @@ Use these tools to provide comprehensive and accurate responses.`
 	return prompt
 }
 
+// ToolDefinitionsFromMCP converts discovered MCP tools into tool definitions for native
+// function calling. Pass the result as Prompt.Tools so providers that support tools
+// receive structured schemas instead of a text description.
+func ToolDefinitionsFromMCP(tools []MCPToolInfo) []ToolDefinition {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	definitions := make([]ToolDefinition, len(tools))
+	for i, tool := range tools {
+		definitions[i] = ToolDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  tool.Schema,
+		}
+	}
+	return definitions
+}
+
+// ExtractToolCalls returns the tool calls requested in an LLM response.
+// Native tool calls from Response.ToolCalls are preferred; when the provider returned
+// none, the TOOL_CALL{...} text protocol in the response content is parsed as a fallback.
+func ExtractToolCalls(response Response) []ToolCall {
+	if len(response.ToolCalls) > 0 {
+		return response.ToolCalls
+	}
+
+	var toolCalls []ToolCall
+	for i, parsed := range ParseLLMToolCalls(response.Content) {
+		name, ok := parsed["name"].(string)
+		if !ok || name == "" {
+			continue
+		}
+		args, ok := parsed["args"].(map[string]interface{})
+		if !ok {
+			args = make(map[string]interface{})
+		}
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        fmt.Sprintf("text_call_%d", i),
+			Name:      name,
+			Arguments: args,
+		})
+	}
+	return toolCalls
+}
+
 // FormatSchemaForLLM converts a tool schema map to a readable string format for the LLM
 // This function takes a JSON schema from MCP tool discovery and formats it in a way
 // that LLMs can easily understand and use to make proper tool calls.
@@ -38,11 +38,15 @@ type AgentMemoryConfig struct {
 
 	// Search settings
 	Search SearchConfigToml `toml:"search"`
+
+	// Vector index settings (memory provider)
+	Index IndexConfig `toml:"index"`
 }
 
 // DocumentConfig represents document processing configuration
 type DocumentConfig struct {
 	AutoChunk                bool     `toml:"auto_chunk"`                 // default: true
+	ChunkStrategy            string   `toml:"chunk_strategy"`             // auto (default), fixed, recursive, markdown, code
 	SupportedTypes           []string `toml:"supported_types"`            // default: ["pdf", "txt", "md", "web", "code"]
 	MaxFileSize              string   `toml:"max_file_size"`              // default: "10MB"
 	EnableMetadataExtraction bool     `toml:"enable_metadata_extraction"` // default: true
@@ -59,6 +63,17 @@ type EmbeddingConfig struct {
 	Endpoint        string `toml:"endpoint"`         // Custom endpoint (deprecated, use BaseURL)
 	MaxBatchSize    int    `toml:"max_batch_size"`   // default: 100
 	TimeoutSeconds  int    `toml:"timeout_seconds"`  // default: 30
+
+	// Service, when set, is used instead of the service of Provider
+	Service EmbeddingService `toml:"-"`
+}
+
+// IndexConfig represents the vector index of the in-memory provider
+type IndexConfig struct {
+	Type           string `toml:"type"`            // flat (default, exact), hnsw (approximate)
+	M              int    `toml:"m"`               // HNSW links per node; default: 16
+	EfConstruction int    `toml:"ef_construction"` // HNSW candidates when inserting; default: 200
+	EfSearch       int    `toml:"ef_search"`       // HNSW candidates when searching; default: 64
 }
 
 // SearchConfigToml represents search configuration
@@ -66,8 +81,16 @@ type SearchConfigToml struct {
 	HybridSearch         bool    `toml:"hybrid_search"`          // default: true
 	KeywordWeight        float32 `toml:"keyword_weight"`         // default: 0.3
 	SemanticWeight       float32 `toml:"semantic_weight"`        // default: 0.7
+	FusionMethod         string  `toml:"fusion_method"`          // weighted (default), rrf
+	RRFK                 int     `toml:"rrf_k"`                  // default: 60
 	EnableReranking      bool    `toml:"enable_reranking"`       // default: false
-	RerankingModel       string  `toml:"reranking_model"`        // Model for reranking
+	Reranker             string  `toml:"reranker"`               // mmr (default), cross_encoder, llm
+	RerankingModel       string  `toml:"reranking_model"`        // Model for reranking (cross_encoder)
+	RerankingEndpoint    string  `toml:"reranking_endpoint"`     // Rerank API URL (cross_encoder)
+	RerankingAPIKey      string  `toml:"reranking_api_key"`      // Bearer token for the rerank API
+	RerankingProvider    string  `toml:"reranking_provider"`     // Provider from [providers] (llm; default: agent_flow.provider)
+	RerankCandidates     int     `toml:"rerank_candidates"`      // Results fetched for the reranker; default: 3 × the limit
+	MMRLambda            float32 `toml:"mmr_lambda"`             // Relevance vs diversity for mmr; default: 0.7
 	EnableQueryExpansion bool    `toml:"enable_query_expansion"` // default: false
 }
 
@@ -141,6 +164,22 @@ func NewMemory(config AgentMemoryConfig) (Memory, error) {
 	if config.Search.SemanticWeight == 0 {
 		config.Search.SemanticWeight = 0.7
 	}
+	if config.Search.FusionMethod == "" {
+		config.Search.FusionMethod = string(FusionWeighted)
+	}
+	if config.Search.RRFK == 0 {
+		config.Search.RRFK = DefaultRRFK
+	}
+	if err := validateFusionMethod(config.Search.FusionMethod); err != nil {
+		return nil, err
+	}
+	if _, err := newRerankerFromConfig(config, nil); err != nil {
+		return nil, err
+	}
+
+	if _, err := NewChunker(config.Documents.ChunkStrategy, config.ChunkSize, config.ChunkOverlap); err != nil {
+		return nil, err
+	}
 
 	switch config.Provider {
 	case "memory":
@@ -215,6 +254,53 @@ func removeDuplicates(slice []string) []string {
 	return result
 }
 
+// ChatMessagesFromHistory converts stored conversation history into chat messages
+// that can be passed as Prompt.Messages.
+func ChatMessagesFromHistory(history []Message) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(history))
+	for _, msg := range history {
+		messages = append(messages, ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	return messages
+}
+
+// buildContextMessages assembles RAG context as chat messages: a system message carrying
+// personal memory and knowledge base results, followed by the chat history turns.
+// The caller appends the current query as the final user turn.
+func buildContextMessages(results *HybridResult, history []Message, config *ContextConfig) []ChatMessage {
+	var builder strings.Builder
+
+	if len(results.PersonalMemory) > 0 {
+		builder.WriteString("Personal Memory:\n")
+		for i, result := range results.PersonalMemory {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Content))
+		}
+	}
+
+	if len(results.Knowledge) > 0 {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("Knowledge Base:\n")
+		for i, result := range results.Knowledge {
+			source := ""
+			if config.IncludeSources && result.Source != "" {
+				source = fmt.Sprintf(" (Source: %s)", result.Source)
+			}
+			builder.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, result.Content, source))
+		}
+	}
+
+	messages := make([]ChatMessage, 0, len(history)+1)
+	if builder.Len() > 0 {
+		messages = append(messages, ChatMessage{
+			Role:    ChatRoleSystem,
+			Content: "Relevant context:\n" + builder.String(),
+		})
+	}
+	return append(messages, ChatMessagesFromHistory(history)...)
+}
+
 func estimateTokenCount(text string) int {
 	// Rough estimation: ~4 characters per token
 	return len(text) / 4
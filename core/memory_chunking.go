@@ -0,0 +1,418 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParentDocumentIDKey is the Document.Metadata key holding the ID of the document a chunk
+// was split from.
+const ParentDocumentIDKey = "parent_document_id"
+
+// Chunking strategies for [agent_memory.documents] chunk_strategy
+const (
+	ChunkStrategyAuto      = "auto"      // markdown for Markdown, code for code, recursive otherwise
+	ChunkStrategyFixed     = "fixed"     // fixed-size windows of ChunkSize tokens
+	ChunkStrategyRecursive = "recursive" // paragraphs, then lines, sentences and words
+	ChunkStrategyMarkdown  = "markdown"  // sections under headings, keeping their heading path
+	ChunkStrategyCode      = "code"      // whole functions, types and classes
+)
+
+// charsPerToken matches the estimate used by estimateTokenCount.
+const charsPerToken = 4
+
+// Chunker splits document content into chunks of at most a configured number of tokens.
+type Chunker interface {
+	Chunk(content string) []string
+}
+
+// NewChunker creates a chunker for a strategy. Sizes are in tokens; the overlap is capped
+// at half the chunk size. The auto strategy, which depends on the document type, yields a
+// recursive chunker here.
+func NewChunker(strategy string, chunkSize, chunkOverlap int) (Chunker, error) {
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+	if chunkOverlap < 0 {
+		chunkOverlap = 0
+	}
+	if chunkOverlap > chunkSize/2 {
+		chunkOverlap = chunkSize / 2
+	}
+
+	switch strings.ToLower(strategy) {
+	case "", ChunkStrategyAuto, ChunkStrategyRecursive:
+		return NewRecursiveChunker(chunkSize, chunkOverlap), nil
+	case ChunkStrategyFixed:
+		return NewFixedTokenChunker(chunkSize, chunkOverlap), nil
+	case ChunkStrategyMarkdown:
+		return NewMarkdownChunker(chunkSize, chunkOverlap), nil
+	case ChunkStrategyCode:
+		return NewCodeChunker(chunkSize, chunkOverlap), nil
+	default:
+		return nil, fmt.Errorf("unknown chunk strategy %q", strategy)
+	}
+}
+
+// ChunkDocument splits a document with chunker. A document that fits in one chunk is
+// returned unchanged. Otherwise each chunk gets the ID "<id>_chunk_<n>", its ChunkIndex
+// and ChunkTotal, and the parent's ID under ParentDocumentIDKey in its metadata.
+func ChunkDocument(doc Document, chunker Chunker) []Document {
+	pieces := chunker.Chunk(doc.Content)
+	if len(pieces) <= 1 {
+		return []Document{doc}
+	}
+
+	chunks := make([]Document, len(pieces))
+	for i, piece := range pieces {
+		chunk := doc
+		chunk.ID = fmt.Sprintf("%s_chunk_%d", doc.ID, i)
+		chunk.Content = piece
+		chunk.ChunkIndex = i
+		chunk.ChunkTotal = len(pieces)
+		chunk.Metadata = make(map[string]any, len(doc.Metadata)+1)
+		for key, value := range doc.Metadata {
+			chunk.Metadata[key] = value
+		}
+		chunk.Metadata[ParentDocumentIDKey] = doc.ID
+		chunks[i] = chunk
+	}
+	return chunks
+}
+
+// chunkForIngest applies the memory configuration's chunking to a document being ingested.
+// Documents are left whole when auto_chunk is off or when they are already chunks.
+func chunkForIngest(config AgentMemoryConfig, doc Document) []Document {
+	if !config.Documents.AutoChunk || doc.ChunkTotal > 0 {
+		return []Document{doc}
+	}
+
+	strategy := strings.ToLower(config.Documents.ChunkStrategy)
+	if strategy == "" || strategy == ChunkStrategyAuto {
+		switch doc.Type {
+		case DocumentTypeMarkdown:
+			strategy = ChunkStrategyMarkdown
+		case DocumentTypeCode:
+			strategy = ChunkStrategyCode
+		default:
+			strategy = ChunkStrategyRecursive
+		}
+	}
+	chunker, err := NewChunker(strategy, config.ChunkSize, config.ChunkOverlap)
+	if err != nil {
+		Logger().Warn().Err(err).Str("document_id", doc.ID).Msg("Falling back to recursive chunking")
+		chunker = NewRecursiveChunker(config.ChunkSize, config.ChunkOverlap)
+	}
+	return ChunkDocument(doc, chunker)
+}
+
+// FixedTokenChunker cuts content into windows of a fixed number of tokens that overlap by
+// a fixed number of tokens. Cuts are moved back to whitespace when there is some nearby.
+type FixedTokenChunker struct {
+	size    int
+	overlap int
+}
+
+// NewFixedTokenChunker creates a fixed-size chunker.
+func NewFixedTokenChunker(chunkSize, chunkOverlap int) *FixedTokenChunker {
+	return &FixedTokenChunker{size: chunkSize, overlap: chunkOverlap}
+}
+
+// Chunk implements Chunker.
+func (c *FixedTokenChunker) Chunk(content string) []string {
+	maxLen, overlapLen := c.size*charsPerToken, c.overlap*charsPerToken
+	var chunks []string
+	for start := 0; start < len(content); {
+		end := start + maxLen
+		if end >= len(content) {
+			chunks = appendChunk(chunks, content[start:])
+			break
+		}
+		end = cutPoint(content, start, end)
+		chunks = appendChunk(chunks, content[start:end])
+
+		// An overlap reaching back to the chunk start would make no progress
+		next := end - overlapLen
+		if next <= start {
+			next = end
+		}
+		for next < end && !utf8.RuneStart(content[next]) {
+			next++
+		}
+		// Start the overlap at a word
+		if i := strings.IndexFunc(content[next:end], unicode.IsSpace); i >= 0 {
+			_, width := utf8.DecodeRuneInString(content[next+i:])
+			next += i + width
+		}
+		start = next
+	}
+	return chunks
+}
+
+// cutPoint returns where to end a chunk spanning content[start:end]: after the last
+// whitespace in the final quarter of the window, or else on a rune boundary.
+func cutPoint(content string, start, end int) int {
+	window := content[start+(end-start)*3/4 : end]
+	if i := strings.LastIndexFunc(window, unicode.IsSpace); i >= 0 {
+		_, width := utf8.DecodeRuneInString(window[i:])
+		return end - len(window) + i + width
+	}
+	cut := end
+	for cut > start+1 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	if utf8.RuneStart(content[cut]) {
+		return cut
+	}
+	// The window holds less than one rune: keep the whole rune
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+	return end
+}
+
+// appendChunk adds a trimmed, non-empty chunk.
+func appendChunk(chunks []string, chunk string) []string {
+	if chunk = strings.TrimSpace(chunk); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// DefaultChunkSeparators are tried in order by the recursive chunker.
+var DefaultChunkSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// RecursiveChunker splits content at the first separator it contains, merges the pieces
+// back into chunks of up to the chunk size, and splits pieces that are still too large
+// with the next separator, down to fixed-size cuts.
+type RecursiveChunker struct {
+	size       int
+	overlap    int
+	separators []string
+	fixed      *FixedTokenChunker
+}
+
+// NewRecursiveChunker creates a recursive chunker. Without separators it uses
+// DefaultChunkSeparators.
+func NewRecursiveChunker(chunkSize, chunkOverlap int, separators ...string) *RecursiveChunker {
+	if len(separators) == 0 {
+		separators = DefaultChunkSeparators
+	}
+	return &RecursiveChunker{
+		size:       chunkSize,
+		overlap:    chunkOverlap,
+		separators: separators,
+		fixed:      NewFixedTokenChunker(chunkSize, chunkOverlap),
+	}
+}
+
+// Chunk implements Chunker.
+func (c *RecursiveChunker) Chunk(content string) []string {
+	return c.split(content, c.separators)
+}
+
+func (c *RecursiveChunker) split(text string, separators []string) []string {
+	maxLen := c.size * charsPerToken
+	if len(text) <= maxLen {
+		return appendChunk(nil, text)
+	}
+
+	var separator string
+	for i, s := range separators {
+		if strings.Contains(text, s) {
+			separator, separators = s, separators[i+1:]
+			break
+		}
+	}
+	if separator == "" {
+		return c.fixed.Chunk(text)
+	}
+
+	var chunks, pieces []string
+	for _, piece := range strings.SplitAfter(text, separator) {
+		if len(piece) <= maxLen {
+			pieces = append(pieces, piece)
+			continue
+		}
+		chunks = append(chunks, mergePieces(pieces, maxLen, c.overlap*charsPerToken)...)
+		pieces = nil
+		chunks = append(chunks, c.split(piece, separators)...)
+	}
+	return append(chunks, mergePieces(pieces, maxLen, c.overlap*charsPerToken)...)
+}
+
+// mergePieces joins consecutive pieces into chunks of at most maxLen bytes. Each chunk
+// starts with the last pieces of the previous one, up to overlapLen bytes.
+func mergePieces(pieces []string, maxLen, overlapLen int) []string {
+	var chunks, window []string
+	length := 0
+	for _, piece := range pieces {
+		if length+len(piece) > maxLen && len(window) > 0 {
+			chunks = appendChunk(chunks, strings.Join(window, ""))
+			for len(window) > 0 && (length > overlapLen || length+len(piece) > maxLen) {
+				length -= len(window[0])
+				window = window[1:]
+			}
+		}
+		window = append(window, piece)
+		length += len(piece)
+	}
+	if len(window) > 0 {
+		chunks = appendChunk(chunks, strings.Join(window, ""))
+	}
+	return chunks
+}
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+\S`)
+
+// MarkdownChunker splits Markdown at headings. Small consecutive sections share a chunk;
+// large sections are split recursively, and each of their chunks starts with the section's
+// heading path so it can be understood on its own. Headings inside
+// fenced code blocks are ignored.
+type MarkdownChunker struct {
+	size      int
+	recursive *RecursiveChunker
+}
+
+// NewMarkdownChunker creates a Markdown chunker.
+func NewMarkdownChunker(chunkSize, chunkOverlap int) *MarkdownChunker {
+	return &MarkdownChunker{size: chunkSize, recursive: NewRecursiveChunker(chunkSize, chunkOverlap)}
+}
+
+// markdownSection is the text under a heading and the headings leading to it.
+type markdownSection struct {
+	path []string
+	text string
+}
+
+// Chunk implements Chunker.
+func (c *MarkdownChunker) Chunk(content string) []string {
+	maxLen := c.size * charsPerToken
+	var chunks []string
+	pending := ""
+	for _, section := range splitMarkdownSections(content) {
+		if len(pending)+len(section.text) <= maxLen {
+			pending += section.text
+			continue
+		}
+		chunks = appendChunk(chunks, pending)
+		pending = ""
+		if len(section.text) <= maxLen {
+			pending = section.text
+			continue
+		}
+
+		prefix := strings.Join(section.path, "\n") + "\n\n"
+		sizeLeft := c.size - (len(prefix)+charsPerToken-1)/charsPerToken
+		if len(section.path) == 0 || sizeLeft < c.size/2 {
+			chunks = append(chunks, c.recursive.Chunk(section.text)...)
+			continue
+		}
+		// The body goes without its heading line, which starts the prefix of every chunk
+		body := section.text[strings.Index(section.text, "\n")+1:]
+		for _, chunk := range NewRecursiveChunker(sizeLeft, c.recursive.overlap).Chunk(body) {
+			chunks = append(chunks, prefix+chunk)
+		}
+	}
+	return appendChunk(chunks, pending)
+}
+
+// splitMarkdownSections splits Markdown before each heading outside code fences.
+func splitMarkdownSections(content string) []markdownSection {
+	var sections []markdownSection
+	var path []string
+	var current strings.Builder
+	inFence := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			sections = append(sections, markdownSection{path: append([]string(nil), path...), text: current.String()})
+			current.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if match := markdownHeading.FindStringSubmatch(line); match != nil && !inFence {
+			flush()
+			level := len(match[1])
+			for len(path) >= level {
+				path = path[:len(path)-1]
+			}
+			path = append(path, trimmed)
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// codeDeclaration matches lines starting a top-level declaration in common languages.
+var codeDeclaration = regexp.MustCompile(`^(func|type|class|def|async def|function|async function|fn|pub fn|pub\(crate\) fn|impl|interface|struct|enum|trait|export|public|private|protected|internal|static|abstract|final|sealed|var|const|let|module|namespace)\b`)
+
+// codeComment matches lines of comments and annotations attached to the next declaration.
+var codeComment = regexp.MustCompile(`^\s*(//|#|/\*|\*|@|--|""")`)
+
+// CodeChunker splits source code between top-level declarations, keeping each function,
+// type or class whole with the comments above it. Small declarations share a chunk;
+// declarations larger than the chunk size are split recursively by blank lines and lines.
+type CodeChunker struct {
+	size      int
+	recursive *RecursiveChunker
+}
+
+// NewCodeChunker creates a code chunker.
+func NewCodeChunker(chunkSize, chunkOverlap int) *CodeChunker {
+	return &CodeChunker{
+		size:      chunkSize,
+		recursive: NewRecursiveChunker(chunkSize, chunkOverlap, "\n\n", "\n", " "),
+	}
+}
+
+// Chunk implements Chunker.
+func (c *CodeChunker) Chunk(content string) []string {
+	maxLen := c.size * charsPerToken
+	var chunks []string
+	pending := ""
+	for _, unit := range splitCodeDeclarations(content) {
+		if len(pending)+len(unit) <= maxLen {
+			pending += unit
+			continue
+		}
+		chunks = appendChunk(chunks, pending)
+		pending = ""
+		if len(unit) <= maxLen {
+			pending = unit
+			continue
+		}
+		chunks = append(chunks, c.recursive.Chunk(unit)...)
+	}
+	return appendChunk(chunks, pending)
+}
+
+// splitCodeDeclarations splits code before each top-level declaration and the comment
+// block directly above it.
+func splitCodeDeclarations(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	var units []string
+	start := 0
+	for i, line := range lines {
+		if i == 0 || !codeDeclaration.MatchString(line) {
+			continue
+		}
+		boundary := i
+		for boundary > start && codeComment.MatchString(lines[boundary-1]) {
+			boundary--
+		}
+		if boundary > start {
+			units = append(units, strings.Join(lines[start:boundary], ""))
+			start = boundary
+		}
+	}
+	return append(units, strings.Join(lines[start:], ""))
+}
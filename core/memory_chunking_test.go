@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func words(n int, prefix string) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return strings.Join(parts, " ")
+}
+
+func checkChunkSizes(t *testing.T, chunks []string, size int) {
+	t.Helper()
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > size*charsPerToken {
+			t.Errorf("chunk %d has %d bytes, more than %d tokens", i, len(chunk), size)
+		}
+	}
+}
+
+func TestFixedTokenChunker_SizeAndOverlap(t *testing.T) {
+	content := words(200, "w")
+	chunks := NewFixedTokenChunker(20, 5).Chunk(content)
+	checkChunkSizes(t, chunks, 20)
+
+	for i := 1; i < len(chunks); i++ {
+		previous := strings.Fields(chunks[i-1])
+		first := strings.Fields(chunks[i])[0]
+		if !strings.Contains(strings.Join(previous[len(previous)/2:], " "), first) {
+			t.Errorf("chunk %d does not overlap the end of chunk %d: starts with %q", i, i-1, first)
+		}
+	}
+	// Cuts fall between words
+	for _, chunk := range chunks {
+		for _, word := range strings.Fields(chunk) {
+			if !strings.HasPrefix(word, "w") {
+				t.Fatalf("word cut in half: %q", word)
+			}
+		}
+	}
+}
+
+func TestFixedTokenChunker_MultibyteLargeOverlap(t *testing.T) {
+	content := strings.Repeat("日本語テキスト", 300) + " " + strings.Repeat("é", 500)
+	for _, size := range []int{1, 2, 5, 8} {
+		for _, chunker := range []Chunker{NewFixedTokenChunker(size, size*2), NewRecursiveChunker(size, size)} {
+			chunks := chunker.Chunk(content)
+			if len(chunks) == 0 {
+				t.Fatalf("size %d: no chunks", size)
+			}
+			for i, chunk := range chunks {
+				if !utf8.ValidString(chunk) {
+					t.Fatalf("size %d: chunk %d splits a rune: %q", size, i, chunk)
+				}
+			}
+			if last := chunks[len(chunks)-1]; !strings.HasSuffix(content, last) {
+				t.Errorf("size %d: last chunk %q does not end the content", size, last)
+			}
+		}
+	}
+}
+
+func TestRecursiveChunker_PrefersParagraphs(t *testing.T) {
+	paragraphs := []string{words(15, "a"), words(15, "b"), words(15, "c"), words(15, "d")}
+	chunks := NewRecursiveChunker(30, 0).Chunk(strings.Join(paragraphs, "\n\n"))
+	checkChunkSizes(t, chunks, 30)
+	for _, chunk := range chunks {
+		for _, paragraph := range strings.Split(chunk, "\n\n") {
+			if !contains(strings.Join(paragraphs, "|"), strings.TrimSpace(paragraph)) {
+				t.Errorf("paragraph split although it fits in a chunk: %q", paragraph)
+			}
+		}
+	}
+
+	// A paragraph larger than a chunk is split further
+	chunks = NewRecursiveChunker(10, 2).Chunk(words(100, "x"))
+	checkChunkSizes(t, chunks, 10)
+}
+
+func TestMarkdownChunker_SplitsAtHeadings(t *testing.T) {
+	content := "# Guide\n\nIntro text.\n\n## Install\n\n" + words(60, "i") + "\n\n" +
+		"```sh\n# not a heading\n```\n\n## Usage\n\nRun it.\n"
+	chunks := NewMarkdownChunker(40, 0).Chunk(content)
+	checkChunkSizes(t, chunks, 40)
+
+	if !strings.HasPrefix(chunks[0], "# Guide") {
+		t.Errorf("expected the first chunk to start with the title, got %q", chunks[0])
+	}
+	var continued bool
+	for _, chunk := range chunks[1:] {
+		if strings.HasPrefix(chunk, "# Guide\n## Install\n\n") && !strings.Contains(chunk, "i0 ") {
+			continued = true
+		}
+		if strings.HasPrefix(chunk, "# not a heading") {
+			t.Error("split at a heading inside a code fence")
+		}
+	}
+	if !continued {
+		t.Errorf("expected continuation chunks of a long section to repeat its heading path: %q", chunks)
+	}
+	if last := chunks[len(chunks)-1]; !strings.Contains(last, "## Usage") {
+		t.Errorf("expected the usage section in the last chunk, got %q", last)
+	}
+}
+
+func TestCodeChunker_KeepsFunctionsWhole(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package demo\n\n")
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&b, "// F%d does work.\nfunc F%d() int {\n\tx := %d\n\treturn x * 2\n}\n\n", i, i, i)
+	}
+	chunks := NewCodeChunker(30, 0).Chunk(b.String())
+	checkChunkSizes(t, chunks, 30)
+
+	for _, chunk := range chunks {
+		if strings.Count(chunk, "{") != strings.Count(chunk, "}") {
+			t.Errorf("function split across chunks: %q", chunk)
+		}
+		for i := 0; i < 6; i++ {
+			if strings.Contains(chunk, fmt.Sprintf("func F%d(", i)) && !strings.Contains(chunk, fmt.Sprintf("// F%d does work.", i)) {
+				t.Errorf("comment separated from func F%d", i)
+			}
+		}
+	}
+}
+
+func TestNewChunker_UnknownStrategy(t *testing.T) {
+	if _, err := NewChunker("sentences", 100, 10); err == nil {
+		t.Error("expected an unknown strategy to be rejected")
+	}
+	if _, err := NewMemory(AgentMemoryConfig{Provider: "memory", Documents: DocumentConfig{ChunkStrategy: "sentences"}}); err == nil {
+		t.Error("expected NewMemory to reject an unknown chunk strategy")
+	}
+}
+
+func TestInMemoryProvider_IngestDocumentChunks(t *testing.T) {
+	memory, err := NewMemory(AgentMemoryConfig{
+		Provider:     "memory",
+		ChunkSize:    20,
+		ChunkOverlap: 5,
+		Documents:    DocumentConfig{AutoChunk: true},
+	})
+	if err != nil {
+		t.Fatalf("NewMemory failed: %v", err)
+	}
+	provider := memory.(*InMemoryProvider)
+	ctx := context.Background()
+
+	doc := Document{ID: "manual", Content: words(100, "w") + " needle", Metadata: map[string]any{"team": "docs"}}
+	if err := memory.IngestDocument(ctx, doc); err != nil {
+		t.Fatalf("IngestDocument failed: %v", err)
+	}
+	if len(provider.documents) < 2 {
+		t.Fatalf("expected the document to be chunked, got %d entries", len(provider.documents))
+	}
+	if _, ok := provider.documents["manual"]; ok {
+		t.Error("expected only chunks to be stored")
+	}
+	total := len(provider.documents)
+	for id, chunk := range provider.documents {
+		if chunk.ChunkTotal != total || id != fmt.Sprintf("manual_chunk_%d", chunk.ChunkIndex) {
+			t.Errorf("unexpected chunk %s: index %d of %d", id, chunk.ChunkIndex, chunk.ChunkTotal)
+		}
+		if chunk.Metadata[ParentDocumentIDKey] != "manual" || chunk.Metadata["team"] != "docs" {
+			t.Errorf("chunk %s lost its metadata: %v", id, chunk.Metadata)
+		}
+	}
+
+	results, err := memory.SearchKnowledge(ctx, "needle")
+	if err != nil || len(results) != 1 || results[0].ChunkIndex != total-1 {
+		t.Errorf("expected the last chunk to match, got %v (%v)", results, err)
+	}
+
+	// Ingesting a shorter version replaces the chunks
+	if err := memory.IngestDocument(ctx, Document{ID: "manual", Content: "short"}); err != nil {
+		t.Fatalf("IngestDocument failed: %v", err)
+	}
+	if len(provider.documents) != 1 || provider.documents["manual"].Content != "short" {
+		t.Errorf("expected old chunks to be replaced, got %d entries", len(provider.documents))
+	}
+}
+
+func TestInMemoryProvider_NoChunkingWithoutAutoChunk(t *testing.T) {
+	memory := QuickMemory()
+	if err := memory.IngestDocument(context.Background(), Document{ID: "whole", Content: words(2000, "w")}); err != nil {
+		t.Fatalf("IngestDocument failed: %v", err)
+	}
+	if got := len(memory.(*InMemoryProvider).documents); got != 1 {
+		t.Errorf("expected the document to be stored whole, got %d entries", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	mathrand "math/rand"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 )
@@ -19,6 +20,31 @@ type EmbeddingService interface {
 	GetDimensions() int
 }
 
+// newEmbeddingService creates the embedding service of a memory configuration:
+// config.Embedding.Service when set, otherwise the service of config.Embedding.Provider.
+// Providers other than openai and ollama get dummy embeddings for development. The
+// OpenAI API key falls back to the OPENAI_API_KEY environment variable.
+func newEmbeddingService(config AgentMemoryConfig) (EmbeddingService, error) {
+	if config.Embedding.Service != nil {
+		return config.Embedding.Service, nil
+	}
+	switch config.Embedding.Provider {
+	case "openai":
+		apiKey := config.Embedding.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("OpenAI API key is required for embedding service")
+		}
+		return NewOpenAIEmbeddingService(apiKey, config.Embedding.Model), nil
+	case "ollama":
+		return NewOllamaEmbeddingService(config.Embedding.Model, config.Embedding.BaseURL), nil
+	default:
+		return NewDummyEmbeddingService(config.Dimensions), nil
+	}
+}
+
 // OpenAIEmbeddingService implements EmbeddingService using OpenAI API
 type OpenAIEmbeddingService struct {
 	apiKey     string
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// Vector index types of the in-memory provider
+const (
+	IndexFlat = "flat"
+	IndexHNSW = "hnsw"
+)
+
+// HNSW defaults
+const (
+	defaultHNSWM              = 16
+	defaultHNSWEfConstruction = 200
+	defaultHNSWEfSearch       = 64
+)
+
+// validateIndexType checks a configured vector index type.
+func validateIndexType(indexType string) error {
+	switch indexType {
+	case "", IndexFlat, IndexHNSW:
+		return nil
+	default:
+		return fmt.Errorf("unknown vector index type %q (use %q or %q)", indexType, IndexFlat, IndexHNSW)
+	}
+}
+
+// hnswIndex is a Hierarchical Navigable Small World graph for approximate nearest
+// neighbour search by cosine similarity. Nodes sit on a random number of layers; a search
+// walks greedily down from the sparse top layer and explores the dense bottom layer.
+// Vectors are stored normalized, so similarity is their dot product.
+type hnswIndex struct {
+	m              int // links per node above layer 0; layer 0 keeps 2*m
+	efConstruction int
+	efSearch       int
+	levelFactor    float64
+	rng            *rand.Rand
+
+	nodes    map[string]*hnswNode
+	entry    string
+	maxLevel int
+}
+
+type hnswNode struct {
+	vector    []float32
+	level     int
+	neighbors [][]string // layer -> linked node IDs
+}
+
+// hnswCandidate is a node and its similarity to a query.
+type hnswCandidate struct {
+	id         string
+	similarity float64
+}
+
+func newHNSWIndex(config IndexConfig) *hnswIndex {
+	ix := &hnswIndex{
+		m:              config.M,
+		efConstruction: config.EfConstruction,
+		efSearch:       config.EfSearch,
+		rng:            rand.New(rand.NewSource(1)),
+		nodes:          make(map[string]*hnswNode),
+	}
+	if ix.m < 2 {
+		ix.m = defaultHNSWM
+	}
+	if ix.efConstruction <= 0 {
+		ix.efConstruction = defaultHNSWEfConstruction
+	}
+	if ix.efSearch <= 0 {
+		ix.efSearch = defaultHNSWEfSearch
+	}
+	ix.levelFactor = 1 / math.Log(float64(ix.m))
+	return ix
+}
+
+// len returns the number of indexed vectors.
+func (ix *hnswIndex) len() int {
+	return len(ix.nodes)
+}
+
+// maxLinks is how many links a node keeps on a layer.
+func (ix *hnswIndex) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * ix.m
+	}
+	return ix.m
+}
+
+// add indexes a vector, replacing an earlier one with the same ID.
+func (ix *hnswIndex) add(id string, vector []float32) {
+	ix.remove(id)
+
+	level := int(-math.Log(1-ix.rng.Float64()) * ix.levelFactor)
+	node := &hnswNode{vector: normalize(vector), level: level, neighbors: make([][]string, level+1)}
+	ix.nodes[id] = node
+	if len(ix.nodes) == 1 {
+		ix.entry = id
+		ix.maxLevel = level
+		return
+	}
+
+	entry := []hnswCandidate{{id: ix.entry, similarity: dot(node.vector, ix.nodes[ix.entry].vector)}}
+	for l := ix.maxLevel; l > level; l-- {
+		entry = ix.searchLayer(node.vector, entry, 1, l)
+	}
+	for l := min(level, ix.maxLevel); l >= 0; l-- {
+		candidates := ix.searchLayer(node.vector, entry, ix.efConstruction, l)
+		for _, c := range candidates {
+			if len(node.neighbors[l]) == ix.maxLinks(l) {
+				break
+			}
+			node.neighbors[l] = append(node.neighbors[l], c.id)
+			neighbor := ix.nodes[c.id]
+			neighbor.neighbors[l] = append(neighbor.neighbors[l], id)
+			ix.prune(neighbor, l)
+		}
+		entry = candidates
+	}
+	if level > ix.maxLevel {
+		ix.entry = id
+		ix.maxLevel = level
+	}
+}
+
+// remove drops a vector from the index. Nodes that linked to it are relinked to its
+// neighbours so the graph stays connected.
+func (ix *hnswIndex) remove(id string) {
+	removed, ok := ix.nodes[id]
+	if !ok {
+		return
+	}
+	delete(ix.nodes, id)
+
+	for otherID, node := range ix.nodes {
+		for l := range node.neighbors {
+			links := node.neighbors[l][:0]
+			for _, link := range node.neighbors[l] {
+				if link != id {
+					links = append(links, link)
+				}
+			}
+			if len(links) == len(node.neighbors[l]) {
+				continue
+			}
+			node.neighbors[l] = links
+			if l < len(removed.neighbors) {
+				for _, link := range removed.neighbors[l] {
+					if link != otherID && ix.nodes[link] != nil && !slices.Contains(node.neighbors[l], link) {
+						node.neighbors[l] = append(node.neighbors[l], link)
+					}
+				}
+				ix.prune(node, l)
+			}
+		}
+	}
+
+	if ix.entry == id {
+		ix.entry = ""
+		ix.maxLevel = 0
+		for otherID, node := range ix.nodes {
+			if ix.entry == "" || node.level > ix.maxLevel || (node.level == ix.maxLevel && otherID < ix.entry) {
+				ix.entry = otherID
+				ix.maxLevel = node.level
+			}
+		}
+	}
+}
+
+// prune keeps the most similar links of a node on a layer.
+func (ix *hnswIndex) prune(node *hnswNode, level int) {
+	if len(node.neighbors[level]) <= ix.maxLinks(level) {
+		return
+	}
+	candidates := make([]hnswCandidate, len(node.neighbors[level]))
+	for i, link := range node.neighbors[level] {
+		candidates[i] = hnswCandidate{id: link, similarity: dot(node.vector, ix.nodes[link].vector)}
+	}
+	sortCandidates(candidates)
+	links := make([]string, ix.maxLinks(level))
+	for i := range links {
+		links[i] = candidates[i].id
+	}
+	node.neighbors[level] = links
+}
+
+// search returns up to k indexed vectors most similar to a query, best first.
+func (ix *hnswIndex) search(query []float32, k int) []hnswCandidate {
+	if len(ix.nodes) == 0 || k <= 0 {
+		return nil
+	}
+	query = normalize(query)
+	entry := []hnswCandidate{{id: ix.entry, similarity: dot(query, ix.nodes[ix.entry].vector)}}
+	for l := ix.maxLevel; l > 0; l-- {
+		entry = ix.searchLayer(query, entry, 1, l)
+	}
+	results := ix.searchLayer(query, entry, max(ix.efSearch, k), 0)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// searchLayer finds the ef nodes of a layer most similar to a query, best first, by
+// expanding the best unexplored candidate until none can improve the results.
+func (ix *hnswIndex) searchLayer(query []float32, entry []hnswCandidate, ef, level int) []hnswCandidate {
+	visited := make(map[string]bool, ef*2)
+	var candidates, results []hnswCandidate
+	for _, c := range entry {
+		visited[c.id] = true
+		candidates = insertCandidate(candidates, c)
+		results = insertCandidate(results, c)
+	}
+	if len(results) > ef {
+		results = results[:ef]
+	}
+
+	for len(candidates) > 0 {
+		current := candidates[0]
+		candidates = candidates[1:]
+		if len(results) >= ef && current.similarity < results[len(results)-1].similarity {
+			break
+		}
+		node := ix.nodes[current.id]
+		if level >= len(node.neighbors) {
+			continue
+		}
+		for _, link := range node.neighbors[level] {
+			if visited[link] {
+				continue
+			}
+			visited[link] = true
+			c := hnswCandidate{id: link, similarity: dot(query, ix.nodes[link].vector)}
+			if len(results) < ef || c.similarity > results[len(results)-1].similarity {
+				candidates = insertCandidate(candidates, c)
+				results = insertCandidate(results, c)
+				if len(results) > ef {
+					results = results[:ef]
+				}
+			}
+		}
+	}
+	return results
+}
+
+// insertCandidate inserts a candidate into a slice sorted by similarity, best first.
+func insertCandidate(candidates []hnswCandidate, c hnswCandidate) []hnswCandidate {
+	i := sort.Search(len(candidates), func(i int) bool { return candidates[i].similarity < c.similarity })
+	candidates = append(candidates, hnswCandidate{})
+	copy(candidates[i+1:], candidates[i:])
+	candidates[i] = c
+	return candidates
+}
+
+// sortCandidates orders candidates by similarity, best first.
+func sortCandidates(candidates []hnswCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+}
+
+// normalize returns a copy of a vector scaled to unit length.
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	out := make([]float32, len(vector))
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
+}
+
+// dot is the dot product of two vectors, or 0 if their lengths differ.
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
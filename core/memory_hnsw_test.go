@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// topicEmbeddings embeds text as counts of topic words, so texts about the same topic are
+// similar even when they share no other words.
+type topicEmbeddings struct {
+	topics [][]string
+	err    error
+}
+
+func newTopicEmbeddings() *topicEmbeddings {
+	return &topicEmbeddings{topics: [][]string{
+		{"router", "wifi", "network", "modem", "internet"},
+		{"invoice", "billing", "payment", "refund", "charge"},
+		{"password", "login", "account", "sign"},
+	}}
+}
+
+func (e *topicEmbeddings) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	vector := make([]float32, len(e.topics)+1)
+	vector[len(e.topics)] = 0.1 // keep unrelated texts from being zero vectors
+	for _, term := range tokenize(text) {
+		for i, words := range e.topics {
+			for _, word := range words {
+				if term == word {
+					vector[i]++
+				}
+			}
+		}
+	}
+	return vector, nil
+}
+
+func (e *topicEmbeddings) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector, err := e.GenerateEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (e *topicEmbeddings) GetDimensions() int {
+	return len(e.topics) + 1
+}
+
+func TestInMemoryVectorSearch(t *testing.T) {
+	for _, indexType := range []string{IndexFlat, IndexHNSW} {
+		t.Run(indexType, func(t *testing.T) {
+			embeddings := newTopicEmbeddings()
+			memory, err := NewMemory(AgentMemoryConfig{
+				Provider:                "memory",
+				KnowledgeScoreThreshold: 0.5,
+				Embedding:               EmbeddingConfig{Service: embeddings},
+				Index:                   IndexConfig{Type: indexType},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+
+			// Personal memory ranks by similarity, without shared words
+			for _, content := range []string{"The refund was charged twice", "My wifi keeps dropping", "Reset my login"} {
+				if err := memory.Store(ctx, content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			results, err := memory.Query(ctx, "modem internet outage", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 2 || results[0].Content != "My wifi keeps dropping" {
+				t.Errorf("unexpected personal results: %+v", results)
+			}
+
+			docs := []Document{
+				{ID: "net", Title: "Connectivity", Content: "Restart the router and the modem when the network is down.", Tags: []string{"support"}},
+				{ID: "bill", Title: "Payments", Content: "Every invoice lists each payment and charge.", Tags: []string{"finance"}},
+				{ID: "auth", Title: "Accounts", Content: "Change your password from the account page."},
+			}
+			if err := memory.IngestDocuments(ctx, docs); err != nil {
+				t.Fatal(err)
+			}
+
+			knowledge, err := memory.SearchKnowledge(ctx, "internet wifi problems", WithHybridWeight(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(knowledge) != 1 || knowledge[0].DocumentID != "net" || knowledge[0].Score < 0.99 {
+				t.Errorf("expected only the network document above the threshold, got %+v", knowledge)
+			}
+
+			knowledge, _ = memory.SearchKnowledge(ctx, "refund", WithHybridWeight(1), WithTags([]string{"support"}))
+			if len(knowledge) != 0 {
+				t.Errorf("filters should apply to vector search, got %+v", knowledge)
+			}
+
+			// Re-ingesting replaces the embedding
+			if err := memory.IngestDocument(ctx, Document{ID: "net", Content: "How to request a refund"}); err != nil {
+				t.Fatal(err)
+			}
+			knowledge, _ = memory.SearchKnowledge(ctx, "billing question", WithHybridWeight(1), WithScoreThreshold(0.9))
+			if len(knowledge) != 2 {
+				t.Errorf("expected the billing and replaced documents, got %+v", knowledge)
+			}
+
+			embeddings.err = errors.New("embedding service down")
+			if err := memory.Store(ctx, "anything"); err == nil {
+				t.Error("expected Store to fail when embedding fails")
+			}
+			if err := memory.IngestDocument(ctx, Document{Content: "anything"}); err == nil {
+				t.Error("expected IngestDocument to fail when embedding fails")
+			}
+			if _, err := memory.SearchKnowledge(ctx, "anything"); err == nil {
+				t.Error("expected SearchKnowledge to fail when embedding fails")
+			}
+			if _, err := memory.SearchKnowledge(ctx, "anything", WithHybridWeight(0)); err != nil {
+				t.Errorf("keyword-only search should not embed: %v", err)
+			}
+		})
+	}
+
+	if _, err := NewMemory(AgentMemoryConfig{Provider: "memory", Index: IndexConfig{Type: "ivf"}}); err == nil {
+		t.Error("expected an error for an unknown index type")
+	}
+}
+
+func TestHNSWIndexRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	randomVector := func() []float32 {
+		vector := make([]float32, 16)
+		for i := range vector {
+			vector[i] = rng.Float32()*2 - 1
+		}
+		return vector
+	}
+
+	ix := newHNSWIndex(IndexConfig{M: 8, EfConstruction: 100, EfSearch: 50})
+	vectors := make(map[string][]float32)
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("v%d", i)
+		vectors[id] = randomVector()
+		ix.add(id, vectors[id])
+	}
+	// Remove a tenth of the vectors, including the entry point
+	removed := map[string]bool{ix.entry: true}
+	ix.remove(ix.entry)
+	for i := 0; i < 1000; i += 10 {
+		id := fmt.Sprintf("v%d", i)
+		removed[id] = true
+		ix.remove(id)
+	}
+	for id := range removed {
+		delete(vectors, id)
+	}
+	if ix.len() != len(vectors) {
+		t.Fatalf("len = %d, want %d", ix.len(), len(vectors))
+	}
+
+	const k = 10
+	found, total := 0, 0
+	for q := 0; q < 50; q++ {
+		query := randomVector()
+		var exact []hnswCandidate
+		for id, vector := range vectors {
+			exact = append(exact, hnswCandidate{id: id, similarity: cosineSimilarity(query, vector)})
+		}
+		sortCandidates(exact)
+
+		results := ix.search(query, k)
+		if len(results) != k {
+			t.Fatalf("got %d results, want %d", len(results), k)
+		}
+		approximate := make(map[string]bool)
+		for _, r := range results {
+			if removed[r.id] {
+				t.Fatalf("removed vector %s returned", r.id)
+			}
+			approximate[r.id] = true
+		}
+		for _, c := range exact[:k] {
+			total++
+			if approximate[c.id] {
+				found++
+			}
+		}
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want at least 0.9", k, recall)
+	}
+}
+
+func TestHNSWIndexEdgeCases(t *testing.T) {
+	ix := newHNSWIndex(IndexConfig{})
+	if results := ix.search([]float32{1, 0}, 3); results != nil {
+		t.Errorf("empty index returned %v", results)
+	}
+
+	ix.add("a", []float32{1, 0})
+	ix.add("b", []float32{0, 1})
+	ix.add("a", []float32{0, 2})
+	results := ix.search([]float32{0, 1}, 5)
+	if len(results) != 2 || !approx(float32(results[0].similarity), 1) || !approx(float32(results[1].similarity), 1) {
+		t.Errorf("re-adding should replace the vector, got %v", results)
+	}
+
+	ix.remove("a")
+	ix.remove("b")
+	ix.remove("missing")
+	if ix.len() != 0 || ix.entry != "" || ix.search([]float32{0, 1}, 1) != nil {
+		t.Errorf("expected an empty index, got %d nodes and entry %q", ix.len(), ix.entry)
+	}
+}
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FusionMethod selects how hybrid search combines keyword and vector results.
+type FusionMethod string
+
+const (
+	// FusionWeighted adds the vector score and the normalized keyword score, weighted by
+	// SearchConfig.HybridWeight.
+	FusionWeighted FusionMethod = "weighted"
+	// FusionRRF uses reciprocal rank fusion: each result list contributes
+	// weight / (k + rank), so only the order of results matters, not their scores.
+	FusionRRF FusionMethod = "rrf"
+)
+
+// DefaultRRFK is the rank constant of reciprocal rank fusion.
+const DefaultRRFK = 60
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// WithHybridWeight sets the share of the vector score in hybrid search, from 0 (keyword
+// search only) to 1 (vector search only).
+func WithHybridWeight(weight float32) SearchOption {
+	return func(config *SearchConfig) {
+		config.HybridWeight = weight
+	}
+}
+
+// WithFusion sets how keyword and vector results are combined.
+func WithFusion(method FusionMethod) SearchOption {
+	return func(config *SearchConfig) {
+		config.Fusion = method
+	}
+}
+
+// hybridWeight is the default SearchConfig.HybridWeight for the [agent_memory.search]
+// settings: the semantic share of the two weights, or 1 when hybrid search is disabled.
+func (c SearchConfigToml) hybridWeight() float32 {
+	total := c.KeywordWeight + c.SemanticWeight
+	if !c.HybridSearch || total <= 0 {
+		return 1
+	}
+	return c.SemanticWeight / total
+}
+
+// defaultSearchConfig returns the search settings of a memory before options apply.
+func defaultSearchConfig(config AgentMemoryConfig) *SearchConfig {
+	return &SearchConfig{
+		Limit:            config.KnowledgeMaxResults,
+		ScoreThreshold:   config.KnowledgeScoreThreshold,
+		HybridWeight:     config.Search.hybridWeight(),
+		Fusion:           FusionMethod(config.Search.FusionMethod),
+		RRFK:             config.Search.RRFK,
+		RerankCandidates: config.Search.RerankCandidates,
+		IncludeKnowledge: true,
+	}
+}
+
+// validateFusionMethod checks a configured fusion method.
+func validateFusionMethod(method string) error {
+	switch FusionMethod(method) {
+	case "", FusionWeighted, FusionRRF:
+		return nil
+	default:
+		return fmt.Errorf("unknown search fusion method %q (use %q or %q)", method, FusionWeighted, FusionRRF)
+	}
+}
+
+// hybridCandidates is how many results each side of a hybrid search fetches before fusion.
+func hybridCandidates(limit int) int {
+	if limit*4 < 20 {
+		return 20
+	}
+	return limit * 4
+}
+
+// fuseKnowledgeResults merges vector and keyword results by document ID. Vector scores
+// are similarities in [0, 1]; keyword scores may be on any scale and are divided by the
+// best one. Fused scores are in [0, 1]: with weighted fusion a result's weighted scores
+// are added, with RRF its reciprocal ranks, relative to a result ranked first by both.
+// The fused results are sorted by score and cut to config.Limit.
+func fuseKnowledgeResults(vector, keyword []KnowledgeResult, config *SearchConfig) []KnowledgeResult {
+	weight := float64(config.HybridWeight)
+	k := config.RRFK
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	var maxKeyword float32
+	for _, result := range keyword {
+		if result.Score > maxKeyword {
+			maxKeyword = result.Score
+		}
+	}
+
+	scores := make(map[string]float64)
+	fused := make(map[string]KnowledgeResult)
+	var order []string
+	add := func(results []KnowledgeResult, share float64, normalize float32) {
+		for rank, result := range results {
+			if _, seen := fused[result.DocumentID]; !seen {
+				fused[result.DocumentID] = result
+				order = append(order, result.DocumentID)
+			}
+			if config.Fusion == FusionRRF {
+				scores[result.DocumentID] += share * float64(k+1) / float64(k+rank+1)
+			} else if normalize > 0 {
+				scores[result.DocumentID] += share * float64(result.Score/normalize)
+			}
+		}
+	}
+	add(vector, weight, 1)
+	add(keyword, 1-weight, maxKeyword)
+
+	results := make([]KnowledgeResult, 0, len(order))
+	for _, id := range order {
+		result := fused[id]
+		result.Score = float32(scores[id])
+		results = append(results, result)
+	}
+	sortKnowledgeResults(results)
+	if config.Limit > 0 && len(results) > config.Limit {
+		results = results[:config.Limit]
+	}
+	return results
+}
+
+// sortKnowledgeResults orders results by score, highest first, then by document ID.
+func sortKnowledgeResults(results []KnowledgeResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].DocumentID < results[j].DocumentID
+	})
+}
+
+// tokenize splits text into lowercase search terms. Identifiers joined by "-", "_", ".",
+// "/", ":" or "#" (error codes, SKUs, paths) are kept whole as well as split into their
+// parts, so "ERR-4012" matches both "err-4012" and "4012".
+func tokenize(text string) []string {
+	var terms []string
+	isConnector := func(r rune) bool { return strings.ContainsRune("-_./:#", r) }
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !isConnector(r)
+	})
+	for _, word := range words {
+		word = strings.TrimFunc(word, isConnector)
+		if word == "" {
+			continue
+		}
+		parts := strings.FieldsFunc(word, isConnector)
+		if len(parts) > 1 {
+			terms = append(terms, word)
+		}
+		terms = append(terms, parts...)
+	}
+	return terms
+}
+
+// bm25Index is an inverted index that ranks documents with Okapi BM25.
+type bm25Index struct {
+	postings    map[string]map[string]int // term -> document ID -> term frequency
+	terms       map[string][]string       // document ID -> distinct terms
+	lengths     map[string]int            // document ID -> number of terms
+	totalLength int
+}
+
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		postings: make(map[string]map[string]int),
+		terms:    make(map[string][]string),
+		lengths:  make(map[string]int),
+	}
+}
+
+// add indexes a document, replacing an earlier version with the same ID.
+func (ix *bm25Index) add(id, text string) {
+	ix.remove(id)
+	terms := tokenize(text)
+	frequencies := make(map[string]int)
+	for _, term := range terms {
+		frequencies[term]++
+	}
+	distinct := make([]string, 0, len(frequencies))
+	for term, tf := range frequencies {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[string]int)
+		}
+		ix.postings[term][id] = tf
+		distinct = append(distinct, term)
+	}
+	ix.terms[id] = distinct
+	ix.lengths[id] = len(terms)
+	ix.totalLength += len(terms)
+}
+
+// remove drops a document from the index.
+func (ix *bm25Index) remove(id string) {
+	length, ok := ix.lengths[id]
+	if !ok {
+		return
+	}
+	for _, term := range ix.terms[id] {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.terms, id)
+	delete(ix.lengths, id)
+	ix.totalLength -= length
+}
+
+// search returns the BM25 score of every document containing a query term.
+func (ix *bm25Index) search(query string) map[string]float64 {
+	scores := make(map[string]float64)
+	n := float64(len(ix.lengths))
+	if n == 0 {
+		return scores
+	}
+	avgLength := float64(ix.totalLength) / n
+
+	seen := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		postings := ix.postings[term]
+		df := float64(len(postings))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			f := float64(tf)
+			norm := 1 - bm25B + bm25B*float64(ix.lengths[id])/avgLength
+			scores[id] += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+	}
+	return scores
+}
+
+// keywordTSQuery builds a PostgreSQL tsquery matching any term of the query, or "" when
+// the query has no terms.
+func keywordTSQuery(query string) string {
+	seen := make(map[string]bool)
+	var quoted []string
+	for _, term := range tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			quoted = append(quoted, "'"+strings.ReplaceAll(term, "'", "''")+"'")
+		}
+	}
+	return strings.Join(quoted, " | ")
+}
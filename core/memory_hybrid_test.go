@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Error ERR-4012: SKU_99.b failed (see docs/setup.md).")
+	want := []string{"error", "err-4012", "err", "4012", "sku_99.b", "sku", "99", "b", "failed", "see", "docs/setup.md", "docs", "setup", "md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestBM25Index(t *testing.T) {
+	ix := newBM25Index()
+	ix.add("common", "the printer is offline the printer is slow")
+	ix.add("rare", "the printer shows error E1234")
+	ix.add("long", "the printer shows error E1234 "+repeatWords("filler", 60))
+
+	scores := ix.search("printer E1234")
+	if _, ok := scores["common"]; !ok {
+		t.Fatal("expected a match on the common term")
+	}
+	if !(scores["rare"] > scores["long"] && scores["long"] > scores["common"]) {
+		t.Errorf("expected rare > long > common, got %v", scores)
+	}
+
+	ix.add("rare", "replaced content")
+	if _, ok := ix.search("E1234")["rare"]; ok {
+		t.Error("re-adding a document should replace its terms")
+	}
+	ix.remove("long")
+	ix.remove("missing")
+	if len(ix.search("e1234")) != 0 {
+		t.Error("removed document still matches")
+	}
+	if _, ok := ix.postings["filler"]; ok {
+		t.Error("postings of removed documents should be dropped")
+	}
+	if ix.totalLength != ix.lengths["common"]+ix.lengths["rare"] {
+		t.Errorf("totalLength = %d, want %d", ix.totalLength, ix.lengths["common"]+ix.lengths["rare"])
+	}
+}
+
+func repeatWords(word string, n int) string {
+	out := ""
+	for i := 0; i < n; i++ {
+		out += word + " "
+	}
+	return out
+}
+
+func TestFuseKnowledgeResults(t *testing.T) {
+	vector := []KnowledgeResult{{DocumentID: "a", Score: 0.9}, {DocumentID: "b", Score: 0.5}}
+	keyword := []KnowledgeResult{{DocumentID: "c", Score: 8}, {DocumentID: "b", Score: 4}}
+
+	weighted := fuseKnowledgeResults(vector, keyword, &SearchConfig{HybridWeight: 0.5, Limit: 10})
+	scores := map[string]float32{}
+	for _, r := range weighted {
+		scores[r.DocumentID] = r.Score
+	}
+	// a: 0.5*0.9, b: 0.5*0.5 + 0.5*4/8, c: 0.5*8/8
+	if !approx(scores["a"], 0.45) || !approx(scores["b"], 0.5) || !approx(scores["c"], 0.5) {
+		t.Errorf("unexpected weighted scores: %v", scores)
+	}
+	if weighted[0].DocumentID != "b" || weighted[1].DocumentID != "c" || weighted[2].DocumentID != "a" {
+		t.Errorf("unexpected order: %v", weighted)
+	}
+
+	rrf := fuseKnowledgeResults(vector, keyword, &SearchConfig{HybridWeight: 0.5, Fusion: FusionRRF, RRFK: 60, Limit: 2})
+	if len(rrf) != 2 || rrf[0].DocumentID != "b" {
+		t.Fatalf("expected b first and 2 results, got %v", rrf)
+	}
+	// b is second in both lists
+	if !approx(rrf[0].Score, 61.0/62.0) {
+		t.Errorf("b score = %v, want %v", rrf[0].Score, 61.0/62.0)
+	}
+	top := fuseKnowledgeResults(vector[:1], []KnowledgeResult{{DocumentID: "a", Score: 1}}, &SearchConfig{HybridWeight: 0.3, Fusion: FusionRRF})
+	if !approx(top[0].Score, 1) {
+		t.Errorf("a result ranked first by both lists should score 1, got %v", top[0].Score)
+	}
+}
+
+func approx(a, b float32) bool {
+	d := a - b
+	return d < 1e-5 && d > -1e-5
+}
+
+func TestInMemoryHybridSearch(t *testing.T) {
+	memory, err := NewMemory(AgentMemoryConfig{
+		Provider:  "memory",
+		Embedding: EmbeddingConfig{Provider: "dummy"},
+		Search:    SearchConfigToml{HybridSearch: true, KeywordWeight: 0.3, SemanticWeight: 0.7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	docs := []Document{
+		{ID: "reset", Title: "Resetting the router", Content: "Hold the reset button for ten seconds to restore factory settings."},
+		{ID: "err", Title: "Error codes", Content: "ERR-4012 means the firmware signature check failed. Reinstall the firmware."},
+		{ID: "sku", Title: "Accessories", Content: "The wall mount (SKU AX-220-B) fits all routers."},
+	}
+	if err := memory.IngestDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := memory.SearchKnowledge(ctx, "what does ERR-4012 mean?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].DocumentID != "err" {
+		t.Fatalf("expected the error code document first, got %v", results)
+	}
+	if results[0].Score <= 0 || results[0].Score > 1 {
+		t.Errorf("fused score out of range: %v", results[0].Score)
+	}
+
+	// Keyword matches survive a strict similarity threshold
+	results, err = memory.SearchKnowledge(ctx, "ax-220-b wall bracket", WithScoreThreshold(0.99))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].DocumentID != "sku" {
+		t.Errorf("expected the SKU document, got %v", results)
+	}
+
+	// Similarity only: substring matching alone does not find the SKU for this query
+	results, err = memory.SearchKnowledge(ctx, "ax-220-b wall bracket", WithHybridWeight(1), WithScoreThreshold(0.6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no similarity matches, got %v", results)
+	}
+
+	// Keyword only, fused by rank
+	results, err = memory.SearchKnowledge(ctx, "firmware router", WithHybridWeight(0), WithFusion(FusionRRF), WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].DocumentID != "err" {
+		t.Errorf("expected the firmware document only, got %v", results)
+	}
+
+	// Re-ingesting replaces the indexed terms
+	if err := memory.IngestDocument(ctx, Document{ID: "err", Content: "Obsolete page."}); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = memory.SearchKnowledge(ctx, "ERR-4012", WithHybridWeight(0))
+	if len(results) != 0 {
+		t.Errorf("expected stale terms to be gone, got %v", results)
+	}
+}
+
+func TestSearchConfigHybridWeight(t *testing.T) {
+	if w := (SearchConfigToml{HybridSearch: true, KeywordWeight: 0.3, SemanticWeight: 0.7}).hybridWeight(); !approx(w, 0.7) {
+		t.Errorf("hybridWeight = %v, want 0.7", w)
+	}
+	if w := (SearchConfigToml{HybridSearch: true, KeywordWeight: 1, SemanticWeight: 1}).hybridWeight(); !approx(w, 0.5) {
+		t.Errorf("hybridWeight = %v, want 0.5", w)
+	}
+	if w := (SearchConfigToml{KeywordWeight: 0.3, SemanticWeight: 0.7}).hybridWeight(); w != 1 {
+		t.Errorf("hybridWeight with hybrid search disabled = %v, want 1", w)
+	}
+
+	if _, err := NewMemory(AgentMemoryConfig{Provider: "memory", Search: SearchConfigToml{FusionMethod: "max"}}); err == nil {
+		t.Error("expected an error for an unknown fusion method")
+	}
+}
+
+func TestLoadConfigHybridSearch(t *testing.T) {
+	dir := t.TempDir()
+	load := func(content string) *Config {
+		t.Helper()
+		path := filepath.Join(dir, "agentflow.toml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+
+	if !load("[agent_memory]\nprovider = \"memory\"\n").AgentMemory.Search.HybridSearch {
+		t.Error("hybrid search should default to enabled")
+	}
+	config := load("[agent_memory.search]\nhybrid_search = false\nfusion_method = \"rrf\"\nrrf_k = 20\n")
+	if config.AgentMemory.Search.HybridSearch {
+		t.Error("hybrid_search = false should be kept")
+	}
+	if config.AgentMemory.Search.FusionMethod != "rrf" || config.AgentMemory.Search.RRFK != 20 {
+		t.Errorf("unexpected search config: %+v", config.AgentMemory.Search)
+	}
+}
+
+func TestKeywordTSQuery(t *testing.T) {
+	if got := keywordTSQuery("Printer ERR-4012 printer"); got != "'printer' | 'err-4012' | 'err' | '4012'" {
+		t.Errorf("keywordTSQuery = %q", got)
+	}
+	if got := keywordTSQuery(" ?! "); got != "" {
+		t.Errorf("keywordTSQuery of no terms = %q", got)
+	}
+}
@@ -21,13 +21,18 @@ type InMemoryProvider struct {
 	// NEW: Knowledge base storage (global, not session-scoped)
 	knowledge map[string]knowledgeEntry // documentID -> document content
 	documents map[string]Document       // documentID -> document metadata
+	keywords  *bm25Index                // Keyword index over knowledge titles and content
+	reranker  Reranker                  // Reorders knowledge search results, when set
+
+	embeddings EmbeddingService // Embeds content and queries; nil ranks by text matching
+	index      *hnswIndex       // Approximate nearest neighbour index over knowledge, when configured
 }
 
 type vectorEntry struct {
 	Content   string
 	Tags      []string
 	CreatedAt time.Time
-	// For in-memory, we'll use simple string matching instead of real embeddings
+	Embedding []float32 // nil without an embedding service
 }
 
 // NEW: Knowledge base entry for in-memory storage
@@ -35,9 +40,33 @@ type knowledgeEntry struct {
 	Content   string
 	Document  Document
 	CreatedAt time.Time
+	Embedding []float32 // nil without an embedding service
 }
 
+// newInMemoryProvider creates an in-memory provider. With a real embedding service
+// (openai, ollama or a custom Embedding.Service) it stores embeddings and ranks by cosine
+// similarity like pgvector; with dummy embeddings, which carry no meaning, it falls back
+// to simple text matching.
 func newInMemoryProvider(config AgentMemoryConfig) (Memory, error) {
+	if err := validateIndexType(config.Index.Type); err != nil {
+		return nil, err
+	}
+	embeddings, err := newEmbeddingService(config)
+	if err != nil {
+		return nil, err
+	}
+	if _, dummy := embeddings.(*DummyEmbeddingService); dummy {
+		embeddings = nil
+	}
+	var index *hnswIndex
+	if embeddings != nil && config.Index.Type == IndexHNSW {
+		index = newHNSWIndex(config.Index)
+	}
+
+	reranker, err := newRerankerFromConfig(config, embeddings)
+	if err != nil {
+		return nil, err
+	}
 	return &InMemoryProvider{
 		vectors:   make(map[string]vectorEntry),
 		keyValues: make(map[string]any),
@@ -46,10 +75,28 @@ func newInMemoryProvider(config AgentMemoryConfig) (Memory, error) {
 		config:    config,
 		knowledge: make(map[string]knowledgeEntry),
 		documents: make(map[string]Document),
+		keywords:  newBM25Index(),
+		reranker:  reranker,
+
+		embeddings: embeddings,
+		index:      index,
 	}, nil
 }
 
+// embed returns the embedding of a text, or nil without an embedding service.
+func (m *InMemoryProvider) embed(ctx context.Context, text string) ([]float32, error) {
+	if m.embeddings == nil {
+		return nil, nil
+	}
+	return m.embeddings.GenerateEmbedding(ctx, text)
+}
+
 func (m *InMemoryProvider) Store(ctx context.Context, content string, tags ...string) error {
+	embedding, err := m.embed(ctx, content)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -60,12 +107,18 @@ func (m *InMemoryProvider) Store(ctx context.Context, content string, tags ...st
 		Content:   content,
 		Tags:      tags,
 		CreatedAt: time.Now(),
+		Embedding: embedding,
 	}
 
 	return nil
 }
 
 func (m *InMemoryProvider) Query(ctx context.Context, query string, limit ...int) ([]Result, error) {
+	queryEmbedding, err := m.embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -77,46 +130,48 @@ func (m *InMemoryProvider) Query(ctx context.Context, query string, limit ...int
 	sessionID := GetSessionID(ctx)
 	var results []Result
 
-	// Simple text matching for in-memory implementation
 	sessionPrefix := sessionID + ":"
 	for key, entry := range m.vectors {
-		if strings.HasPrefix(key, sessionPrefix) {
-			score := calculateScore(entry.Content, query)
-			tagScore := float32(0)
+		if !strings.HasPrefix(key, sessionPrefix) {
+			continue
+		}
 
-			// Check tag matching
+		var finalScore float32
+		if queryEmbedding != nil {
+			// Rank by cosine similarity, like pgvector
+			finalScore = float32(cosineSimilarity(queryEmbedding, entry.Embedding))
+		} else {
+			// Simple text matching without embeddings
+			finalScore = calculateScore(entry.Content, query)
+
+			// Check tag matching and use the best score
 			for _, tag := range entry.Tags {
-				if tagScore < calculateScore(tag, query) {
-					tagScore = calculateScore(tag, query)
+				if tagScore := calculateScore(tag, query); tagScore > finalScore {
+					finalScore = tagScore
 				}
 			}
 
-			// Use the best score
-			finalScore := score
-			if tagScore > finalScore {
-				finalScore = tagScore
-			}
-
 			// Only include if there's a reasonable match
-			if finalScore > 0.1 {
-				results = append(results, Result{
-					Content:   entry.Content,
-					Score:     finalScore,
-					Tags:      entry.Tags,
-					CreatedAt: entry.CreatedAt,
-				})
-
-				if len(results) >= maxResults {
-					break
-				}
+			if finalScore <= 0.1 {
+				continue
 			}
 		}
+
+		results = append(results, Result{
+			Content:   entry.Content,
+			Score:     finalScore,
+			Tags:      entry.Tags,
+			CreatedAt: entry.CreatedAt,
+		})
 	}
 
 	// Sort results by score (highest first)
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Score > results[j].Score
 	})
+	if maxResults > 0 && len(results) > maxResults {
+		results = results[:maxResults]
+	}
 
 	return results, nil
 }
@@ -222,9 +277,6 @@ func (m *InMemoryProvider) Close() error {
 // RAG methods for InMemoryProvider
 
 func (m *InMemoryProvider) IngestDocument(ctx context.Context, doc Document) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
 	// Generate ID if not provided
 	if doc.ID == "" {
 		doc.ID = generateID()
@@ -236,19 +288,75 @@ func (m *InMemoryProvider) IngestDocument(ctx context.Context, doc Document) err
 	}
 	doc.UpdatedAt = time.Now()
 
-	// Store document metadata
-	m.documents[doc.ID] = doc
+	chunks := chunkForIngest(m.config, doc)
+
+	// Embed the chunks before taking the lock
+	var embeddings [][]float32
+	if m.embeddings != nil {
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Content
+		}
+		var err error
+		embeddings, err = m.embeddings.GenerateEmbeddings(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate document embeddings: %w", err)
+		}
+		if len(embeddings) != len(chunks) {
+			return fmt.Errorf("embedding count mismatch: got %d, expected %d", len(embeddings), len(chunks))
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Replace the chunks of an earlier version of the document
+	m.removeChunks(doc.ID)
+
+	for i, chunk := range chunks {
+		// Store document metadata
+		m.documents[chunk.ID] = chunk
 
-	// Store in knowledge base
-	m.knowledge[doc.ID] = knowledgeEntry{
-		Content:   doc.Content,
-		Document:  doc,
-		CreatedAt: doc.CreatedAt,
+		// Store in knowledge base
+		entry := knowledgeEntry{
+			Content:   chunk.Content,
+			Document:  chunk,
+			CreatedAt: chunk.CreatedAt,
+		}
+		if embeddings != nil {
+			entry.Embedding = embeddings[i]
+			if m.index != nil {
+				m.index.add(chunk.ID, entry.Embedding)
+			}
+		}
+		m.knowledge[chunk.ID] = entry
+		m.keywords.add(chunk.ID, chunk.Title+"\n"+chunk.Content)
 	}
 
 	return nil
 }
 
+// removeChunks deletes a document and the chunks split from it. It must be called with
+// m.mutex held.
+func (m *InMemoryProvider) removeChunks(documentID string) {
+	delete(m.documents, documentID)
+	delete(m.knowledge, documentID)
+	m.keywords.remove(documentID)
+	if m.index != nil {
+		m.index.remove(documentID)
+	}
+	for id, doc := range m.documents {
+		if parent, ok := doc.Metadata[ParentDocumentIDKey].(string); ok && parent == documentID {
+			delete(m.documents, id)
+			delete(m.knowledge, id)
+			m.keywords.remove(id)
+			if m.index != nil {
+				m.index.remove(id)
+			}
+		}
+	}
+}
+
 func (m *InMemoryProvider) IngestDocuments(ctx context.Context, docs []Document) error {
 	for _, doc := range docs {
 		if err := m.IngestDocument(ctx, doc); err != nil {
@@ -258,82 +366,168 @@ func (m *InMemoryProvider) IngestDocuments(ctx context.Context, docs []Document)
 	return nil
 }
 
+// SearchKnowledge ranks knowledge by semantic similarity and, unless HybridWeight is 1,
+// by BM25 keyword relevance, fusing the two. Similarity is the cosine similarity of
+// embeddings with an embedding service, otherwise simple text matching. The score
+// threshold applies to the similarity side only, so exact keyword matches such as error
+// codes are kept.
 func (m *InMemoryProvider) SearchKnowledge(ctx context.Context, query string, options ...SearchOption) ([]KnowledgeResult, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
 	// Apply search options
-	config := &SearchConfig{
-		Limit:            m.config.KnowledgeMaxResults,
-		ScoreThreshold:   m.config.KnowledgeScoreThreshold,
-		IncludeKnowledge: true,
-	}
+	config := defaultSearchConfig(m.config)
+	m.mutex.RLock()
+	config.Reranker = m.reranker
+	m.mutex.RUnlock()
 	for _, opt := range options {
 		opt(config)
 	}
 
-	var results []KnowledgeResult
+	var queryEmbedding []float32
+	if config.HybridWeight > 0 {
+		var err error
+		if queryEmbedding, err = m.embed(ctx, query); err != nil {
+			return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+	}
 
-	// Search through knowledge base
-	for docID, entry := range m.knowledge {
-		doc := entry.Document
+	return rerankSearch(ctx, query, config, func(config *SearchConfig) ([]KnowledgeResult, error) {
+		return m.searchKnowledge(query, queryEmbedding, config), nil
+	})
+}
 
-		// Apply filters
-		if len(config.Sources) > 0 && !contains(doc.Source, config.Sources[0]) {
-			continue
-		}
+// SetReranker sets the reranker applied to knowledge searches; nil disables reranking.
+func (m *InMemoryProvider) SetReranker(reranker Reranker) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.reranker = reranker
+}
 
-		if len(config.DocumentTypes) > 0 && doc.Type != config.DocumentTypes[0] {
-			continue
-		}
+// searchKnowledge retrieves the best config.Limit results for a query.
+func (m *InMemoryProvider) searchKnowledge(query string, queryEmbedding []float32, config *SearchConfig) []KnowledgeResult {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if config.HybridWeight >= 1 {
+		return m.similarityResults(query, queryEmbedding, config, config.Limit)
+	}
+
+	candidates := hybridCandidates(config.Limit)
+	var similar []KnowledgeResult
+	if config.HybridWeight > 0 {
+		similar = m.similarityResults(query, queryEmbedding, config, candidates)
+	}
+	keyword := m.keywordResults(query, config)
+	if len(keyword) > candidates {
+		keyword = keyword[:candidates]
+	}
+	return fuseKnowledgeResults(similar, keyword, config)
+}
+
+// similarityResults returns up to limit results most similar to the query, best first:
+// by cosine similarity with a query embedding, otherwise by simple text matching.
+func (m *InMemoryProvider) similarityResults(query string, queryEmbedding []float32, config *SearchConfig, limit int) []KnowledgeResult {
+	if queryEmbedding != nil && m.index != nil && !hasKnowledgeFilters(config) {
+		return m.indexResults(queryEmbedding, config, limit)
+	}
 
-		if len(config.Tags) > 0 && !containsAnyTag(doc.Tags, config.Tags[0]) {
+	var results []KnowledgeResult
+	for docID, entry := range m.knowledge {
+		if !m.matchesFilters(entry.Document, config) {
 			continue
 		}
 
-		if config.DateRange != nil {
-			if doc.CreatedAt.Before(config.DateRange.Start) || doc.CreatedAt.After(config.DateRange.End) {
+		var score float32
+		if queryEmbedding != nil {
+			score = float32(cosineSimilarity(queryEmbedding, entry.Embedding))
+			if score < config.ScoreThreshold {
 				continue
 			}
-		}
+		} else {
+			score = calculateScore(entry.Content, query)
+			titleScore := calculateScore(entry.Document.Title, query)
+			if titleScore > score {
+				score = titleScore
+			}
 
-		// Calculate score using simple text matching
-		score := calculateScore(entry.Content, query)
-		titleScore := calculateScore(doc.Title, query)
-		if titleScore > score {
-			score = titleScore
+			// Apply score threshold and a basic relevance threshold
+			if score < config.ScoreThreshold || score <= 0.1 {
+				continue
+			}
 		}
+		results = append(results, m.knowledgeResult(docID, entry, score))
+	}
+	sortKnowledgeResults(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
 
-		// Apply score threshold
-		if score < config.ScoreThreshold {
+// indexResults returns up to limit results from the HNSW index, best first.
+func (m *InMemoryProvider) indexResults(queryEmbedding []float32, config *SearchConfig, limit int) []KnowledgeResult {
+	if limit <= 0 {
+		limit = m.index.len()
+	}
+	var results []KnowledgeResult
+	for _, candidate := range m.index.search(queryEmbedding, limit) {
+		entry, ok := m.knowledge[candidate.id]
+		if !ok || float32(candidate.similarity) < config.ScoreThreshold {
 			continue
 		}
+		results = append(results, m.knowledgeResult(candidate.id, entry, float32(candidate.similarity)))
+	}
+	return results
+}
 
-		if score > 0.1 { // Basic relevance threshold
-			results = append(results, KnowledgeResult{
-				Content:    entry.Content,
-				Score:      score,
-				Source:     doc.Source,
-				Title:      doc.Title,
-				DocumentID: docID,
-				Metadata:   doc.Metadata,
-				Tags:       doc.Tags,
-				CreatedAt:  entry.CreatedAt,
-				ChunkIndex: doc.ChunkIndex,
-			})
-
-			if len(results) >= config.Limit {
-				break
-			}
+// keywordResults ranks the knowledge base by BM25, best first.
+func (m *InMemoryProvider) keywordResults(query string, config *SearchConfig) []KnowledgeResult {
+	var results []KnowledgeResult
+	for docID, score := range m.keywords.search(query) {
+		entry, ok := m.knowledge[docID]
+		if !ok || !m.matchesFilters(entry.Document, config) {
+			continue
 		}
+		results = append(results, m.knowledgeResult(docID, entry, float32(score)))
 	}
+	sortKnowledgeResults(results)
+	return results
+}
 
-	// Sort results by score (highest first)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+// hasKnowledgeFilters reports whether a search restricts which documents match.
+func hasKnowledgeFilters(config *SearchConfig) bool {
+	return len(config.Sources) > 0 || len(config.DocumentTypes) > 0 || len(config.Tags) > 0 || config.DateRange != nil
+}
 
-	return results, nil
+func (m *InMemoryProvider) matchesFilters(doc Document, config *SearchConfig) bool {
+	if len(config.Sources) > 0 && !contains(doc.Source, config.Sources[0]) {
+		return false
+	}
+	if len(config.DocumentTypes) > 0 && doc.Type != config.DocumentTypes[0] {
+		return false
+	}
+	if len(config.Tags) > 0 && !containsAnyTag(doc.Tags, config.Tags[0]) {
+		return false
+	}
+	if config.DateRange != nil {
+		if doc.CreatedAt.Before(config.DateRange.Start) || doc.CreatedAt.After(config.DateRange.End) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *InMemoryProvider) knowledgeResult(docID string, entry knowledgeEntry, score float32) KnowledgeResult {
+	doc := entry.Document
+	return KnowledgeResult{
+		Content:    entry.Content,
+		Score:      score,
+		Source:     doc.Source,
+		Title:      doc.Title,
+		DocumentID: docID,
+		Metadata:   doc.Metadata,
+		Tags:       doc.Tags,
+		CreatedAt:  entry.CreatedAt,
+		ChunkIndex: doc.ChunkIndex,
+	}
 }
 
 func (m *InMemoryProvider) SearchAll(ctx context.Context, query string, options ...SearchOption) (*HybridResult, error) {
@@ -430,6 +624,7 @@ func (m *InMemoryProvider) BuildContext(ctx context.Context, query string, optio
 		Knowledge:      searchResults.Knowledge,
 		ChatHistory:    history,
 		ContextText:    contextText,
+		Messages:       buildContextMessages(searchResults, history, config),
 		Sources:        sources,
 		TokenCount:     estimateTokenCount(contextText),
 		Timestamp:      time.Now(),
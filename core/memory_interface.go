@@ -110,6 +110,7 @@ type RAGContext struct {
 	Knowledge      []KnowledgeResult `json:"knowledge"`
 	ChatHistory    []Message         `json:"chat_history"`
 	ContextText    string            `json:"context_text"` // Formatted for LLM
+	Messages       []ChatMessage     `json:"messages"`     // Context and history as chat turns for Prompt.Messages
 	Sources        []string          `json:"sources"`      // Source attribution
 	TokenCount     int               `json:"token_count"`  // Estimated tokens
 	Timestamp      time.Time         `json:"timestamp"`
@@ -126,7 +127,11 @@ type SearchConfig struct {
 	DocumentTypes    []DocumentType `json:"document_types"`    // Filter by type
 	Tags             []string       `json:"tags"`              // Filter by tags
 	DateRange        *DateRange     `json:"date_range"`        // Filter by date
-	HybridWeight     float32        `json:"hybrid_weight"`     // Semantic vs keyword weight
+	HybridWeight     float32        `json:"hybrid_weight"`     // Share of the vector score: 1 vector only, 0 keyword only
+	Fusion           FusionMethod   `json:"fusion"`            // How keyword and vector results combine
+	RRFK             int            `json:"rrf_k"`             // Rank constant for FusionRRF
+	Reranker         Reranker       `json:"-"`                 // Reorders over-fetched results
+	RerankCandidates int            `json:"rerank_candidates"` // Results fetched for the reranker
 	IncludePersonal  bool           `json:"include_personal"`  // Include personal memory
 	IncludeKnowledge bool           `json:"include_knowledge"` // Include knowledge base
 }
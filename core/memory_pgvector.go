@@ -19,6 +19,7 @@ type PgVectorProvider struct {
 	config           AgentMemoryConfig
 	pool             *pgxpool.Pool
 	embeddingService EmbeddingService
+	reranker         Reranker // Reorders knowledge search results, when set
 	mutex            sync.RWMutex
 	retryConfig      RetryConfig
 }
@@ -47,20 +48,18 @@ func newPgVectorProvider(config AgentMemoryConfig) (Memory, error) {
 	}
 
 	// Initialize embedding service
-	var embeddingService EmbeddingService
-	if config.Embedding.Provider == "openai" {
-		if config.Embedding.APIKey == "" {
-			return nil, fmt.Errorf("OpenAI API key is required for embedding service")
-		}
-		embeddingService = NewOpenAIEmbeddingService(config.Embedding.APIKey, config.Embedding.Model)
-	} else if config.Embedding.Provider == "ollama" {
-		embeddingService = NewOllamaEmbeddingService(config.Embedding.Model, config.Embedding.BaseURL)
-	} else {
-		// Use dummy embedding service for development
-		embeddingService = NewDummyEmbeddingService(config.Dimensions)
+	embeddingService, err := newEmbeddingService(config)
+	if err != nil {
+		return nil, err
 	}
 	provider.embeddingService = embeddingService
 
+	reranker, err := newRerankerFromConfig(config, embeddingService)
+	if err != nil {
+		return nil, err
+	}
+	provider.reranker = reranker
+
 	// Initialize database with enhanced connection pooling
 	if err := provider.initialize(); err != nil {
 		return nil, fmt.Errorf("failed to initialize PgVector provider: %w", err)
@@ -205,6 +204,18 @@ func (p *PgVectorProvider) createTables() error {
 		return fmt.Errorf("failed to create knowledge_base table: %w", err)
 	}
 
+	// Full-text index for the keyword side of hybrid search. The "simple" configuration
+	// neither stems nor drops stop words, so identifiers match exactly.
+	keywordSchema := `
+		ALTER TABLE knowledge_base ADD COLUMN IF NOT EXISTS content_tsv tsvector
+			GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+		CREATE INDEX IF NOT EXISTS idx_knowledge_content_tsv ON knowledge_base USING gin(content_tsv);
+	`
+
+	if _, err := p.pool.Exec(ctx, keywordSchema); err != nil {
+		return fmt.Errorf("failed to create knowledge_base keyword index: %w", err)
+	}
+
 	return nil
 }
 
@@ -473,6 +484,29 @@ func (p *PgVectorProvider) IngestDocument(ctx context.Context, doc Document) err
 	}
 	doc.UpdatedAt = time.Now()
 
+	// Replace an earlier version of the document and its chunks; their knowledge base
+	// entries are deleted with them
+	_, err = tx.Exec(ctx, "DELETE FROM documents WHERE id = $1 OR metadata->>'"+ParentDocumentIDKey+"' = $1", doc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing document chunks: %w", err)
+	}
+
+	for _, chunk := range chunkForIngest(p.config, doc) {
+		if err := p.insertDocument(ctx, tx, chunk); err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertDocument stores a document and its embedding within tx.
+func (p *PgVectorProvider) insertDocument(ctx context.Context, tx pgx.Tx, doc Document) error {
 	// Insert document metadata
 	documentQuery := `
 		INSERT INTO documents (id, title, content, source, doc_type, metadata, tags, created_at, updated_at, chunk_index, chunk_total)
@@ -520,11 +554,6 @@ func (p *PgVectorProvider) IngestDocument(ctx context.Context, doc Document) err
 		return fmt.Errorf("failed to insert knowledge base entry: %w", err)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -537,17 +566,54 @@ func (p *PgVectorProvider) IngestDocuments(ctx context.Context, docs []Document)
 	return nil
 }
 
+// SearchKnowledge ranks knowledge by embedding similarity and, unless HybridWeight is 1,
+// by full-text relevance, fusing the two. The score threshold applies to the similarity
+// side only, so exact keyword matches such as error codes are kept.
 func (p *PgVectorProvider) SearchKnowledge(ctx context.Context, query string, options ...SearchOption) ([]KnowledgeResult, error) {
 	// Apply search options
-	config := &SearchConfig{
-		Limit:            p.config.KnowledgeMaxResults,
-		ScoreThreshold:   p.config.KnowledgeScoreThreshold,
-		IncludeKnowledge: true,
-	}
+	config := defaultSearchConfig(p.config)
+	p.mutex.RLock()
+	config.Reranker = p.reranker
+	p.mutex.RUnlock()
 	for _, opt := range options {
 		opt(config)
 	}
 
+	return rerankSearch(ctx, query, config, func(config *SearchConfig) ([]KnowledgeResult, error) {
+		return p.searchKnowledge(ctx, query, config)
+	})
+}
+
+// SetReranker sets the reranker applied to knowledge searches; nil disables reranking.
+func (p *PgVectorProvider) SetReranker(reranker Reranker) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.reranker = reranker
+}
+
+// searchKnowledge retrieves the best config.Limit results for a query.
+func (p *PgVectorProvider) searchKnowledge(ctx context.Context, query string, config *SearchConfig) ([]KnowledgeResult, error) {
+	if config.HybridWeight >= 1 {
+		return p.vectorSearch(ctx, query, config, config.Limit)
+	}
+
+	candidates := hybridCandidates(config.Limit)
+	var vector []KnowledgeResult
+	if config.HybridWeight > 0 {
+		var err error
+		if vector, err = p.vectorSearch(ctx, query, config, candidates); err != nil {
+			return nil, err
+		}
+	}
+	keyword, err := p.keywordSearch(ctx, query, config, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return fuseKnowledgeResults(vector, keyword, config), nil
+}
+
+// vectorSearch returns the knowledge entries closest to the query embedding.
+func (p *PgVectorProvider) vectorSearch(ctx context.Context, query string, config *SearchConfig, limit int) ([]KnowledgeResult, error) {
 	// Generate query embedding
 	queryEmbedding, err := p.embeddingService.GenerateEmbedding(ctx, query)
 	if err != nil {
@@ -572,46 +638,93 @@ func (p *PgVectorProvider) SearchKnowledge(ctx context.Context, query string, op
 	`
 
 	args := []any{pgvector.NewVector(queryEmbedding)}
-	argIndex := 2
+	filters, args := knowledgeFilters(config, args)
+	baseQuery += filters
+	argIndex := len(args) + 1
+
+	// Add score threshold filter
+	if config.ScoreThreshold > 0 {
+		baseQuery += fmt.Sprintf(" AND (1 - (kb.embedding <=> $1)) >= $%d", argIndex)
+		args = append(args, config.ScoreThreshold)
+		argIndex++
+	}
+
+	// Add ordering and limit
+	baseQuery += " ORDER BY kb.embedding <=> $1 LIMIT $" + fmt.Sprintf("%d", argIndex)
+	args = append(args, limit)
+
+	return p.queryKnowledge(ctx, baseQuery, args)
+}
+
+// keywordSearch returns the knowledge entries matching any term of the query, ranked by
+// full-text relevance.
+func (p *PgVectorProvider) keywordSearch(ctx context.Context, query string, config *SearchConfig, limit int) ([]KnowledgeResult, error) {
+	tsQuery := keywordTSQuery(query)
+	if tsQuery == "" {
+		return nil, nil
+	}
+
+	baseQuery := `
+		SELECT 
+			kb.content,
+			ts_rank_cd(kb.content_tsv, to_tsquery('simple', $1), 1) as keyword_score,
+			d.source,
+			d.title,
+			d.id as document_id,
+			d.metadata,
+			d.tags,
+			kb.created_at,
+			d.chunk_index
+		FROM knowledge_base kb
+		JOIN documents d ON kb.document_id = d.id
+		WHERE kb.content_tsv @@ to_tsquery('simple', $1)
+	`
+
+	args := []any{tsQuery}
+	filters, args := knowledgeFilters(config, args)
+	baseQuery += filters
+	baseQuery += fmt.Sprintf(" ORDER BY keyword_score DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	return p.queryKnowledge(ctx, baseQuery, args)
+}
+
+// knowledgeFilters appends the source, type, tag and date filters of a search to a
+// knowledge query whose arguments so far are args.
+func knowledgeFilters(config *SearchConfig, args []any) (string, []any) {
+	var filters strings.Builder
+	argIndex := len(args) + 1
 
-	// Apply filters
 	if len(config.Sources) > 0 {
-		baseQuery += fmt.Sprintf(" AND d.source = $%d", argIndex)
+		filters.WriteString(fmt.Sprintf(" AND d.source = $%d", argIndex))
 		args = append(args, config.Sources[0])
 		argIndex++
 	}
 
 	if len(config.DocumentTypes) > 0 {
-		baseQuery += fmt.Sprintf(" AND d.doc_type = $%d", argIndex)
+		filters.WriteString(fmt.Sprintf(" AND d.doc_type = $%d", argIndex))
 		args = append(args, string(config.DocumentTypes[0]))
 		argIndex++
 	}
 
 	if len(config.Tags) > 0 {
-		baseQuery += fmt.Sprintf(" AND d.tags && $%d", argIndex)
+		filters.WriteString(fmt.Sprintf(" AND d.tags && $%d", argIndex))
 		args = append(args, config.Tags)
 		argIndex++
 	}
 
 	if config.DateRange != nil {
-		baseQuery += fmt.Sprintf(" AND d.created_at BETWEEN $%d AND $%d", argIndex, argIndex+1)
+		filters.WriteString(fmt.Sprintf(" AND d.created_at BETWEEN $%d AND $%d", argIndex, argIndex+1))
 		args = append(args, config.DateRange.Start, config.DateRange.End)
-		argIndex += 2
-	}
-
-	// Add score threshold filter
-	if config.ScoreThreshold > 0 {
-		baseQuery += fmt.Sprintf(" AND (1 - (kb.embedding <=> $1)) >= $%d", argIndex)
-		args = append(args, config.ScoreThreshold)
-		argIndex++
 	}
 
-	// Add ordering and limit
-	baseQuery += " ORDER BY kb.embedding <=> $1 LIMIT $" + fmt.Sprintf("%d", argIndex)
-	args = append(args, config.Limit)
+	return filters.String(), args
+}
 
+// queryKnowledge runs a knowledge query and scans its results.
+func (p *PgVectorProvider) queryKnowledge(ctx context.Context, query string, args []any) ([]KnowledgeResult, error) {
 	// Execute query
-	rows, err := p.pool.Query(ctx, baseQuery, args...)
+	rows, err := p.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search knowledge base: %w", err)
 	}
@@ -653,7 +766,7 @@ func (p *PgVectorProvider) SearchKnowledge(ctx context.Context, query string, op
 		})
 	}
 
-	return results, nil
+	return results, rows.Err()
 }
 
 func (p *PgVectorProvider) SearchAll(ctx context.Context, query string, options ...SearchOption) (*HybridResult, error) {
@@ -750,6 +863,7 @@ func (p *PgVectorProvider) BuildContext(ctx context.Context, query string, optio
 		Knowledge:      searchResults.Knowledge,
 		ChatHistory:    history,
 		ContextText:    contextText,
+		Messages:       buildContextMessages(searchResults, history, config),
 		Sources:        sources,
 		TokenCount:     estimateTokenCount(contextText),
 		Timestamp:      time.Now(),
@@ -934,6 +1048,26 @@ func (p *PgVectorProvider) BatchIngestDocuments(ctx context.Context, docs []Docu
 		return nil
 	}
 
+	// Split documents into chunks, naming them after their parent. replaces holds the
+	// parent ID at each document's first chunk, whose transaction deletes the earlier
+	// version of the document and all of its chunks.
+	var chunks []Document
+	var replaces []string
+	for _, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = generateID()
+		}
+		for i, chunk := range chunkForIngest(p.config, doc) {
+			chunks = append(chunks, chunk)
+			if i == 0 {
+				replaces = append(replaces, doc.ID)
+			} else {
+				replaces = append(replaces, "")
+			}
+		}
+	}
+	docs = chunks
+
 	// Generate embeddings in batch
 	texts := make([]string, len(docs))
 	for i, doc := range docs {
@@ -957,7 +1091,7 @@ func (p *PgVectorProvider) BatchIngestDocuments(ctx context.Context, docs []Docu
 			end = len(docs)
 		}
 
-		err := p.batchIngestChunk(ctx, docs[i:end], embeddings[i:end])
+		err := p.batchIngestChunk(ctx, docs[i:end], embeddings[i:end], replaces[i:end])
 		if err != nil {
 			return fmt.Errorf("failed to ingest batch chunk %d-%d: %w", i, end-1, err)
 		}
@@ -966,7 +1100,7 @@ func (p *PgVectorProvider) BatchIngestDocuments(ctx context.Context, docs []Docu
 	return nil
 }
 
-func (p *PgVectorProvider) batchIngestChunk(ctx context.Context, docs []Document, embeddings [][]float32) error {
+func (p *PgVectorProvider) batchIngestChunk(ctx context.Context, docs []Document, embeddings [][]float32, replaces []string) error {
 	return p.withRetry(ctx, "batch ingest chunk", func() error {
 		tx, err := p.pool.Begin(ctx)
 		if err != nil {
@@ -1001,7 +1135,16 @@ func (p *PgVectorProvider) batchIngestChunk(ctx context.Context, docs []Document
 			}
 			doc.UpdatedAt = now
 
-			// First, delete any existing knowledge base entries for this document
+			// Replace an earlier version of the parent document and its chunks, as
+			// IngestDocument does
+			if replaces[i] != "" {
+				_, err = tx.Exec(ctx, "DELETE FROM documents WHERE id = $1 OR metadata->>'"+ParentDocumentIDKey+"' = $1", replaces[i])
+				if err != nil {
+					return fmt.Errorf("failed to delete existing document chunks for doc %s: %w", replaces[i], err)
+				}
+			}
+
+			// Delete any existing knowledge base entries for this document
 			_, err = tx.Exec(ctx, "DELETE FROM knowledge_base WHERE document_id = $1", doc.ID)
 			if err != nil {
 				return fmt.Errorf("failed to delete existing knowledge base entries for doc %s: %w", doc.ID, err)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -305,6 +306,41 @@ func (suite *PgVectorIntegrationTestSuite) TestKnowledgeOperations() {
 	assert.Equal(suite.T(), "doc2", filteredResults[0].DocumentID)
 }
 
+// Test that batch re-ingesting a shorter document removes its stale chunks
+func (suite *PgVectorIntegrationTestSuite) TestBatchReingestReplacesChunks() {
+	config := suite.config
+	config.ChunkSize = 20
+	config.ChunkOverlap = 0
+	config.Documents.AutoChunk = true
+	memory, err := NewMemory(config)
+	require.NoError(suite.T(), err)
+	defer memory.Close()
+	provider := memory.(*PgVectorProvider)
+
+	chunkCount := func() int {
+		var count int
+		err := suite.pool.QueryRow(suite.ctx,
+			"SELECT COUNT(*) FROM documents WHERE id = $1 OR metadata->>'"+ParentDocumentIDKey+"' = $1", "guide").Scan(&count)
+		require.NoError(suite.T(), err)
+		return count
+	}
+
+	long := Document{ID: "guide", Title: "Guide", Content: strings.Repeat("Routers forward packets between networks. ", 30)}
+	require.NoError(suite.T(), provider.BatchIngestDocuments(suite.ctx, []Document{long}))
+	before := chunkCount()
+	require.Greater(suite.T(), before, 1)
+
+	short := Document{ID: "guide", Title: "Guide", Content: "Routers forward packets."}
+	require.NoError(suite.T(), provider.BatchIngestDocuments(suite.ctx, []Document{short}))
+	assert.Equal(suite.T(), 1, chunkCount())
+
+	results, err := provider.SearchKnowledge(suite.ctx, "networks", WithScoreThreshold(-1), WithLimit(50))
+	require.NoError(suite.T(), err)
+	for _, result := range results {
+		assert.NotContains(suite.T(), result.Content, "networks")
+	}
+}
+
 // Test hybrid search
 func (suite *PgVectorIntegrationTestSuite) TestHybridSearch() {
 	ctx := suite.provider.SetSession(suite.ctx, "test-session-hybrid")
@@ -0,0 +1,507 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Reranker kinds for [agent_memory.search] reranker
+const (
+	RerankerLLM          = "llm"
+	RerankerCrossEncoder = "cross_encoder"
+	RerankerMMR          = "mmr"
+)
+
+const (
+	// DefaultMMRLambda balances relevance against diversity in MMR reranking
+	DefaultMMRLambda = 0.7
+	// defaultRerankPassageChars is how much of each result an LLM reranker reads
+	defaultRerankPassageChars = 1000
+)
+
+// Reranker reorders knowledge search results, returning at most limit of them. Searches
+// with a reranker over-fetch candidates (SearchConfig.RerankCandidates) and keep the
+// reranked best.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []KnowledgeResult, limit int) ([]KnowledgeResult, error)
+}
+
+// RerankableMemory is implemented by memories whose knowledge searches can be reranked.
+type RerankableMemory interface {
+	SetReranker(reranker Reranker)
+}
+
+// WithReranker reranks the results of a single search.
+func WithReranker(reranker Reranker) SearchOption {
+	return func(config *SearchConfig) {
+		config.Reranker = reranker
+	}
+}
+
+// WithRerankCandidates sets how many results a search fetches for the reranker.
+func WithRerankCandidates(candidates int) SearchOption {
+	return func(config *SearchConfig) {
+		config.RerankCandidates = candidates
+	}
+}
+
+// rerankSearch runs search for the candidates of config's reranker and reranks them. A
+// failing reranker is logged and the candidates are returned in their original order.
+// Without a reranker it runs search as configured.
+func rerankSearch(ctx context.Context, query string, config *SearchConfig, search func(*SearchConfig) ([]KnowledgeResult, error)) ([]KnowledgeResult, error) {
+	if config.Reranker == nil {
+		return search(config)
+	}
+
+	candidates := *config
+	candidates.Limit = config.RerankCandidates
+	if candidates.Limit < config.Limit {
+		candidates.Limit = config.Limit * 3
+	}
+	results, err := search(&candidates)
+	if err != nil || len(results) == 0 {
+		return results, err
+	}
+
+	reranked, err := config.Reranker.Rerank(ctx, query, results, config.Limit)
+	if err != nil {
+		Logger().Warn().Err(err).Msg("Knowledge search: reranking failed, keeping retrieval order")
+		if config.Limit > 0 && len(results) > config.Limit {
+			results = results[:config.Limit]
+		}
+		return results, nil
+	}
+	return reranked, nil
+}
+
+// newRerankerFromConfig creates the reranker configured in [agent_memory.search], or nil
+// when reranking is disabled. LLM rerankers need a model and are set with SetReranker.
+// embeddings, when not nil, gives MMR semantic similarity between results.
+func newRerankerFromConfig(config AgentMemoryConfig, embeddings EmbeddingService) (Reranker, error) {
+	search := config.Search
+	if !search.EnableReranking {
+		return nil, nil
+	}
+	switch search.Reranker {
+	case "", RerankerMMR:
+		return NewMMRReranker(MMRConfig{Lambda: search.MMRLambda, Embeddings: embeddings}), nil
+	case RerankerCrossEncoder:
+		if search.RerankingEndpoint == "" {
+			return nil, fmt.Errorf("reranking_endpoint is required for the %s reranker", RerankerCrossEncoder)
+		}
+		return NewCrossEncoderReranker(CrossEncoderConfig{
+			Endpoint: search.RerankingEndpoint,
+			Model:    search.RerankingModel,
+			APIKey:   search.RerankingAPIKey,
+		}), nil
+	case RerankerLLM:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown reranker %q (use %q, %q or %q)", search.Reranker, RerankerLLM, RerankerCrossEncoder, RerankerMMR)
+	}
+}
+
+// InitializeReranker creates the reranker configured in [agent_memory.search], including
+// LLM rerankers, which use reranking_provider from [providers] (default: the main
+// provider). It returns nil when reranking is disabled.
+func (c *Config) InitializeReranker() (Reranker, error) {
+	search := c.AgentMemory.Search
+	if !search.EnableReranking || search.Reranker != RerankerLLM {
+		return newRerankerFromConfig(c.AgentMemory, nil)
+	}
+	provider, err := c.initializeNamedProvider(search.RerankingProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reranking provider: %w", err)
+	}
+	return NewLLMReranker(LLMRerankerConfig{Provider: provider}), nil
+}
+
+// sortByRerankScore orders results by score, keeping retrieval order for equal scores,
+// and cuts them to limit.
+func sortByRerankScore(results []KnowledgeResult, limit int) []KnowledgeResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// LLMRerankerConfig configures an LLM reranker
+type LLMRerankerConfig struct {
+	// Provider is the model that rates the results. Required.
+	Provider ModelProvider
+	// MaxPassageChars is how much of each result the model reads. Default 1000.
+	MaxPassageChars int
+	// Instructions are added to the system prompt, e.g. what makes a result useful.
+	Instructions string
+}
+
+// llmReranker asks a model to rate each result's relevance to the query
+type llmReranker struct {
+	config LLMRerankerConfig
+}
+
+// llmRerankScores is the model's rating of the results
+type llmRerankScores struct {
+	Scores []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	} `json:"scores"`
+}
+
+// NewLLMReranker creates a reranker that has a model rate every result from 0 to 10.
+// Results are ordered by rating, with scores set to rating / 10; results the model
+// leaves out get 0.
+func NewLLMReranker(config LLMRerankerConfig) Reranker {
+	if config.MaxPassageChars <= 0 {
+		config.MaxPassageChars = defaultRerankPassageChars
+	}
+	return &llmReranker{config: config}
+}
+
+// Rerank implements Reranker
+func (r *llmReranker) Rerank(ctx context.Context, query string, results []KnowledgeResult, limit int) ([]KnowledgeResult, error) {
+	if r.config.Provider == nil {
+		return nil, fmt.Errorf("LLM reranker has no provider")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Query: %s\n\nPassages:\n", query))
+	for i, result := range results {
+		content := result.Content
+		if len(content) > r.config.MaxPassageChars {
+			content = truncateUTF8(content, r.config.MaxPassageChars) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("\n[%d]", i))
+		if result.Title != "" {
+			sb.WriteString(" " + result.Title)
+		}
+		sb.WriteString("\n" + content + "\n")
+	}
+
+	system := "You rate how well passages answer a search query. Score every passage from 0 " +
+		"(irrelevant) to 10 (answers the query directly). Reply with a JSON object: " +
+		`{"scores": [{"index": <passage number>, "score": <0-10>}, ...]}.`
+	if r.config.Instructions != "" {
+		system += "\n\n" + r.config.Instructions
+	}
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"scores": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"index": map[string]interface{}{"type": "integer"},
+						"score": map[string]interface{}{"type": "number"},
+					},
+					"required": []interface{}{"index", "score"},
+				},
+			},
+		},
+		"required": []interface{}{"scores"},
+	}
+
+	var rated llmRerankScores
+	prompt := Prompt{System: system, User: sb.String()}
+	if _, err := CallStructured(ctx, r.config.Provider, prompt, &rated, StructuredOutputOptions{Schema: schema, Name: "rerank_scores"}); err != nil {
+		return nil, fmt.Errorf("LLM reranking failed: %w", err)
+	}
+
+	reranked := make([]KnowledgeResult, len(results))
+	copy(reranked, results)
+	for i := range reranked {
+		reranked[i].Score = 0
+	}
+	for _, s := range rated.Scores {
+		if s.Index >= 0 && s.Index < len(reranked) {
+			reranked[s.Index].Score = float32(math.Max(0, math.Min(10, s.Score)) / 10)
+		}
+	}
+	return sortByRerankScore(reranked, limit), nil
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// CrossEncoderConfig configures a cross-encoder reranking endpoint
+type CrossEncoderConfig struct {
+	// Endpoint is the URL of the rerank API, e.g. "http://localhost:7997/rerank". Required.
+	Endpoint string
+	// Model is sent with each request; endpoints serving one model may ignore it.
+	Model string
+	// APIKey, when set, is sent as a bearer token.
+	APIKey string
+	// Timeout of each request. Default 30s.
+	Timeout time.Duration
+	// HTTPClient overrides the client used for requests.
+	HTTPClient *http.Client
+}
+
+// crossEncoderReranker scores query and result pairs with a cross-encoder served over HTTP
+type crossEncoderReranker struct {
+	config CrossEncoderConfig
+	client *http.Client
+}
+
+type crossEncoderRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type crossEncoderResult struct {
+	Index          int      `json:"index"`
+	RelevanceScore *float64 `json:"relevance_score"`
+	Score          *float64 `json:"score"`
+}
+
+// NewCrossEncoderReranker creates a reranker that calls a rerank API in the format used by
+// Cohere, Jina, Infinity and vLLM: it posts {"model", "query", "documents", "top_n"} and
+// reads {"results": [{"index", "relevance_score"}]}. A bare array of {"index", "score"},
+// as returned by text-embeddings-inference, is accepted too. Scores are set to the
+// endpoint's relevance scores.
+func NewCrossEncoderReranker(config CrossEncoderConfig) Reranker {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	return &crossEncoderReranker{config: config, client: client}
+}
+
+// Rerank implements Reranker
+func (r *crossEncoderReranker) Rerank(ctx context.Context, query string, results []KnowledgeResult, limit int) ([]KnowledgeResult, error) {
+	documents := make([]string, len(results))
+	for i, result := range results {
+		documents[i] = result.Content
+	}
+	requestBody, err := json.Marshal(crossEncoderRequest{
+		Model:     r.config.Model,
+		Query:     query,
+		Documents: documents,
+		TopN:      limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.config.Endpoint, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank API error %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var scored []crossEncoderResult
+	if bytes.HasPrefix(bytes.TrimSpace(responseBody), []byte("[")) {
+		err = json.Unmarshal(responseBody, &scored)
+	} else {
+		var response struct {
+			Results []crossEncoderResult `json:"results"`
+		}
+		err = json.Unmarshal(responseBody, &response)
+		scored = response.Results
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	reranked := make([]KnowledgeResult, 0, len(scored))
+	seen := make(map[int]bool)
+	for _, s := range scored {
+		if s.Index < 0 || s.Index >= len(results) || seen[s.Index] {
+			return nil, fmt.Errorf("invalid result index %d in rerank response", s.Index)
+		}
+		seen[s.Index] = true
+		result := results[s.Index]
+		switch {
+		case s.RelevanceScore != nil:
+			result.Score = float32(*s.RelevanceScore)
+		case s.Score != nil:
+			result.Score = float32(*s.Score)
+		default:
+			return nil, fmt.Errorf("rerank response has no score for result %d", s.Index)
+		}
+		reranked = append(reranked, result)
+	}
+	return sortByRerankScore(reranked, limit), nil
+}
+
+// MMRConfig configures maximal marginal relevance reranking
+type MMRConfig struct {
+	// Lambda weighs relevance against diversity, from 0 (diversity only) to 1 (relevance
+	// only). Default DefaultMMRLambda.
+	Lambda float32
+	// Embeddings, when set, measures how similar results are by the cosine similarity of
+	// their embeddings; otherwise by the overlap of their terms.
+	Embeddings EmbeddingService
+}
+
+// mmrReranker picks results that are relevant but unlike those already picked
+type mmrReranker struct {
+	config MMRConfig
+}
+
+// NewMMRReranker creates a reranker that uses maximal marginal relevance to cut
+// near-duplicates: each pick maximizes lambda * relevance - (1 - lambda) * the highest
+// similarity to an earlier pick, where relevance is the result's score relative to the
+// best. Scores are left as retrieved.
+func NewMMRReranker(config MMRConfig) Reranker {
+	if config.Lambda <= 0 || config.Lambda > 1 {
+		config.Lambda = DefaultMMRLambda
+	}
+	return &mmrReranker{config: config}
+}
+
+// Rerank implements Reranker
+func (r *mmrReranker) Rerank(ctx context.Context, query string, results []KnowledgeResult, limit int) ([]KnowledgeResult, error) {
+	if limit <= 0 || limit > len(results) {
+		limit = len(results)
+	}
+	similarity, err := r.similarity(ctx, results)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxScore float32
+	for _, result := range results {
+		if result.Score > maxScore {
+			maxScore = result.Score
+		}
+	}
+	relevance := func(i int) float64 {
+		if maxScore <= 0 {
+			return 0
+		}
+		return float64(results[i].Score / maxScore)
+	}
+
+	lambda := float64(r.config.Lambda)
+	picked := make([]int, 0, limit)
+	used := make([]bool, len(results))
+	for len(picked) < limit {
+		best, bestValue := -1, math.Inf(-1)
+		for i := range results {
+			if used[i] {
+				continue
+			}
+			maxSimilarity := 0.0
+			for _, j := range picked {
+				if s := similarity(i, j); s > maxSimilarity {
+					maxSimilarity = s
+				}
+			}
+			if value := lambda*relevance(i) - (1-lambda)*maxSimilarity; value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		used[best] = true
+		picked = append(picked, best)
+	}
+
+	reranked := make([]KnowledgeResult, len(picked))
+	for i, index := range picked {
+		reranked[i] = results[index]
+	}
+	return reranked, nil
+}
+
+// similarity returns a function measuring how alike two results are, from 0 to 1.
+func (r *mmrReranker) similarity(ctx context.Context, results []KnowledgeResult) (func(i, j int) float64, error) {
+	if r.config.Embeddings != nil {
+		texts := make([]string, len(results))
+		for i, result := range results {
+			texts[i] = result.Content
+		}
+		vectors, err := r.config.Embeddings.GenerateEmbeddings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed results for MMR: %w", err)
+		}
+		if len(vectors) != len(results) {
+			return nil, fmt.Errorf("embedding service returned %d embeddings for %d results", len(vectors), len(results))
+		}
+		return func(i, j int) float64 {
+			return math.Max(0, cosineSimilarity(vectors[i], vectors[j]))
+		}, nil
+	}
+
+	terms := make([]map[string]bool, len(results))
+	for i, result := range results {
+		terms[i] = make(map[string]bool)
+		for _, term := range tokenize(result.Content) {
+			terms[i][term] = true
+		}
+	}
+	return func(i, j int) float64 {
+		return jaccard(terms[i], terms[j])
+	}, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
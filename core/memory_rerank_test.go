@@ -0,0 +1,260 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func rerankCandidates() []KnowledgeResult {
+	return []KnowledgeResult{
+		{DocumentID: "a", Content: "reset the router by holding the reset button", Score: 0.9},
+		{DocumentID: "b", Content: "reset the router by holding the reset button for ten seconds", Score: 0.85},
+		{DocumentID: "c", Content: "firmware updates install automatically at night", Score: 0.6},
+	}
+}
+
+func resultIDs(results []KnowledgeResult) string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.DocumentID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestMMRReranker(t *testing.T) {
+	ctx := context.Background()
+
+	diverse, err := NewMMRReranker(MMRConfig{Lambda: 0.5}).Rerank(ctx, "reset router", rerankCandidates(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(diverse); got != "a,c" {
+		t.Errorf("expected the near-duplicate to be skipped, got %s", got)
+	}
+	if diverse[0].Score != 0.9 {
+		t.Errorf("MMR should keep retrieval scores, got %v", diverse[0].Score)
+	}
+
+	relevant, err := NewMMRReranker(MMRConfig{Lambda: 1}).Rerank(ctx, "reset router", rerankCandidates(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(relevant); got != "a,b,c" {
+		t.Errorf("lambda 1 should keep relevance order, got %s", got)
+	}
+
+	embedded, err := NewMMRReranker(MMRConfig{Lambda: 0.5, Embeddings: NewDummyEmbeddingService(32)}).Rerank(ctx, "reset router", rerankCandidates(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) != 3 || embedded[0].DocumentID != "a" {
+		t.Errorf("unexpected embedding MMR result: %s", resultIDs(embedded))
+	}
+}
+
+func TestCrossEncoderReranker(t *testing.T) {
+	var request crossEncoderRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Write([]byte(`{"results": [{"index": 2, "relevance_score": 0.97}, {"index": 0, "relevance_score": 0.41}]}`))
+	}))
+	defer server.Close()
+
+	reranker := NewCrossEncoderReranker(CrossEncoderConfig{Endpoint: server.URL, Model: "bge-reranker", APIKey: "secret"})
+	results, err := reranker.Rerank(context.Background(), "firmware", rerankCandidates(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(results); got != "c,a" || results[0].Score != 0.97 {
+		t.Errorf("unexpected results %s (%v)", got, results[0].Score)
+	}
+	if request.Query != "firmware" || request.Model != "bge-reranker" || request.TopN != 2 || len(request.Documents) != 3 {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestCrossEncoderRerankerResponses(t *testing.T) {
+	respond := func(status int, body string) Reranker {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return NewCrossEncoderReranker(CrossEncoderConfig{Endpoint: server.URL})
+	}
+	ctx := context.Background()
+
+	results, err := respond(http.StatusOK, `[{"index": 1, "score": 0.2}, {"index": 0, "score": 0.8}]`).Rerank(ctx, "q", rerankCandidates(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(results); got != "a,b" {
+		t.Errorf("bare array response: got %s", got)
+	}
+
+	if _, err := respond(http.StatusInternalServerError, "boom").Rerank(ctx, "q", rerankCandidates(), 2); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected an API error, got %v", err)
+	}
+	if _, err := respond(http.StatusOK, `{"results": [{"index": 7, "relevance_score": 1}]}`).Rerank(ctx, "q", rerankCandidates(), 2); err == nil {
+		t.Error("expected an error for an out of range index")
+	}
+	if _, err := respond(http.StatusOK, `{"results": [{"index": 0}]}`).Rerank(ctx, "q", rerankCandidates(), 2); err == nil {
+		t.Error("expected an error for a missing score")
+	}
+}
+
+func TestLLMReranker(t *testing.T) {
+	provider := NewMockModelProvider().RespondText(MatchSystemContains("rate how well passages"),
+		`{"scores": [{"index": 0, "score": 3}, {"index": 2, "score": 9.5}, {"index": 7, "score": 10}]}`)
+	reranker := NewLLMReranker(LLMRerankerConfig{Provider: provider, MaxPassageChars: 20, Instructions: "Prefer official docs."})
+
+	results, err := reranker.Rerank(context.Background(), "firmware updates", rerankCandidates(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(results); got != "c,a" {
+		t.Errorf("unexpected order %s", got)
+	}
+	if !approx(results[0].Score, 0.95) || !approx(results[1].Score, 0.3) {
+		t.Errorf("unexpected scores %v, %v", results[0].Score, results[1].Score)
+	}
+
+	calls := provider.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(calls))
+	}
+	if !strings.Contains(calls[0].User, "Query: firmware updates") || !strings.Contains(calls[0].User, "[2]") ||
+		strings.Contains(calls[0].User, "ten seconds") || !strings.Contains(calls[0].System, "Prefer official docs.") {
+		t.Errorf("unexpected prompt: %+v", calls[0])
+	}
+
+	if _, err := NewLLMReranker(LLMRerankerConfig{}).Rerank(context.Background(), "q", rerankCandidates(), 1); err == nil {
+		t.Error("expected an error without a provider")
+	}
+}
+
+// recordingReranker reverses its input and records how many candidates it saw
+type recordingReranker struct {
+	seen int
+	err  error
+}
+
+func (r *recordingReranker) Rerank(ctx context.Context, query string, results []KnowledgeResult, limit int) ([]KnowledgeResult, error) {
+	r.seen = len(results)
+	if r.err != nil {
+		return nil, r.err
+	}
+	reversed := make([]KnowledgeResult, 0, len(results))
+	for i := len(results) - 1; i >= 0 && len(reversed) < limit; i-- {
+		reversed = append(reversed, results[i])
+	}
+	return reversed, nil
+}
+
+func TestSearchKnowledgeReranking(t *testing.T) {
+	memory, err := NewMemory(AgentMemoryConfig{Provider: "memory", KnowledgeMaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, id := range []string{"one", "two", "three", "four", "five", "six", "seven"} {
+		if err := memory.IngestDocument(ctx, Document{ID: id, Content: "router guide " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reranker := &recordingReranker{}
+	results, err := memory.SearchKnowledge(ctx, "router guide", WithReranker(reranker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reranker.seen != 6 || len(results) != 2 {
+		t.Errorf("expected 6 candidates cut to 2 results, got %d and %d", reranker.seen, len(results))
+	}
+
+	results, _ = memory.SearchKnowledge(ctx, "router guide", WithReranker(reranker), WithRerankCandidates(4), WithLimit(3))
+	if reranker.seen != 4 || len(results) != 3 {
+		t.Errorf("expected 4 candidates cut to 3 results, got %d and %d", reranker.seen, len(results))
+	}
+
+	// A failing reranker keeps the retrieval order
+	memory.(RerankableMemory).SetReranker(&recordingReranker{err: errors.New("unavailable")})
+	results, err = memory.SearchKnowledge(ctx, "router guide")
+	if err != nil || len(results) != 2 {
+		t.Errorf("expected 2 results despite the reranker failing, got %d (%v)", len(results), err)
+	}
+
+	// SearchAll and BuildContext go through the reranker too
+	memory.(RerankableMemory).SetReranker(reranker)
+	reranker.seen = 0
+	if _, err := memory.SearchAll(ctx, "router guide", WithIncludePersonal(false)); err != nil || reranker.seen == 0 {
+		t.Errorf("SearchAll did not rerank (%v)", err)
+	}
+}
+
+func TestRerankerFromConfig(t *testing.T) {
+	search := func(s SearchConfigToml) AgentMemoryConfig {
+		return AgentMemoryConfig{Provider: "memory", Search: s}
+	}
+
+	if r, err := newRerankerFromConfig(search(SearchConfigToml{Reranker: RerankerMMR}), nil); r != nil || err != nil {
+		t.Errorf("reranking disabled: got %v, %v", r, err)
+	}
+	if r, _ := newRerankerFromConfig(search(SearchConfigToml{EnableReranking: true}), nil); r == nil {
+		t.Error("expected MMR by default")
+	}
+	if _, err := NewMemory(search(SearchConfigToml{EnableReranking: true, Reranker: RerankerCrossEncoder})); err == nil {
+		t.Error("expected an error for a cross-encoder without an endpoint")
+	}
+	if _, err := NewMemory(search(SearchConfigToml{EnableReranking: true, Reranker: "magic"})); err == nil {
+		t.Error("expected an error for an unknown reranker")
+	}
+
+	path := filepath.Join(t.TempDir(), "agentflow.toml")
+	content := `
+[agent_flow]
+provider = "mock"
+
+[providers.mock]
+response = '{"scores": []}'
+
+[agent_memory]
+provider = "memory"
+
+[agent_memory.search]
+enable_reranking = true
+reranker = "llm"
+rerank_candidates = 12
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reranker, err := config.InitializeReranker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reranker.(*llmReranker); !ok {
+		t.Errorf("expected an LLM reranker, got %T", reranker)
+	}
+	if config.AgentMemory.Search.RerankCandidates != 12 {
+		t.Errorf("rerank_candidates = %d", config.AgentMemory.Search.RerankCandidates)
+	}
+}
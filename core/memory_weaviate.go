@@ -1,44 +1,344 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-// WeaviateProvider - production-ready vector database (stub)
+// Weaviate classes used by the Weaviate provider
+const (
+	weaviateMemoryClass    = "AgentMemory"
+	weaviateKeyValueClass  = "AgentKeyValue"
+	weaviateMessageClass   = "AgentMessage"
+	weaviateKnowledgeClass = "AgentKnowledge"
+)
+
+// DefaultWeaviateURL is the Weaviate endpoint used when no connection is configured.
+const DefaultWeaviateURL = "http://localhost:8080"
+
+// weaviateMaxLimit is Weaviate's default maximum number of results, used for queries
+// without a limit.
+const weaviateMaxLimit = 10000
+
+// weaviateKnowledgeFields are the properties returned for knowledge results.
+const weaviateKnowledgeFields = "documentId title content source docType tags metadata chunkIndex createdAt"
+
+// WeaviateProvider - production-ready Weaviate vector database, accessed through its REST
+// and GraphQL APIs. Vectors come from the configured embedding service; Weaviate does
+// not vectorize. The API key, if needed, is read from WEAVIATE_API_KEY.
 type WeaviateProvider struct {
-	config AgentMemoryConfig
+	config           AgentMemoryConfig
+	baseURL          string
+	apiKey           string
+	client           *http.Client
+	embeddingService EmbeddingService
+	reranker         Reranker // Reorders knowledge search results, when set
+	mutex            sync.RWMutex
 }
 
-// newWeaviateProvider creates a new Weaviate provider
+// newWeaviateProvider creates a new Weaviate provider and creates its classes if needed
 func newWeaviateProvider(config AgentMemoryConfig) (Memory, error) {
-	// TODO: Implement Weaviate provider
-	// For now, return an error indicating it needs implementation
-	return nil, fmt.Errorf("Weaviate provider not yet implemented - use 'memory' or 'pgvector' provider for now")
+	baseURL := strings.TrimRight(config.Connection, "/")
+	if baseURL == "" {
+		baseURL = DefaultWeaviateURL
+	}
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("Weaviate connection must be an HTTP URL, got %q", config.Connection)
+	}
+
+	embeddingService, err := newEmbeddingService(config)
+	if err != nil {
+		return nil, err
+	}
+	reranker, err := newRerankerFromConfig(config, embeddingService)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(config.Embedding.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	provider := &WeaviateProvider{
+		config:           config,
+		baseURL:          baseURL,
+		apiKey:           os.Getenv("WEAVIATE_API_KEY"),
+		client:           &http.Client{Timeout: timeout},
+		embeddingService: embeddingService,
+		reranker:         reranker,
+	}
+
+	if err := provider.initialize(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to initialize Weaviate provider: %w", err)
+	}
+
+	return provider, nil
 }
 
+// Schema initialization
+
+// weaviateProperty is a property of a Weaviate class.
+type weaviateProperty struct {
+	Name     string   `json:"name"`
+	DataType []string `json:"dataType"`
+}
+
+// weaviateClasses returns the classes the provider stores its data in.
+func weaviateClasses() map[string][]weaviateProperty {
+	text := func(name string) weaviateProperty { return weaviateProperty{Name: name, DataType: []string{"text"}} }
+	textArray := func(name string) weaviateProperty { return weaviateProperty{Name: name, DataType: []string{"text[]"}} }
+	integer := func(name string) weaviateProperty { return weaviateProperty{Name: name, DataType: []string{"int"}} }
+	date := func(name string) weaviateProperty { return weaviateProperty{Name: name, DataType: []string{"date"}} }
+
+	return map[string][]weaviateProperty{
+		weaviateMemoryClass: {
+			text("sessionId"), text("content"), textArray("tags"), date("createdAt"),
+		},
+		weaviateKeyValueClass: {
+			text("sessionId"), text("key"), text("value"),
+		},
+		weaviateMessageClass: {
+			text("sessionId"), text("role"), text("content"), date("createdAt"), integer("sequence"),
+		},
+		weaviateKnowledgeClass: {
+			text("documentId"), text("parentId"), text("title"), text("content"), text("source"),
+			text("docType"), textArray("tags"), text("metadata"), integer("chunkIndex"),
+			integer("chunkTotal"), date("createdAt"), date("updatedAt"),
+		},
+	}
+}
+
+// initialize checks that Weaviate is reachable and creates missing classes
+func (w *WeaviateProvider) initialize(ctx context.Context) error {
+	if err := w.do(ctx, http.MethodGet, "/v1/.well-known/ready", nil, nil); err != nil {
+		return fmt.Errorf("Weaviate is not ready at %s: %w", w.baseURL, err)
+	}
+
+	for class, properties := range weaviateClasses() {
+		err := w.do(ctx, http.MethodGet, "/v1/schema/"+class, nil, nil)
+		if err == nil {
+			continue
+		}
+		if !isWeaviateNotFound(err) {
+			return fmt.Errorf("failed to get class %s: %w", class, err)
+		}
+
+		schema := map[string]any{
+			"class":             class,
+			"vectorizer":        "none",
+			"vectorIndexConfig": map[string]any{"distance": "cosine"},
+			"properties":        properties,
+		}
+		if err := w.do(ctx, http.MethodPost, "/v1/schema", schema, nil); err != nil {
+			return fmt.Errorf("failed to create class %s: %w", class, err)
+		}
+	}
+
+	return nil
+}
+
+// Personal memory
+
 func (w *WeaviateProvider) Store(ctx context.Context, content string, tags ...string) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+
+	// Generate embedding using the embedding service
+	embedding, err := w.embeddingService.GenerateEmbedding(ctx, content)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+	object := weaviateObject{
+		Class: weaviateMemoryClass,
+		ID:    weaviateUUID(weaviateMemoryClass, sessionID+":"+generateID()),
+		Properties: map[string]any{
+			"sessionId": sessionID,
+			"content":   content,
+			"tags":      tags,
+			"createdAt": time.Now().Format(time.RFC3339Nano),
+		},
+		Vector: embedding,
+	}
+	if err := w.putObjects(ctx, []weaviateObject{object}); err != nil {
+		return fmt.Errorf("failed to store memory: %w", err)
+	}
+
+	return nil
 }
 
 func (w *WeaviateProvider) Query(ctx context.Context, query string, limit ...int) ([]Result, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+	maxResults := w.config.MaxResults
+	if len(limit) > 0 && limit[0] > 0 {
+		maxResults = limit[0]
+	}
+	if maxResults <= 0 {
+		maxResults = weaviateMaxLimit
+	}
+
+	// Generate query embedding
+	queryEmbedding, err := w.embeddingService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	vector, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query embedding: %w", err)
+	}
+	graphQL := fmt.Sprintf(`{ Get { %s(nearVector: {vector: %s}, where: %s, limit: %d) { content tags createdAt _additional { distance } } } }`,
+		weaviateMemoryClass, vector, weaviateEqual("sessionId", sessionID).graphQL(), maxResults)
+
+	var objects []struct {
+		Content    string             `json:"content"`
+		Tags       []string           `json:"tags"`
+		CreatedAt  time.Time          `json:"createdAt"`
+		Additional weaviateAdditional `json:"_additional"`
+	}
+	if err := w.get(ctx, graphQL, weaviateMemoryClass, &objects); err != nil {
+		return nil, fmt.Errorf("failed to query memory: %w", err)
+	}
+
+	results := make([]Result, 0, len(objects))
+	for _, object := range objects {
+		results = append(results, Result{
+			Content:   object.Content,
+			Score:     1 - float32(object.Additional.Distance),
+			Tags:      object.Tags,
+			CreatedAt: object.CreatedAt,
+		})
+	}
+
+	return results, nil
 }
 
 func (w *WeaviateProvider) Remember(ctx context.Context, key string, value any) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+
+	// Convert value to JSON
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	// The object ID is derived from the session and key, so storing again replaces it
+	object := weaviateObject{
+		Class: weaviateKeyValueClass,
+		ID:    weaviateUUID(weaviateKeyValueClass, sessionID+":"+key),
+		Properties: map[string]any{
+			"sessionId": sessionID,
+			"key":       key,
+			"value":     string(jsonValue),
+		},
+	}
+	if err := w.putObjects(ctx, []weaviateObject{object}); err != nil {
+		return fmt.Errorf("failed to store key-value: %w", err)
+	}
+
+	return nil
 }
 
 func (w *WeaviateProvider) Recall(ctx context.Context, key string) (any, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+	id := weaviateUUID(weaviateKeyValueClass, sessionID+":"+key)
+
+	var object struct {
+		Properties struct {
+			Value string `json:"value"`
+		} `json:"properties"`
+	}
+	if err := w.do(ctx, http.MethodGet, "/v1/objects/"+weaviateKeyValueClass+"/"+id, nil, &object); err != nil {
+		if isWeaviateNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to recall key-value: %w", err)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(object.Properties.Value), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return value, nil
 }
 
 func (w *WeaviateProvider) AddMessage(ctx context.Context, role, content string) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+	now := time.Now()
+
+	object := weaviateObject{
+		Class: weaviateMessageClass,
+		ID:    weaviateUUID(weaviateMessageClass, sessionID+":"+generateID()),
+		Properties: map[string]any{
+			"sessionId": sessionID,
+			"role":      role,
+			"content":   content,
+			"createdAt": now.Format(time.RFC3339Nano),
+			// Orders messages added within the same millisecond
+			"sequence": now.UnixNano(),
+		},
+	}
+	if err := w.putObjects(ctx, []weaviateObject{object}); err != nil {
+		return fmt.Errorf("failed to add message: %w", err)
+	}
+
+	return nil
 }
 
 func (w *WeaviateProvider) GetHistory(ctx context.Context, limit ...int) ([]Message, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+
+	// Weaviate needs a limit; without one, return up to its default maximum
+	maxMessages := weaviateMaxLimit
+	order := "asc"
+	if len(limit) > 0 && limit[0] > 0 {
+		maxMessages = limit[0]
+		order = "desc"
+	}
+
+	graphQL := fmt.Sprintf(`{ Get { %s(where: %s, sort: [{path: ["sequence"], order: %s}], limit: %d) { role content createdAt } } }`,
+		weaviateMessageClass, weaviateEqual("sessionId", sessionID).graphQL(), order, maxMessages)
+
+	var objects []struct {
+		Role      string    `json:"role"`
+		Content   string    `json:"content"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	if err := w.get(ctx, graphQL, weaviateMessageClass, &objects); err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	var messages []Message
+	for _, object := range objects {
+		messages = append(messages, Message{
+			Role:      object.Role,
+			Content:   object.Content,
+			CreatedAt: object.CreatedAt,
+		})
+	}
+
+	// If we used a limit, reverse the order to get chronological order
+	if order == "desc" {
+		for i := len(messages)/2 - 1; i >= 0; i-- {
+			opp := len(messages) - 1 - i
+			messages[i], messages[opp] = messages[opp], messages[i]
+		}
+	}
+
+	return messages, nil
 }
 
 func (w *WeaviateProvider) NewSession() string {
@@ -50,29 +350,676 @@ func (w *WeaviateProvider) SetSession(ctx context.Context, sessionID string) con
 }
 
 func (w *WeaviateProvider) ClearSession(ctx context.Context) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	sessionID := GetSessionID(ctx)
+
+	for _, class := range []string{weaviateMemoryClass, weaviateKeyValueClass, weaviateMessageClass} {
+		if err := w.deleteObjects(ctx, class, weaviateEqual("sessionId", sessionID)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", class, err)
+		}
+	}
+
+	return nil
 }
 
 func (w *WeaviateProvider) Close() error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	w.client.CloseIdleConnections()
+	return nil
 }
 
+// RAG methods for WeaviateProvider
+
 func (w *WeaviateProvider) IngestDocument(ctx context.Context, doc Document) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	// Generate ID if not provided
+	if doc.ID == "" {
+		doc.ID = generateID()
+	}
+
+	// Set timestamps
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+	doc.UpdatedAt = time.Now()
+
+	chunks := chunkForIngest(w.config, doc)
+
+	// Generate embeddings for the chunks in one batch
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Content
+	}
+	embeddings, err := w.embeddingService.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate document embeddings: %w", err)
+	}
+	if len(embeddings) != len(chunks) {
+		return fmt.Errorf("embedding count mismatch: got %d, expected %d", len(embeddings), len(chunks))
+	}
+
+	// Replace an earlier version of the document and its chunks
+	replaced := weaviateWhere{Operator: "Or", Operands: []weaviateWhere{
+		weaviateEqual("documentId", doc.ID),
+		weaviateEqual("parentId", doc.ID),
+	}}
+	if err := w.deleteObjects(ctx, weaviateKnowledgeClass, replaced); err != nil {
+		return fmt.Errorf("failed to delete existing document chunks: %w", err)
+	}
+
+	objects := make([]weaviateObject, len(chunks))
+	for i, chunk := range chunks {
+		object, err := knowledgeObject(chunk, doc.ID, embeddings[i])
+		if err != nil {
+			return err
+		}
+		objects[i] = object
+	}
+	if err := w.putObjects(ctx, objects); err != nil {
+		return fmt.Errorf("failed to insert document: %w", err)
+	}
+
+	return nil
+}
+
+// knowledgeObject converts a document chunk into a Weaviate knowledge object.
+func knowledgeObject(chunk Document, parentID string, embedding []float32) (weaviateObject, error) {
+	metadataJSON, err := json.Marshal(chunk.Metadata)
+	if err != nil {
+		return weaviateObject{}, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	tags := chunk.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	chunkTotal := chunk.ChunkTotal
+	if chunkTotal == 0 {
+		chunkTotal = 1
+	}
+
+	return weaviateObject{
+		Class: weaviateKnowledgeClass,
+		ID:    weaviateUUID(weaviateKnowledgeClass, chunk.ID),
+		Properties: map[string]any{
+			"documentId": chunk.ID,
+			"parentId":   parentID,
+			"title":      chunk.Title,
+			"content":    chunk.Content,
+			"source":     chunk.Source,
+			"docType":    string(chunk.Type),
+			"tags":       tags,
+			"metadata":   string(metadataJSON),
+			"chunkIndex": chunk.ChunkIndex,
+			"chunkTotal": chunkTotal,
+			"createdAt":  chunk.CreatedAt.Format(time.RFC3339Nano),
+			"updatedAt":  chunk.UpdatedAt.Format(time.RFC3339Nano),
+		},
+		Vector: embedding,
+	}, nil
 }
 
 func (w *WeaviateProvider) IngestDocuments(ctx context.Context, docs []Document) error {
-	return fmt.Errorf("Weaviate provider not yet implemented")
+	for _, doc := range docs {
+		if err := w.IngestDocument(ctx, doc); err != nil {
+			return fmt.Errorf("failed to ingest document %s: %w", doc.ID, err)
+		}
+	}
+	return nil
 }
 
+// SearchKnowledge ranks knowledge by embedding similarity and, unless HybridWeight is 1,
+// by Weaviate's BM25 keyword relevance, fusing the two. The score threshold applies to
+// the similarity side only, so exact keyword matches such as error codes are kept.
 func (w *WeaviateProvider) SearchKnowledge(ctx context.Context, query string, options ...SearchOption) ([]KnowledgeResult, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	// Apply search options
+	config := defaultSearchConfig(w.config)
+	w.mutex.RLock()
+	config.Reranker = w.reranker
+	w.mutex.RUnlock()
+	for _, opt := range options {
+		opt(config)
+	}
+
+	return rerankSearch(ctx, query, config, func(config *SearchConfig) ([]KnowledgeResult, error) {
+		return w.searchKnowledge(ctx, query, config)
+	})
+}
+
+// SetReranker sets the reranker applied to knowledge searches; nil disables reranking.
+func (w *WeaviateProvider) SetReranker(reranker Reranker) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.reranker = reranker
+}
+
+// searchKnowledge retrieves the best config.Limit results for a query.
+func (w *WeaviateProvider) searchKnowledge(ctx context.Context, query string, config *SearchConfig) ([]KnowledgeResult, error) {
+	if config.HybridWeight >= 1 {
+		return w.vectorSearch(ctx, query, config, config.Limit)
+	}
+
+	candidates := hybridCandidates(config.Limit)
+	var vector []KnowledgeResult
+	if config.HybridWeight > 0 {
+		var err error
+		if vector, err = w.vectorSearch(ctx, query, config, candidates); err != nil {
+			return nil, err
+		}
+	}
+	keyword, err := w.keywordSearch(ctx, query, config, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return fuseKnowledgeResults(vector, keyword, config), nil
+}
+
+// vectorSearch returns the knowledge objects closest to the query embedding.
+func (w *WeaviateProvider) vectorSearch(ctx context.Context, query string, config *SearchConfig, limit int) ([]KnowledgeResult, error) {
+	// Generate query embedding
+	queryEmbedding, err := w.embeddingService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	vector, err := json.Marshal(queryEmbedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query embedding: %w", err)
+	}
+
+	// Cosine distance is 1 - similarity
+	nearVector := "vector: " + string(vector)
+	if config.ScoreThreshold > 0 {
+		nearVector += ", distance: " + strconv.FormatFloat(1-float64(config.ScoreThreshold), 'f', -1, 32)
+	}
+	arguments := fmt.Sprintf("nearVector: {%s}", nearVector)
+
+	return w.queryKnowledge(ctx, arguments, config, limit, "distance")
+}
+
+// keywordSearch returns the knowledge objects matching the query, ranked by BM25 over
+// their titles and content.
+func (w *WeaviateProvider) keywordSearch(ctx context.Context, query string, config *SearchConfig, limit int) ([]KnowledgeResult, error) {
+	if len(tokenize(query)) == 0 {
+		return nil, nil
+	}
+	arguments := fmt.Sprintf(`bm25: {query: %s, properties: ["title", "content"]}`, graphQLString(query))
+
+	return w.queryKnowledge(ctx, arguments, config, limit, "score")
+}
+
+// weaviateKnowledgeFilter returns the source, type, tag and date filters of a search, or
+// nil when there are none.
+func weaviateKnowledgeFilter(config *SearchConfig) *weaviateWhere {
+	var operands []weaviateWhere
+
+	if len(config.Sources) > 0 {
+		operands = append(operands, weaviateContainsAny("source", config.Sources))
+	}
+
+	if len(config.DocumentTypes) > 0 {
+		docTypes := make([]string, len(config.DocumentTypes))
+		for i, docType := range config.DocumentTypes {
+			docTypes[i] = string(docType)
+		}
+		operands = append(operands, weaviateContainsAny("docType", docTypes))
+	}
+
+	if len(config.Tags) > 0 {
+		operands = append(operands, weaviateContainsAny("tags", config.Tags))
+	}
+
+	if config.DateRange != nil {
+		operands = append(operands,
+			weaviateWhere{Path: []string{"createdAt"}, Operator: "GreaterThanEqual", ValueDate: config.DateRange.Start.Format(time.RFC3339Nano)},
+			weaviateWhere{Path: []string{"createdAt"}, Operator: "LessThanEqual", ValueDate: config.DateRange.End.Format(time.RFC3339Nano)})
+	}
+
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return &operands[0]
+	default:
+		return &weaviateWhere{Operator: "And", Operands: operands}
+	}
+}
+
+// queryKnowledge runs a knowledge search with the given search arguments. The score of a
+// result is 1 - its distance with scoreField "distance", or its BM25 score with "score".
+func (w *WeaviateProvider) queryKnowledge(ctx context.Context, arguments string, config *SearchConfig, limit int, scoreField string) ([]KnowledgeResult, error) {
+	if filter := weaviateKnowledgeFilter(config); filter != nil {
+		arguments += ", where: " + filter.graphQL()
+	}
+	if limit > 0 {
+		arguments += fmt.Sprintf(", limit: %d", limit)
+	}
+	graphQL := fmt.Sprintf(`{ Get { %s(%s) { %s _additional { %s } } } }`,
+		weaviateKnowledgeClass, arguments, weaviateKnowledgeFields, scoreField)
+
+	var objects []struct {
+		DocumentID string             `json:"documentId"`
+		Title      string             `json:"title"`
+		Content    string             `json:"content"`
+		Source     string             `json:"source"`
+		Tags       []string           `json:"tags"`
+		Metadata   string             `json:"metadata"`
+		ChunkIndex int                `json:"chunkIndex"`
+		CreatedAt  time.Time          `json:"createdAt"`
+		Additional weaviateAdditional `json:"_additional"`
+	}
+	if err := w.get(ctx, graphQL, weaviateKnowledgeClass, &objects); err != nil {
+		return nil, fmt.Errorf("failed to search knowledge base: %w", err)
+	}
+
+	results := make([]KnowledgeResult, 0, len(objects))
+	for _, object := range objects {
+		// Parse metadata
+		var metadata map[string]any
+		if object.Metadata != "" {
+			if err := json.Unmarshal([]byte(object.Metadata), &metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		score := float32(object.Additional.Score)
+		if scoreField == "distance" {
+			score = 1 - float32(object.Additional.Distance)
+		}
+
+		results = append(results, KnowledgeResult{
+			Content:    object.Content,
+			Score:      score,
+			Source:     object.Source,
+			Title:      object.Title,
+			DocumentID: object.DocumentID,
+			Metadata:   metadata,
+			Tags:       object.Tags,
+			CreatedAt:  object.CreatedAt,
+			ChunkIndex: object.ChunkIndex,
+		})
+	}
+
+	return results, nil
 }
 
 func (w *WeaviateProvider) SearchAll(ctx context.Context, query string, options ...SearchOption) (*HybridResult, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	start := time.Now()
+
+	// Apply search options
+	config := &SearchConfig{
+		Limit:            w.config.MaxResults,
+		ScoreThreshold:   0.0,
+		IncludePersonal:  true,
+		IncludeKnowledge: true,
+	}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	result := &HybridResult{
+		Query:          query,
+		PersonalMemory: []Result{},
+		Knowledge:      []KnowledgeResult{},
+	}
+
+	// Search personal memory if enabled
+	if config.IncludePersonal {
+		personalResults, err := w.Query(ctx, query, config.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search personal memory: %w", err)
+		}
+		result.PersonalMemory = personalResults
+	}
+
+	// Search knowledge base if enabled
+	if config.IncludeKnowledge {
+		knowledgeResults, err := w.SearchKnowledge(ctx, query, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search knowledge base: %w", err)
+		}
+		result.Knowledge = knowledgeResults
+	}
+
+	result.TotalResults = len(result.PersonalMemory) + len(result.Knowledge)
+	result.SearchTime = time.Since(start)
+
+	return result, nil
 }
 
 func (w *WeaviateProvider) BuildContext(ctx context.Context, query string, options ...ContextOption) (*RAGContext, error) {
-	return nil, fmt.Errorf("Weaviate provider not yet implemented")
+	// Apply context options
+	config := &ContextConfig{
+		MaxTokens:       w.config.RAGMaxContextTokens,
+		PersonalWeight:  w.config.RAGPersonalWeight,
+		KnowledgeWeight: w.config.RAGKnowledgeWeight,
+		HistoryLimit:    5,
+		IncludeSources:  w.config.RAGIncludeSources,
+		FormatTemplate:  "", // Use default formatting
+	}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	// Get hybrid search results
+	searchResults, err := w.SearchAll(ctx, query,
+		WithLimit(config.MaxTokens/100), // Rough estimate: 100 tokens per result
+		WithIncludePersonal(config.PersonalWeight > 0),
+		WithIncludeKnowledge(config.KnowledgeWeight > 0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for context: %w", err)
+	}
+
+	// Get chat history
+	history, err := w.GetHistory(ctx, config.HistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	// Build context text
+	contextText := w.formatContextText(query, searchResults, history, config)
+
+	// Collect sources
+	sources := []string{}
+	for _, result := range searchResults.Knowledge {
+		if result.Source != "" {
+			sources = append(sources, result.Source)
+		}
+	}
+
+	// Remove duplicates
+	sources = removeDuplicates(sources)
+
+	return &RAGContext{
+		Query:          query,
+		PersonalMemory: searchResults.PersonalMemory,
+		Knowledge:      searchResults.Knowledge,
+		ChatHistory:    history,
+		ContextText:    contextText,
+		Messages:       buildContextMessages(searchResults, history, config),
+		Sources:        sources,
+		TokenCount:     estimateTokenCount(contextText),
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+// Helper function for Weaviate context formatting
+func (w *WeaviateProvider) formatContextText(query string, results *HybridResult, history []Message, config *ContextConfig) string {
+	if config.FormatTemplate != "" {
+		// TODO: Implement custom template formatting
+		return config.FormatTemplate
+	}
+
+	var builder strings.Builder
+
+	// Add query
+	builder.WriteString(fmt.Sprintf("Query: %s\n\n", query))
+
+	// Add personal memory context
+	if len(results.PersonalMemory) > 0 {
+		builder.WriteString("Personal Memory:\n")
+		for i, result := range results.PersonalMemory {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Content))
+		}
+		builder.WriteString("\n")
+	}
+
+	// Add knowledge base context
+	if len(results.Knowledge) > 0 {
+		builder.WriteString("Knowledge Base:\n")
+		for i, result := range results.Knowledge {
+			source := ""
+			if config.IncludeSources && result.Source != "" {
+				source = fmt.Sprintf(" (Source: %s)", result.Source)
+			}
+			builder.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, result.Content, source))
+		}
+		builder.WriteString("\n")
+	}
+
+	// Add recent chat history
+	if len(history) > 0 {
+		builder.WriteString("Recent Conversation:\n")
+		for _, msg := range history {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+		}
+	}
+
+	return builder.String()
+}
+
+// Weaviate API helpers
+
+// weaviateObject is an object in a batch import.
+type weaviateObject struct {
+	Class      string         `json:"class"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Vector     []float32      `json:"vector,omitempty"`
+}
+
+// weaviateWhere is a Weaviate filter. Leaf filters compare the property at Path with a
+// value; "And" and "Or" filters combine their Operands.
+type weaviateWhere struct {
+	Operator       string          `json:"operator"`
+	Path           []string        `json:"path,omitempty"`
+	ValueText      *string         `json:"valueText,omitempty"`
+	ValueTextArray []string        `json:"valueTextArray,omitempty"`
+	ValueDate      string          `json:"valueDate,omitempty"`
+	Operands       []weaviateWhere `json:"operands,omitempty"`
+}
+
+// weaviateEqual filters on a text property equal to value.
+func weaviateEqual(property, value string) weaviateWhere {
+	return weaviateWhere{Path: []string{property}, Operator: "Equal", ValueText: &value}
+}
+
+// weaviateContainsAny filters on a text or text[] property matching any of values.
+func weaviateContainsAny(property string, values []string) weaviateWhere {
+	return weaviateWhere{Path: []string{property}, Operator: "ContainsAny", ValueTextArray: values}
+}
+
+// graphQL renders the filter as a GraphQL input object.
+func (f weaviateWhere) graphQL() string {
+	parts := []string{"operator: " + f.Operator}
+	if len(f.Path) > 0 {
+		path := make([]string, len(f.Path))
+		for i, p := range f.Path {
+			path[i] = graphQLString(p)
+		}
+		parts = append(parts, "path: ["+strings.Join(path, ", ")+"]")
+	}
+	if f.ValueText != nil {
+		parts = append(parts, "valueText: "+graphQLString(*f.ValueText))
+	}
+	if f.ValueTextArray != nil {
+		values := make([]string, len(f.ValueTextArray))
+		for i, v := range f.ValueTextArray {
+			values[i] = graphQLString(v)
+		}
+		parts = append(parts, "valueText: ["+strings.Join(values, ", ")+"]")
+	}
+	if f.ValueDate != "" {
+		parts = append(parts, "valueDate: "+graphQLString(f.ValueDate))
+	}
+	if len(f.Operands) > 0 {
+		operands := make([]string, len(f.Operands))
+		for i, operand := range f.Operands {
+			operands[i] = operand.graphQL()
+		}
+		parts = append(parts, "operands: ["+strings.Join(operands, ", ")+"]")
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// graphQLString quotes a GraphQL string literal; its escapes are those of JSON.
+func graphQLString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// weaviateAdditional holds the _additional fields of a search result. Weaviate returns
+// BM25 scores as strings.
+type weaviateAdditional struct {
+	Distance float64 `json:"distance"`
+	Score    float64 `json:"score"`
+}
+
+// UnmarshalJSON accepts scores as numbers or strings.
+func (a *weaviateAdditional) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Distance float64         `json:"distance"`
+		Score    json.RawMessage `json:"score"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	a.Distance = raw.Distance
+	a.Score = 0
+	if len(raw.Score) > 0 && string(raw.Score) != "null" {
+		score := strings.Trim(string(raw.Score), `"`)
+		value, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return fmt.Errorf("invalid score %s: %w", raw.Score, err)
+		}
+		a.Score = value
+	}
+	return nil
+}
+
+// weaviateUUID derives a stable UUID (version 5 layout) from a class and key, so writing
+// the same key again replaces the object.
+func weaviateUUID(class, key string) string {
+	sum := sha1.Sum([]byte(class + "\x00" + key))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// weaviateError is an error response of the Weaviate API.
+type weaviateError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *weaviateError) Error() string {
+	return fmt.Sprintf("Weaviate API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+func isWeaviateNotFound(err error) bool {
+	apiErr, ok := err.(*weaviateError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request to the Weaviate API and decodes the JSON response into out, if not nil.
+func (w *WeaviateProvider) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, w.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &weaviateError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// get runs a GraphQL Get query and decodes the objects returned for class into out.
+func (w *WeaviateProvider) get(ctx context.Context, query, class string, out any) error {
+	var response struct {
+		Data struct {
+			Get map[string]json.RawMessage `json:"Get"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := w.do(ctx, http.MethodPost, "/v1/graphql", map[string]string{"query": query}, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+
+	objects := response.Data.Get[class]
+	if len(objects) == 0 || string(objects) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(objects, out); err != nil {
+		return fmt.Errorf("failed to decode %s objects: %w", class, err)
+	}
+	return nil
+}
+
+// putObjects imports objects in a batch, replacing existing objects with the same IDs.
+func (w *WeaviateProvider) putObjects(ctx context.Context, objects []weaviateObject) error {
+	var results []struct {
+		ID     string `json:"id"`
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := w.do(ctx, http.MethodPost, "/v1/batch/objects", map[string]any{"objects": objects}, &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.Result.Errors != nil && len(result.Result.Errors.Error) > 0 {
+			return fmt.Errorf("object %s: %s", result.ID, result.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// deleteObjects deletes the objects of a class matching a filter.
+func (w *WeaviateProvider) deleteObjects(ctx context.Context, class string, where weaviateWhere) error {
+	request := map[string]any{
+		"match":  map[string]any{"class": class, "where": where},
+		"output": "minimal",
+	}
+	var response struct {
+		Results struct {
+			Failed int `json:"failed"`
+		} `json:"results"`
+	}
+	if err := w.do(ctx, http.MethodDelete, "/v1/batch/objects", request, &response); err != nil {
+		return err
+	}
+	if response.Results.Failed > 0 {
+		return fmt.Errorf("%d objects could not be deleted", response.Results.Failed)
+	}
+	return nil
 }
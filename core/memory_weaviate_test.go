@@ -0,0 +1,359 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWeaviate serves the parts of the Weaviate API the provider uses. GraphQL queries
+// are answered by the graphQL function.
+type fakeWeaviate struct {
+	mu      sync.Mutex
+	classes map[string]map[string]any
+	objects map[string]weaviateObject
+	queries []string
+	deletes int
+	graphQL func(query string) string
+}
+
+func newFakeWeaviate(t *testing.T, existing ...string) (*fakeWeaviate, *httptest.Server) {
+	f := &fakeWeaviate{classes: make(map[string]map[string]any), objects: make(map[string]weaviateObject)}
+	for _, class := range existing {
+		f.classes[class] = nil
+	}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeWeaviate) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/.well-known/ready":
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/schema/"):
+		if _, ok := f.classes[strings.TrimPrefix(r.URL.Path, "/v1/schema/")]; !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/schema":
+		var class map[string]any
+		json.NewDecoder(r.Body).Decode(&class)
+		f.classes[class["class"].(string)] = class
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batch/objects":
+		var batch struct {
+			Objects []weaviateObject `json:"objects"`
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		var results []map[string]any
+		for _, object := range batch.Objects {
+			f.objects[object.ID] = object
+			results = append(results, map[string]any{"id": object.ID, "result": map[string]any{}})
+		}
+		json.NewEncoder(w).Encode(results)
+	case r.Method == http.MethodDelete && r.URL.Path == "/v1/batch/objects":
+		var request struct {
+			Match struct {
+				Class string        `json:"class"`
+				Where weaviateWhere `json:"where"`
+			} `json:"match"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		f.deletes++
+		for id, object := range f.objects {
+			if object.Class == request.Match.Class && fakeMatches(request.Match.Where, object.Properties) {
+				delete(f.objects, id)
+			}
+		}
+		w.Write([]byte(`{"results": {"failed": 0}}`))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/objects/"):
+		parts := strings.Split(r.URL.Path, "/")
+		object, ok := f.objects[parts[len(parts)-1]]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(object)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/graphql":
+		var request struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		f.queries = append(f.queries, request.Query)
+		if f.graphQL == nil {
+			w.Write([]byte(`{"data": {"Get": {}}}`))
+			return
+		}
+		w.Write([]byte(f.graphQL(request.Query)))
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+// fakeMatches evaluates Equal, And and Or filters on text properties.
+func fakeMatches(where weaviateWhere, properties map[string]any) bool {
+	switch where.Operator {
+	case "And", "Or":
+		for _, operand := range where.Operands {
+			if fakeMatches(operand, properties) == (where.Operator == "Or") {
+				return where.Operator == "Or"
+			}
+		}
+		return where.Operator == "And"
+	case "Equal":
+		return where.ValueText != nil && properties[where.Path[0]] == *where.ValueText
+	}
+	return false
+}
+
+func (f *fakeWeaviate) objectsOf(class string) []weaviateObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var objects []weaviateObject
+	for _, object := range f.objects {
+		if object.Class == class {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+func (f *fakeWeaviate) lastQuery() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.queries) == 0 {
+		return ""
+	}
+	return f.queries[len(f.queries)-1]
+}
+
+func newTestWeaviateMemory(t *testing.T, server *httptest.Server, config AgentMemoryConfig) Memory {
+	t.Helper()
+	config.Provider = "weaviate"
+	config.Connection = server.URL
+	config.Dimensions = 8
+	memory, err := NewMemory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return memory
+}
+
+func TestWeaviateProviderSchema(t *testing.T) {
+	fake, server := newFakeWeaviate(t, weaviateMemoryClass)
+	newTestWeaviateMemory(t, server, AgentMemoryConfig{})
+
+	if len(fake.classes) != 4 || fake.classes[weaviateMemoryClass] != nil {
+		t.Fatalf("expected the 3 missing classes to be created, got %v", fake.classes)
+	}
+	knowledge := fake.classes[weaviateKnowledgeClass]
+	if knowledge["vectorizer"] != "none" || len(knowledge["properties"].([]any)) != len(weaviateClasses()[weaviateKnowledgeClass]) {
+		t.Errorf("unexpected knowledge class: %v", knowledge)
+	}
+
+	if _, err := NewMemory(AgentMemoryConfig{Provider: "weaviate", Connection: "localhost:8080"}); err == nil {
+		t.Error("expected an error for a connection without a scheme")
+	}
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	if _, err := NewMemory(AgentMemoryConfig{Provider: "weaviate", Connection: down.URL}); err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("expected a readiness error, got %v", err)
+	}
+}
+
+func TestWeaviateProviderDefaultLimit(t *testing.T) {
+	fake, server := newFakeWeaviate(t)
+	memory, err := newWeaviateProvider(AgentMemoryConfig{Connection: server.URL, Dimensions: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := memory.SetSession(context.Background(), "s1")
+
+	fake.graphQL = func(query string) string { return `{"data": {"Get": {}}}` }
+	if _, err := memory.Query(ctx, "drinks"); err != nil {
+		t.Fatal(err)
+	}
+	if query := fake.lastQuery(); strings.Contains(query, "limit: 0") || !strings.Contains(query, "limit: 10000") {
+		t.Errorf("expected a query without max_results to use the default limit: %s", query)
+	}
+	if _, err := memory.GetHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if query := fake.lastQuery(); !strings.Contains(query, "limit: 10000") {
+		t.Errorf("expected the full history to use the default limit: %s", query)
+	}
+}
+
+func TestWeaviateProviderSessionData(t *testing.T) {
+	fake, server := newFakeWeaviate(t)
+	memory := newTestWeaviateMemory(t, server, AgentMemoryConfig{})
+	ctx := memory.SetSession(context.Background(), "s1")
+
+	if err := memory.Store(ctx, "likes tea", "preference"); err != nil {
+		t.Fatal(err)
+	}
+	stored := fake.objectsOf(weaviateMemoryClass)
+	if len(stored) != 1 || stored[0].Properties["sessionId"] != "s1" || len(stored[0].Vector) != 8 {
+		t.Fatalf("unexpected stored memory: %+v", stored)
+	}
+
+	fake.graphQL = func(query string) string {
+		return `{"data": {"Get": {"AgentMemory": [{"content": "likes tea", "tags": ["preference"], "createdAt": "2025-01-02T03:04:05Z", "_additional": {"distance": 0.25}}]}}}`
+	}
+	results, err := memory.Query(ctx, "drinks", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Score != 0.75 || results[0].Tags[0] != "preference" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if query := fake.lastQuery(); !strings.Contains(query, "nearVector") || !strings.Contains(query, `valueText: "s1"`) || !strings.Contains(query, "limit: 3") {
+		t.Errorf("unexpected query: %s", query)
+	}
+
+	// Key-value pairs are replaced in place
+	memory.Remember(ctx, "color", "blue")
+	if err := memory.Remember(ctx, "color", map[string]any{"primary": "green"}); err != nil {
+		t.Fatal(err)
+	}
+	value, err := memory.Recall(ctx, "color")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := value.(map[string]any); !ok || m["primary"] != "green" || len(fake.objectsOf(weaviateKeyValueClass)) != 1 {
+		t.Errorf("unexpected recalled value %v", value)
+	}
+	if value, err := memory.Recall(memory.SetSession(ctx, "s2"), "color"); value != nil || err != nil {
+		t.Errorf("expected no value in another session, got %v, %v", value, err)
+	}
+
+	if err := memory.AddMessage(ctx, "user", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	fake.graphQL = func(query string) string {
+		return `{"data": {"Get": {"AgentMessage": [{"role": "assistant", "content": "hello"}, {"role": "user", "content": "hi"}]}}}`
+	}
+	history, err := memory.GetHistory(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Content != "hi" || history[1].Content != "hello" {
+		t.Errorf("expected chronological history, got %+v", history)
+	}
+	if query := fake.lastQuery(); !strings.Contains(query, "order: desc") || !strings.Contains(query, "limit: 2") {
+		t.Errorf("unexpected history query: %s", query)
+	}
+
+	if err := memory.ClearSession(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fake.deletes != 3 || len(fake.objectsOf(weaviateMemoryClass))+len(fake.objectsOf(weaviateKeyValueClass))+len(fake.objectsOf(weaviateMessageClass)) != 0 {
+		t.Errorf("expected the session to be cleared, %d deletes", fake.deletes)
+	}
+}
+
+func TestWeaviateProviderKnowledge(t *testing.T) {
+	fake, server := newFakeWeaviate(t)
+	memory := newTestWeaviateMemory(t, server, AgentMemoryConfig{
+		ChunkSize:         10,
+		ChunkOverlap:      2,
+		RAGIncludeSources: true,
+		Documents:         DocumentConfig{AutoChunk: true},
+		Search:            SearchConfigToml{HybridSearch: true, KeywordWeight: 0.5, SemanticWeight: 0.5},
+	})
+	ctx := context.Background()
+
+	doc := Document{ID: "guide", Title: "Guide", Content: strings.Repeat("Routers need firmware updates to stay secure. ", 10), Metadata: map[string]any{"lang": "en"}}
+	if err := memory.IngestDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	chunks := fake.objectsOf(weaviateKnowledgeClass)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if chunk.Properties["parentId"] != "guide" || len(chunk.Vector) != 8 {
+			t.Errorf("unexpected chunk: %+v", chunk.Properties)
+		}
+	}
+
+	// Re-ingesting replaces every chunk
+	doc.Content = "Short guide."
+	if err := memory.IngestDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if chunks := fake.objectsOf(weaviateKnowledgeClass); len(chunks) != 1 || chunks[0].Properties["content"] != "Short guide." {
+		t.Fatalf("expected the old chunks to be replaced, got %d", len(chunks))
+	}
+
+	var vectorQuery, keywordQuery string
+	fake.graphQL = func(query string) string {
+		if strings.Contains(query, "nearVector") {
+			vectorQuery = query
+			return `{"data": {"Get": {"AgentKnowledge": [
+				{"documentId": "a", "content": "A", "metadata": "{\"lang\":\"en\"}", "_additional": {"distance": 0.1}},
+				{"documentId": "b", "content": "B", "source": "b.md", "_additional": {"distance": 0.2}}]}}}`
+		}
+		keywordQuery = query
+		return `{"data": {"Get": {"AgentKnowledge": [
+			{"documentId": "c", "content": "C", "_additional": {"score": "4.0"}},
+			{"documentId": "b", "content": "B", "source": "b.md", "_additional": {"score": "2.0"}}]}}}`
+	}
+
+	results, err := memory.SearchKnowledge(ctx, "firmware \"update\"", WithScoreThreshold(0.7), WithTags([]string{"ops", "it"}), WithDocumentTypes([]DocumentType{DocumentTypeMarkdown, DocumentTypeText}), WithSources([]string{"a.md", "b.md"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultIDs(results); got != "b,c,a" {
+		t.Errorf("unexpected fused order %s", got)
+	}
+	if results[2].Metadata["lang"] != "en" {
+		t.Errorf("metadata not decoded: %+v", results[2])
+	}
+	for _, want := range []string{
+		"distance: 0.3",
+		`operator: ContainsAny, path: ["tags"], valueText: ["ops", "it"]`,
+		`operator: ContainsAny, path: ["docType"], valueText: ["md", "txt"]`,
+		`operator: ContainsAny, path: ["source"], valueText: ["a.md", "b.md"]`,
+		"operator: And",
+	} {
+		if !strings.Contains(vectorQuery, want) {
+			t.Errorf("vector query missing %q: %s", want, vectorQuery)
+		}
+	}
+	if !strings.Contains(keywordQuery, `bm25: {query: "firmware \"update\""`) || strings.Contains(keywordQuery, "distance") {
+		t.Errorf("unexpected keyword query: %s", keywordQuery)
+	}
+
+	rag, err := memory.BuildContext(ctx, "firmware")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rag.Knowledge) != 3 || len(rag.Sources) != 1 || !strings.Contains(rag.ContextText, "(Source: b.md)") {
+		t.Errorf("unexpected context: %+v", rag)
+	}
+
+	fake.graphQL = func(string) string {
+		return `{"errors": [{"message": "no such class"}]}`
+	}
+	if _, err := memory.SearchKnowledge(ctx, "firmware"); err == nil || !strings.Contains(err.Error(), "no such class") {
+		t.Errorf("expected the GraphQL error, got %v", err)
+	}
+}
+
+func TestWeaviateUUID(t *testing.T) {
+	id := weaviateUUID(weaviateKeyValueClass, "s1:color")
+	if id != weaviateUUID(weaviateKeyValueClass, "s1:color") || id == weaviateUUID(weaviateKeyValueClass, "s2:color") {
+		t.Error("UUIDs should be stable per key")
+	}
+	if len(id) != 36 || id[14] != '5' || !strings.ContainsRune("89ab", rune(id[19])) {
+		t.Errorf("not a version 5 UUID: %s", id)
+	}
+}
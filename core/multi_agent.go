@@ -94,6 +94,19 @@ func NewLoopAgent(name string, maxIterations int, timeout time.Duration,
 	}
 }
 
+// NewLoopAgentWithConditions creates a loop agent that also stops as soon as one of
+// config.Conditions holds after an iteration
+func NewLoopAgentWithConditions(name string, config LoopConfig, timeout time.Duration, subAgent Agent) Agent {
+	return &loopAgent{
+		name:          name,
+		maxIterations: config.MaxIterations,
+		timeout:       timeout,
+		condition:     config.Condition,
+		conditions:    config.Conditions,
+		subAgent:      subAgent,
+	}
+}
+
 // =============================================================================
 // AGENT COMPOSITION BUILDER PATTERN
 // =============================================================================
@@ -105,12 +118,14 @@ type CompositionBuilder struct {
 	mode       string
 	config     MultiAgentConfig
 	loopConfig LoopConfig
+	itemsKey   string
 }
 
 // LoopConfig contains configuration specific to loop compositions
 type LoopConfig struct {
 	MaxIterations int
-	Condition     func(State) bool
+	Condition     func(State) bool // Checked before each iteration; true ends the loop
+	Conditions    []LoopCondition  // Checked after each iteration; the first to hold ends the loop
 }
 
 // NewComposition creates a new composition builder with the given name
@@ -147,10 +162,27 @@ func (cb *CompositionBuilder) AsLoop(maxIterations int, condition func(State) bo
 	cb.loopConfig = LoopConfig{
 		MaxIterations: maxIterations,
 		Condition:     condition,
+		Conditions:    cb.loopConfig.Conditions,
 	}
 	return cb
 }
 
+// AsMapReduce configures the composition to run the first agent once per item of the
+// list under itemsKey and pass the collected outputs to the second agent, if any. The
+// concurrency and error strategy of the composition apply to the mappers.
+func (cb *CompositionBuilder) AsMapReduce(itemsKey string) *CompositionBuilder {
+	cb.mode = "map-reduce"
+	cb.itemsKey = itemsKey
+	return cb
+}
+
+// WithLoopConditions adds termination conditions to a loop composition, such as
+// StopOnConvergence or StopWhenJudged. They are checked after each iteration.
+func (cb *CompositionBuilder) WithLoopConditions(conditions ...LoopCondition) *CompositionBuilder {
+	cb.loopConfig.Conditions = append(cb.loopConfig.Conditions, conditions...)
+	return cb
+}
+
 // WithTimeout sets the overall timeout for the composition
 func (cb *CompositionBuilder) WithTimeout(timeout time.Duration) *CompositionBuilder {
 	cb.config.Timeout = timeout
@@ -169,7 +201,7 @@ func (cb *CompositionBuilder) WithStateStrategy(strategy StateHandlingStrategy)
 	return cb
 }
 
-// WithMaxConcurrency sets the maximum number of concurrent agents (for parallel mode)
+// WithMaxConcurrency sets the maximum number of concurrent agents (for parallel and map-reduce modes)
 func (cb *CompositionBuilder) WithMaxConcurrency(max int) *CompositionBuilder {
 	cb.config.MaxConcurrency = max
 	return cb
@@ -190,10 +222,24 @@ func (cb *CompositionBuilder) Build() (Agent, error) {
 		if len(cb.agents) != 1 {
 			return nil, fmt.Errorf("loop composition '%s' requires exactly one agent, got %d", cb.name, len(cb.agents))
 		}
-		return NewLoopAgent(cb.name, cb.loopConfig.MaxIterations, cb.config.Timeout,
-			cb.loopConfig.Condition, cb.agents[0]), nil
+		return NewLoopAgentWithConditions(cb.name, cb.loopConfig, cb.config.Timeout, cb.agents[0]), nil
+	case "map-reduce":
+		if len(cb.agents) > 2 {
+			return nil, fmt.Errorf("map-reduce composition '%s' takes a mapper and an optional reducer, got %d agents", cb.name, len(cb.agents))
+		}
+		var reducer Agent
+		if len(cb.agents) == 2 {
+			reducer = cb.agents[1]
+		}
+		config := MapReduceConfig{
+			ItemsKey:       cb.itemsKey,
+			MaxConcurrency: cb.config.MaxConcurrency,
+			ErrorStrategy:  cb.config.ErrorStrategy,
+			Timeout:        cb.config.Timeout,
+		}
+		return NewMapReduceAgent(cb.name, config, cb.agents[0], reducer), nil
 	case "":
-		return nil, fmt.Errorf("composition '%s' mode not specified - use AsParallel(), AsSequential(), or AsLoop()", cb.name)
+		return nil, fmt.Errorf("composition '%s' mode not specified - use AsParallel(), AsSequential(), AsLoop(), or AsMapReduce()", cb.name)
 	default:
 		return nil, fmt.Errorf("unknown composition mode '%s' for composition '%s'", cb.mode, cb.name)
 	}
@@ -385,6 +431,7 @@ type loopAgent struct {
 	maxIterations int
 	timeout       time.Duration
 	condition     func(State) bool
+	conditions    []LoopCondition
 	subAgent      Agent
 }
 
@@ -402,6 +449,7 @@ func (la *loopAgent) Run(ctx context.Context, inputState State) (State, error) {
 	}
 
 	currentState := inputState.Clone()
+	tracker := newLoopTracker(runCtx, la.conditions)
 
 	for i := 0; i < la.maxIterations; i++ {
 		// Check if context is done
@@ -416,13 +464,23 @@ func (la *loopAgent) Run(ctx context.Context, inputState State) (State, error) {
 			break
 		}
 
-		// Run the sub-agent
-		result, err := la.subAgent.Run(runCtx, currentState)
+		// Run the sub-agent on a copy, so the previous state survives for convergence checks
+		result, err := la.subAgent.Run(runCtx, currentState.Clone())
 		if err != nil {
 			return currentState, fmt.Errorf("loop agent %s iteration %d: %w", la.name, i, err)
 		}
 
+		previous := currentState
 		currentState = result
+
+		reason, err := tracker.check(runCtx, i+1, previous, currentState)
+		if err != nil {
+			return currentState, fmt.Errorf("loop agent %s iteration %d: termination check failed: %w", la.name, i, err)
+		}
+		if reason != "" {
+			Logger().Debug().Str("agent", la.name).Int("iteration", i+1).Str("reason", reason).Msg("Loop agent termination condition met")
+			break
+		}
 	}
 
 	return currentState, nil
@@ -60,6 +60,12 @@ type EnhancedRunnerConfig struct {
 	Config              OrchestrationConfig // Orchestration-specific configuration
 	CollaborativeAgents []string            // List of agent names for collaborative execution
 	SequentialAgents    []string            // List of agent names for sequential execution
+	CheckpointStore     CheckpointStore     // Optional: sequential and loop workflows checkpoint here after each step
+	Graph               *WorkflowGraph      // For graph orchestration: nodes and edges of the workflow
+	Supervisor          SupervisorConfig    // For supervisor orchestration: the deciding model and its agents
+	MapReduce           MapReduceConfig     // For map/reduce orchestration: the list, mapper and reducer
+	MaxIterations       int                 // For loop orchestration: maximum iterations (default 5)
+	LoopConditions      []LoopCondition     // For loop orchestration: stop early when one of these holds
 }
 
 // =============================================================================
@@ -86,14 +92,25 @@ func NewSequentialOrchestrator(registry *CallbackRegistry, agentNames []string)
 
 // NewLoopOrchestrator creates an orchestrator that runs a single agent in a loop
 func NewLoopOrchestrator(registry *CallbackRegistry, agentNames []string) Orchestrator {
+	return NewLoopOrchestratorWithConditions(registry, agentNames, 0)
+}
+
+// NewLoopOrchestratorWithConditions creates a loop orchestrator that stops after
+// maxIterations (5 when not positive), when the agent sets loop_completed, or as soon as
+// one of the conditions holds after an iteration
+func NewLoopOrchestratorWithConditions(registry *CallbackRegistry, agentNames []string, maxIterations int, conditions ...LoopCondition) Orchestrator {
 	agentName := ""
 	if len(agentNames) > 0 {
 		agentName = agentNames[0] // Use first agent for loop
 	}
+	if maxIterations <= 0 {
+		maxIterations = 5 // Default iterations
+	}
 	return &loopOrchestrator{
 		handlers:         make(map[string]AgentHandler),
 		agentName:        agentName,
-		maxIterations:    5, // Default iterations
+		maxIterations:    maxIterations,
+		conditions:       conditions,
 		callbackRegistry: registry,
 	}
 }
@@ -115,11 +132,29 @@ func NewRunnerWithOrchestration(cfg EnhancedRunnerConfig) Runner {
 	case OrchestrationSequential:
 		orch = NewSequentialOrchestrator(callbackRegistry, cfg.SequentialAgents)
 	case OrchestrationLoop:
-		orch = NewLoopOrchestrator(callbackRegistry, cfg.SequentialAgents)
+		orch = NewLoopOrchestratorWithConditions(callbackRegistry, cfg.SequentialAgents, cfg.MaxIterations, cfg.LoopConditions...)
+	case OrchestrationGraph:
+		orch = NewGraphOrchestrator(callbackRegistry, cfg.Graph)
+	case OrchestrationSupervisor:
+		supervisor := cfg.Supervisor
+		if supervisor.ErrorHandler == "" && cfg.RunnerConfig.Config != nil {
+			supervisor.ErrorHandler = cfg.RunnerConfig.Config.ErrorRouting.ErrorHandlerName
+		}
+		orch = NewSupervisorOrchestrator(callbackRegistry, supervisor)
+	case OrchestrationMapReduce:
+		orch = NewMapReduceOrchestrator(callbackRegistry, cfg.MapReduce)
 	default:
 		orch = NewRouteOrchestrator(callbackRegistry)
 	}
 
+	if cfg.CheckpointStore != nil {
+		if checkpointing, ok := orch.(CheckpointingOrchestrator); ok {
+			checkpointing.SetCheckpointStore(cfg.CheckpointStore)
+		} else {
+			Logger().Warn().Str("mode", string(cfg.OrchestrationMode)).Msg("Orchestration mode does not support checkpoints, ignoring checkpoint store")
+		}
+	}
+
 	// Set the orchestrator on the runner
 	if runnerImpl, ok := runner.(*RunnerImpl); ok {
 		runnerImpl.SetOrchestrator(orch)
@@ -151,9 +186,12 @@ func NewRunnerWithOrchestration(cfg EnhancedRunnerConfig) Runner {
 
 // OrchestrationBuilder provides fluent interface for orchestration setup
 type OrchestrationBuilder struct {
-	mode   OrchestrationMode
-	agents map[string]AgentHandler
-	config OrchestrationConfig
+	mode       OrchestrationMode
+	agents     map[string]AgentHandler
+	config     OrchestrationConfig
+	graph      *WorkflowGraph
+	supervisor SupervisorConfig
+	mapReduce  MapReduceConfig
 }
 
 // NewOrchestrationBuilder creates a new orchestration builder with the specified mode
@@ -210,6 +248,24 @@ func (ob *OrchestrationBuilder) WithConfig(config OrchestrationConfig) *Orchestr
 	return ob
 }
 
+// WithGraph sets the workflow graph for graph orchestration
+func (ob *OrchestrationBuilder) WithGraph(graph *WorkflowGraph) *OrchestrationBuilder {
+	ob.graph = graph
+	return ob
+}
+
+// WithSupervisor sets the supervisor model and agents for supervisor orchestration
+func (ob *OrchestrationBuilder) WithSupervisor(config SupervisorConfig) *OrchestrationBuilder {
+	ob.supervisor = config
+	return ob
+}
+
+// WithMapReduce sets the list, mapper and reducer for map/reduce orchestration
+func (ob *OrchestrationBuilder) WithMapReduce(config MapReduceConfig) *OrchestrationBuilder {
+	ob.mapReduce = config
+	return ob
+}
+
 // Build creates the configured runner with the specified orchestration mode
 func (ob *OrchestrationBuilder) Build() Runner {
 	// Ensure we have memory and sessionID to satisfy Runner requirements
@@ -224,6 +280,9 @@ func (ob *OrchestrationBuilder) Build() Runner {
 		},
 		OrchestrationMode: ob.mode,
 		Config:            ob.config,
+		Graph:             ob.graph,
+		Supervisor:        ob.supervisor,
+		MapReduce:         ob.mapReduce,
 	})
 }
 
@@ -409,7 +468,7 @@ func (o *collaborativeOrchestrator) Dispatch(ctx context.Context, event Event) (
 				Str("agent", agentName).
 				Msg("CollaborativeOrchestrator: Dispatching to agent")
 
-			result, err := h.Run(ctx, event, currentState)
+			result, err := runAgentHandler(ctx, agentName, h, event, currentState)
 			if err != nil {
 				result.Error = err.Error()
 			}
@@ -617,7 +676,7 @@ func (o *mixedOrchestrator) executeCollaborativePhase(ctx context.Context, event
 		go func(agentName string, h AgentHandler) {
 			defer wg.Done()
 
-			result, err := h.Run(ctx, event, state)
+			result, err := runAgentHandler(ctx, agentName, h, event, state)
 			if err != nil {
 				result = AgentResult{
 					OutputState: state,
@@ -690,7 +749,7 @@ func (o *mixedOrchestrator) executeSequentialPhase(ctx context.Context, event Ev
 			Int("total", len(o.sequentialAgents)).
 			Msg("MixedOrchestrator: Executing sequential agent")
 
-		result, err := handler.Run(ctx, event, currentState)
+		result, err := runAgentHandler(ctx, agentName, handler, event, currentState)
 		if err != nil {
 			return AgentResult{}, fmt.Errorf("sequential agent %s (position %d) failed: %w", agentName, i, err)
 		}
@@ -743,6 +802,7 @@ type sequentialOrchestrator struct {
 	handlers         map[string]AgentHandler
 	agentSequence    []string
 	callbackRegistry *CallbackRegistry
+	checkpoints      CheckpointStore
 	mu               sync.RWMutex
 }
 
@@ -778,10 +838,19 @@ func (o *sequentialOrchestrator) Dispatch(ctx context.Context, event Event) (Age
 		currentState.SetMeta(key, value)
 	}
 
-	var state State = currentState // Use State interface for chaining
+	if result, handled, err := dispatchOutsideWorkflow(ctx, o.handlers, o.agentSequence, event, currentState); handled {
+		return result, err
+	}
+
+	// Resumed workflows continue after the last agent that completed
+	state, start, err := resumePoint(ctx, o.checkpoints, OrchestrationSequential, event, currentState)
+	if err != nil {
+		return AgentResult{}, err
+	}
 
 	// Execute agents in sequence
-	for i, agentName := range o.agentSequence {
+	for i := start; i < len(o.agentSequence); i++ {
+		agentName := o.agentSequence[i]
 		handler, exists := o.handlers[agentName]
 		if !exists {
 			Logger().Warn().Str("agent", agentName).Msg("SequentialOrchestrator: Agent not found, skipping")
@@ -793,16 +862,32 @@ func (o *sequentialOrchestrator) Dispatch(ctx context.Context, event Event) (Age
 			Int("position", i).
 			Msg("SequentialOrchestrator: Executing agent")
 
-		result, err := handler.Run(ctx, event, state)
+		result, err := runAgentHandler(ctx, agentName, handler, event, state)
 		if err != nil {
+			saveCheckpoint(ctx, o.checkpoints, OrchestrationSequential, event, i, agentName, state, false, err)
 			return AgentResult{}, fmt.Errorf("sequential agent %s failed: %w", agentName, err)
 		}
 
 		// Pass output state to next agent
 		state = result.OutputState
+		saveCheckpoint(ctx, o.checkpoints, OrchestrationSequential, event, i+1, agentName, state, i+1 == len(o.agentSequence), nil)
 	}
 
-	return AgentResult{OutputState: state}, nil
+	return AgentResult{OutputState: finishWorkflow(state)}, nil
+}
+
+// SetCheckpointStore enables checkpointing after each agent in the sequence
+func (o *sequentialOrchestrator) SetCheckpointStore(store CheckpointStore) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.checkpoints = store
+}
+
+// CheckpointStore returns the checkpoint store, or nil when checkpointing is disabled
+func (o *sequentialOrchestrator) CheckpointStore() CheckpointStore {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.checkpoints
 }
 
 // GetCallbackRegistry returns the callback registry
@@ -827,7 +912,9 @@ type loopOrchestrator struct {
 	handlers         map[string]AgentHandler
 	agentName        string
 	maxIterations    int
+	conditions       []LoopCondition
 	callbackRegistry *CallbackRegistry
+	checkpoints      CheckpointStore
 	mu               sync.RWMutex
 }
 
@@ -868,18 +955,29 @@ func (o *loopOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResu
 		currentState.SetMeta(key, value)
 	}
 
-	var state State = currentState // Use State interface for chaining
+	if result, handled, err := dispatchOutsideWorkflow(ctx, o.handlers, []string{o.agentName}, event, currentState); handled {
+		return result, err
+	}
+
+	// Resumed loops continue after the last iteration that completed
+	state, start, err := resumePoint(ctx, o.checkpoints, OrchestrationLoop, event, currentState)
+	if err != nil {
+		return AgentResult{}, err
+	}
 
 	// Execute agent in loop
-	for i := 0; i < o.maxIterations; i++ {
+	tracker := newLoopTracker(ctx, o.conditions)
+	for i := start; i < o.maxIterations; i++ {
 		Logger().Debug().
 			Str("agent", o.agentName).
 			Int("iteration", i+1).
 			Int("max_iterations", o.maxIterations).
 			Msg("LoopOrchestrator: Executing agent iteration")
 
-		result, err := handler.Run(ctx, event, state)
+		// The handler gets a copy so convergence checks can compare against the state it started from
+		result, err := runAgentHandler(ctx, o.agentName, handler, event, state.Clone())
 		if err != nil {
+			saveCheckpoint(ctx, o.checkpoints, OrchestrationLoop, event, i, o.agentName, state, false, err)
 			return AgentResult{}, fmt.Errorf("loop agent %s (iteration %d) failed: %w", o.agentName, i+1, err)
 		}
 
@@ -890,12 +988,31 @@ func (o *loopOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResu
 					Str("agent", o.agentName).
 					Int("iteration", i+1).
 					Msg("LoopOrchestrator: Agent signaled completion")
-				return AgentResult{OutputState: result.OutputState}, nil
+				saveCheckpoint(ctx, o.checkpoints, OrchestrationLoop, event, i+1, o.agentName, result.OutputState, true, nil)
+				return AgentResult{OutputState: finishLoop(result.OutputState, i+1, "loop_completed")}, nil
 			}
 		}
 
 		// Pass output state to next iteration
+		previous := state
 		state = result.OutputState
+
+		// Check termination conditions, which may annotate state for the next iteration
+		reason, err := tracker.check(ctx, i+1, previous, state)
+		if err != nil {
+			saveCheckpoint(ctx, o.checkpoints, OrchestrationLoop, event, i+1, o.agentName, state, false, err)
+			return AgentResult{}, fmt.Errorf("loop agent %s (iteration %d): termination check failed: %w", o.agentName, i+1, err)
+		}
+		if reason != "" {
+			Logger().Info().
+				Str("agent", o.agentName).
+				Int("iteration", i+1).
+				Str("reason", reason).
+				Msg("LoopOrchestrator: Termination condition met")
+			saveCheckpoint(ctx, o.checkpoints, OrchestrationLoop, event, i+1, o.agentName, state, true, nil)
+			return AgentResult{OutputState: finishLoop(state, i+1, reason)}, nil
+		}
+		saveCheckpoint(ctx, o.checkpoints, OrchestrationLoop, event, i+1, o.agentName, state, i+1 == o.maxIterations, nil)
 	}
 
 	Logger().Info().
@@ -903,7 +1020,21 @@ func (o *loopOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResu
 		Int("iterations", o.maxIterations).
 		Msg("LoopOrchestrator: Completed all iterations")
 
-	return AgentResult{OutputState: state}, nil
+	return AgentResult{OutputState: finishLoop(state, o.maxIterations, "max_iterations")}, nil
+}
+
+// SetCheckpointStore enables checkpointing after each loop iteration
+func (o *loopOrchestrator) SetCheckpointStore(store CheckpointStore) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.checkpoints = store
+}
+
+// CheckpointStore returns the checkpoint store, or nil when checkpointing is disabled
+func (o *loopOrchestrator) CheckpointStore() CheckpointStore {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.checkpoints
 }
 
 // GetCallbackRegistry returns the callback registry
@@ -918,3 +1049,42 @@ func (o *loopOrchestrator) Stop() {
 	o.handlers = make(map[string]AgentHandler)
 	Logger().Debug().Msg("LoopOrchestrator: Stopped")
 }
+
+// =============================================================================
+// WORKFLOW HELPERS
+// =============================================================================
+
+// dispatchOutsideWorkflow sends an event routed to a registered agent that is not part of
+// the workflow, such as error-handler, straight to that agent instead of running the
+// workflow again. handled reports whether the event was dispatched.
+func dispatchOutsideWorkflow(ctx context.Context, handlers map[string]AgentHandler, workflowAgents []string, event Event, state State) (AgentResult, bool, error) {
+	route, _ := event.GetMetadataValue(RouteMetadataKey)
+	if route == "" {
+		return AgentResult{}, false, nil
+	}
+	for _, name := range workflowAgents {
+		if name == route {
+			return AgentResult{}, false, nil
+		}
+	}
+	handler, exists := handlers[route]
+	if !exists {
+		return AgentResult{}, false, nil
+	}
+
+	result, err := runAgentHandler(ctx, route, handler, event, state)
+	return result, true, err
+}
+
+// finishWorkflow clears the route on the final state of a workflow, so the runner does
+// not emit a follow-up event that would start the workflow over.
+func finishWorkflow(state State) State {
+	if state == nil {
+		return NewState()
+	}
+	if route, ok := state.GetMeta(RouteMetadataKey); ok && route != "" {
+		state = state.Clone()
+		state.SetMeta(RouteMetadataKey, "")
+	}
+	return state
+}
@@ -135,7 +135,7 @@ func (o *RouteOrchestrator) Dispatch(ctx context.Context, event Event) (AgentRes
 		Str("event_id", event.GetID()).
 		Interface("state_keys", currentState.Keys()).
 		Msg("RouteOrchestrator: Running agent")
-	agentResult, agentErr = handler.Run(ctx, event, currentState)
+	agentResult, agentErr = runAgentHandler(ctx, targetName, handler, event, currentState)
 
 	// 3. Invoke AfterAgentRun hooks (always, even on error)
 	if o.registry != nil {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,6 +17,15 @@ type Runner interface {
 	// Emit sends an event into the processing pipeline.
 	Emit(event Event) error
 
+	// Run emits an event and waits until its session settles: the event and every follow-up
+	// event it causes in that session have been processed. It returns the last successful
+	// agent result, and the error of the last agent that failed along the way, if any.
+	Run(ctx context.Context, event Event) (AgentResult, error)
+
+	// RunAsync is like Run but returns immediately. The returned channel receives exactly
+	// one RunResult and is then closed.
+	RunAsync(ctx context.Context, event Event) <-chan RunResult
+
 	// RegisterAgent associates an agent name with a handler responsible for invoking it.
 	RegisterAgent(name string, handler AgentHandler) error
 
@@ -41,14 +51,31 @@ type Runner interface {
 	DumpTrace(sessionID string) ([]TraceEntry, error)
 }
 
+// Resumer is implemented by runners that can continue a checkpointed workflow. RunnerImpl
+// implements it; check for it with a type assertion on a Runner.
+type Resumer interface {
+	// Resume continues the checkpointed workflow of a session from its last completed step
+	// and waits for it like Run. The orchestrator must be a CheckpointingOrchestrator with a
+	// checkpoint store.
+	Resume(ctx context.Context, sessionID string) (AgentResult, error)
+}
+
+var _ Resumer = (*RunnerImpl)(nil)
+
 // RunnerImpl implements the Runner interface.
 type RunnerImpl struct {
-	queue             chan Event
+	queue             EventQueue
 	orchestrator      Orchestrator
 	registry          *CallbackRegistry
 	traceLogger       TraceLogger
 	tracer            trace.Tracer
 	errorRouterConfig *ErrorRouterConfig
+	usageLedger       *UsageLedger
+	maxConcurrency    int
+	maxQueued         int
+	sessions          *sessionQueues
+	tracker           *sessionTracker
+	scheduler         *scheduler
 
 	stopOnce sync.Once
 	stopChan chan struct{}
@@ -57,16 +84,92 @@ type RunnerImpl struct {
 	started  bool
 }
 
-// NewRunner creates a new RunnerImpl.
+// NewRunner creates a new RunnerImpl. Its queue is a PriorityEventQueue holding at most
+// queueSize events.
 func NewRunner(queueSize int) *RunnerImpl {
 	if queueSize <= 0 {
 		queueSize = 100
 	}
-	return &RunnerImpl{
-		queue:    make(chan Event, queueSize),
-		stopChan: make(chan struct{}),
-		registry: NewCallbackRegistry(),
+	r := &RunnerImpl{
+		queue:          NewPriorityEventQueue(queueSize),
+		stopChan:       make(chan struct{}),
+		registry:       NewCallbackRegistry(),
+		maxConcurrency: 1,
+		sessions:       newSessionQueues(),
+		tracker:        newSessionTracker(),
+	}
+	r.scheduler = newScheduler(r)
+	return r
+}
+
+// SetEventQueue replaces the runner's queue, e.g. with a durable FileEventQueue. Events
+// left unacknowledged in a durable queue are replayed when the runner starts.
+func (r *RunnerImpl) SetEventQueue(queue EventQueue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		Logger().Warn().Msg("Attempted to set event queue while runner is running.")
+		return
+	}
+	r.queue = queue
+}
+
+// AddSchedule registers a schedule on which the runner emits events while it is running.
+// Each event is routed to the schedule's agent in a fresh session.
+func (r *RunnerImpl) AddSchedule(schedule Schedule) error {
+	return r.scheduler.add(schedule)
+}
+
+// RemoveSchedule stops a schedule. Runs already emitted are not interrupted.
+func (r *RunnerImpl) RemoveSchedule(name string) {
+	r.scheduler.remove(name)
+}
+
+// SetScheduleStore replaces the store remembering when each schedule last fired, e.g.
+// with a FileScheduleStore so runs missed while the process was down are caught up.
+func (r *RunnerImpl) SetScheduleStore(store ScheduleStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		Logger().Warn().Msg("Attempted to set schedule store while runner is running.")
+		return
+	}
+	r.scheduler.setStore(store)
+}
+
+// SetMaxConcurrency sets how many events the runner processes at once. Events of different
+// sessions run in parallel up to this limit; events of the same session always run one at a
+// time, in order. Values below 1 are treated as 1.
+func (r *RunnerImpl) SetMaxConcurrency(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		Logger().Warn().Msg("Attempted to set max concurrency while runner is running.")
+		return
+	}
+	if n < 1 {
+		n = 1
 	}
+	r.maxConcurrency = n
+}
+
+// MaxConcurrency returns the maximum number of events processed at once.
+func (r *RunnerImpl) MaxConcurrency() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.maxConcurrency
+}
+
+// SetMaxQueuedPerSession limits how many events a session may have queued or in flight.
+// Follow-up events the runner emits count towards the limit. Emitting beyond it fails with
+// ErrSessionQueueFull, so one busy session cannot fill the queue. 0 means no limit.
+func (r *RunnerImpl) SetMaxQueuedPerSession(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	r.maxQueued = n
 }
 
 // SetCallbackRegistry assigns the callback registry to the runner.
@@ -109,6 +212,25 @@ func (r *RunnerImpl) SetErrorRouterConfig(config *ErrorRouterConfig) {
 	r.errorRouterConfig = config
 }
 
+// SetUsageLedger assigns the ledger that model usage is charged to. Each event's model calls
+// are recorded under the event's session, and events for sessions over budget are dropped.
+func (r *RunnerImpl) SetUsageLedger(ledger *UsageLedger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		Logger().Warn().Msg("Attempted to set usage ledger while runner is running.")
+		return
+	}
+	r.usageLedger = ledger
+}
+
+// UsageLedger returns the runner's usage ledger, or nil if usage is not being tracked.
+func (r *RunnerImpl) UsageLedger() *UsageLedger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.usageLedger
+}
+
 // getErrorRouterConfig returns the error router configuration or default if not set.
 func (r *RunnerImpl) getErrorRouterConfig() *ErrorRouterConfig {
 	r.mu.RLock()
@@ -169,23 +291,136 @@ func (r *RunnerImpl) Emit(event Event) error {
 	}
 	r.mu.RUnlock()
 
+	_, err := r.emit(event)
+	return err
+}
+
+// emit queues an event and returns the settle signal of its session.
+func (r *RunnerImpl) emit(event Event) (*sessionSettle, error) {
+	sessionID, _ := event.GetMetadataValue(SessionIDKey)
+	if sessionID == "" {
+		sessionID = event.GetID()
+		Logger().Warn().Str("event_id", event.GetID()).Msg("Emit: Warning - event missing session ID, using event ID as fallback.")
+		event.SetMetadata(SessionIDKey, sessionID)
+	}
+
 	Logger().Debug().Str("event_id", event.GetID()).Msg("Emit attempting to queue event")
 
 	timeout := 1 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	select {
-	case r.queue <- event:
-		Logger().Debug().Str("event_id", event.GetID()).Msg("Emit successfully queued event")
-		return nil
-	case <-ctx.Done():
-		Logger().Debug().Str("event_id", event.GetID()).Msg("Emit timed out")
-		return fmt.Errorf("failed to emit event: queue full or blocked")
-	case <-r.stopChan:
-		Logger().Debug().Str("event_id", event.GetID()).Msg("Emit failed: runner stopped while waiting to queue")
-		return errors.New("runner stopped while emitting")
+	stopChan := r.stopChan
+	go func() {
+		select {
+		case <-stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	r.mu.RLock()
+	maxQueued := r.maxQueued
+	r.mu.RUnlock()
+
+	// Count the event before queuing it so its session cannot settle before it is processed
+	settle, ok := r.tracker.addLimited(sessionID, maxQueued)
+	if !ok {
+		Logger().Warn().Str("event_id", event.GetID()).Str("session_id", sessionID).Int("limit", maxQueued).Msg("Emit rejected: session has too many queued events")
+		return nil, fmt.Errorf("failed to emit event for session %s: %w", sessionID, ErrSessionQueueFull)
+	}
+	if err := r.queue.Push(ctx, event); err != nil {
+		r.tracker.remove(sessionID, 1)
+		select {
+		case <-stopChan:
+			Logger().Debug().Str("event_id", event.GetID()).Msg("Emit failed: runner stopped while waiting to queue")
+			return nil, errors.New("runner stopped while emitting")
+		default:
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			Logger().Debug().Str("event_id", event.GetID()).Msg("Emit timed out")
+			return nil, fmt.Errorf("failed to emit event: queue full or blocked")
+		}
+		Logger().Error().Str("event_id", event.GetID()).Err(err).Msg("Emit failed to queue event")
+		return nil, fmt.Errorf("failed to emit event: %w", err)
+	}
+	Logger().Debug().Str("event_id", event.GetID()).Msg("Emit successfully queued event")
+	return settle, nil
+}
+
+// RunResult is the outcome of a RunAsync call.
+type RunResult struct {
+	Result AgentResult
+	Err    error
+}
+
+// Run implements Runner.
+func (r *RunnerImpl) Run(ctx context.Context, event Event) (AgentResult, error) {
+	result := <-r.RunAsync(ctx, event)
+	return result.Result, result.Err
+}
+
+// Resume implements Resumer. The event that started the workflow is emitted again, marked
+// with ResumeMetadataKey so the orchestrator skips the steps already completed.
+func (r *RunnerImpl) Resume(ctx context.Context, sessionID string) (AgentResult, error) {
+	r.mu.RLock()
+	orchestrator := r.orchestrator
+	r.mu.RUnlock()
+
+	checkpointing, ok := orchestrator.(CheckpointingOrchestrator)
+	if !ok || checkpointing.CheckpointStore() == nil {
+		return AgentResult{}, errors.New("cannot resume: orchestrator has no checkpoint store")
+	}
+	checkpoint, err := checkpointing.CheckpointStore().Load(ctx, sessionID)
+	if err != nil {
+		return AgentResult{}, fmt.Errorf("cannot resume session %s: %w", sessionID, err)
+	}
+	if checkpoint.Completed {
+		return AgentResult{OutputState: checkpoint.State}, fmt.Errorf("cannot resume session %s: %w", sessionID, ErrCheckpointCompleted)
+	}
+	if checkpoint.Event == nil {
+		return AgentResult{}, fmt.Errorf("cannot resume session %s: checkpoint has no event", sessionID)
 	}
+
+	event := NewEvent(checkpoint.Event.GetTargetAgentID(), checkpoint.Event.GetData(), checkpoint.Event.GetMetadata())
+	event.SetSourceAgentID(checkpoint.Event.GetSourceAgentID())
+	event.SetMetadata(SessionIDKey, sessionID)
+	event.SetMetadata(ResumeMetadataKey, "true")
+	return r.Run(ctx, event)
+}
+
+// RunAsync implements Runner. Waiting ends early with ctx's error if ctx is done, or with
+// ErrRunnerStopped if the runner stops first. Concurrent runs in the same session share
+// the session's outcome.
+func (r *RunnerImpl) RunAsync(ctx context.Context, event Event) <-chan RunResult {
+	out := make(chan RunResult, 1)
+
+	r.mu.RLock()
+	started := r.started
+	r.mu.RUnlock()
+	if !started {
+		out <- RunResult{Err: errors.New("runner is not running")}
+		close(out)
+		return out
+	}
+
+	settle, err := r.emit(event)
+	if err != nil {
+		out <- RunResult{Err: err}
+		close(out)
+		return out
+	}
+
+	go func() {
+		defer close(out)
+		select {
+		case <-settle.done:
+			out <- RunResult{Result: settle.result, Err: settle.err}
+		case <-ctx.Done():
+			out <- RunResult{Err: ctx.Err()}
+		}
+	}()
+	return out
 }
 
 // Start begins the runner's event processing loop in a separate goroutine.
@@ -199,13 +434,28 @@ func (r *RunnerImpl) Start(ctx context.Context) error {
 		r.mu.Unlock()
 		return errors.New("orchestrator must be set before starting runner")
 	}
+	recovered, err := r.queue.Recover()
+	if err != nil {
+		r.mu.Unlock()
+		return fmt.Errorf("failed to recover queued events: %w", err)
+	}
+	for _, event := range recovered {
+		sessionID, _ := event.GetMetadataValue(SessionIDKey)
+		r.tracker.add(sessionID)
+	}
 	r.started = true
 	r.stopChan = make(chan struct{})
+	r.sessions = newSessionQueues()
 	r.wg.Add(1)
 	r.mu.Unlock()
 
+	if len(recovered) > 0 {
+		Logger().Info().Int("events", len(recovered)).Msg("Runner: Replaying unacknowledged events from the queue.")
+	}
+
 	Logger().Info().Msg("Runner started.")
 	go r.loop(ctx)
+	r.scheduler.start(ctx)
 	return nil
 }
 
@@ -225,6 +475,7 @@ func (r *RunnerImpl) Stop() {
 	Logger().Debug().Msg("Runner Stop: stopChan closed.")
 
 	r.mu.Unlock()
+	r.scheduler.stop()
 	Logger().Debug().Msg("Runner Stop: Released lock, waiting for loop goroutine (wg.Wait)...")
 
 	r.wg.Wait()
@@ -240,143 +491,255 @@ func (r *RunnerImpl) Stop() {
 	Logger().Info().Msg("Runner Stop: Completed.")
 }
 
-// loop is the main event processing goroutine.
+// loop is the main event processing goroutine. It dispatches events to a pool of at most
+// maxConcurrency workers: events of different sessions are processed in parallel, while the
+// events of one session are processed one at a time, in the order they were queued. Events
+// are only popped once a worker is free, so the queue decides which session runs next.
 func (r *RunnerImpl) loop(ctx context.Context) {
 	defer r.wg.Done()
+
+	var workers sync.WaitGroup
+	defer func() {
+		workers.Wait()
+		// Events still queued will not be processed; release anyone waiting on them
+		r.tracker.abort(ErrRunnerStopped)
+	}()
+	slots := make(chan struct{}, r.MaxConcurrency())
+
+	// Popping is interrupted when the runner stops as well as when ctx is done
+	popCtx, cancelPop := context.WithCancel(ctx)
+	defer cancelPop()
+	go func() {
+		select {
+		case <-r.stopChan:
+			cancelPop()
+		case <-popCtx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-popCtx.Done():
+			Logger().Debug().Msg("Runner loop: Stopped while waiting for a worker. Exiting.")
+			return
+		}
+
+		event, err := r.queue.Pop(popCtx)
+		if err != nil {
+			<-slots
+			switch {
+			case ctx.Err() != nil:
+				Logger().Debug().Msg("Runner loop: Context cancelled. Exiting.")
+			case popCtx.Err() != nil:
+				Logger().Debug().Msg("Runner loop: Stop signal received. Exiting.")
+			default:
+				Logger().Error().Err(err).Msg("Runner loop: Event queue failed. Exiting.")
+			}
+			return
+		}
+
+		sessionID, _ := event.GetMetadataValue(SessionIDKey) // Set by Emit
+		if !r.sessions.enqueue(sessionID, event) {
+			Logger().Debug().Str("event_id", event.GetID()).Str("session_id", sessionID).Msg("Runner loop: Session busy, event queued behind it")
+			<-slots
+			continue
+		}
+
+		// The session was idle: start a worker for it in the reserved slot
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer func() { <-slots }()
+			r.runSession(ctx, sessionID)
+		}()
+	}
+}
+
+// runSession processes the queued events of a session in order until none are left,
+// or until the runner stops.
+func (r *RunnerImpl) runSession(ctx context.Context, sessionID string) {
 	for {
 		select {
 		case <-ctx.Done():
-			Logger().Debug().Msg("Runner loop: Context cancelled. Exiting.")
+			r.sessions.clear(sessionID)
 			return
 		case <-r.stopChan:
-			Logger().Debug().Msg("Runner loop: Stop signal received. Exiting.")
+			r.sessions.clear(sessionID)
 			return
-		case event := <-r.queue:
-			eventCtx, eventCancel := context.WithCancel(ctx)
-			defer eventCancel()
-
-			sessionID, _ := event.GetMetadataValue(SessionIDKey)
-			if sessionID == "" {
-				sessionID = event.GetID()
-				Logger().Warn().Str("event_id", event.GetID()).Msg("Runner loop: Warning - event missing session ID, using event ID as fallback.")
-				event.SetMetadata(SessionIDKey, sessionID)
-			}
-			Logger().Debug().Str("event_id", event.GetID()).Str("session_id", sessionID).Msg("Runner loop: Processing event")
+		default:
+		}
+
+		event, ok := r.sessions.next(sessionID)
+		if !ok {
+			return
+		}
+		result, err := r.processEvent(ctx, event, sessionID)
+		if ackErr := r.queue.Ack(event); ackErr != nil {
+			Logger().Error().Str("event_id", event.GetID()).Err(ackErr).Msg("Runner: Failed to acknowledge event")
+		}
+		r.tracker.done(sessionID, result, err)
+	}
+}
 
-			var currentState State = NewState()
+// processEvent runs the callbacks and orchestrator for a single event and returns the
+// agent's result, with the final state of the event as its OutputState.
+func (r *RunnerImpl) processEvent(ctx context.Context, event Event, sessionID string) (AgentResult, error) {
+	eventCtx, eventCancel := context.WithCancel(ctx)
+	defer eventCancel()
+	eventCtx = withAgentTokenSink(eventCtx, r.emitAgentToken)
 
-			if r.registry != nil {
-				Logger().Debug().Msg("Runner: Invoking BeforeEventHandling callbacks")
-				callbackArgs := CallbackArgs{
-					Hook:    HookBeforeEventHandling,
-					Event:   event,
-					State:   currentState,
-					AgentID: "",
-				}
-				newState, err := r.registry.Invoke(eventCtx, callbackArgs)
-				if err != nil {
-					Logger().Error().Str("event_id", event.GetID()).Err(err).Msg("Runner loop: Error during BeforeEventHandling callbacks. Skipping event.")
-					continue
-				}
-				if newState != nil {
-					currentState = newState
-				}
-				Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook BeforeEventHandling.")
-			}
+	Logger().Debug().Str("event_id", event.GetID()).Str("session_id", sessionID).Msg("Runner loop: Processing event")
 
-			var agentResult AgentResult
-			var agentErr error
-			var invokedAgentID string
+	if ledger := r.UsageLedger(); ledger != nil {
+		if err := ledger.CheckBudget(sessionID); err != nil {
+			Logger().Error().Str("event_id", event.GetID()).Str("session_id", sessionID).Err(err).Msg("Runner loop: Session is over its usage budget. Dropping event.")
+			r.traceBudgetExceeded(event, sessionID, err)
+			return AgentResult{}, err
+		}
+		eventCtx = WithUsageLedger(eventCtx, ledger, sessionID)
+	}
 
-			r.mu.RLock()
-			orchestrator := r.orchestrator
-			r.mu.RUnlock()
+	var currentState State = NewState()
 
-			if orchestrator != nil {
-				targetAgentID := "unknown"
-				if routeKey, ok := event.GetMetadataValue(RouteMetadataKey); ok {
-					targetAgentID = routeKey
-				} else if event.GetTargetAgentID() != "" {
-					targetAgentID = event.GetTargetAgentID()
-				}
-				invokedAgentID = targetAgentID
-
-				if r.registry != nil {
-					Logger().Debug().Str("agent_id", invokedAgentID).Msgf("Runner: Invoking %s callbacks", HookBeforeAgentRun)
-					callbackArgs := CallbackArgs{
-						Hook:    HookBeforeAgentRun,
-						Event:   event,
-						State:   currentState,
-						AgentID: invokedAgentID,
-					}
-					newState, err := r.registry.Invoke(eventCtx, callbackArgs)
-					if err != nil {
-						Logger().Error().Str("event_id", event.GetID()).Str("agent_id", invokedAgentID).Err(err).Msg("Runner loop: Error during BeforeAgentRun callbacks")
-						agentErr = fmt.Errorf("BeforeAgentRun callback failed: %w", err)
-					} else {
-						if newState != nil {
-							currentState = newState
-						}
-						Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook BeforeAgentRun.")
-					}
-				}
+	if r.registry != nil {
+		Logger().Debug().Msg("Runner: Invoking BeforeEventHandling callbacks")
+		callbackArgs := CallbackArgs{
+			Hook:    HookBeforeEventHandling,
+			Event:   event,
+			State:   currentState,
+			AgentID: "",
+		}
+		newState, err := r.registry.Invoke(eventCtx, callbackArgs)
+		if err != nil {
+			Logger().Error().Str("event_id", event.GetID()).Err(err).Msg("Runner loop: Error during BeforeEventHandling callbacks. Skipping event.")
+			return AgentResult{}, fmt.Errorf("BeforeEventHandling callback failed: %w", err)
+		}
+		if newState != nil {
+			currentState = newState
+		}
+		Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook BeforeEventHandling.")
+	}
 
-				if agentErr == nil {
-					Logger().Debug().Str("event_id", event.GetID()).Msg("Runner loop: Dispatching event to orchestrator")
-					agentResult, agentErr = orchestrator.Dispatch(eventCtx, event)
-				}
+	var agentResult AgentResult
+	var agentErr error
+	var invokedAgentID string
 
-				if agentErr != nil {
-					Logger().Error().Str("event_id", event.GetID()).Err(agentErr).Msg("Runner loop: Error during agent execution/dispatch")
-					if r.registry != nil {
-						Logger().Debug().Str("agent_id", invokedAgentID).Msgf("Runner: Invoking %s callbacks", HookAgentError)
-						callbackArgs := CallbackArgs{
-							Hook:    HookAgentError,
-							Event:   event,
-							AgentID: invokedAgentID,
-							Error:   agentErr,
-							State:   currentState,
-						}
-						newState, cbErr := r.registry.Invoke(eventCtx, callbackArgs)
-						if cbErr != nil {
-							Logger().Error().Str("event_id", event.GetID()).Err(cbErr).Msg("Runner loop: Error during AgentError callback")
-						}
-						if newState != nil {
-							currentState = newState
-						}
-						Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook AgentError.")
-					}
-				}
+	r.mu.RLock()
+	orchestrator := r.orchestrator
+	r.mu.RUnlock()
+
+	if orchestrator != nil {
+		targetAgentID := "unknown"
+		if routeKey, ok := event.GetMetadataValue(RouteMetadataKey); ok {
+			targetAgentID = routeKey
+		} else if event.GetTargetAgentID() != "" {
+			targetAgentID = event.GetTargetAgentID()
+		}
+		invokedAgentID = targetAgentID
+
+		if r.registry != nil {
+			Logger().Debug().Str("agent_id", invokedAgentID).Msgf("Runner: Invoking %s callbacks", HookBeforeAgentRun)
+			callbackArgs := CallbackArgs{
+				Hook:    HookBeforeAgentRun,
+				Event:   event,
+				State:   currentState,
+				AgentID: invokedAgentID,
+			}
+			newState, err := r.registry.Invoke(eventCtx, callbackArgs)
+			if err != nil {
+				Logger().Error().Str("event_id", event.GetID()).Str("agent_id", invokedAgentID).Err(err).Msg("Runner loop: Error during BeforeAgentRun callbacks")
+				agentErr = fmt.Errorf("BeforeAgentRun callback failed: %w", err)
 			} else {
-				Logger().Error().Str("event_id", event.GetID()).Msg("Runner loop: Orchestrator is nil, cannot dispatch event")
-				agentErr = errors.New("orchestrator not configured")
-				invokedAgentID = "orchestrator"
+				if newState != nil {
+					currentState = newState
+				}
+				Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook BeforeAgentRun.")
 			}
+		}
 
-			r.processAgentResult(eventCtx, event, agentResult, agentErr, invokedAgentID)
+		if agentErr == nil {
+			Logger().Debug().Str("event_id", event.GetID()).Msg("Runner loop: Dispatching event to orchestrator")
+			agentResult, agentErr = orchestrator.Dispatch(eventCtx, event)
+		}
 
+		if agentErr != nil {
+			Logger().Error().Str("event_id", event.GetID()).Err(agentErr).Msg("Runner loop: Error during agent execution/dispatch")
 			if r.registry != nil {
-				Logger().Debug().Msg("Runner: Invoking AfterEventHandling callbacks")
-				finalStateForEvent := currentState
-				if agentErr == nil && agentResult.OutputState != nil {
-					finalStateForEvent = agentResult.OutputState
-				}
+				Logger().Debug().Str("agent_id", invokedAgentID).Msgf("Runner: Invoking %s callbacks", HookAgentError)
 				callbackArgs := CallbackArgs{
-					Hook:    HookAfterEventHandling,
+					Hook:    HookAgentError,
 					Event:   event,
-					State:   finalStateForEvent,
 					AgentID: invokedAgentID,
 					Error:   agentErr,
+					State:   currentState,
 				}
-				_, cbErr := r.registry.Invoke(eventCtx, callbackArgs)
+				newState, cbErr := r.registry.Invoke(eventCtx, callbackArgs)
 				if cbErr != nil {
-					Logger().Error().Str("event_id", event.GetID()).Err(cbErr).Msg("Runner loop: Error during AfterEventHandling callbacks")
+					Logger().Error().Str("event_id", event.GetID()).Err(cbErr).Msg("Runner loop: Error during AgentError callback")
+				}
+				if newState != nil {
+					currentState = newState
 				}
-				Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook AfterEventHandling.")
+				Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook AgentError.")
 			}
+		}
+	} else {
+		Logger().Error().Str("event_id", event.GetID()).Msg("Runner loop: Orchestrator is nil, cannot dispatch event")
+		agentErr = errors.New("orchestrator not configured")
+		invokedAgentID = "orchestrator"
+	}
+
+	r.processAgentResult(eventCtx, event, agentResult, agentErr, invokedAgentID)
 
-			Logger().Debug().Str("event_id", event.GetID()).Msg("Runner loop finished processing event")
+	finalStateForEvent := currentState
+	if agentErr == nil && agentResult.OutputState != nil {
+		finalStateForEvent = agentResult.OutputState
+	}
+	if r.registry != nil {
+		Logger().Debug().Msg("Runner: Invoking AfterEventHandling callbacks")
+		callbackArgs := CallbackArgs{
+			Hook:    HookAfterEventHandling,
+			Event:   event,
+			State:   finalStateForEvent,
+			AgentID: invokedAgentID,
+			Error:   agentErr,
+		}
+		_, cbErr := r.registry.Invoke(eventCtx, callbackArgs)
+		if cbErr != nil {
+			Logger().Error().Str("event_id", event.GetID()).Err(cbErr).Msg("Runner loop: Error during AfterEventHandling callbacks")
 		}
+		Logger().Debug().Msg("CallbackRegistry.Invoke: Finished invoking callbacks for hook AfterEventHandling.")
+	}
+
+	Logger().Debug().Str("event_id", event.GetID()).Msg("Runner loop finished processing event")
+	if agentErr != nil {
+		return agentResult, agentErr
+	}
+	agentResult.OutputState = finalStateForEvent
+	return agentResult, nil
+}
+
+// emitAgentToken forwards partial output from a streaming agent to HookAgentToken callbacks.
+// States returned by token callbacks are ignored; the agent's own result remains authoritative.
+func (r *RunnerImpl) emitAgentToken(ctx context.Context, event Event, state State, agentID string, token Token) {
+	r.mu.RLock()
+	registry := r.registry
+	r.mu.RUnlock()
+	if registry == nil {
+		return
+	}
+
+	callbackArgs := CallbackArgs{
+		Ctx:     ctx,
+		Hook:    HookAgentToken,
+		Event:   event,
+		State:   state,
+		AgentID: agentID,
+		Token:   token,
+	}
+	if _, err := registry.Invoke(ctx, callbackArgs); err != nil {
+		Logger().Error().Str("event_id", event.GetID()).Str("agent_id", agentID).Err(err).Msg("Runner: Error during AgentToken callbacks")
 	}
 }
 
@@ -394,6 +757,11 @@ func (r *RunnerImpl) processAgentResult(ctx context.Context, originalEvent Event
 		// Use enhanced error routing system
 		errorRouterConfig := r.getErrorRouterConfig()
 		failureEvent := CreateEnhancedErrorEvent(originalEvent, agentID, agentErr, errorRouterConfig)
+		if _, ok := failureEvent.GetMetadataValue(PriorityKey); !ok {
+			if priority, ok := originalEvent.GetMetadataValue(PriorityKey); ok {
+				failureEvent.SetMetadata(PriorityKey, priority)
+			}
+		}
 
 		if err := r.Emit(failureEvent); err != nil {
 			Logger().Error().
@@ -426,6 +794,11 @@ func (r *RunnerImpl) processAgentResult(ctx context.Context, originalEvent Event
 				}
 				successMeta[SessionIDKey] = sessionID
 				successMeta["status"] = "success"
+				if _, ok := successMeta[PriorityKey]; !ok {
+					if priority, ok := originalEvent.GetMetadataValue(PriorityKey); ok {
+						successMeta[PriorityKey] = priority
+					}
+				}
 
 				successEvent := NewEvent(
 					route,
@@ -454,16 +827,195 @@ func (r *RunnerImpl) processAgentResult(ctx context.Context, originalEvent Event
 	}
 }
 
-// DumpTrace retrieves trace entries.
+// sessionQueues holds the events waiting behind the event a worker is processing, per session.
+// A session is present while a worker owns it.
+type sessionQueues struct {
+	mu      sync.Mutex
+	pending map[string][]Event
+}
+
+func newSessionQueues() *sessionQueues {
+	return &sessionQueues{pending: make(map[string][]Event)}
+}
+
+// enqueue adds an event to its session and reports whether the session was idle,
+// in which case the caller must start a worker for it.
+func (q *sessionQueues) enqueue(sessionID string, event Event) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events, busy := q.pending[sessionID]
+	q.pending[sessionID] = append(events, event)
+	return !busy
+}
+
+// next pops the session's oldest event. When none are left the session becomes idle.
+func (q *sessionQueues) next(sessionID string) (Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events := q.pending[sessionID]
+	if len(events) == 0 {
+		delete(q.pending, sessionID)
+		return nil, false
+	}
+	q.pending[sessionID] = events[1:]
+	return events[0], true
+}
+
+// clear drops the session's remaining events and marks it idle.
+func (q *sessionQueues) clear(sessionID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, sessionID)
+}
+
+// ErrRunnerStopped is returned to callers waiting on a run when the runner stops first.
+var ErrRunnerStopped = errors.New("runner stopped")
+
+// sessionTracker counts the events each session has queued or in flight, so that Run can
+// wait for a session to settle. Follow-up events are emitted, and counted, before the event
+// that caused them is marked done, so the count only reaches zero once a chain is complete.
+type sessionTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*trackedSession
+}
+
+// trackedSession is a session with outstanding events.
+type trackedSession struct {
+	outstanding int
+	settle      *sessionSettle
+}
+
+// sessionSettle is closed when a session has no outstanding events left, and then carries
+// the outcome of the events processed since it became busy.
+type sessionSettle struct {
+	done   chan struct{}
+	result AgentResult
+	err    error
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{sessions: make(map[string]*trackedSession)}
+}
+
+// add counts a new event for the session and returns the signal for its current chain.
+func (t *sessionTracker) add(sessionID string) *sessionSettle {
+	settle, _ := t.addLimited(sessionID, 0)
+	return settle
+}
+
+// addLimited is like add but refuses the event, returning false, when the session already
+// has limit outstanding events. A limit of 0 means no limit.
+func (t *sessionTracker) addLimited(sessionID string, limit int) (*sessionSettle, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	session, ok := t.sessions[sessionID]
+	if !ok {
+		session = &trackedSession{settle: &sessionSettle{done: make(chan struct{})}}
+		t.sessions[sessionID] = session
+	} else if limit > 0 && session.outstanding >= limit {
+		return nil, false
+	}
+	session.outstanding++
+	return session.settle, true
+}
+
+// remove uncounts events that were never queued.
+func (t *sessionTracker) remove(sessionID string, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(sessionID, n)
+}
+
+// done records the outcome of a processed event.
+func (t *sessionTracker) done(sessionID string, result AgentResult, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if session, ok := t.sessions[sessionID]; ok {
+		if err != nil {
+			session.settle.err = err
+		} else {
+			session.settle.result = result
+		}
+	}
+	t.release(sessionID, 1)
+}
+
+// release must be called with t.mu held.
+func (t *sessionTracker) release(sessionID string, n int) {
+	session, ok := t.sessions[sessionID]
+	if !ok {
+		return
+	}
+	session.outstanding -= n
+	if session.outstanding <= 0 {
+		delete(t.sessions, sessionID)
+		close(session.settle.done)
+	}
+}
+
+// abort settles every session with err, for events that will never be processed.
+func (t *sessionTracker) abort(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for sessionID, session := range t.sessions {
+		session.settle.err = err
+		delete(t.sessions, sessionID)
+		close(session.settle.done)
+	}
+}
+
+// traceBudgetExceeded records that an event was dropped because its session is over budget.
+func (r *RunnerImpl) traceBudgetExceeded(event Event, sessionID string, err error) {
+	r.mu.RLock()
+	logger := r.traceLogger
+	r.mu.RUnlock()
+	if logger == nil {
+		return
+	}
+	logger.Log(TraceEntry{
+		Timestamp:     time.Now(),
+		Type:          "budget_exceeded",
+		EventID:       event.GetID(),
+		SessionID:     sessionID,
+		TargetAgentID: event.GetTargetAgentID(),
+		Error:         err.Error(),
+	})
+}
+
+// DumpTrace retrieves trace entries. When the runner has a usage ledger, the session's
+// model calls are merged in as "llm_usage" entries, ordered by timestamp.
 func (r *RunnerImpl) DumpTrace(sessionID string) ([]TraceEntry, error) {
 	r.mu.RLock()
 	logger := r.traceLogger
+	ledger := r.usageLedger
 	r.mu.RUnlock()
 
 	if logger == nil {
 		return nil, errors.New("trace logger is not set")
 	}
-	return logger.GetTrace(sessionID)
+	entries, err := logger.GetTrace(sessionID)
+	if err != nil || ledger == nil {
+		return entries, err
+	}
+
+	records := ledger.Records(sessionID)
+	if len(records) == 0 {
+		return entries, nil
+	}
+	for i := range records {
+		record := records[i]
+		entries = append(entries, TraceEntry{
+			Timestamp: record.Timestamp,
+			Type:      "llm_usage",
+			SessionID: record.SessionID,
+			AgentID:   record.AgentID,
+			Usage:     &record,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
 }
 
 const (
@@ -10,14 +10,18 @@ import (
 // RunnerConfig allows customization but provides sensible defaults.
 // Breaking change: Memory is now required
 type RunnerConfig struct {
-	QueueSize    int
-	Orchestrator Orchestrator
-	Agents       map[string]AgentHandler
-	Memory       Memory      // REQUIRED: Memory is now central to the system
-	SessionID    string      // REQUIRED: Session ID for memory operations
-	TraceLogger  TraceLogger // Optional trace logger
-	ConfigPath   string      // Path to agentflow.toml config file
-	Config       *Config     // Pre-loaded configuration (optional)
+	QueueSize           int
+	MaxConcurrentAgents int // Events processed in parallel across sessions; defaults to [runtime].max_concurrent_agents, or 1 without a config
+	Orchestrator        Orchestrator
+	Agents              map[string]AgentHandler
+	Memory              Memory       // REQUIRED: Memory is now central to the system
+	SessionID           string       // REQUIRED: Session ID for memory operations
+	TraceLogger         TraceLogger  // Optional trace logger
+	UsageLedger         *UsageLedger // Optional usage ledger; built from [usage] in the config when nil
+	EventQueue          EventQueue   // Optional event queue; built from [runtime].queue_type when nil
+	MaxQueuedPerSession int          // Events one session may have queued or in flight; defaults to [runtime].max_queued_per_session, 0 means no limit
+	ConfigPath          string       // Path to agentflow.toml config file
+	Config              *Config      // Pre-loaded configuration (optional)
 }
 
 // NewRunnerWithConfig wires up everything, registers agents, and returns a ready-to-use runner.
@@ -60,6 +64,19 @@ func NewRunnerWithConfig(cfg RunnerConfig) Runner {
 		if cfg.QueueSize <= 0 && config.Runtime.MaxConcurrentAgents > 0 {
 			cfg.QueueSize = config.Runtime.MaxConcurrentAgents
 		}
+		if cfg.MaxConcurrentAgents <= 0 {
+			cfg.MaxConcurrentAgents = config.Runtime.MaxConcurrentAgents
+		}
+		if cfg.MaxQueuedPerSession <= 0 {
+			cfg.MaxQueuedPerSession = config.Runtime.MaxQueuedPerSession
+		}
+		if cfg.EventQueue == nil {
+			queue, err := newEventQueueFromConfig(config, cfg.QueueSize)
+			if err != nil {
+				log.Printf("Warning: Failed to create %s event queue, using in-memory queue: %v", config.Runtime.QueueType, err)
+			}
+			cfg.EventQueue = queue
+		}
 
 		Logger().Info().
 			Str("config_name", config.AgentFlow.Name).
@@ -76,6 +93,15 @@ func NewRunnerWithConfig(cfg RunnerConfig) Runner {
 		queueSize = 10
 	}
 	runner := NewRunner(queueSize)
+	if cfg.MaxConcurrentAgents > 0 {
+		runner.SetMaxConcurrency(cfg.MaxConcurrentAgents)
+	}
+	if cfg.EventQueue != nil {
+		runner.SetEventQueue(cfg.EventQueue)
+	}
+	if cfg.MaxQueuedPerSession > 0 {
+		runner.SetMaxQueuedPerSession(cfg.MaxQueuedPerSession)
+	}
 
 	// Callbacks and tracing
 	callbackRegistry := NewCallbackRegistry()
@@ -92,6 +118,15 @@ func NewRunnerWithConfig(cfg RunnerConfig) Runner {
 	runner.SetTraceLogger(traceLogger)
 	RegisterTraceHooks(callbackRegistry, traceLogger)
 
+	// Usage accounting
+	usageLedger := cfg.UsageLedger
+	if usageLedger == nil && config != nil && config.Usage.Enabled {
+		usageLedger = NewUsageLedger(config.Usage.ledgerConfig())
+	}
+	if usageLedger != nil {
+		runner.SetUsageLedger(usageLedger)
+	}
+
 	// Orchestrator
 	var orch Orchestrator
 	if cfg.Orchestrator != nil {
@@ -135,6 +170,22 @@ func NewRunnerWithConfig(cfg RunnerConfig) Runner {
 	// Automatically configure error routing based on available error handlers
 	configureErrorRouting(runner, cfg.Agents)
 
+	// Scheduled events
+	if config != nil {
+		if store := newScheduleStoreFromConfig(config); store != nil {
+			runner.SetScheduleStore(store)
+		}
+		schedules, err := config.BuildSchedules()
+		if err != nil {
+			log.Printf("Warning: Ignoring [[schedules]]: %v", err)
+		}
+		for _, schedule := range schedules {
+			if err := runner.AddSchedule(schedule); err != nil {
+				log.Printf("Warning: Failed to add schedule %s: %v", schedule.Name, err)
+			}
+		}
+	}
+
 	return runner
 }
 
@@ -149,6 +200,9 @@ func NewRunnerFromConfig(configPath string) (Runner, error) {
 	if err := config.ValidateOrchestrationConfig(); err != nil {
 		return nil, fmt.Errorf("invalid orchestration configuration: %w", err)
 	}
+	if _, err := config.BuildSchedules(); err != nil {
+		return nil, fmt.Errorf("invalid schedules configuration: %w", err)
+	}
 
 	// Auto-initialize memory based on config
 	memory, err := NewMemory(config.AgentMemory)
@@ -156,6 +210,17 @@ func NewRunnerFromConfig(configPath string) (Runner, error) {
 		return nil, fmt.Errorf("failed to initialize memory: %w", err)
 	}
 
+	// LLM rerankers need a model provider, which NewMemory does not create
+	if config.AgentMemory.Search.EnableReranking && config.AgentMemory.Search.Reranker == RerankerLLM {
+		reranker, err := config.InitializeReranker()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize reranker: %w", err)
+		}
+		if rerankable, ok := memory.(RerankableMemory); ok {
+			rerankable.SetReranker(reranker)
+		}
+	}
+
 	// Generate session ID
 	sessionID := GenerateSessionID()
 
@@ -245,6 +310,16 @@ func (m *MemoryAwareAgentHandler) Run(ctx context.Context, event Event, state St
 	return m.handler.Run(ctx, event, state)
 }
 
+// RunStream injects memory like Run and streams through the wrapped handler when it
+// supports streaming, so wrapping an agent does not hide its partial output.
+func (m *MemoryAwareAgentHandler) RunStream(ctx context.Context, event Event, state State, emit TokenEmitter) (AgentResult, error) {
+	ctx = WithMemory(ctx, m.memory, m.sessionID)
+	if streaming, ok := m.handler.(StreamingAgentHandler); ok {
+		return streaming.RunStream(ctx, event, state, emit)
+	}
+	return m.handler.Run(ctx, event, state)
+}
+
 // NewMemoryAwareAgentHandler creates a new memory-aware agent handler wrapper
 func NewMemoryAwareAgentHandler(handler AgentHandler, memory Memory, sessionID string) *MemoryAwareAgentHandler {
 	return &MemoryAwareAgentHandler{
@@ -364,6 +439,18 @@ func createRunnerWithOrchestration(config *Config, memory Memory, sessionID stri
 		// Create mixed runner using orchestration system
 		return createMixedRunnerFromConfig(runnerConfig, orch)
 
+	case "graph":
+		// Create graph runner using orchestration system
+		return createGraphRunnerFromConfig(runnerConfig, orch)
+
+	case "supervisor":
+		// Create supervisor runner using orchestration system
+		return createSupervisorRunnerFromConfig(runnerConfig, config)
+
+	case "map-reduce":
+		// Create map/reduce runner using orchestration system
+		return createMapReduceRunnerFromConfig(runnerConfig, orch)
+
 	default:
 		return nil, fmt.Errorf("unsupported orchestration mode: %s", orch.Mode)
 	}
@@ -394,6 +481,9 @@ func createSequentialRunnerFromConfig(runnerConfig RunnerConfig, orch *Orchestra
 		OrchestrationMode: OrchestrationSequential,
 		SequentialAgents:  orch.SequentialAgents,
 	}
+	if orch.CheckpointDir != "" {
+		enhancedConfig.CheckpointStore = NewFileCheckpointStore(orch.CheckpointDir)
+	}
 	
 	return NewRunnerWithOrchestration(enhancedConfig), nil
 }
@@ -401,10 +491,20 @@ func createSequentialRunnerFromConfig(runnerConfig RunnerConfig, orch *Orchestra
 // createLoopRunnerFromConfig creates a loop runner from configuration
 func createLoopRunnerFromConfig(runnerConfig RunnerConfig, orch *OrchestrationConfigToml) (Runner, error) {
 	// Create enhanced runner config for orchestration
+	conditions, err := loopConditionsFromConfig(runnerConfig.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loop termination: %w", err)
+	}
+
 	enhancedConfig := EnhancedRunnerConfig{
 		RunnerConfig:      runnerConfig,
 		OrchestrationMode: OrchestrationLoop,
 		SequentialAgents:  []string{orch.LoopAgent}, // Loop uses SequentialAgents for agent name
+		MaxIterations:     orch.MaxIterations,
+		LoopConditions:    conditions,
+	}
+	if orch.CheckpointDir != "" {
+		enhancedConfig.CheckpointStore = NewFileCheckpointStore(orch.CheckpointDir)
 	}
 	
 	return NewRunnerWithOrchestration(enhancedConfig), nil
@@ -422,3 +522,63 @@ func createMixedRunnerFromConfig(runnerConfig RunnerConfig, orch *OrchestrationC
 	
 	return NewRunnerWithOrchestration(enhancedConfig), nil
 }
+
+// createGraphRunnerFromConfig creates a graph runner from configuration
+func createGraphRunnerFromConfig(runnerConfig RunnerConfig, orch *OrchestrationConfigToml) (Runner, error) {
+	graph, err := NewWorkflowGraphFromConfig(orch.Graph)
+	if err != nil {
+		return nil, fmt.Errorf("invalid graph orchestration: %w", err)
+	}
+
+	enhancedConfig := EnhancedRunnerConfig{
+		RunnerConfig:      runnerConfig,
+		OrchestrationMode: OrchestrationGraph,
+		Graph:             graph,
+	}
+
+	return NewRunnerWithOrchestration(enhancedConfig), nil
+}
+
+// createSupervisorRunnerFromConfig creates a supervisor runner from configuration
+func createSupervisorRunnerFromConfig(runnerConfig RunnerConfig, config *Config) (Runner, error) {
+	orch := &config.Orchestration
+
+	provider, err := config.initializeNamedProvider(orch.SupervisorProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize supervisor provider: %w", err)
+	}
+
+	enhancedConfig := EnhancedRunnerConfig{
+		RunnerConfig:      runnerConfig,
+		OrchestrationMode: OrchestrationSupervisor,
+		Supervisor: SupervisorConfig{
+			Provider:     provider,
+			Agents:       orch.SupervisorAgents,
+			Descriptions: orch.AgentDescriptions,
+			MaxSteps:     orch.MaxSteps,
+			Instructions: orch.SupervisorInstructions,
+		},
+	}
+
+	return NewRunnerWithOrchestration(enhancedConfig), nil
+}
+
+// createMapReduceRunnerFromConfig creates a map/reduce runner from configuration
+func createMapReduceRunnerFromConfig(runnerConfig RunnerConfig, orch *OrchestrationConfigToml) (Runner, error) {
+	enhancedConfig := EnhancedRunnerConfig{
+		RunnerConfig:      runnerConfig,
+		OrchestrationMode: OrchestrationMapReduce,
+		MapReduce: MapReduceConfig{
+			ItemsKey:       orch.ItemsKey,
+			Mapper:         orch.MapAgent,
+			Reducer:        orch.ReduceAgent,
+			ItemKey:        orch.ItemKey,
+			OutputKey:      orch.OutputKey,
+			ResultsKey:     orch.ResultsKey,
+			MaxConcurrency: orch.MaxConcurrency,
+			ErrorStrategy:  ErrorHandlingStrategy(orch.ErrorStrategy),
+		},
+	}
+
+	return NewRunnerWithOrchestration(enhancedConfig), nil
+}
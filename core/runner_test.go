@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startTestRunner starts a runner with the given agents and concurrency.
+func startTestRunner(t *testing.T, concurrency int, agents map[string]AgentHandler) Runner {
+	t.Helper()
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents:              agents,
+		Memory:              QuickMemory(),
+		SessionID:           "runner-test",
+		MaxConcurrentAgents: concurrency,
+		QueueSize:           50,
+		Config:              &Config{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := runner.Start(ctx); err != nil {
+		cancel()
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	t.Cleanup(func() {
+		runner.Stop()
+		cancel()
+	})
+	return runner
+}
+
+func emitToWorker(t *testing.T, runner Runner, sessionID string, data EventData) {
+	t.Helper()
+	meta := map[string]string{SessionIDKey: sessionID, RouteMetadataKey: "worker"}
+	if err := runner.Emit(NewEvent("worker", data, meta)); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+}
+
+func TestRunner_ProcessesSessionsInParallel(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan string, 2)
+	runner := startTestRunner(t, 2, map[string]AgentHandler{"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		sessionID, _ := event.GetMetadataValue(SessionIDKey)
+		if sessionID == "slow" {
+			<-release
+		}
+		done <- sessionID
+		return AgentResult{OutputState: NewState()}, nil
+	})})
+	defer close(release)
+
+	emitToWorker(t, runner, "slow", EventData{})
+	emitToWorker(t, runner, "fast", EventData{})
+
+	select {
+	case sessionID := <-done:
+		if sessionID != "fast" {
+			t.Errorf("expected the fast session to finish first, got %s", sessionID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a slow session blocked the runner")
+	}
+}
+
+func TestRunner_KeepsSessionOrderAndConcurrencyLimit(t *testing.T) {
+	var mu sync.Mutex
+	order := map[string][]int{}
+	inFlight := map[string]int{}
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+
+	const sessions, perSession = 4, 5
+	wg.Add(sessions * perSession)
+	runner := startTestRunner(t, 2, map[string]AgentHandler{"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		defer wg.Done()
+		sessionID, _ := event.GetMetadataValue(SessionIDKey)
+
+		mu.Lock()
+		inFlight[sessionID]++
+		if inFlight[sessionID] > 1 {
+			t.Errorf("session %s processed two events at once", sessionID)
+		}
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		order[sessionID] = append(order[sessionID], event.GetData()["seq"].(int))
+		inFlight[sessionID]--
+		running--
+		mu.Unlock()
+		return AgentResult{OutputState: NewState()}, nil
+	})})
+
+	for seq := 0; seq < perSession; seq++ {
+		for s := 0; s < sessions; s++ {
+			emitToWorker(t, runner, fmt.Sprintf("s%d", s), EventData{"seq": seq})
+		}
+	}
+
+	waitDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waitDone)
+	}()
+	select {
+	case <-waitDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events to be processed")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 events in flight, saw %d", maxRunning)
+	}
+	for s := 0; s < sessions; s++ {
+		sessionID := fmt.Sprintf("s%d", s)
+		for i, seq := range order[sessionID] {
+			if seq != i {
+				t.Errorf("session %s processed events out of order: %v", sessionID, order[sessionID])
+				break
+			}
+		}
+	}
+}
+
+func TestNewRunnerWithConfig_MaxConcurrentAgentsFromConfig(t *testing.T) {
+	config := &Config{}
+	config.Runtime.MaxConcurrentAgents = 6
+	runner := NewRunnerWithConfig(RunnerConfig{Memory: QuickMemory(), SessionID: "cfg", Config: config})
+	if got := runner.(*RunnerImpl).MaxConcurrency(); got != 6 {
+		t.Errorf("expected concurrency from [runtime], got %d", got)
+	}
+
+	if got := NewRunner(10).MaxConcurrency(); got != 1 {
+		t.Errorf("expected runners to process one event at a time by default, got %d", got)
+	}
+}
+
+func TestRunner_RunWaitsForFollowUpEvents(t *testing.T) {
+	runner := startTestRunner(t, 2, map[string]AgentHandler{
+		"planner": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			time.Sleep(10 * time.Millisecond)
+			output := NewState()
+			output.Set("plan", "write a haiku")
+			output.SetMeta(RouteMetadataKey, "writer")
+			return AgentResult{OutputState: output}, nil
+		}),
+		"writer": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			output := NewState()
+			output.Set("answer", fmt.Sprintf("done: %v", event.GetData()["plan"]))
+			return AgentResult{OutputState: output}, nil
+		}),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	event := NewEvent("planner", EventData{}, map[string]string{SessionIDKey: "run", RouteMetadataKey: "planner"})
+	result, err := runner.Run(ctx, event)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if answer, _ := result.OutputState.Get("answer"); answer != "done: write a haiku" {
+		t.Errorf("expected the final agent's state, got %v", answer)
+	}
+}
+
+func TestRunner_RunReportsAgentErrors(t *testing.T) {
+	runner := startTestRunner(t, 1, map[string]AgentHandler{
+		"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			return AgentResult{}, errors.New("tool unavailable")
+		}),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := <-runner.RunAsync(ctx, NewEvent("worker", EventData{}, map[string]string{SessionIDKey: "fail", RouteMetadataKey: "worker"}))
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "tool unavailable") {
+		t.Errorf("expected the agent error, got %v", result.Err)
+	}
+}
+
+func TestRunner_RunAsyncReleasedOnStop(t *testing.T) {
+	release := make(chan struct{})
+	runner := startTestRunner(t, 1, map[string]AgentHandler{
+		"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			<-release
+			return AgentResult{OutputState: NewState()}, nil
+		}),
+	})
+
+	// The first session occupies the only worker, so the second never starts
+	emitToWorker(t, runner, "busy", EventData{})
+	waiting := runner.RunAsync(context.Background(), NewEvent("worker", EventData{}, map[string]string{SessionIDKey: "queued", RouteMetadataKey: "worker"}))
+
+	stopped := make(chan struct{})
+	go func() {
+		runner.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case result := <-waiting:
+		if !errors.Is(result.Err, ErrRunnerStopped) {
+			t.Errorf("expected ErrRunnerStopped, got %v", result.Err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunAsync did not return after Stop")
+	}
+	<-stopped
+}
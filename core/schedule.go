@@ -0,0 +1,572 @@
+// Package core provides cron and interval scheduled events for the Runner
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ScheduleNameKey is the metadata key naming the schedule that emitted an event
+	ScheduleNameKey = "schedule_name"
+	// ScheduledTimeKey is the metadata key holding the time an event was scheduled for,
+	// in RFC 3339 format. For catch-up runs it lies in the past.
+	ScheduledTimeKey = "scheduled_time"
+	// ScheduleCatchUpKey is set to "true" on events emitted for runs missed while the
+	// runner was down
+	ScheduleCatchUpKey = "schedule_catch_up"
+
+	// maxMissedRuns caps the catch-up runs emitted for one schedule under MissedRunAll
+	maxMissedRuns = 100
+)
+
+// OverlapPolicy decides what a schedule does when it fires while its previous run is
+// still in progress
+type OverlapPolicy string
+
+const (
+	OverlapSkip  OverlapPolicy = "skip"  // Drop the new run (default)
+	OverlapQueue OverlapPolicy = "queue" // Start the new run when the previous one finishes; at most one waits
+	OverlapAllow OverlapPolicy = "allow" // Start the new run anyway
+)
+
+// maxQueuedRuns is how many runs an OverlapQueue schedule keeps waiting. Later runs are
+// skipped, so a schedule that fires faster than it finishes does not pile up runs.
+const maxQueuedRuns = 1
+
+// MissedRunPolicy decides what a schedule does about runs it missed while the runner was
+// down. Missed runs are only known when the schedule store outlives the process.
+type MissedRunPolicy string
+
+const (
+	MissedRunSkip MissedRunPolicy = "skip"     // Ignore missed runs (default)
+	MissedRunOnce MissedRunPolicy = "run_once" // Run once for all missed runs
+	MissedRunAll  MissedRunPolicy = "run_all"  // Run every missed run, up to 100, one after another unless overlap is allowed
+)
+
+// Schedule describes events the runner emits on a cron expression or a fixed interval.
+// Each event targets Agent and gets a fresh session ID.
+type Schedule struct {
+	Name     string            // Unique name, used to remember the last run. Required.
+	Cron     string            // Cron expression, see ParseCron. Set either Cron or Interval.
+	Interval time.Duration     // Fixed interval between runs
+	Location *time.Location    // Time zone of the cron expression (default: local time)
+	Agent    string            // Agent the events are routed to. Required.
+	Data     EventData         // Data of each event
+	Metadata map[string]string // Extra metadata of each event
+	Overlap  OverlapPolicy     // Default OverlapSkip
+	Missed   MissedRunPolicy   // Default MissedRunSkip
+}
+
+// validate checks the schedule and returns the function computing its next run
+func (s Schedule) validate() (func(time.Time) time.Time, error) {
+	if s.Name == "" {
+		return nil, errors.New("schedule requires a name")
+	}
+	if s.Agent == "" {
+		return nil, fmt.Errorf("schedule %s requires an agent", s.Name)
+	}
+	switch s.Overlap {
+	case "", OverlapSkip, OverlapQueue, OverlapAllow:
+	default:
+		return nil, fmt.Errorf("schedule %s: invalid overlap policy %q: valid options are skip, queue and allow", s.Name, s.Overlap)
+	}
+	switch s.Missed {
+	case "", MissedRunSkip, MissedRunOnce, MissedRunAll:
+	default:
+		return nil, fmt.Errorf("schedule %s: invalid missed run policy %q: valid options are skip, run_once and run_all", s.Name, s.Missed)
+	}
+
+	switch {
+	case s.Cron != "" && s.Interval > 0:
+		return nil, fmt.Errorf("schedule %s: set either a cron expression or an interval, not both", s.Name)
+	case s.Cron != "":
+		cron, err := ParseCron(s.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		location := s.Location
+		if location == nil {
+			location = time.Local
+		}
+		if cron.Next(time.Now().In(location)).IsZero() {
+			return nil, fmt.Errorf("schedule %s: cron expression %q never fires", s.Name, s.Cron)
+		}
+		return func(t time.Time) time.Time { return cron.Next(t.In(location)) }, nil
+	case s.Interval > 0:
+		interval := s.Interval
+		return func(t time.Time) time.Time { return t.Add(interval) }, nil
+	default:
+		return nil, fmt.Errorf("schedule %s requires a cron expression or a positive interval", s.Name)
+	}
+}
+
+// =============================================================================
+// SCHEDULE STORES
+// =============================================================================
+
+// ScheduleStore remembers when each schedule last fired, so runs missed while the runner
+// was down can be detected when it starts again
+type ScheduleStore interface {
+	// LastRun returns the last time the schedule fired; ok is false if it never did.
+	LastRun(name string) (last time.Time, ok bool, err error)
+	// SetLastRun records the time the schedule fired.
+	SetLastRun(name string, t time.Time) error
+}
+
+// MemoryScheduleStore keeps last run times in memory. Missed runs are not detected
+// across restarts with it.
+type MemoryScheduleStore struct {
+	mu   sync.RWMutex
+	runs map[string]time.Time
+}
+
+// NewMemoryScheduleStore creates an empty in-memory schedule store.
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{runs: make(map[string]time.Time)}
+}
+
+// LastRun implements ScheduleStore.
+func (s *MemoryScheduleStore) LastRun(name string) (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	last, ok := s.runs[name]
+	return last, ok, nil
+}
+
+// SetLastRun implements ScheduleStore.
+func (s *MemoryScheduleStore) SetLastRun(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[name] = t
+	return nil
+}
+
+// FileScheduleStore keeps last run times in a JSON file, so missed runs are detected after
+// the process restarts.
+type FileScheduleStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileScheduleStore creates a store writing to path. The file and its directory are
+// created on the first SetLastRun.
+func NewFileScheduleStore(path string) *FileScheduleStore {
+	return &FileScheduleStore{path: path}
+}
+
+// LastRun implements ScheduleStore.
+func (s *FileScheduleStore) LastRun(name string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs, err := s.read()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	last, ok := runs[name]
+	return last, ok, nil
+}
+
+// SetLastRun implements ScheduleStore. The file is replaced atomically.
+func (s *FileScheduleStore) SetLastRun(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs, err := s.read()
+	if err != nil {
+		return err
+	}
+	runs[name] = t
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule state: %w", err)
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create schedule state directory: %w", err)
+		}
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedule state: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to write schedule state: %w", err)
+	}
+	return nil
+}
+
+func (s *FileScheduleStore) read() (map[string]time.Time, error) {
+	runs := make(map[string]time.Time)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule state: %w", err)
+	}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule state %s: %w", s.path, err)
+	}
+	return runs, nil
+}
+
+// =============================================================================
+// SCHEDULER
+// =============================================================================
+
+// scheduler fires the schedules of a runner while it is running
+type scheduler struct {
+	runner Runner
+	store  ScheduleStore
+
+	mu      sync.Mutex
+	entries map[string]*scheduleEntry
+	ctx     context.Context // Set while the runner is running
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// scheduleEntry is a registered schedule and its runs in progress
+type scheduleEntry struct {
+	schedule Schedule
+	next     func(time.Time) time.Time
+	stop     chan struct{}
+
+	mu      sync.Mutex
+	running int
+	queued  []scheduledRun
+}
+
+// scheduledRun is one firing of a schedule
+type scheduledRun struct {
+	at      time.Time
+	catchUp bool
+}
+
+func newScheduler(runner Runner) *scheduler {
+	return &scheduler{
+		runner:  runner,
+		store:   NewMemoryScheduleStore(),
+		entries: make(map[string]*scheduleEntry),
+	}
+}
+
+// setStore replaces the store of last run times
+func (s *scheduler) setStore(store ScheduleStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+}
+
+// add registers a schedule, starting it right away if the runner is running
+func (s *scheduler) add(schedule Schedule) error {
+	next, err := schedule.validate()
+	if err != nil {
+		return err
+	}
+	if schedule.Overlap == "" {
+		schedule.Overlap = OverlapSkip
+	}
+	if schedule.Missed == "" {
+		schedule.Missed = MissedRunSkip
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[schedule.Name]; exists {
+		return fmt.Errorf("schedule %s is already registered", schedule.Name)
+	}
+	entry := &scheduleEntry{schedule: schedule, next: next, stop: make(chan struct{})}
+	s.entries[schedule.Name] = entry
+	if s.ctx != nil {
+		s.startEntry(s.ctx, entry)
+	}
+	return nil
+}
+
+// remove unregisters a schedule. Runs in progress are not interrupted.
+func (s *scheduler) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[name]; ok {
+		close(entry.stop)
+		delete(s.entries, name)
+	}
+}
+
+// start begins firing the registered schedules
+func (s *scheduler) start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, entry := range s.entries {
+		s.startEntry(s.ctx, entry)
+	}
+	if len(s.entries) > 0 {
+		Logger().Info().Int("schedules", len(s.entries)).Msg("Scheduler: Started.")
+	}
+}
+
+// stop stops firing schedules and waits for the scheduler's goroutines to finish
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.ctx, s.cancel = nil, nil
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// startEntry runs a schedule's timer loop. Called with s.mu held.
+func (s *scheduler) startEntry(ctx context.Context, entry *scheduleEntry) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runEntry(ctx, entry)
+	}()
+}
+
+// runEntry catches up on missed runs, then fires the schedule until it is stopped
+func (s *scheduler) runEntry(ctx context.Context, entry *scheduleEntry) {
+	name := entry.schedule.Name
+	now := time.Now()
+	next := entry.next(now)
+
+	if last, ok, err := s.store.LastRun(name); err != nil {
+		Logger().Error().Str("schedule", name).Err(err).Msg("Scheduler: Failed to read last run, not catching up")
+	} else if ok {
+		var missed []time.Time
+		next = entry.next(last)
+		for !next.IsZero() && !next.After(now) {
+			if len(missed) == maxMissedRuns {
+				next = entry.next(now)
+				break
+			}
+			missed = append(missed, next)
+			next = entry.next(next)
+		}
+		s.catchUp(ctx, entry, missed)
+	}
+
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.fire(ctx, entry, scheduledRun{at: next})
+			// Skip runs that fell due while firing took long
+			following := entry.next(next)
+			if now := time.Now(); !following.After(now) {
+				following = entry.next(now)
+			}
+			next = following
+		case <-entry.stop:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+	Logger().Warn().Str("schedule", name).Msg("Scheduler: Schedule has no further runs")
+}
+
+// catchUp applies the schedule's missed run policy to the runs missed while it was down
+func (s *scheduler) catchUp(ctx context.Context, entry *scheduleEntry, missed []time.Time) {
+	if len(missed) == 0 {
+		return
+	}
+	name := entry.schedule.Name
+	switch entry.schedule.Missed {
+	case MissedRunOnce:
+		Logger().Info().Str("schedule", name).Int("missed", len(missed)).Msg("Scheduler: Running once for missed runs")
+		s.fire(ctx, entry, scheduledRun{at: missed[len(missed)-1], catchUp: true})
+	case MissedRunAll:
+		Logger().Info().Str("schedule", name).Int("missed", len(missed)).Msg("Scheduler: Running missed runs")
+		for _, at := range missed {
+			s.fire(ctx, entry, scheduledRun{at: at, catchUp: true})
+		}
+	default:
+		Logger().Info().Str("schedule", name).Int("missed", len(missed)).Msg("Scheduler: Skipping missed runs")
+		if err := s.store.SetLastRun(name, missed[len(missed)-1]); err != nil {
+			Logger().Error().Str("schedule", name).Err(err).Msg("Scheduler: Failed to record last run")
+		}
+	}
+}
+
+// fire starts a run of the schedule, applying its overlap policy
+func (s *scheduler) fire(ctx context.Context, entry *scheduleEntry, run scheduledRun) {
+	name := entry.schedule.Name
+	if err := s.store.SetLastRun(name, run.at); err != nil {
+		Logger().Error().Str("schedule", name).Err(err).Msg("Scheduler: Failed to record last run")
+	}
+
+	entry.mu.Lock()
+	if entry.running > 0 {
+		switch entry.schedule.Overlap {
+		case OverlapQueue:
+			if run.catchUp || entry.pendingRuns() < maxQueuedRuns {
+				entry.queued = append(entry.queued, run)
+				entry.mu.Unlock()
+				Logger().Debug().Str("schedule", name).Msg("Scheduler: Previous run in progress, queued run")
+				return
+			}
+			entry.mu.Unlock()
+			Logger().Info().Str("schedule", name).Time("scheduled_time", run.at).Msg("Scheduler: Run already queued, skipped run")
+			return
+		case OverlapSkip:
+			// Catch-up runs are not dropped; they run one after another
+			if run.catchUp {
+				entry.queued = append(entry.queued, run)
+				entry.mu.Unlock()
+				return
+			}
+			entry.mu.Unlock()
+			Logger().Info().Str("schedule", name).Time("scheduled_time", run.at).Msg("Scheduler: Previous run in progress, skipped run")
+			return
+		}
+	}
+	entry.running++
+	entry.mu.Unlock()
+
+	s.launch(ctx, entry, run)
+}
+
+// launch emits the event for a run and waits for its session in the background. Queued
+// runs start when it finishes.
+func (s *scheduler) launch(ctx context.Context, entry *scheduleEntry, run scheduledRun) {
+	event := entry.event(run)
+	name := entry.schedule.Name
+	Logger().Info().Str("schedule", name).Str("agent", entry.schedule.Agent).Str("event_id", event.GetID()).Msg("Scheduler: Emitting scheduled event")
+	results := s.runner.RunAsync(ctx, event)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		result := <-results
+		if result.Err != nil && !errors.Is(result.Err, context.Canceled) {
+			Logger().Error().Str("schedule", name).Str("event_id", event.GetID()).Err(result.Err).Msg("Scheduler: Scheduled run failed")
+		}
+
+		entry.mu.Lock()
+		entry.running--
+		if len(entry.queued) == 0 || ctx.Err() != nil {
+			entry.mu.Unlock()
+			return
+		}
+		queued := entry.queued[0]
+		entry.queued = entry.queued[1:]
+		entry.running++
+		entry.mu.Unlock()
+		s.launch(ctx, entry, queued)
+	}()
+}
+
+// pendingRuns counts the queued runs that are not catch-up runs. The caller holds e.mu.
+func (e *scheduleEntry) pendingRuns() int {
+	pending := 0
+	for _, run := range e.queued {
+		if !run.catchUp {
+			pending++
+		}
+	}
+	return pending
+}
+
+// event builds the event emitted for a run, in a fresh session
+func (e *scheduleEntry) event(run scheduledRun) Event {
+	data := make(EventData, len(e.schedule.Data))
+	for key, value := range e.schedule.Data {
+		data[key] = value
+	}
+	metadata := make(map[string]string, len(e.schedule.Metadata)+5)
+	for key, value := range e.schedule.Metadata {
+		metadata[key] = value
+	}
+	metadata[SessionIDKey] = GenerateSessionID()
+	metadata[RouteMetadataKey] = e.schedule.Agent
+	metadata[ScheduleNameKey] = e.schedule.Name
+	metadata[ScheduledTimeKey] = run.at.Format(time.RFC3339)
+	if run.catchUp {
+		metadata[ScheduleCatchUpKey] = "true"
+	}
+	return NewEvent(e.schedule.Agent, data, metadata)
+}
+
+// =============================================================================
+// SCHEDULE CONFIGURATION
+// =============================================================================
+
+// ScheduleConfigToml represents a [[schedules]] entry in TOML format
+type ScheduleConfigToml struct {
+	Name     string                 `toml:"name"`
+	Cron     string                 `toml:"cron"`     // Cron expression, e.g. "0 2 * * *"
+	Every    string                 `toml:"every"`    // Interval instead of cron, e.g. "15m"
+	Timezone string                 `toml:"timezone"` // IANA time zone of the cron expression (default: local)
+	Agent    string                 `toml:"agent"`    // Agent the events are routed to
+	Overlap  string                 `toml:"overlap"`  // skip (default), queue or allow
+	Missed   string                 `toml:"missed"`   // skip (default), run_once or run_all
+	Data     map[string]interface{} `toml:"data"`     // Data of each event
+	Metadata map[string]string      `toml:"metadata"` // Extra metadata of each event
+}
+
+// BuildSchedules converts the [[schedules]] entries of the configuration to schedules
+func (c *Config) BuildSchedules() ([]Schedule, error) {
+	schedules := make([]Schedule, 0, len(c.Schedules))
+	seen := make(map[string]bool)
+	for i, entry := range c.Schedules {
+		schedule := Schedule{
+			Name:     entry.Name,
+			Cron:     entry.Cron,
+			Agent:    entry.Agent,
+			Data:     EventData(entry.Data),
+			Metadata: entry.Metadata,
+			Overlap:  OverlapPolicy(strings.ToLower(entry.Overlap)),
+			Missed:   MissedRunPolicy(strings.ToLower(entry.Missed)),
+		}
+		if schedule.Name == "" {
+			return nil, fmt.Errorf("schedules[%d] requires a name", i)
+		}
+		if seen[schedule.Name] {
+			return nil, fmt.Errorf("duplicate schedule name %s", schedule.Name)
+		}
+		seen[schedule.Name] = true
+
+		if entry.Every != "" {
+			interval, err := time.ParseDuration(entry.Every)
+			if err != nil || interval <= 0 {
+				return nil, fmt.Errorf("schedule %s: invalid every %q", entry.Name, entry.Every)
+			}
+			schedule.Interval = interval
+		}
+		if entry.Timezone != "" {
+			location, err := time.LoadLocation(entry.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: invalid timezone %q: %w", entry.Name, entry.Timezone, err)
+			}
+			schedule.Location = location
+		}
+		if _, err := schedule.validate(); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// newScheduleStoreFromConfig returns the store configured by [runtime].schedule_state_path,
+// or nil to keep the in-memory default
+func newScheduleStoreFromConfig(config *Config) ScheduleStore {
+	if config.Runtime.ScheduleStatePath == "" {
+		return nil
+	}
+	return NewFileScheduleStore(config.Runtime.ScheduleStatePath)
+}
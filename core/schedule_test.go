@@ -0,0 +1,327 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC) // A Friday
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, time.March, 16, 2, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * mon-fri", time.Date(2024, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{"30 8 * * sat,sun", time.Date(2024, time.March, 16, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 1", time.Date(2024, time.March, 18, 12, 0, 0, 0, time.UTC)}, // 1st of the month or Monday
+		{"0 0 * * 7", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, time.March, 15, 10, 25, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.March, 15, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.expression)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", tt.expression, err)
+			continue
+		}
+		if got := cron.Next(from); !got.Equal(tt.want) {
+			t.Errorf("ParseCron(%q).Next = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err == nil {
+		cron, _ := ParseCron("0 2 * * *")
+		if got := cron.Next(from.In(berlin)); !got.Equal(time.Date(2024, time.March, 16, 1, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected 02:00 Berlin time, got %v", got.UTC())
+		}
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "* * * * funday"} {
+		if _, err := ParseCron(invalid); err == nil {
+			t.Errorf("expected ParseCron(%q) to fail", invalid)
+		}
+	}
+	never, _ := ParseCron("0 0 30 2 *")
+	if !never.Next(from).IsZero() {
+		t.Error("expected February 30th never to fire")
+	}
+}
+
+// scheduleRunner records the events a scheduler emits; each run finishes when finish is called
+type scheduleRunner struct {
+	Runner
+	mu      sync.Mutex
+	events  []Event
+	pending []chan RunResult
+	emitted chan Event
+}
+
+func newScheduleRunner() *scheduleRunner {
+	return &scheduleRunner{emitted: make(chan Event, 100)}
+}
+
+func (r *scheduleRunner) RunAsync(ctx context.Context, event Event) <-chan RunResult {
+	out := make(chan RunResult, 1)
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.pending = append(r.pending, out)
+	r.mu.Unlock()
+	r.emitted <- event
+	return out
+}
+
+// finish completes the oldest unfinished run
+func (r *scheduleRunner) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[0] <- RunResult{}
+	r.pending = r.pending[1:]
+}
+
+func (r *scheduleRunner) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// waitEmitted waits for n more events
+func (r *scheduleRunner) waitEmitted(t *testing.T, n int) []Event {
+	t.Helper()
+	var events []Event
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-r.emitted:
+			events = append(events, event)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d of %d", i+1, n)
+		}
+	}
+	return events
+}
+
+func TestScheduler_OverlapPolicies(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		policy       OverlapPolicy
+		whileRunning int // Runs started while the first is still running
+		total        int // Runs started once all have finished
+	}{
+		{OverlapSkip, 1, 1},
+		{OverlapQueue, 1, 2}, // One run waits, the third is skipped
+		{OverlapAllow, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			runner := newScheduleRunner()
+			s := newScheduler(runner)
+			if err := s.add(Schedule{Name: "digest", Interval: time.Hour, Agent: "digest", Overlap: tt.policy}); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+			entry := s.entries["digest"]
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			for i := 0; i < 3; i++ {
+				s.fire(ctx, entry, scheduledRun{at: at.Add(time.Duration(i) * time.Hour)})
+			}
+			if got := runner.count(); got != tt.whileRunning {
+				t.Fatalf("expected %d runs while the first is running, got %d", tt.whileRunning, got)
+			}
+			runner.waitEmitted(t, tt.whileRunning)
+			for finished := 0; finished < runner.count(); finished++ {
+				runner.finish()
+				if tt.policy == OverlapQueue && finished < tt.total-tt.whileRunning {
+					runner.waitEmitted(t, 1)
+				}
+			}
+			if got := runner.count(); got != tt.total {
+				t.Errorf("expected %d runs in total, got %d", tt.total, got)
+			}
+			cancel()
+			s.stop()
+		})
+	}
+}
+
+func TestScheduler_MissedRuns(t *testing.T) {
+	tests := []struct {
+		policy MissedRunPolicy
+		runs   int
+	}{
+		{MissedRunSkip, 0},
+		{MissedRunOnce, 1},
+		{MissedRunAll, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			store := NewFileScheduleStore(filepath.Join(t.TempDir(), "state", "schedules.json"))
+			last := time.Now().Add(-210 * time.Minute).Truncate(time.Second)
+			if err := store.SetLastRun("hourly", last); err != nil {
+				t.Fatalf("SetLastRun failed: %v", err)
+			}
+
+			runner := newScheduleRunner()
+			s := newScheduler(runner)
+			s.setStore(store)
+			s.add(Schedule{Name: "hourly", Interval: time.Hour, Agent: "report", Missed: tt.policy, Data: EventData{"kind": "hourly"}})
+			s.start(context.Background())
+
+			var events []Event
+			for i := 0; i < tt.runs; i++ {
+				events = append(events, runner.waitEmitted(t, 1)...)
+				runner.finish()
+			}
+			s.stop()
+			if runner.count() != tt.runs {
+				t.Fatalf("expected %d catch-up runs, got %d", tt.runs, runner.count())
+			}
+
+			for i, event := range events {
+				metadata := event.GetMetadata()
+				if metadata[ScheduleCatchUpKey] != "true" || metadata[ScheduleNameKey] != "hourly" || metadata[RouteMetadataKey] != "report" {
+					t.Errorf("unexpected catch-up metadata %v", metadata)
+				}
+				if event.GetData()["kind"] != "hourly" {
+					t.Errorf("expected the schedule's data, got %v", event.GetData())
+				}
+				if tt.policy == MissedRunAll && metadata[ScheduledTimeKey] != last.Add(time.Duration(i+1)*time.Hour).Format(time.RFC3339) {
+					t.Errorf("run %d: unexpected scheduled time %s", i, metadata[ScheduledTimeKey])
+				}
+			}
+			if recorded, _, _ := store.LastRun("hourly"); !recorded.Equal(last.Add(3 * time.Hour)) {
+				t.Errorf("expected the last missed run to be recorded, got %v", recorded)
+			}
+		})
+	}
+}
+
+func TestRunner_AddSchedule(t *testing.T) {
+	var mu sync.Mutex
+	var sessions []string
+	ran := make(chan struct{}, 10)
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents: map[string]AgentHandler{
+			"digest": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+				mu.Lock()
+				sessions = append(sessions, event.GetSessionID())
+				mu.Unlock()
+				select {
+				case ran <- struct{}{}:
+				default:
+				}
+				state.SetMeta(RouteMetadataKey, "")
+				return AgentResult{OutputState: state}, nil
+			}),
+		},
+		Memory:    QuickMemory(),
+		SessionID: "schedule-test",
+		Config:    &Config{},
+	}).(*RunnerImpl)
+
+	if err := runner.AddSchedule(Schedule{Name: "fast", Interval: 20 * time.Millisecond, Agent: "digest"}); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	if err := runner.AddSchedule(Schedule{Name: "fast", Interval: time.Second, Agent: "digest"}); err == nil {
+		t.Error("expected a duplicate schedule name to be rejected")
+	}
+	if err := runner.AddSchedule(Schedule{Name: "bad", Cron: "0 2 * * *", Interval: time.Second, Agent: "digest"}); err == nil {
+		t.Error("expected a schedule with both cron and interval to be rejected")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for scheduled runs")
+		}
+	}
+	runner.RemoveSchedule("fast")
+	runner.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sessions[0] == sessions[1] || sessions[0] == "" {
+		t.Errorf("expected each scheduled event in a fresh session, got %v", sessions)
+	}
+}
+
+func TestConfig_BuildSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentflow.toml")
+	content := `
+[agent_flow]
+name = "digests"
+
+[runtime]
+schedule_state_path = ".agentflow/schedules.json"
+
+[[schedules]]
+name = "nightly-digest"
+cron = "0 2 * * *"
+timezone = "UTC"
+agent = "digest"
+overlap = "queue"
+missed = "run_once"
+
+[schedules.data]
+topic = "ai"
+
+[[schedules]]
+name = "poll"
+every = "15m"
+agent = "poller"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	schedules, err := config.BuildSchedules()
+	if err != nil || len(schedules) != 2 {
+		t.Fatalf("expected 2 schedules, got %d (%v)", len(schedules), err)
+	}
+	nightly := schedules[0]
+	if nightly.Location != time.UTC || nightly.Overlap != OverlapQueue || nightly.Missed != MissedRunOnce || nightly.Data["topic"] != "ai" {
+		t.Errorf("unexpected nightly schedule %+v", nightly)
+	}
+	if schedules[1].Interval != 15*time.Minute {
+		t.Errorf("expected a 15m interval, got %v", schedules[1].Interval)
+	}
+	if _, ok := newScheduleStoreFromConfig(config).(*FileScheduleStore); !ok {
+		t.Error("expected schedule_state_path to select a file store")
+	}
+
+	for _, invalid := range []ScheduleConfigToml{
+		{Name: "no-agent", Every: "1m"},
+		{Name: "no-timing", Agent: "a"},
+		{Name: "bad-every", Every: "soon", Agent: "a"},
+		{Name: "bad-zone", Cron: "@daily", Timezone: "Mars/Olympus", Agent: "a"},
+		{Name: "bad-overlap", Every: "1m", Agent: "a", Overlap: "sometimes"},
+	} {
+		config.Schedules = []ScheduleConfigToml{invalid}
+		if _, err := config.BuildSchedules(); err == nil {
+			t.Errorf("expected schedule %s to be rejected", invalid.Name)
+		}
+	}
+	config.Schedules = []ScheduleConfigToml{{Name: "x", Every: "1m", Agent: "a"}, {Name: "x", Every: "2m", Agent: "a"}}
+	if _, err := config.BuildSchedules(); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected duplicate names to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,612 @@
+// Package core provides schema-validated structured output from language models for AgentFlow.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultStructuredOutputRetries is the number of repair attempts made after an invalid reply.
+const DefaultStructuredOutputRetries = 2
+
+// DefaultStructuredOutputKey is the state key a structured output agent stores its result under.
+const DefaultStructuredOutputKey = "structured_output"
+
+// ErrInvalidStructuredOutput is wrapped by StructuredOutputError when the model never
+// produced JSON matching the schema.
+var ErrInvalidStructuredOutput = errors.New("model output does not match schema")
+
+// StructuredOutputError reports a structured call whose replies all failed validation.
+type StructuredOutputError struct {
+	Attempts int      // Number of model calls made
+	Errors   []string // Validation errors of the last reply
+	Content  string   // Raw content of the last reply
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("%v after %d attempt(s): %s", ErrInvalidStructuredOutput, e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// Unwrap returns ErrInvalidStructuredOutput so callers can use errors.Is.
+func (e *StructuredOutputError) Unwrap() error {
+	return ErrInvalidStructuredOutput
+}
+
+// StructuredOutputOptions configures CallStructured.
+type StructuredOutputOptions struct {
+	// Schema is the JSON schema replies must match. nil derives it from the target type.
+	Schema map[string]interface{}
+	// Name identifies the schema to providers that require one. Defaults to "response".
+	Name string
+	// MaxRetries is the number of repair attempts after an invalid reply. 0 uses
+	// DefaultStructuredOutputRetries; a negative value disables repair.
+	MaxRetries int
+}
+
+// CallStructured asks the model for JSON matching a schema and decodes the reply into target,
+// which must be a non-nil pointer. The schema is sent as the prompt's ResponseFormat. When a
+// reply is not valid JSON or does not match the schema, the validation errors are fed back to
+// the model and the call is retried; once the retries are used up a *StructuredOutputError is
+// returned. Provider errors are returned as-is without retrying.
+func CallStructured(ctx context.Context, provider ModelProvider, prompt Prompt, target interface{}, options StructuredOutputOptions) (Response, error) {
+	value := reflect.ValueOf(target)
+	if !value.IsValid() || value.Kind() != reflect.Ptr || value.IsNil() {
+		return Response{}, fmt.Errorf("structured output target must be a non-nil pointer, got %T", target)
+	}
+
+	schema := options.Schema
+	if schema == nil {
+		var err error
+		if schema, err = JSONSchemaFor(target); err != nil {
+			return Response{}, err
+		}
+	}
+	name := options.Name
+	if name == "" {
+		name = "response"
+	}
+	retries := options.MaxRetries
+	if retries == 0 {
+		retries = DefaultStructuredOutputRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	prompt.Parameters.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONSchema, Name: name, Schema: schema}
+	prompt.Messages = append([]ChatMessage(nil), prompt.Messages...)
+
+	var problems []string
+	var response Response
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		var err error
+		response, err = provider.Call(ctx, prompt)
+		if err != nil {
+			return response, err
+		}
+
+		problems = decodeStructured(response.Content, schema, target)
+		if len(problems) == 0 {
+			return response, nil
+		}
+		Logger().Debug().
+			Int("attempt", attempt).
+			Strs("errors", problems).
+			Msg("CallStructured: Reply does not match schema")
+
+		// Continue the conversation with the invalid reply and what was wrong with it
+		if prompt.User != "" {
+			prompt.Messages = append(prompt.Messages, ChatMessage{Role: ChatRoleUser, Content: prompt.User})
+		}
+		prompt.Messages = append(prompt.Messages, ChatMessage{Role: ChatRoleAssistant, Content: response.Content})
+		prompt.User = repairInstruction(problems, schema)
+	}
+
+	return response, &StructuredOutputError{Attempts: retries + 1, Errors: problems, Content: response.Content}
+}
+
+// decodeStructured validates a reply against the schema and decodes it into target,
+// returning the problems found.
+func decodeStructured(content string, schema map[string]interface{}, target interface{}) []string {
+	raw := extractJSON(content)
+	var generic interface{}
+	if err := json.Unmarshal([]byte(raw), &generic); err != nil {
+		return []string{fmt.Sprintf("reply is not valid JSON: %v", err)}
+	}
+	if problems := ValidateJSONSchema(generic, schema); len(problems) > 0 {
+		return problems
+	}
+	if err := json.Unmarshal([]byte(raw), target); err != nil {
+		return []string{fmt.Sprintf("reply does not fit the result type: %v", err)}
+	}
+	return nil
+}
+
+// extractJSON strips code fences and surrounding prose from a reply.
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```")
+		if newline := strings.IndexByte(content, '\n'); newline >= 0 {
+			content = content[newline+1:] // Drop the language tag
+		}
+		content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+	}
+	if strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[") {
+		return content
+	}
+	start, end := strings.IndexByte(content, '{'), strings.LastIndexByte(content, '}')
+	if start >= 0 && end > start {
+		return content[start : end+1]
+	}
+	return content
+}
+
+func repairInstruction(problems []string, schema map[string]interface{}) string {
+	var b strings.Builder
+	b.WriteString("Your previous reply did not match the required JSON schema:\n")
+	for _, problem := range problems {
+		b.WriteString("- ")
+		b.WriteString(problem)
+		b.WriteString("\n")
+	}
+	if encoded, err := json.Marshal(schema); err == nil {
+		b.WriteString("Schema: ")
+		b.Write(encoded)
+		b.WriteString("\n")
+	}
+	b.WriteString("Reply again with only the corrected JSON, no prose and no code fences.")
+	return b.String()
+}
+
+// JSONSchemaFor derives a JSON schema from the type of v, which may be a value or a pointer.
+// Struct fields use their json names; fields without omitempty are required. A field's
+// `description` tag becomes its description and an `enum` tag ("a,b,c") restricts its values.
+func JSONSchemaFor(v interface{}) (map[string]interface{}, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, errors.New("cannot derive a JSON schema from nil")
+	}
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}, nil // encoding/json encodes []byte as base64
+		}
+		items, err := schemaForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot derive a JSON schema for %s: map keys must be strings", t)
+		}
+		values, err := schemaForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("cannot derive a JSON schema for recursive type %s", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		if err := addStructFields(t, properties, &required, visiting); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}, nil
+	default:
+		return nil, fmt.Errorf("cannot derive a JSON schema for %s", t)
+	}
+}
+
+// addStructFields adds the exported fields of t, flattening embedded structs as encoding/json does.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addStructFields(embedded, properties, required, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := schemaForType(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values := []interface{}{}
+			for _, value := range strings.Split(enum, ",") {
+				values = append(values, strings.TrimSpace(value))
+			}
+			schema["enum"] = values
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// ValidateJSONSchema checks a decoded JSON value against a schema and returns one message per
+// problem found, or nil when the value is valid. It supports the keywords used by generated and
+// typical hand-written schemas: type, properties, required, additionalProperties, items, enum,
+// minimum, maximum, minLength, maxLength, minItems and maxItems.
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) []string {
+	var problems []string
+	validateSchema("$", value, schema, &problems)
+	return problems
+}
+
+func validateSchema(path string, value interface{}, schema map[string]interface{}, problems *[]string) {
+	if types := schemaStrings(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, want := range types {
+			if jsonTypeMatches(value, want) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value)))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !enumContains(enum, value) {
+			encoded, _ := json.Marshal(enum)
+			*problems = append(*problems, fmt.Sprintf("%s: must be one of %s", path, encoded))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(path, v, schema, problems)
+	case []interface{}:
+		if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < min {
+			*problems = append(*problems, fmt.Sprintf("%s: must have at least %v items", path, min))
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > max {
+			*problems = append(*problems, fmt.Sprintf("%s: must have at most %v items", path, max))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(fmt.Sprintf("%s[%d]", path, i), item, items, problems)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := schemaNumber(schema["minLength"]); ok && length < min {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at least %v characters", path, min))
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && length > max {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at most %v characters", path, max))
+		}
+	case float64:
+		if min, ok := schemaNumber(schema["minimum"]); ok && v < min {
+			*problems = append(*problems, fmt.Sprintf("%s: must be >= %v", path, min))
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && v > max {
+			*problems = append(*problems, fmt.Sprintf("%s: must be <= %v", path, max))
+		}
+	}
+}
+
+func validateObject(path string, object map[string]interface{}, schema map[string]interface{}, problems *[]string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, name := range schemaStrings(schema["required"]) {
+		if _, ok := object[name]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Report problems in a stable order
+
+	for _, key := range keys {
+		child := path + "." + key
+		if property, ok := properties[key].(map[string]interface{}); ok {
+			validateSchema(child, object[key], property, problems)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*problems = append(*problems, fmt.Sprintf("%s: unexpected property", child))
+			}
+		case map[string]interface{}:
+			validateSchema(child, object[key], additional, problems)
+		}
+	}
+}
+
+func jsonTypeMatches(value interface{}, want string) bool {
+	switch want {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	default:
+		return true // Unknown types are not enforced
+	}
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// schemaStrings reads a string or list of strings, as found in "type" and "required".
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func enumContains(enum interface{}, value interface{}) bool {
+	items := reflect.ValueOf(enum)
+	if items.Kind() != reflect.Slice {
+		return true
+	}
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i).Interface()
+		if number, ok := schemaNumber(item); ok {
+			if v, isNumber := value.(float64); isNumber && v == number {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStructured reads a structured result from state into target, a non-nil pointer. It accepts
+// values stored with their own type as well as generic JSON values, such as those left by a
+// state that was serialized and restored.
+func GetStructured(state State, key string, target interface{}) error {
+	value, ok := state.Get(key)
+	if !ok {
+		return fmt.Errorf("state has no value for key %q", key)
+	}
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return fmt.Errorf("structured output target must be a non-nil pointer, got %T", target)
+	}
+
+	stored := reflect.ValueOf(value)
+	if stored.IsValid() && stored.Type().AssignableTo(targetValue.Elem().Type()) {
+		targetValue.Elem().Set(stored)
+		return nil
+	}
+	if stored.IsValid() && stored.Kind() == reflect.Ptr && !stored.IsNil() && stored.Elem().Type().AssignableTo(targetValue.Elem().Type()) {
+		targetValue.Elem().Set(stored.Elem())
+		return nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode state value %q: %w", key, err)
+	}
+	if err := json.Unmarshal(encoded, target); err != nil {
+		return fmt.Errorf("state value %q does not fit %T: %w", key, target, err)
+	}
+	return nil
+}
+
+// StructuredAgentConfig configures an agent created by NewStructuredOutputAgent.
+type StructuredAgentConfig struct {
+	// SystemPrompt instructs the model. The schema is sent separately.
+	SystemPrompt string
+	// Prompt builds the user input from the event and state. nil uses the event's "message"
+	// or "content" data, or all event data as JSON.
+	Prompt func(event Event, state State) (string, error)
+	// NewResult returns a pointer to a fresh value to decode each reply into,
+	// e.g. func() interface{} { return &Decision{} }. Required.
+	NewResult func() interface{}
+	// OutputKey is the state key the decoded value is stored under.
+	// Defaults to DefaultStructuredOutputKey.
+	OutputKey string
+	// RouteField names a top-level string property of the reply whose value becomes
+	// the next route (RouteMetadataKey), so RouteOrchestrator can branch on it safely.
+	RouteField string
+	// Options configures the schema and repair retries.
+	Options StructuredOutputOptions
+}
+
+// NewStructuredOutputAgent creates an agent that calls the model with CallStructured and stores
+// the decoded result in its output state as a typed value (the type returned by NewResult,
+// dereferenced). Read it back with GetStructured. A reply that never validates fails the agent
+// with a *StructuredOutputError.
+func NewStructuredOutputAgent(provider ModelProvider, config StructuredAgentConfig) AgentHandler {
+	outputKey := config.OutputKey
+	if outputKey == "" {
+		outputKey = DefaultStructuredOutputKey
+	}
+
+	return AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		if config.NewResult == nil {
+			return AgentResult{}, errors.New("structured output agent requires NewResult")
+		}
+		input, err := structuredAgentInput(config, event, state)
+		if err != nil {
+			return AgentResult{}, err
+		}
+
+		result := config.NewResult()
+		if _, err := CallStructured(ctx, provider, Prompt{System: config.SystemPrompt, User: input}, result, config.Options); err != nil {
+			return AgentResult{}, err
+		}
+
+		outputState := state.Clone()
+		outputState.Set(outputKey, reflect.ValueOf(result).Elem().Interface())
+		if config.RouteField != "" {
+			route, err := structuredRoute(result, config.RouteField)
+			if err != nil {
+				return AgentResult{}, err
+			}
+			outputState.SetMeta(RouteMetadataKey, route)
+		}
+		return AgentResult{OutputState: outputState}, nil
+	})
+}
+
+func structuredAgentInput(config StructuredAgentConfig, event Event, state State) (string, error) {
+	if config.Prompt != nil {
+		return config.Prompt(event, state)
+	}
+	data := event.GetData()
+	for _, key := range []string{"message", "content"} {
+		if text, ok := data[key].(string); ok && text != "" {
+			return text, nil
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event data: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// structuredRoute reads the route field of a decoded result.
+func structuredRoute(result interface{}, field string) (string, error) {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return "", fmt.Errorf("route field %q requires an object result: %w", field, err)
+	}
+	route, ok := fields[field].(string)
+	if !ok || route == "" {
+		return "", fmt.Errorf("route field %q is missing or not a string", field)
+	}
+	return route, nil
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type triageDecision struct {
+	Route      string   `json:"route" enum:"billing,support" description:"Team to handle the ticket"`
+	Confidence float64  `json:"confidence"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+func TestJSONSchemaFor(t *testing.T) {
+	schema, err := JSONSchemaFor(&triageDecision{})
+	if err != nil {
+		t.Fatalf("JSONSchemaFor failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(schema["required"], []string{"route", "confidence"}) {
+		t.Errorf("expected fields without omitempty to be required, got %v", schema["required"])
+	}
+	properties := schema["properties"].(map[string]interface{})
+	route := properties["route"].(map[string]interface{})
+	if route["type"] != "string" || route["description"] != "Team to handle the ticket" {
+		t.Errorf("unexpected route schema: %v", route)
+	}
+	if !reflect.DeepEqual(route["enum"], []interface{}{"billing", "support"}) {
+		t.Errorf("unexpected route enum: %v", route["enum"])
+	}
+	if tags := properties["tags"].(map[string]interface{}); tags["type"] != "array" {
+		t.Errorf("unexpected tags schema: %v", tags)
+	}
+
+	type node struct {
+		Children []node `json:"children"`
+	}
+	if _, err := JSONSchemaFor(node{}); err == nil {
+		t.Error("expected an error for a recursive type")
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema, _ := JSONSchemaFor(triageDecision{})
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"valid", map[string]interface{}{"route": "billing", "confidence": 0.9}, nil},
+		{"wrong type", []interface{}{}, []string{"$: expected object, got array"}},
+		{"missing and unexpected", map[string]interface{}{"route": "sales", "extra": true}, []string{
+			`$: missing required property "confidence"`,
+			"$.extra: unexpected property",
+			`$.route: must be one of ["billing","support"]`,
+		}},
+		{"nested items", map[string]interface{}{"route": "support", "confidence": 1.0, "tags": []interface{}{"ok", 3.0}}, []string{
+			"$.tags[1]: expected string, got number",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateJSONSchema(tt.value, schema); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateJSONSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallStructured_RepairsInvalidReplies(t *testing.T) {
+	provider := NewMockModelProvider().
+		RespondText(MatchUserContains("did not match"), "```json\n{\"route\": \"support\", \"confidence\": 0.7}\n```").
+		SetDefaultResponse(Response{Content: `Sure! {"route": "sales", "confidence": "high"}`})
+
+	var decision triageDecision
+	_, err := CallStructured(context.Background(), provider, Prompt{User: "My printer is on fire"}, &decision, StructuredOutputOptions{})
+	if err != nil {
+		t.Fatalf("CallStructured failed: %v", err)
+	}
+	if decision.Route != "support" || decision.Confidence != 0.7 {
+		t.Errorf("unexpected decision: %+v", decision)
+	}
+
+	calls := provider.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected one repair attempt, got %d calls", len(calls))
+	}
+	if format := calls[0].Parameters.ResponseFormat; format == nil || format.Type != ResponseFormatJSONSchema {
+		t.Errorf("expected the schema to be sent as the response format, got %+v", format)
+	}
+	repair := calls[1]
+	if len(repair.Messages) != 2 || repair.Messages[0].Content != "My printer is on fire" || repair.Messages[1].Role != ChatRoleAssistant {
+		t.Errorf("expected the invalid reply to be part of the conversation, got %+v", repair.Messages)
+	}
+	if !strings.Contains(repair.User, "$.confidence: expected number, got string") {
+		t.Errorf("expected validation errors to be fed back, got %q", repair.User)
+	}
+}
+
+func TestCallStructured_FailsWithTypedError(t *testing.T) {
+	provider := NewMockModelProvider().SetDefaultResponse(Response{Content: "I cannot answer in JSON."})
+
+	var decision triageDecision
+	_, err := CallStructured(context.Background(), provider, Prompt{User: "hi"}, &decision, StructuredOutputOptions{MaxRetries: 1})
+
+	var structuredErr *StructuredOutputError
+	if !errors.As(err, &structuredErr) || !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Fatalf("expected a StructuredOutputError, got %v", err)
+	}
+	if structuredErr.Attempts != 2 || len(provider.Calls()) != 2 {
+		t.Errorf("expected 2 attempts, got %d (calls: %d)", structuredErr.Attempts, len(provider.Calls()))
+	}
+	if structuredErr.Content != "I cannot answer in JSON." {
+		t.Errorf("expected the last reply to be kept, got %q", structuredErr.Content)
+	}
+
+	// Provider errors are not retried
+	failing := NewMockModelProvider().RespondError(MatchAny(), errors.New("rate limited"))
+	if _, err := CallStructured(context.Background(), failing, Prompt{User: "hi"}, &decision, StructuredOutputOptions{}); err == nil || errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Errorf("expected the provider error, got %v", err)
+	}
+	if len(failing.Calls()) != 1 {
+		t.Errorf("expected a single call, got %d", len(failing.Calls()))
+	}
+}
+
+func TestStructuredOutputAgent_StoresTypedResultAndRoutes(t *testing.T) {
+	provider := NewMockModelProvider().RespondText(MatchAny(), `{"route": "billing", "confidence": 0.95}`)
+	agent := NewStructuredOutputAgent(provider, StructuredAgentConfig{
+		SystemPrompt: "Route the ticket.",
+		NewResult:    func() interface{} { return &triageDecision{} },
+		RouteField:   "route",
+	})
+
+	result, err := agent.Run(context.Background(), NewEvent("triage", EventData{"message": "I was charged twice"}, nil), NewState())
+	if err != nil {
+		t.Fatalf("agent failed: %v", err)
+	}
+	value, _ := result.OutputState.Get(DefaultStructuredOutputKey)
+	if decision, ok := value.(triageDecision); !ok || decision.Route != "billing" {
+		t.Errorf("expected a typed triageDecision in state, got %#v", value)
+	}
+	if route, _ := result.OutputState.GetMeta(RouteMetadataKey); route != "billing" {
+		t.Errorf("expected the route to be set from the reply, got %q", route)
+	}
+	if user := provider.Calls()[0].User; user != "I was charged twice" {
+		t.Errorf("expected the event message as input, got %q", user)
+	}
+
+	// Generic JSON values, e.g. from a restored state, decode into the typed result too
+	restored := NewState()
+	restored.Set("decision", map[string]interface{}{"route": "support", "confidence": 0.5})
+	var decision triageDecision
+	if err := GetStructured(restored, "decision", &decision); err != nil || decision.Route != "support" {
+		t.Errorf("GetStructured() = %+v, %v", decision, err)
+	}
+}
@@ -0,0 +1,329 @@
+// Package core provides LLM-driven supervisor orchestration for multi-agent systems
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OrchestrationSupervisor lets an LLM supervisor choose which agent runs next
+const OrchestrationSupervisor OrchestrationMode = "supervisor"
+
+const (
+	// SupervisorFinish is the decision that ends a supervised workflow
+	SupervisorFinish = "FINISH"
+	// DefaultSupervisorMaxSteps is the number of agent runs allowed when no budget is configured
+	DefaultSupervisorMaxSteps = 10
+
+	// SupervisorTaskKey is the metadata key holding the supervisor's instructions for the
+	// agent it picked
+	SupervisorTaskKey = "supervisor_task"
+	// SupervisorStepsKey is the metadata key recording how many agents the supervisor ran
+	SupervisorStepsKey = "supervisor_steps"
+	// SupervisorStopReasonKey is the metadata key recording why the supervisor stopped:
+	// "finished" or "max_steps"
+	SupervisorStopReasonKey = "supervisor_stop_reason"
+)
+
+// AgentDescriber is implemented by handlers that can say what they do. The supervisor
+// shows these descriptions to its model when choosing the next agent.
+type AgentDescriber interface {
+	Description() string
+}
+
+// SupervisorConfig configures supervisor orchestration
+type SupervisorConfig struct {
+	// Provider is the model that makes the routing decisions. Required.
+	Provider ModelProvider
+	// Agents lists the agents the supervisor may choose from. Empty means every
+	// registered agent except the error handlers.
+	Agents []string
+	// ErrorHandler is the name of the configured error handler. Like "error-handler" and
+	// "error_handler", it is never offered to the supervisor when Agents is empty.
+	ErrorHandler string
+	// Descriptions describes agents to the supervisor. They take precedence over
+	// descriptions from handlers implementing AgentDescriber.
+	Descriptions map[string]string
+	// MaxSteps is the maximum number of agent runs per event. 0 uses DefaultSupervisorMaxSteps.
+	MaxSteps int
+	// Instructions are added to the supervisor's system prompt, e.g. the team's goal.
+	Instructions string
+}
+
+// SupervisorDecision is the supervisor's choice for the next step
+type SupervisorDecision struct {
+	Next   string `json:"next"`             // Agent to run next, or SupervisorFinish
+	Task   string `json:"task,omitempty"`   // Instructions for that agent
+	Reason string `json:"reason,omitempty"` // Why the supervisor chose it
+}
+
+// supervisorStep records a completed step for the supervisor's next prompt
+type supervisorStep struct {
+	Agent  string `json:"agent"`
+	Task   string `json:"task,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// supervisorOrchestrator runs the agents chosen by an LLM supervisor one at a time,
+// passing each agent's output state to the next, until the supervisor finishes or the
+// step budget is used up
+type supervisorOrchestrator struct {
+	handlers         map[string]AgentHandler
+	config           SupervisorConfig
+	callbackRegistry *CallbackRegistry
+	mu               sync.RWMutex
+}
+
+// NewSupervisorOrchestrator creates an orchestrator where an LLM supervisor sees the
+// agents' descriptions and the current state, and picks the agent to run next until it
+// decides the work is done
+func NewSupervisorOrchestrator(registry *CallbackRegistry, config SupervisorConfig) Orchestrator {
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = DefaultSupervisorMaxSteps
+	}
+	return &supervisorOrchestrator{
+		handlers:         make(map[string]AgentHandler),
+		config:           config,
+		callbackRegistry: registry,
+	}
+}
+
+// RegisterAgent adds an agent handler the supervisor can choose
+func (o *supervisorOrchestrator) RegisterAgent(name string, handler AgentHandler) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil for agent %s", name)
+	}
+	if strings.EqualFold(name, SupervisorFinish) {
+		return fmt.Errorf("agent name %s is reserved by the supervisor", name)
+	}
+
+	o.handlers[name] = handler
+	Logger().Debug().Str("agent", name).Msg("SupervisorOrchestrator: Agent registered")
+	return nil
+}
+
+// Dispatch lets the supervisor work on the event until it finishes
+func (o *supervisorOrchestrator) Dispatch(ctx context.Context, event Event) (AgentResult, error) {
+	if event == nil {
+		err := errors.New("cannot dispatch nil event")
+		return AgentResult{Error: err.Error()}, err
+	}
+	if o.config.Provider == nil {
+		err := errors.New("supervisor orchestration requires a model provider")
+		return AgentResult{Error: err.Error()}, err
+	}
+
+	o.mu.RLock()
+	handlers := make(map[string]AgentHandler, len(o.handlers))
+	for name, handler := range o.handlers {
+		handlers[name] = handler
+	}
+	o.mu.RUnlock()
+
+	// Initialize state from event data
+	var currentState State = NewState()
+	for key, value := range event.GetData() {
+		currentState.Set(key, value)
+	}
+	for key, value := range event.GetMetadata() {
+		currentState.SetMeta(key, value)
+	}
+
+	workers := o.workers(handlers)
+	if result, handled, err := dispatchOutsideWorkflow(ctx, handlers, workers, event, currentState); handled {
+		return result, err
+	}
+	if len(workers) == 0 {
+		err := errors.New("supervisor has no agents to choose from")
+		return AgentResult{Error: err.Error()}, err
+	}
+	for _, name := range workers {
+		if _, ok := handlers[name]; !ok {
+			err := fmt.Errorf("supervisor agent %s is not registered", name)
+			return AgentResult{Error: err.Error()}, err
+		}
+	}
+
+	var history []supervisorStep
+	stopReason := "max_steps"
+	for step := 0; step < o.config.MaxSteps; step++ {
+		decision, err := o.decide(ctx, workers, handlers, currentState, history)
+		if err != nil {
+			err = fmt.Errorf("supervisor decision failed at step %d: %w", step+1, err)
+			return AgentResult{Error: err.Error()}, err
+		}
+		if decision.Next == SupervisorFinish {
+			Logger().Debug().Int("steps", step).Str("reason", decision.Reason).Msg("SupervisorOrchestrator: Supervisor finished")
+			stopReason = "finished"
+			break
+		}
+
+		Logger().Debug().
+			Int("step", step+1).
+			Str("agent", decision.Next).
+			Str("reason", decision.Reason).
+			Msg("SupervisorOrchestrator: Running agent chosen by supervisor")
+
+		// Always set, so an agent never sees a task left over from an earlier step
+		input := currentState.Clone()
+		input.SetMeta(SupervisorTaskKey, decision.Task)
+		result, err := runAgentHandler(ctx, decision.Next, handlers[decision.Next], event, input)
+		if err != nil {
+			err = fmt.Errorf("supervisor step %d: agent %s failed: %w", step+1, decision.Next, err)
+			return AgentResult{Error: err.Error()}, err
+		}
+		if result.OutputState != nil {
+			currentState = result.OutputState
+		} else {
+			currentState = input
+		}
+		history = append(history, supervisorStep{Agent: decision.Next, Task: decision.Task, Reason: decision.Reason})
+	}
+
+	if stopReason == "max_steps" {
+		Logger().Warn().Int("max_steps", o.config.MaxSteps).Msg("SupervisorOrchestrator: Step budget used up before the supervisor finished")
+	}
+
+	// The last task was meant for the agent that ran it, not for the caller
+	finalState := NewState()
+	finished := finishWorkflow(currentState)
+	for _, key := range finished.Keys() {
+		value, _ := finished.Get(key)
+		finalState.Set(key, value)
+	}
+	for _, key := range finished.MetaKeys() {
+		if key != SupervisorTaskKey {
+			value, _ := finished.GetMeta(key)
+			finalState.SetMeta(key, value)
+		}
+	}
+	finalState.SetMeta(SupervisorStepsKey, strconv.Itoa(len(history)))
+	finalState.SetMeta(SupervisorStopReasonKey, stopReason)
+	return AgentResult{OutputState: finalState}, nil
+}
+
+// workers returns the agents the supervisor may choose, in a stable order
+func (o *supervisorOrchestrator) workers(handlers map[string]AgentHandler) []string {
+	if len(o.config.Agents) > 0 {
+		return o.config.Agents
+	}
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		if !o.isErrorHandler(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isErrorHandler reports whether an agent handles error events rather than doing work.
+// Events routed to it are dispatched to it directly, outside the supervised workflow.
+func (o *supervisorOrchestrator) isErrorHandler(name string) bool {
+	return name == "error-handler" || name == "error_handler" || (o.config.ErrorHandler != "" && name == o.config.ErrorHandler)
+}
+
+// decide asks the supervisor model for the next step. The reply is constrained to the
+// worker names and SupervisorFinish by a JSON schema, with repair retries on invalid replies.
+func (o *supervisorOrchestrator) decide(ctx context.Context, workers []string, handlers map[string]AgentHandler, state State, history []supervisorStep) (SupervisorDecision, error) {
+	choices := make([]interface{}, 0, len(workers)+1)
+	for _, name := range workers {
+		choices = append(choices, name)
+	}
+	choices = append(choices, SupervisorFinish)
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"next":   map[string]interface{}{"type": "string", "enum": choices},
+			"task":   map[string]interface{}{"type": "string"},
+			"reason": map[string]interface{}{"type": "string"},
+		},
+		"required":             []interface{}{"next"},
+		"additionalProperties": false,
+	}
+
+	prompt := Prompt{
+		System: o.systemPrompt(workers, handlers),
+		User:   supervisorUserPrompt(state, history, o.config.MaxSteps-len(history)),
+	}
+	var decision SupervisorDecision
+	if _, err := CallStructured(ctx, o.config.Provider, prompt, &decision, StructuredOutputOptions{Schema: schema, Name: "supervisor_decision"}); err != nil {
+		return decision, err
+	}
+	return decision, nil
+}
+
+// systemPrompt describes the supervisor's role and its agents
+func (o *supervisorOrchestrator) systemPrompt(workers []string, handlers map[string]AgentHandler) string {
+	var sb strings.Builder
+	sb.WriteString("You are a supervisor coordinating a team of agents. Each turn, choose the one agent ")
+	sb.WriteString("that should work next on the current state, or choose ")
+	sb.WriteString(SupervisorFinish)
+	sb.WriteString(" when the work is complete.\n")
+	if o.config.Instructions != "" {
+		sb.WriteString("\n")
+		sb.WriteString(o.config.Instructions)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\nAgents:\n")
+	for _, name := range workers {
+		description := o.config.Descriptions[name]
+		if description == "" {
+			if describer, ok := handlers[name].(AgentDescriber); ok {
+				description = describer.Description()
+			}
+		}
+		if description == "" {
+			description = "No description available."
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, description))
+	}
+
+	sb.WriteString("\nReply with a JSON object: {\"next\": <agent name or \"")
+	sb.WriteString(SupervisorFinish)
+	sb.WriteString("\">, \"task\": <instructions for that agent>, \"reason\": <why>}.")
+	return sb.String()
+}
+
+// supervisorUserPrompt shows the supervisor the current state and the steps taken so far
+func supervisorUserPrompt(state State, history []supervisorStep, remaining int) string {
+	var sb strings.Builder
+	sb.WriteString("Current state:\n")
+	sb.WriteString(stateDataJSON(state))
+
+	sb.WriteString("\n\nSteps taken so far:\n")
+	if len(history) == 0 {
+		sb.WriteString("none\n")
+	}
+	for i, step := range history {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, step.Agent))
+		if step.Task != "" {
+			sb.WriteString(fmt.Sprintf(" (task: %s)", step.Task))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("\nSteps remaining: %d. Which agent should run next?", remaining))
+	return sb.String()
+}
+
+// GetCallbackRegistry returns the callback registry
+func (o *supervisorOrchestrator) GetCallbackRegistry() *CallbackRegistry {
+	return o.callbackRegistry
+}
+
+// Stop halts the supervisor orchestrator
+func (o *supervisorOrchestrator) Stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handlers = make(map[string]AgentHandler)
+	Logger().Debug().Msg("SupervisorOrchestrator: Stopped")
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// describedAgent is a handler that describes itself to the supervisor
+type describedAgent struct {
+	AgentHandlerFunc
+	description string
+}
+
+func (a describedAgent) Description() string {
+	return a.description
+}
+
+func TestSupervisorOrchestrator_RoutesUntilFinished(t *testing.T) {
+	provider := NewMockModelProvider().
+		RespondText(MatchUserContains(`"draft"`), `{"next": "FINISH", "reason": "the draft answers the question"}`).
+		RespondText(MatchUserContains(`"notes"`), `{"next": "writer", "task": "write two paragraphs", "reason": "research is done"}`).
+		SetDefaultResponse(Response{Content: `{"next": "researcher", "task": "find sources", "reason": "nothing known yet"}`})
+
+	var mu sync.Mutex
+	var calls []string
+	tasks := map[string]string{}
+	worker := func(name, key string) AgentHandlerFunc {
+		return func(ctx context.Context, event Event, state State) (AgentResult, error) {
+			task, _ := state.GetMeta(SupervisorTaskKey)
+			mu.Lock()
+			calls = append(calls, name)
+			tasks[name] = task
+			mu.Unlock()
+			output := state.Clone()
+			output.Set(key, name+" output")
+			return AgentResult{OutputState: output}, nil
+		}
+	}
+
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents: map[string]AgentHandler{
+				"researcher": describedAgent{worker("researcher", "notes"), "Finds facts and sources"},
+				"writer":     worker("writer", "draft"),
+			},
+			Memory:    QuickMemory(),
+			SessionID: "supervisor-test",
+			Config:    &Config{},
+		},
+		OrchestrationMode: OrchestrationSupervisor,
+		Supervisor: SupervisorConfig{
+			Provider:     provider,
+			Descriptions: map[string]string{"writer": "Turns notes into prose"},
+			Instructions: "Answer the user's question.",
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	event := NewEvent("supervisor", EventData{"question": "Why is the sky blue?"}, map[string]string{SessionIDKey: "sky", RouteMetadataKey: "supervisor"})
+	result, err := runner.Run(ctx, event)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if draft, _ := result.OutputState.Get("draft"); draft != "writer output" {
+		t.Errorf("expected the writer's draft in the result, got %v", result.OutputState)
+	}
+	if steps, _ := result.OutputState.GetMeta(SupervisorStepsKey); steps != "2" {
+		t.Errorf("expected 2 steps, got %q", steps)
+	}
+	if reason, _ := result.OutputState.GetMeta(SupervisorStopReasonKey); reason != "finished" {
+		t.Errorf("expected the supervisor to finish, got %q", reason)
+	}
+	if _, ok := result.OutputState.GetMeta(SupervisorTaskKey); ok {
+		t.Error("expected the supervisor task not to be returned to the caller")
+	}
+
+	mu.Lock()
+	if len(calls) != 2 || calls[0] != "researcher" || calls[1] != "writer" {
+		t.Errorf("expected researcher then writer, got %v", calls)
+	}
+	if tasks["researcher"] != "find sources" || tasks["writer"] != "write two paragraphs" {
+		t.Errorf("expected each agent to receive its task, got %v", tasks)
+	}
+	mu.Unlock()
+
+	prompts := provider.Calls()
+	if len(prompts) != 3 {
+		t.Fatalf("expected 3 supervisor decisions, got %d", len(prompts))
+	}
+	for _, want := range []string{"researcher: Finds facts and sources", "writer: Turns notes into prose", "Answer the user's question."} {
+		if !strings.Contains(prompts[0].System, want) {
+			t.Errorf("expected the system prompt to contain %q:\n%s", want, prompts[0].System)
+		}
+	}
+	if !strings.Contains(prompts[2].User, "1. researcher (task: find sources)") {
+		t.Errorf("expected the steps taken to be shown to the supervisor:\n%s", prompts[2].User)
+	}
+}
+
+func TestSupervisorOrchestrator_StopsAtStepBudget(t *testing.T) {
+	provider := NewMockModelProvider().SetDefaultResponse(Response{Content: `{"next": "refine"}`})
+	orch := NewSupervisorOrchestrator(NewCallbackRegistry(), SupervisorConfig{Provider: provider, MaxSteps: 3})
+
+	runs := 0
+	orch.RegisterAgent("refine", AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		runs++
+		return AgentResult{OutputState: state.Clone()}, nil
+	}))
+
+	result, err := orch.Dispatch(context.Background(), NewEvent("", EventData{}, nil))
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("expected 3 runs within the budget, got %d", runs)
+	}
+	if reason, _ := result.OutputState.GetMeta(SupervisorStopReasonKey); reason != "max_steps" {
+		t.Errorf("expected the budget to stop the supervisor, got %q", reason)
+	}
+}
+
+func TestSupervisorOrchestrator_Errors(t *testing.T) {
+	noop := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		return AgentResult{OutputState: state}, nil
+	})
+
+	// Replies naming an unknown agent are repaired, then fail the dispatch
+	provider := NewMockModelProvider().SetDefaultResponse(Response{Content: `{"next": "nobody"}`})
+	orch := NewSupervisorOrchestrator(NewCallbackRegistry(), SupervisorConfig{Provider: provider})
+	orch.RegisterAgent("worker", noop)
+	if _, err := orch.Dispatch(context.Background(), NewEvent("", EventData{}, nil)); !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Errorf("expected an invalid decision error, got %v", err)
+	}
+
+	orch = NewSupervisorOrchestrator(NewCallbackRegistry(), SupervisorConfig{Provider: provider, Agents: []string{"missing"}})
+	if _, err := orch.Dispatch(context.Background(), NewEvent("", EventData{}, nil)); err == nil {
+		t.Error("expected an error for an unregistered supervisor agent")
+	}
+
+	if _, err := NewSupervisorOrchestrator(nil, SupervisorConfig{}).Dispatch(context.Background(), NewEvent("", EventData{}, nil)); err == nil {
+		t.Error("expected an error without a provider")
+	}
+	if err := orch.RegisterAgent("finish", noop); err == nil {
+		t.Error("expected the FINISH name to be reserved")
+	}
+}
+
+func TestSupervisorOrchestrator_ExcludesErrorHandlers(t *testing.T) {
+	provider := NewMockModelProvider().SetDefaultResponse(Response{Content: `{"next": "FINISH"}`})
+	handled := 0
+	config := &Config{}
+	config.ErrorRouting.ErrorHandlerName = "oops"
+
+	runner := NewRunnerWithOrchestration(EnhancedRunnerConfig{
+		RunnerConfig: RunnerConfig{
+			Agents: map[string]AgentHandler{
+				"worker": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+					return AgentResult{OutputState: state}, nil
+				}),
+				"oops": AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+					handled++
+					state.SetMeta(RouteMetadataKey, "")
+					return AgentResult{OutputState: state}, nil
+				}),
+			},
+			Memory:    QuickMemory(),
+			SessionID: "supervisor-errors",
+			Config:    config,
+		},
+		OrchestrationMode: OrchestrationSupervisor,
+		Supervisor:        SupervisorConfig{Provider: provider},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	if _, err := runner.Run(ctx, NewEvent("supervisor", EventData{}, map[string]string{SessionIDKey: "s", RouteMetadataKey: "supervisor"})); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	prompts := provider.Calls()
+	if len(prompts) != 1 {
+		t.Fatalf("expected 1 supervisor decision, got %d", len(prompts))
+	}
+	if !strings.Contains(prompts[0].System, "- worker:") || strings.Contains(prompts[0].System, "error-handler") || strings.Contains(prompts[0].System, "oops") {
+		t.Errorf("expected only the worker to be offered:\n%s", prompts[0].System)
+	}
+
+	// Error events go straight to the handlers, without a supervisor loop
+	for _, route := range []string{"error-handler", "oops"} {
+		if _, err := runner.Run(ctx, NewEvent("supervisor", EventData{}, map[string]string{SessionIDKey: "s", RouteMetadataKey: route})); err != nil {
+			t.Fatalf("Run to %s failed: %v", route, err)
+		}
+	}
+	if calls := len(provider.Calls()); calls != 1 || handled != 1 {
+		t.Errorf("expected error events to skip the supervisor, got %d decisions and %d handled", calls, handled)
+	}
+}
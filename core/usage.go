@@ -0,0 +1,435 @@
+// Package core provides token usage accounting, cost estimation and budgets for AgentFlow.
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned once a session has used more tokens or cost than its budget allows.
+var ErrBudgetExceeded = errors.New("usage budget exceeded")
+
+// ModelPrice is the price of a model in currency units per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million" toml:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million" toml:"output_per_million"`
+}
+
+// PriceTable maps model names to prices. A key ending in "*" matches any model with
+// that prefix, and a provider name matches every model of that provider.
+type PriceTable map[string]ModelPrice
+
+// Lookup finds the price for a model: an exact model match wins, then the longest
+// matching prefix pattern, then the provider name.
+func (t PriceTable) Lookup(provider, model string) (ModelPrice, bool) {
+	if price, ok := t[model]; ok && model != "" {
+		return price, true
+	}
+	bestLen := -1
+	var best ModelPrice
+	for key, price := range t {
+		prefix := strings.TrimSuffix(key, "*")
+		if prefix == key || !strings.HasPrefix(model, prefix) {
+			continue
+		}
+		if len(prefix) > bestLen {
+			bestLen = len(prefix)
+			best = price
+		}
+	}
+	if bestLen >= 0 {
+		return best, true
+	}
+	if price, ok := t[provider]; ok && provider != "" {
+		return price, true
+	}
+	return ModelPrice{}, false
+}
+
+// Cost estimates the cost of a call from its token counts.
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1e6
+}
+
+// UsageBudget limits what a single session may consume. Zero values mean no limit.
+type UsageBudget struct {
+	MaxTokensPerSession int
+	MaxCostPerSession   float64
+}
+
+// UsageRecord is the usage of a single model call.
+type UsageRecord struct {
+	Timestamp        time.Time `json:"timestamp"`
+	SessionID        string    `json:"session_id"`
+	AgentID          string    `json:"agent_id,omitempty"`
+	Provider         string    `json:"provider,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// UsageTotals aggregates usage over any number of calls.
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotals) add(record UsageRecord) {
+	t.Calls++
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	t.TotalTokens += record.TotalTokens
+	t.Cost += record.Cost
+}
+
+// UsageSummary breaks down a session's usage by agent and by model.
+type UsageSummary struct {
+	SessionID string                 `json:"session_id"`
+	Total     UsageTotals            `json:"total"`
+	ByAgent   map[string]UsageTotals `json:"by_agent"`
+	ByModel   map[string]UsageTotals `json:"by_model"`
+}
+
+// SummarizeUsage aggregates records into a UsageSummary. Calls made outside any agent are
+// grouped under "(none)"; the model key is "provider/model", or just the provider when the
+// model is unknown.
+func SummarizeUsage(sessionID string, records []UsageRecord) UsageSummary {
+	summary := UsageSummary{
+		SessionID: sessionID,
+		ByAgent:   make(map[string]UsageTotals),
+		ByModel:   make(map[string]UsageTotals),
+	}
+	for _, record := range records {
+		summary.Total.add(record)
+
+		agent := record.AgentID
+		if agent == "" {
+			agent = "(none)"
+		}
+		agentTotals := summary.ByAgent[agent]
+		agentTotals.add(record)
+		summary.ByAgent[agent] = agentTotals
+
+		model := record.Provider
+		if record.Model != "" {
+			model = strings.TrimPrefix(record.Provider+"/"+record.Model, "/")
+		}
+		modelTotals := summary.ByModel[model]
+		modelTotals.add(record)
+		summary.ByModel[model] = modelTotals
+	}
+	return summary
+}
+
+// UsageLedgerConfig configures a UsageLedger.
+type UsageLedgerConfig struct {
+	// Prices is used to estimate the cost of each call. Unpriced models cost 0.
+	Prices PriceTable
+	// Budget aborts a session once it is exceeded.
+	Budget UsageBudget
+	// LogDir, when set, receives one <session>.usage.jsonl file per session,
+	// appended to as calls complete. `agentcli usage` reads these files.
+	LogDir string
+}
+
+// UsageLedger records token usage per session, agent and model, estimates cost and
+// enforces per-session budgets. It is safe for concurrent use.
+type UsageLedger struct {
+	mu       sync.RWMutex
+	config   UsageLedgerConfig
+	records  map[string][]UsageRecord
+	totals   map[string]UsageTotals
+	exceeded map[string]error
+}
+
+// NewUsageLedger creates an empty ledger.
+func NewUsageLedger(config UsageLedgerConfig) *UsageLedger {
+	return &UsageLedger{
+		config:   config,
+		records:  make(map[string][]UsageRecord),
+		totals:   make(map[string]UsageTotals),
+		exceeded: make(map[string]error),
+	}
+}
+
+// Record adds a call to the ledger, filling in the timestamp and estimated cost.
+// It returns an error wrapping ErrBudgetExceeded when this call pushes the session
+// over budget; the call is recorded either way.
+func (l *UsageLedger) Record(record UsageRecord) error {
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	if record.SessionID == "" {
+		record.SessionID = "default"
+	}
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	if price, ok := l.config.Prices.Lookup(record.Provider, record.Model); ok {
+		record.Cost = price.Cost(record.PromptTokens, record.CompletionTokens)
+	}
+
+	l.mu.Lock()
+	l.records[record.SessionID] = append(l.records[record.SessionID], record)
+	totals := l.totals[record.SessionID]
+	totals.add(record)
+	l.totals[record.SessionID] = totals
+
+	var budgetErr error
+	if l.exceeded[record.SessionID] == nil {
+		budgetErr = l.config.Budget.check(totals)
+		if budgetErr != nil {
+			l.exceeded[record.SessionID] = budgetErr
+		}
+	}
+	l.mu.Unlock()
+
+	if err := l.persist(record); err != nil {
+		Logger().Error().Str("session_id", record.SessionID).Err(err).Msg("UsageLedger: Failed to persist usage record")
+	}
+	if budgetErr != nil {
+		Logger().Warn().Str("session_id", record.SessionID).Err(budgetErr).Msg("UsageLedger: Session exceeded its usage budget")
+	}
+	return budgetErr
+}
+
+// check reports an error wrapping ErrBudgetExceeded when totals are over the budget.
+func (b UsageBudget) check(totals UsageTotals) error {
+	if b.MaxTokensPerSession > 0 && totals.TotalTokens > b.MaxTokensPerSession {
+		return fmt.Errorf("%w: %d tokens used, limit is %d", ErrBudgetExceeded, totals.TotalTokens, b.MaxTokensPerSession)
+	}
+	if b.MaxCostPerSession > 0 && totals.Cost > b.MaxCostPerSession {
+		return fmt.Errorf("%w: cost %.4f, limit is %.4f", ErrBudgetExceeded, totals.Cost, b.MaxCostPerSession)
+	}
+	return nil
+}
+
+// CheckBudget returns an error wrapping ErrBudgetExceeded if the session is over budget.
+func (l *UsageLedger) CheckBudget(sessionID string) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.exceeded[sessionID]
+}
+
+// Records returns the calls recorded for a session, oldest first.
+func (l *UsageLedger) Records(sessionID string) []UsageRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	records := make([]UsageRecord, len(l.records[sessionID]))
+	copy(records, l.records[sessionID])
+	return records
+}
+
+// Summary aggregates a session's usage by agent and model.
+func (l *UsageLedger) Summary(sessionID string) UsageSummary {
+	return SummarizeUsage(sessionID, l.Records(sessionID))
+}
+
+// Sessions lists the sessions with recorded usage, sorted by ID.
+func (l *UsageLedger) Sessions() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	sessions := make([]string, 0, len(l.records))
+	for sessionID := range l.records {
+		sessions = append(sessions, sessionID)
+	}
+	sort.Strings(sessions)
+	return sessions
+}
+
+// persist appends a record to the session's usage file when LogDir is set.
+func (l *UsageLedger) persist(record UsageRecord) error {
+	if l.config.LogDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	path, err := UsageLogPath(l.config.LogDir, record.SessionID)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.config.LogDir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// UsageLogPath returns the usage file written for a session under dir. Session IDs that
+// could name a file outside dir are rejected.
+func UsageLogPath(dir, sessionID string) (string, error) {
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\`) || sessionID == "." || sessionID == ".." {
+		return "", fmt.Errorf("invalid session ID for usage log: %q", sessionID)
+	}
+	return filepath.Join(dir, sessionID+".usage.jsonl"), nil
+}
+
+// LoadUsageRecords reads a usage file written by a UsageLedger.
+func LoadUsageRecords(path string) ([]UsageRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record UsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+type usageLedgerKey struct{}
+type usageAgentKey struct{}
+
+// usageScope is the ledger and session that model calls made with a context are charged to.
+type usageScope struct {
+	ledger    *UsageLedger
+	sessionID string
+}
+
+// WithUsageLedger charges model calls made with the returned context to sessionID in ledger.
+// The Runner does this for every event when it has a ledger.
+func WithUsageLedger(ctx context.Context, ledger *UsageLedger, sessionID string) context.Context {
+	return context.WithValue(ctx, usageLedgerKey{}, usageScope{ledger: ledger, sessionID: sessionID})
+}
+
+// WithUsageAgent attributes model calls made with the returned context to agentID.
+// Orchestrators do this before running each agent.
+func WithUsageAgent(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, usageAgentKey{}, agentID)
+}
+
+// usageScopeFromContext returns the ledger, session and agent attached to ctx, if any.
+func usageScopeFromContext(ctx context.Context) (usageScope, string, bool) {
+	scope, ok := ctx.Value(usageLedgerKey{}).(usageScope)
+	if !ok || scope.ledger == nil {
+		return usageScope{}, "", false
+	}
+	agentID, _ := ctx.Value(usageAgentKey{}).(string)
+	return scope, agentID, true
+}
+
+// usageTrackingProvider records the usage of every call into the ledger found on the call's context.
+type usageTrackingProvider struct {
+	inner    ModelProvider
+	provider string
+	model    string
+}
+
+// NewUsageTrackingProvider wraps a provider so that calls made with a context carrying a
+// UsageLedger are recorded under the given provider and model names, and are refused with
+// ErrBudgetExceeded once the session is over budget. Providers created from configuration
+// are wrapped automatically.
+func NewUsageTrackingProvider(inner ModelProvider, provider, model string) ModelProvider {
+	return &usageTrackingProvider{inner: inner, provider: provider, model: model}
+}
+
+func (p *usageTrackingProvider) record(ctx context.Context, usage UsageStats) {
+	scope, agentID, ok := usageScopeFromContext(ctx)
+	if !ok || (usage.TotalTokens == 0 && usage.PromptTokens == 0 && usage.CompletionTokens == 0) {
+		return
+	}
+	// Budget errors are enforced on the next call; this call has already been paid for
+	_ = scope.ledger.Record(UsageRecord{
+		SessionID:        scope.sessionID,
+		AgentID:          agentID,
+		Provider:         p.provider,
+		Model:            p.model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	})
+}
+
+func (p *usageTrackingProvider) checkBudget(ctx context.Context) error {
+	scope, _, ok := usageScopeFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return scope.ledger.CheckBudget(scope.sessionID)
+}
+
+// Call implements ModelProvider.
+func (p *usageTrackingProvider) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	if err := p.checkBudget(ctx); err != nil {
+		return Response{}, err
+	}
+	response, err := p.inner.Call(ctx, prompt)
+	if err == nil {
+		p.record(ctx, response.Usage)
+	}
+	return response, err
+}
+
+// Stream implements ModelProvider, recording the usage reported on the final token.
+func (p *usageTrackingProvider) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	if err := p.checkBudget(ctx); err != nil {
+		return nil, err
+	}
+	tokens, err := p.inner.Stream(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, ok := usageScopeFromContext(ctx); !ok {
+		return tokens, nil
+	}
+
+	out := make(chan Token)
+	go func() {
+		defer close(out)
+		for token := range tokens {
+			if token.Usage != nil {
+				p.record(ctx, *token.Usage)
+			}
+			select {
+			case out <- token:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Embeddings implements ModelProvider. Embedding calls are subject to the budget but
+// are not recorded, since providers do not report their token usage.
+func (p *usageTrackingProvider) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if err := p.checkBudget(ctx); err != nil {
+		return nil, err
+	}
+	return p.inner.Embeddings(ctx, texts)
+}
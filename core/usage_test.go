@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := PriceTable{
+		"gpt-4o":       {InputPerMillion: 2.5, OutputPerMillion: 10},
+		"gpt-4o-*":     {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+		"claude-*":     {InputPerMillion: 3, OutputPerMillion: 15},
+		"local-ollama": {},
+	}
+
+	tests := []struct {
+		provider, model string
+		want            float64
+		found           bool
+	}{
+		{"openai", "gpt-4o", 2.5, true},
+		{"openai", "gpt-4o-mini", 0.15, true},
+		{"anthropic", "claude-3-5-haiku-latest", 3, true},
+		{"local-ollama", "llama3.2", 0, true},
+		{"azure", "unknown", 0, false},
+	}
+	for _, tt := range tests {
+		price, found := prices.Lookup(tt.provider, tt.model)
+		if found != tt.found || price.InputPerMillion != tt.want {
+			t.Errorf("Lookup(%q, %q) = %+v, %v; want input %v, %v", tt.provider, tt.model, price, found, tt.want, tt.found)
+		}
+	}
+
+	if cost := prices["gpt-4o"].Cost(1000, 500); cost != 0.0075 {
+		t.Errorf("expected cost 0.0075, got %v", cost)
+	}
+}
+
+func TestUsageLedger_SummaryAndBudget(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewUsageLedger(UsageLedgerConfig{
+		Prices: PriceTable{"gpt-4o": {InputPerMillion: 1e6, OutputPerMillion: 1e6}},
+		Budget: UsageBudget{MaxTokensPerSession: 50},
+		LogDir: dir,
+	})
+
+	if err := ledger.Record(UsageRecord{SessionID: "s1", AgentID: "planner", Provider: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5}); err != nil {
+		t.Fatalf("unexpected budget error: %v", err)
+	}
+	if err := ledger.Record(UsageRecord{SessionID: "s1", AgentID: "writer", Provider: "ollama", PromptTokens: 30, CompletionTokens: 10}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if err := ledger.CheckBudget("s1"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected session s1 to stay over budget, got %v", err)
+	}
+	if err := ledger.CheckBudget("s2"); err != nil {
+		t.Errorf("expected other sessions to be unaffected, got %v", err)
+	}
+
+	summary := ledger.Summary("s1")
+	if summary.Total.Calls != 2 || summary.Total.TotalTokens != 55 || summary.Total.Cost != 15 {
+		t.Errorf("unexpected totals: %+v", summary.Total)
+	}
+	if summary.ByAgent["planner"].TotalTokens != 15 || summary.ByAgent["writer"].TotalTokens != 40 {
+		t.Errorf("unexpected per-agent totals: %+v", summary.ByAgent)
+	}
+	if _, ok := summary.ByModel["openai/gpt-4o"]; !ok {
+		t.Errorf("expected provider/model key, got %+v", summary.ByModel)
+	}
+	if _, ok := summary.ByModel["ollama"]; !ok {
+		t.Errorf("expected provider key for unknown model, got %+v", summary.ByModel)
+	}
+
+	path, err := UsageLogPath(dir, "s1")
+	if err != nil {
+		t.Fatalf("UsageLogPath failed: %v", err)
+	}
+	records, err := LoadUsageRecords(path)
+	if err != nil {
+		t.Fatalf("LoadUsageRecords failed: %v", err)
+	}
+	if persisted := SummarizeUsage("s1", records); persisted.Total != summary.Total {
+		t.Errorf("persisted totals %+v differ from ledger totals %+v", persisted.Total, summary.Total)
+	}
+}
+
+func TestUsageLogPath_RejectsUnsafeSessionIDs(t *testing.T) {
+	dir := t.TempDir()
+	for _, sessionID := range []string{"", ".", "..", "../escape", "a/b", `a\b`} {
+		if _, err := UsageLogPath(dir, sessionID); err == nil {
+			t.Errorf("expected session ID %q to be rejected", sessionID)
+		}
+	}
+	if path, err := UsageLogPath(dir, "run.2024-01-01"); err != nil || filepath.Dir(path) != dir {
+		t.Errorf("expected a dotted session ID to stay in %s, got %q (%v)", dir, path, err)
+	}
+
+	// The ledger keeps its totals but writes nothing outside LogDir
+	logDir := filepath.Join(dir, "logs")
+	ledger := NewUsageLedger(UsageLedgerConfig{LogDir: logDir})
+	ledger.Record(UsageRecord{SessionID: "../escape", PromptTokens: 1})
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.usage.jsonl")); len(matches) != 0 {
+		t.Errorf("expected no usage file outside the log directory, found %v", matches)
+	}
+	if summary := ledger.Summary("../escape"); summary.Total.Calls != 1 {
+		t.Errorf("expected the record to be counted, got %+v", summary.Total)
+	}
+}
+
+func TestUsageTrackingProvider_RecordsAndEnforcesBudget(t *testing.T) {
+	mock := NewMockModelProvider().Respond(MatchAny(), Response{
+		Content: "done",
+		Usage:   UsageStats{PromptTokens: 8, CompletionTokens: 4, TotalTokens: 12},
+	})
+	provider := NewUsageTrackingProvider(mock, "mock", "mock-large")
+	ledger := NewUsageLedger(UsageLedgerConfig{Budget: UsageBudget{MaxTokensPerSession: 20}})
+
+	// Without a ledger on the context calls pass straight through
+	if _, err := provider.Call(context.Background(), Prompt{User: "hi"}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	ctx := WithUsageAgent(WithUsageLedger(context.Background(), ledger, "s1"), "researcher")
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Call(ctx, Prompt{User: "hi"}); err != nil {
+			t.Fatalf("Call %d failed: %v", i, err)
+		}
+	}
+	if _, err := provider.Call(ctx, Prompt{User: "hi"}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected calls to be refused once over budget, got %v", err)
+	}
+	if _, err := provider.Stream(ctx, Prompt{User: "hi"}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected streams to be refused once over budget, got %v", err)
+	}
+
+	records := ledger.Records("s1")
+	if len(records) != 2 || records[0].AgentID != "researcher" || records[0].Model != "mock-large" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestUsageTrackingProvider_RecordsStreamUsage(t *testing.T) {
+	mock := NewMockModelProvider().RespondText(MatchAny(), "streamed words here")
+	provider := NewUsageTrackingProvider(mock, "mock", "")
+	ledger := NewUsageLedger(UsageLedgerConfig{})
+
+	tokens, err := provider.Stream(WithUsageLedger(context.Background(), ledger, "s1"), Prompt{User: "a long enough prompt"})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	for range tokens {
+	}
+	if summary := ledger.Summary("s1"); summary.Total.Calls != 1 || summary.Total.TotalTokens == 0 {
+		t.Errorf("expected the final token's usage to be recorded, got %+v", summary.Total)
+	}
+}
+
+func TestRunnerChargesUsageAndAbortsOverBudget(t *testing.T) {
+	provider := NewUsageTrackingProvider(NewMockModelProvider().Respond(MatchAny(), Response{
+		Content: "ok",
+		Usage:   UsageStats{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+	}), "mock", "")
+	ledger := NewUsageLedger(UsageLedgerConfig{Budget: UsageBudget{MaxTokensPerSession: 25}})
+
+	var runs int32
+	agent := AgentHandlerFunc(func(ctx context.Context, event Event, state State) (AgentResult, error) {
+		atomic.AddInt32(&runs, 1)
+		if _, err := provider.Call(ctx, Prompt{User: "work"}); err != nil {
+			return AgentResult{}, err
+		}
+		return AgentResult{OutputState: state}, nil
+	})
+
+	runner := NewRunnerWithConfig(RunnerConfig{
+		Agents:      map[string]AgentHandler{"worker": agent},
+		Memory:      QuickMemory(),
+		SessionID:   "usage-session",
+		UsageLedger: ledger,
+		Config:      &Config{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runner.Start(ctx); err != nil {
+		t.Fatalf("Runner failed to start: %v", err)
+	}
+	defer runner.Stop()
+
+	meta := map[string]string{SessionIDKey: "budget", RouteMetadataKey: "worker"}
+	if err := runner.Emit(NewEvent("worker", EventData{}, meta)); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	if err := runner.Emit(NewEvent("worker", EventData{}, meta)); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+
+	var entries []TraceEntry
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		entries, _ = runner.DumpTrace("budget")
+		if hasTraceType(entries, "budget_exceeded") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !hasTraceType(entries, "budget_exceeded") {
+		t.Fatalf("expected the second event to be dropped, trace: %+v", entries)
+	}
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Errorf("expected the agent to run once, ran %d times", got)
+	}
+	for _, entry := range entries {
+		if entry.Type == "llm_usage" {
+			if entry.AgentID != "worker" || entry.Usage == nil || entry.Usage.TotalTokens != 30 {
+				t.Errorf("unexpected usage entry: %+v", entry)
+			}
+			return
+		}
+	}
+	t.Errorf("expected an llm_usage entry in the trace: %+v", entries)
+}
+
+func hasTraceType(entries []TraceEntry, entryType string) bool {
+	for _, entry := range entries {
+		if entry.Type == entryType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInitializeProvider_UsageTracking(t *testing.T) {
+	cfg := &Config{Providers: map[string]map[string]interface{}{
+		"mock": {"model": "mock-small"},
+	}}
+	cfg.AgentFlow.Provider = "mock"
+	provider, err := cfg.InitializeProvider()
+	if err != nil {
+		t.Fatalf("InitializeProvider failed: %v", err)
+	}
+
+	ledger := NewUsageLedger(UsageLedgerConfig{LogDir: filepath.Join(t.TempDir(), "usage")})
+	if _, err := provider.Call(WithUsageLedger(context.Background(), ledger, "cfg"), Prompt{User: "count these tokens please"}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	records := ledger.Records("cfg")
+	if len(records) != 1 || records[0].Provider != "mock" || records[0].Model != "mock-small" {
+		t.Errorf("expected configured providers to be tracked, got %+v", records)
+	}
+}
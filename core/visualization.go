@@ -71,6 +71,8 @@ func (cb *CompositionBuilder) GenerateMermaidDiagramWithConfig(config MermaidCon
 		cb.generateSequentialDiagram(&diagram, config)
 	case "loop":
 		cb.generateLoopDiagram(&diagram, config)
+	case "map-reduce":
+		generateMapReduceDiagram(&diagram, cb.agents)
 	default:
 		cb.generateDefaultDiagram(&diagram, config)
 	}
@@ -209,6 +211,12 @@ func (ob *OrchestrationBuilder) GenerateMermaidDiagramWithConfig(config MermaidC
 		ob.generateParallelOrchestrationDiagram(&diagram, config)
 	case OrchestrationLoop:
 		ob.generateLoopOrchestrationDiagram(&diagram, config)
+	case OrchestrationGraph:
+		if ob.graph == nil {
+			ob.generateDefaultOrchestrationDiagram(&diagram, config)
+		} else {
+			writeGraphDiagram(&diagram, ob.graph)
+		}
 	default:
 		ob.generateDefaultOrchestrationDiagram(&diagram, config)
 	}
@@ -319,6 +327,100 @@ func (ob *OrchestrationBuilder) generateDefaultOrchestrationDiagram(diagram *str
 	diagram.WriteString("    UNKNOWN --> RESULT\n")
 }
 
+// =============================================================================
+// WORKFLOW GRAPH VISUALIZATION
+// =============================================================================
+
+// GenerateGraphMermaidDiagram generates a Mermaid diagram for a workflow graph
+func GenerateGraphMermaidDiagram(graph *WorkflowGraph) string {
+	return GenerateGraphMermaidDiagramWithConfig(graph, DefaultMermaidConfig())
+}
+
+// GenerateGraphMermaidDiagramWithConfig generates a Mermaid diagram for a workflow graph.
+// Conditional edges are drawn dotted and labelled with their condition.
+func GenerateGraphMermaidDiagramWithConfig(graph *WorkflowGraph, config MermaidConfig) string {
+	var diagram strings.Builder
+
+	title := "Graph Workflow"
+	if config.Title != "" {
+		title = config.Title
+	}
+
+	diagram.WriteString(fmt.Sprintf("---\ntitle: \"%s\"\n---\n", title))
+	diagram.WriteString(fmt.Sprintf("flowchart %s\n", config.Direction))
+	writeGraphDiagram(&diagram, graph)
+
+	if config.ShowMetadata {
+		diagram.WriteString("\n    %% Graph Metadata\n")
+		diagram.WriteString(fmt.Sprintf("    %%%% Nodes: %d\n", len(graph.nodes)))
+		diagram.WriteString(fmt.Sprintf("    %%%% Edges: %d\n", len(graph.edges)))
+	}
+
+	diagram.WriteString("\n    %% Graph Styling\n")
+	diagram.WriteString("    classDef eventNode fill:#e3f2fd,stroke:#0277bd,stroke-width:2px,color:#000\n")
+	diagram.WriteString("    classDef handlerNode fill:#fce4ec,stroke:#c2185b,stroke-width:2px,color:#000\n")
+	diagram.WriteString("    classDef resultNode fill:#e8f5e8,stroke:#388e3c,stroke-width:2px,color:#000\n")
+	diagram.WriteString("    class EVENT eventNode\n")
+	diagram.WriteString("    class RESULT resultNode\n")
+	for _, node := range graph.nodes {
+		diagram.WriteString(fmt.Sprintf("    class %s handlerNode\n", mermaidNodeID(node.Name)))
+	}
+
+	return diagram.String()
+}
+
+// writeGraphDiagram writes the nodes and edges of a workflow graph, from the input event
+// through its entry nodes to the result
+func writeGraphDiagram(diagram *strings.Builder, graph *WorkflowGraph) {
+	diagram.WriteString("    EVENT[\"📨 Input Event\"]\n")
+	for _, node := range graph.nodes {
+		label := node.Name
+		if node.Join == JoinAny {
+			label += " (any)"
+		}
+		diagram.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", mermaidNodeID(node.Name), label))
+	}
+	diagram.WriteString("    RESULT[\"🎯 Final Result\"]\n\n")
+
+	outgoing := make(map[string]bool)
+	for _, entry := range graph.EntryNodes() {
+		diagram.WriteString(fmt.Sprintf("    EVENT --> %s\n", mermaidNodeID(entry)))
+	}
+	for _, edge := range graph.edges {
+		outgoing[edge.From] = true
+		if edge.Condition == nil {
+			diagram.WriteString(fmt.Sprintf("    %s --> %s\n", mermaidNodeID(edge.From), mermaidNodeID(edge.To)))
+			continue
+		}
+		label := edge.Label
+		if label == "" {
+			label = "condition"
+		}
+		label = strings.ReplaceAll(label, "\"", "'")
+		diagram.WriteString(fmt.Sprintf("    %s -.->|\"%s\"| %s\n", mermaidNodeID(edge.From), label, mermaidNodeID(edge.To)))
+	}
+	for _, node := range graph.nodes {
+		if !outgoing[node.Name] {
+			diagram.WriteString(fmt.Sprintf("    %s --> RESULT\n", mermaidNodeID(node.Name)))
+		}
+	}
+}
+
+// mermaidNodeID turns an agent name into a Mermaid node ID that cannot clash with the
+// EVENT and RESULT nodes
+func mermaidNodeID(name string) string {
+	var id strings.Builder
+	id.WriteString("N_")
+	for _, r := range name {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			id.WriteRune(r)
+		} else {
+			id.WriteRune('_')
+		}
+	}
+	return id.String()
+}
+
 // =============================================================================
 // HELPER METHODS
 // =============================================================================
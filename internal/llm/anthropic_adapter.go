@@ -0,0 +1,403 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicDefaultBaseURL is the root of the public Anthropic API.
+	anthropicDefaultBaseURL = "https://api.anthropic.com"
+	// anthropicAPIVersion is sent in the anthropic-version header.
+	anthropicAPIVersion = "2023-06-01"
+)
+
+// AnthropicAdapter implements the ModelProvider interface for Anthropic's Messages API.
+type AnthropicAdapter struct {
+	apiKey      string
+	model       string
+	maxTokens   int
+	temperature float32
+	baseURL     string // API root, e.g. https://api.anthropic.com
+}
+
+// NewAnthropicAdapter creates a new AnthropicAdapter instance.
+func NewAnthropicAdapter(apiKey, model string, maxTokens int, temperature float32) (*AnthropicAdapter, error) {
+	if apiKey == "" {
+		return nil, errors.New("API key cannot be empty")
+	}
+	if model == "" {
+		model = "claude-3-5-haiku-latest" // Default model
+	}
+	if maxTokens == 0 {
+		maxTokens = 1024 // The Messages API requires max_tokens
+	}
+	if temperature == 0 {
+		temperature = 0.7 // Default temperature
+	}
+
+	return &AnthropicAdapter{
+		apiKey:      apiKey,
+		model:       model,
+		maxTokens:   maxTokens,
+		temperature: temperature,
+		baseURL:     anthropicDefaultBaseURL,
+	}, nil
+}
+
+// --- API Specific Structs ---
+
+// anthropicContentBlock is a single content block in a Messages API message.
+// Only the fields relevant to the block's Type are set.
+type anthropicContentBlock struct {
+	Type      string                 `json:"type"`                  // "text", "tool_use" or "tool_result"
+	Text      string                 `json:"text,omitempty"`        // For "text"
+	ID        string                 `json:"id,omitempty"`          // For "tool_use"
+	Name      string                 `json:"name,omitempty"`        // For "tool_use"
+	Input     map[string]interface{} `json:"input,omitempty"`       // For "tool_use"
+	ToolUseID string                 `json:"tool_use_id,omitempty"` // For "tool_result"
+	Content   string                 `json:"content,omitempty"`     // For "tool_result"
+}
+
+// anthropicMessage is a user or assistant turn. The Messages API has no system or tool roles.
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicTool describes a function the model may call.
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// anthropicUsage reports token counts for a request.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicMessagesResponse is the body of a non-streaming Messages API response.
+type anthropicMessagesResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicStreamEvent is the data payload of a Messages API server-sent event.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"` // For message_start
+	Index        int                   `json:"index"`         // Content block, for content_block_start and content_block_delta
+	ContentBlock anthropicContentBlock `json:"content_block"` // For content_block_start
+	Delta        struct {
+		Type        string `json:"type"`         // "text_delta" or "input_json_delta" for content_block_delta
+		Text        string `json:"text"`         // For text_delta
+		PartialJSON string `json:"partial_json"` // For input_json_delta
+		StopReason  string `json:"stop_reason"`  // For message_delta
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage"` // For message_delta
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"` // For error
+}
+
+// Call implements the ModelProvider interface for a single request/response.
+func (a *AnthropicAdapter) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	if !hasUserInput(prompt) {
+		return Response{}, errors.New("user prompt cannot be empty")
+	}
+
+	body, err := a.buildRequestBody(prompt, false)
+	if err != nil {
+		return Response{}, err
+	}
+	resp, err := a.doMessagesRequest(ctx, body)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp anthropicMessagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var content strings.Builder
+	var toolCalls []ToolCall
+	for _, block := range apiResp.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			args := block.Input
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			toolCalls = append(toolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: args})
+		}
+	}
+
+	return Response{
+		Content:   content.String(),
+		ToolCalls: toolCalls,
+		Usage: UsageStats{
+			PromptTokens:     apiResp.Usage.InputTokens,
+			CompletionTokens: apiResp.Usage.OutputTokens,
+			TotalTokens:      apiResp.Usage.InputTokens + apiResp.Usage.OutputTokens,
+		},
+		FinishReason: apiResp.StopReason,
+	}, nil
+}
+
+// Stream implements the ModelProvider interface for streaming responses.
+// Text deltas are forwarded as they arrive; the final token carries the stop reason, usage
+// and the tool calls assembled from tool_use blocks.
+func (a *AnthropicAdapter) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	if !hasUserInput(prompt) {
+		return nil, errors.New("user prompt cannot be empty")
+	}
+
+	body, err := a.buildRequestBody(prompt, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.doMessagesRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenChan := make(chan Token)
+	go func() {
+		defer close(tokenChan)
+		defer resp.Body.Close()
+
+		index := 0
+		stopReason := ""
+		var usage UsageStats
+		// Tool use blocks in order, and their position by content block index
+		var toolCalls []ToolCall
+		var toolInputs []string
+		toolBlocks := make(map[int]int)
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data:") {
+				continue // Skip event: lines and blank separators; the payload repeats the type
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+				sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("stream decode error: %w", err)})
+				return
+			}
+
+			switch event.Type {
+			case "message_start":
+				usage.PromptTokens = event.Message.Usage.InputTokens
+			case "content_block_start":
+				if event.ContentBlock.Type == "tool_use" {
+					toolBlocks[event.Index] = len(toolCalls)
+					toolCalls = append(toolCalls, ToolCall{ID: event.ContentBlock.ID, Name: event.ContentBlock.Name})
+					toolInputs = append(toolInputs, "")
+				}
+			case "content_block_delta":
+				switch event.Delta.Type {
+				case "text_delta":
+					if event.Delta.Text == "" {
+						continue
+					}
+					if !sendToken(ctx, tokenChan, Token{Content: event.Delta.Text, Index: index}) {
+						return
+					}
+					index++
+				case "input_json_delta":
+					if i, ok := toolBlocks[event.Index]; ok {
+						toolInputs[i] += event.Delta.PartialJSON
+					}
+				}
+			case "message_delta":
+				stopReason = event.Delta.StopReason
+				if event.Usage != nil {
+					usage.CompletionTokens = event.Usage.OutputTokens
+				}
+			case "message_stop":
+				for i, input := range toolInputs {
+					args := make(map[string]interface{})
+					if input != "" {
+						if err := json.Unmarshal([]byte(input), &args); err != nil {
+							sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("stream decode error: failed to decode arguments for tool call %s: %w", toolCalls[i].Name, err)})
+							return
+						}
+					}
+					toolCalls[i].Arguments = args
+				}
+				usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+				sendToken(ctx, tokenChan, Token{Index: index, FinishReason: stopReason, Usage: &usage, ToolCalls: toolCalls})
+				return
+			case "error":
+				message := "unknown error"
+				if event.Error != nil {
+					message = event.Error.Message
+				}
+				sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("Anthropic stream error: %s", message)})
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("stream read error: %w", err)})
+		}
+	}()
+
+	return tokenChan, nil
+}
+
+// Embeddings is not supported: Anthropic does not offer an embeddings API.
+func (a *AnthropicAdapter) Embeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return nil, errors.New("embeddings are not supported by the Anthropic API; configure a separate embedding provider")
+}
+
+// buildRequestBody prepares the Messages API payload, preferring explicit prompt parameters.
+// The API has no penalties, seed or JSON mode; a requested response format is added to the
+// system prompt instead.
+func (a *AnthropicAdapter) buildRequestBody(prompt Prompt, stream bool) (map[string]interface{}, error) {
+	maxTokens := a.maxTokens
+	if prompt.Parameters.MaxTokens != nil && *prompt.Parameters.MaxTokens > 0 {
+		maxTokens = int(*prompt.Parameters.MaxTokens)
+	}
+	temperature := a.temperature
+	if prompt.Parameters.Temperature != nil {
+		temperature = *prompt.Parameters.Temperature
+	}
+
+	system, messages := buildAnthropicMessages(prompt)
+	instruction, err := jsonFormatInstruction(prompt.Parameters.ResponseFormat)
+	if err != nil {
+		return nil, err
+	}
+	if instruction != "" {
+		system = strings.TrimPrefix(system+"\n\n"+instruction, "\n\n")
+	}
+
+	body := map[string]interface{}{
+		"model":       a.model,
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": temperature,
+	}
+	if system != "" {
+		body["system"] = system
+	}
+	if prompt.Parameters.TopP != nil {
+		body["top_p"] = *prompt.Parameters.TopP
+	}
+	if len(prompt.Parameters.StopSequences) > 0 {
+		body["stop_sequences"] = prompt.Parameters.StopSequences
+	}
+	if len(prompt.Tools) > 0 {
+		tools := make([]anthropicTool, len(prompt.Tools))
+		for i, tool := range mapToolDefinitions(prompt.Tools) {
+			tools[i] = anthropicTool{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				InputSchema: tool.Function.Parameters,
+			}
+		}
+		body["tools"] = tools
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return body, nil
+}
+
+// buildAnthropicMessages splits the prompt into the top-level system text and the
+// user/assistant turns. Tool results become tool_result blocks on a user turn and
+// consecutive turns with the same role are merged, as the Messages API expects.
+func buildAnthropicMessages(prompt Prompt) (string, []anthropicMessage) {
+	var system []string
+	messages := make([]anthropicMessage, 0, len(prompt.Messages)+1)
+
+	for _, msg := range chatMessages(prompt) {
+		role := msg.Role
+		var blocks []anthropicContentBlock
+
+		switch msg.Role {
+		case ChatRoleSystem:
+			system = append(system, msg.Content)
+			continue
+		case ChatRoleTool:
+			role = ChatRoleUser
+			blocks = append(blocks, anthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		default:
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := call.Arguments
+				if input == nil {
+					input = make(map[string]interface{})
+				}
+				blocks = append(blocks, anthropicContentBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content = append(messages[last].Content, blocks...)
+			continue
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	return strings.Join(system, "\n\n"), messages
+}
+
+// doMessagesRequest posts the payload to /v1/messages and returns the response once the status is OK.
+// The caller must close the response body.
+func (a *AnthropicAdapter) doMessagesRequest(ctx context.Context, body map[string]interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/v1/messages", bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	// No client timeout: streamed responses are bounded by ctx instead
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Anthropic API error: %s", string(respBody))
+	}
+	return resp, nil
+}
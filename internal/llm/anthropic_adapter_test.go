@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnthropicAdapter(t *testing.T) {
+	_, err := NewAnthropicAdapter("", "", 0, 0)
+	assert.Error(t, err, "an API key is required")
+
+	adapter, err := NewAnthropicAdapter("test-key", "", 0, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, adapter.model)
+	assert.Equal(t, 1024, adapter.maxTokens)
+	assert.Equal(t, anthropicDefaultBaseURL, adapter.baseURL)
+}
+
+func TestAnthropicAdapter_Call(t *testing.T) {
+	var requestBody struct {
+		System    string             `json:"system"`
+		MaxTokens int                `json:"max_tokens"`
+		Messages  []anthropicMessage `json:"messages"`
+		Tools     []anthropicTool    `json:"tools"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"content": [
+				{"type": "text", "text": "Let me check."},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 20, "output_tokens": 8}
+		}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewAnthropicAdapter("test-key", "claude-test", 256, 0.5)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	response, err := adapter.Call(context.Background(), Prompt{
+		System: "You are a weather assistant.",
+		Messages: []ChatMessage{
+			{Role: ChatRoleUser, Content: "Weather in Paris and Rome?"},
+			{Role: ChatRoleAssistant, ToolCalls: []ToolCall{
+				{ID: "toolu_0", Name: "get_weather", Arguments: map[string]interface{}{"city": "Rome"}},
+			}},
+			{Role: ChatRoleTool, Content: "sunny", ToolCallID: "toolu_0"},
+		},
+		User:  "And Paris?",
+		Tools: []ToolDefinition{{Name: "get_weather", Description: "Get the weather"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "You are a weather assistant.", requestBody.System)
+	assert.Equal(t, 256, requestBody.MaxTokens)
+	require.Len(t, requestBody.Messages, 3, "tool result and the new user turn should be merged")
+	assert.Equal(t, "assistant", requestBody.Messages[1].Role)
+	assert.Equal(t, "tool_use", requestBody.Messages[1].Content[0].Type)
+	assert.Equal(t, "user", requestBody.Messages[2].Role)
+	require.Len(t, requestBody.Messages[2].Content, 2)
+	assert.Equal(t, "tool_result", requestBody.Messages[2].Content[0].Type)
+	assert.Equal(t, "toolu_0", requestBody.Messages[2].Content[0].ToolUseID)
+	assert.Equal(t, "And Paris?", requestBody.Messages[2].Content[1].Text)
+	require.Len(t, requestBody.Tools, 1)
+	assert.NotNil(t, requestBody.Tools[0].InputSchema)
+
+	assert.Equal(t, "Let me check.", response.Content)
+	assert.Equal(t, "tool_use", response.FinishReason)
+	assert.Equal(t, 28, response.Usage.TotalTokens)
+	require.Len(t, response.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", response.ToolCalls[0].ID)
+	assert.Equal(t, "Paris", response.ToolCalls[0].Arguments["city"])
+}
+
+func TestAnthropicAdapter_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":10,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewAnthropicAdapter("test-key", "claude-test", 256, 0.5)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{User: "Say hello"})
+	require.NoError(t, err)
+
+	var received []Token
+	for token := range tokens {
+		require.NoError(t, token.Error)
+		received = append(received, token)
+	}
+
+	require.Len(t, received, 3)
+	assert.Equal(t, "Hello", received[0].Content)
+	assert.Equal(t, " there", received[1].Content)
+	final := received[2]
+	assert.Equal(t, "end_turn", final.FinishReason)
+	require.NotNil(t, final.Usage)
+	assert.Equal(t, 14, final.Usage.TotalTokens)
+}
+
+func TestAnthropicAdapter_Stream_ToolUse(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/anthropic_stream_tool_use.sse")
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	adapter, err := NewAnthropicAdapter("test-key", "claude-test", 256, 0.5)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{
+		User:  "What's the weather and time in Paris?",
+		Tools: []ToolDefinition{{Name: "get_weather"}, {Name: "get_time"}},
+	})
+	require.NoError(t, err)
+
+	var received []Token
+	for token := range tokens {
+		require.NoError(t, token.Error)
+		received = append(received, token)
+	}
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "Let me check the weather.", received[0].Content)
+	final := received[1]
+	assert.Equal(t, "tool_use", final.FinishReason)
+	require.Len(t, final.ToolCalls, 2)
+	assert.Equal(t, "toolu_01T1x1fJ34qAmk2tNTrN7Up6", final.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", final.ToolCalls[0].Name)
+	assert.Equal(t, map[string]interface{}{"city": "Paris", "unit": "celsius"}, final.ToolCalls[0].Arguments)
+	assert.Equal(t, "get_time", final.ToolCalls[1].Name)
+	assert.Empty(t, final.ToolCalls[1].Arguments)
+	require.NotNil(t, final.Usage)
+	assert.Equal(t, 561, final.Usage.TotalTokens)
+}
+
+func TestAnthropicAdapter_Embeddings(t *testing.T) {
+	adapter, err := NewAnthropicAdapter("test-key", "", 0, 0)
+	require.NoError(t, err)
+	_, err = adapter.Embeddings(context.Background(), []string{"text"})
+	assert.Error(t, err)
+}
@@ -1,30 +1,30 @@
 package llm
 
 import (
-	"bufio" // For SSE streaming
 	"bytes" // For request body
 	"context"
 	"encoding/json" // For JSON handling
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http" // For HTTP requests
-	"strings"  // For SSE parsing
-	"time"     // For HTTP client timeout
+	"strings"
+	"time" // For HTTP client timeout
 )
 
 // --- API Specific Structs ---
 
 const (
 	// Define a specific API version to use
-	azureAPIVersion = "2024-02-15-preview" // Or choose another appropriate version
+	azureAPIVersion = "2024-10-21" // First GA version supporting json_schema response formats
 )
 
 // Structure for chat messages sent to the API
 type azureChatMessage struct {
-	Role    string `json:"role"`              // "system", "user", "assistant"
-	Content string `json:"content,omitempty"` // Text content
+	Role       string           `json:"role"`                   // "system", "user", "assistant", "tool"
+	Content    string           `json:"content,omitempty"`      // Text content
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`   // Tool calls requested by the assistant
+	ToolCallID string           `json:"tool_call_id,omitempty"` // Tool call a "tool" message answers
 	// TODO: Add support for multi-modal content if needed
 }
 
@@ -34,7 +34,42 @@ type azureChatCompletionsRequest struct {
 	Stream      bool               `json:"stream,omitempty"`
 	Temperature *float32           `json:"temperature,omitempty"`
 	MaxTokens   *int32             `json:"max_tokens,omitempty"`
-	// TODO: Add other parameters like top_p, stop, presence_penalty etc.
+	Tools       []openAITool       `json:"tools,omitempty"`
+
+	TopP             *float32              `json:"top_p,omitempty"`
+	Stop             []string              `json:"stop,omitempty"`
+	PresencePenalty  *float32              `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32              `json:"frequency_penalty,omitempty"`
+	Seed             *int64                `json:"seed,omitempty"`
+	ResponseFormat   *openAIResponseFormat `json:"response_format,omitempty"`
+	StreamOptions    *azureStreamOptions   `json:"stream_options,omitempty"`
+}
+
+// azureStreamOptions asks for a final usage chunk, so streamed calls still report token counts
+type azureStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// newAzureChatRequest builds a Chat Completions request from the prompt's messages and parameters.
+func newAzureChatRequest(messages []azureChatMessage, prompt Prompt, stream bool) azureChatCompletionsRequest {
+	params := prompt.Parameters
+	request := azureChatCompletionsRequest{
+		Messages:         messages,
+		Stream:           stream,
+		Temperature:      params.Temperature,
+		MaxTokens:        params.MaxTokens,
+		Tools:            mapToolDefinitions(prompt.Tools),
+		TopP:             params.TopP,
+		Stop:             params.StopSequences,
+		PresencePenalty:  params.PresencePenalty,
+		FrequencyPenalty: params.FrequencyPenalty,
+		Seed:             params.Seed,
+		ResponseFormat:   toOpenAIResponseFormat(params.ResponseFormat),
+	}
+	if stream {
+		request.StreamOptions = &azureStreamOptions{IncludeUsage: true}
+	}
+	return request
 }
 
 // Response structure for non-streaming Chat Completions API
@@ -53,24 +88,6 @@ type azureChatCompletionsResponse struct {
 	// TODO: Add fields for content filtering results if needed
 }
 
-// Response structure for streamed Chat Completions API chunks (SSE data)
-type azureChatCompletionsStreamChunk struct {
-	ID      string `json:"id"`
-	Choices []struct {
-		Index int `json:"index"`
-		Delta struct {
-			Role    string `json:"role,omitempty"`    // Usually only present in the first chunk for assistant
-			Content string `json:"content,omitempty"` // The token delta
-		} `json:"delta"`
-		FinishReason *string `json:"finish_reason,omitempty"` // Present in the last chunk for a choice
-	} `json:"choices"`
-	Usage *struct { // Usually nil until the very end, sometimes in a separate final chunk
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage,omitempty"`
-}
-
 // Request structure for the Embeddings API
 type azureEmbeddingsRequest struct {
 	Input []string `json:"input"`
@@ -190,27 +207,33 @@ func (a *AzureOpenAIAdapter) doRequest(ctx context.Context, method, url string,
 }
 
 // mapInternalPrompt maps our internal Prompt to the API's chat message format.
-func mapInternalPrompt(prompt Prompt) []azureChatMessage {
-	messages := []azureChatMessage{}
-	if prompt.System != "" {
-		messages = append(messages, azureChatMessage{Role: "system", Content: prompt.System})
-	}
-	if prompt.User != "" {
-		messages = append(messages, azureChatMessage{Role: "user", Content: prompt.User})
+func mapInternalPrompt(prompt Prompt) ([]azureChatMessage, error) {
+	chat := chatMessages(prompt)
+	messages := make([]azureChatMessage, 0, len(chat))
+	for _, msg := range chat {
+		toolCalls, err := toOpenAIToolCalls(msg.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, azureChatMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCalls:  toolCalls,
+			ToolCallID: msg.ToolCallID,
+		})
 	}
-	// TODO: Map history if added to llm.Prompt
-	return messages
+	return messages, nil
 }
 
 // Call implements the ModelProvider interface for a single request/response.
 func (a *AzureOpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response, error) {
-	apiReq := azureChatCompletionsRequest{
-		Messages:    mapInternalPrompt(prompt),
-		Stream:      false,
-		Temperature: prompt.Parameters.Temperature,
-		MaxTokens:   prompt.Parameters.MaxTokens,
+	messages, err := mapInternalPrompt(prompt)
+	if err != nil {
+		return Response{}, err
 	}
 
+	apiReq := newAzureChatRequest(messages, prompt, false)
+
 	url := a.buildURL(a.chatDeployment, "chat/completions")
 	httpResp, err := a.doRequest(ctx, http.MethodPost, url, apiReq)
 	if err != nil {
@@ -235,6 +258,11 @@ func (a *AzureOpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response,
 	if len(apiResp.Choices) > 0 {
 		llmResp.Content = apiResp.Choices[0].Message.Content
 		llmResp.FinishReason = apiResp.Choices[0].FinishReason
+		toolCalls, err := mapOpenAIToolCalls(apiResp.Choices[0].Message.ToolCalls)
+		if err != nil {
+			return Response{}, err
+		}
+		llmResp.ToolCalls = toolCalls
 	} else {
 		// This case should ideally be covered by non-2xx status code, but check just in case
 		return Response{}, errors.New("api returned success but no choices")
@@ -245,13 +273,13 @@ func (a *AzureOpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response,
 
 // Stream implements the ModelProvider interface for streaming responses.
 func (a *AzureOpenAIAdapter) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
-	apiReq := azureChatCompletionsRequest{
-		Messages:    mapInternalPrompt(prompt),
-		Stream:      true, // Enable streaming
-		Temperature: prompt.Parameters.Temperature,
-		MaxTokens:   prompt.Parameters.MaxTokens,
+	messages, err := mapInternalPrompt(prompt)
+	if err != nil {
+		return nil, err
 	}
 
+	apiReq := newAzureChatRequest(messages, prompt, true) // Enable streaming
+
 	url := a.buildURL(a.chatDeployment, "chat/completions")
 	httpResp, err := a.doRequest(ctx, http.MethodPost, url, apiReq)
 	if err != nil {
@@ -265,62 +293,7 @@ func (a *AzureOpenAIAdapter) Stream(ctx context.Context, prompt Prompt) (<-chan
 	go func() {
 		defer close(tokenChan)
 		defer httpResp.Body.Close()
-
-		scanner := bufio.NewScanner(httpResp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "" {
-				continue // Skip empty lines
-			}
-
-			// Check for context cancellation during processing
-			select {
-			case <-ctx.Done():
-				log.Printf("Azure stream context cancelled during SSE processing")
-				return
-			default:
-				// continue processing
-			}
-
-			if strings.HasPrefix(line, "data:") {
-				data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-				if data == "[DONE]" {
-					return // Stream finished successfully
-				}
-
-				var chunk azureChatCompletionsStreamChunk
-				if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-					log.Printf("Error decoding stream chunk JSON: %v, data: %s", err, data)
-					tokenChan <- Token{Error: fmt.Errorf("stream decode error: %w", err)}
-					return
-				}
-
-				// Extract content delta
-				if len(chunk.Choices) > 0 {
-					contentDelta := chunk.Choices[0].Delta.Content
-					if contentDelta != "" {
-						select {
-						case tokenChan <- Token{Content: contentDelta}:
-						case <-ctx.Done():
-							log.Printf("Azure stream context cancelled during token send")
-							return
-						}
-					}
-					// TODO: Could potentially capture finish_reason from the last chunk here
-				}
-			} else {
-				log.Printf("Unexpected SSE line: %s", line)
-			}
-		}
-
-		// Check for scanner errors after loop
-		if err := scanner.Err(); err != nil {
-			// Don't send if context was cancelled, as that's the likely cause
-			if ctx.Err() == nil {
-				log.Printf("Error reading stream body: %v", err)
-				tokenChan <- Token{Error: fmt.Errorf("stream read error: %w", err)}
-			}
-		}
+		readChatCompletionsStream(ctx, httpResp.Body, tokenChan)
 	}()
 
 	return tokenChan, nil
@@ -2,6 +2,9 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -71,6 +74,74 @@ func TestNewAzureOpenAIAdapter(t *testing.T) {
 	// }
 }
 
+func TestAzureOpenAIAdapter_Stream(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":"Checking"}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: {"id":"1","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":9,"total_tokens":21}}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewAzureOpenAIAdapter(AzureOpenAIAdapterOptions{
+		Endpoint:            server.URL,
+		APIKey:              "key",
+		ChatDeployment:      "chat",
+		EmbeddingDeployment: "embed",
+	})
+	if err != nil {
+		t.Fatalf("NewAzureOpenAIAdapter failed: %v", err)
+	}
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{User: "Weather in Paris?"})
+	if err != nil {
+		t.Fatalf("Stream() failed: %v", err)
+	}
+	var received []Token
+	for token := range tokens {
+		if token.Error != nil {
+			t.Fatalf("Stream() received error in token: %v", token.Error)
+		}
+		received = append(received, token)
+	}
+
+	if options, ok := requestBody["stream_options"].(map[string]interface{}); !ok || options["include_usage"] != true {
+		t.Errorf("expected stream_options.include_usage in the request, got %v", requestBody["stream_options"])
+	}
+	if len(received) != 2 || received[0].Content != "Checking" {
+		t.Fatalf("expected a content token and a final token, got %+v", received)
+	}
+	final := received[1]
+	if final.FinishReason != "tool_calls" || final.Usage == nil || final.Usage.TotalTokens != 21 {
+		t.Errorf("unexpected final token: %+v", final)
+	}
+	if len(final.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", final.ToolCalls)
+	}
+	if call := final.ToolCalls[0]; call.ID != "call_1" || call.Name != "get_weather" || call.Arguments["city"] != "Paris" {
+		t.Errorf("unexpected first tool call: %+v", call)
+	}
+	if call := final.ToolCalls[1]; call.ID != "call_2" || call.Name != "get_time" || len(call.Arguments) != 0 {
+		t.Errorf("unexpected second tool call: %+v", call)
+	}
+}
+
 // --- Integration Tests (Requires Environment Variables) ---
 
 const (
@@ -0,0 +1,20 @@
+package llm
+
+// chatMessages flattens a Prompt into the ordered list of turns sent to chat APIs:
+// the System message first, then any history in Messages, then User as the final turn.
+func chatMessages(prompt Prompt) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(prompt.Messages)+2)
+	if prompt.System != "" {
+		messages = append(messages, ChatMessage{Role: ChatRoleSystem, Content: prompt.System})
+	}
+	messages = append(messages, prompt.Messages...)
+	if prompt.User != "" {
+		messages = append(messages, ChatMessage{Role: ChatRoleUser, Content: prompt.User})
+	}
+	return messages
+}
+
+// hasUserInput reports whether the prompt carries any conversational input beyond the system message.
+func hasUserInput(prompt Prompt) bool {
+	return prompt.User != "" || len(prompt.Messages) > 0
+}
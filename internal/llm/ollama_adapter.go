@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -52,87 +53,219 @@ func (o *OllamaAdapter) SetEmbeddingModel(model string) {
 	}
 }
 
+// ollamaChatResponse is a /api/chat response body, or a single NDJSON line when streaming.
+type ollamaChatResponse struct {
+	Message struct {
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"` // Tokens in the prompt
+	EvalCount       int    `json:"eval_count"`        // Tokens generated
+	Error           string `json:"error,omitempty"`   // Set when the stream fails mid-way
+}
+
+// usage converts Ollama's token counters to UsageStats.
+func (r ollamaChatResponse) usage() UsageStats {
+	return UsageStats{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
 // Call implements the ModelProvider interface for a single request/response.
 func (o *OllamaAdapter) Call(ctx context.Context, prompt Prompt) (Response, error) {
-	if prompt.System == "" && prompt.User == "" {
+	if prompt.System == "" && !hasUserInput(prompt) {
 		return Response{}, errors.New("both system and user prompts cannot be empty")
 	}
 
-	// Determine final parameters, preferring explicit prompt settings
-	var finalMaxTokens int
-if prompt.Parameters.MaxTokens != nil && *prompt.Parameters.MaxTokens > 0 {
-	finalMaxTokens = int(*prompt.Parameters.MaxTokens)
-} else {
-	finalMaxTokens = o.maxTokens
-}
+	// Make the HTTP request with timeout
+	client := &http.Client{
+		Timeout: 30 * time.Second, // Add 30 second timeout
+	}
+	resp, err := o.doChatRequest(ctx, client, o.buildRequestBody(prompt, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
 
-var finalTemperature float32
-if prompt.Parameters.Temperature != nil && *prompt.Parameters.Temperature > 0 {
-	finalTemperature = *prompt.Parameters.Temperature
-} else {
-	finalTemperature = o.temperature
+	return Response{
+		Content:      apiResp.Message.Content,
+		Usage:        apiResp.usage(),
+		FinishReason: apiResp.DoneReason,
+		ToolCalls:    mapOllamaToolCalls(apiResp.Message.ToolCalls),
+	}, nil
 }
 
-	// Build messages array
-	messages := []map[string]string{}
-	if prompt.System != "" {
-		messages = append(messages, map[string]string{"role": "system", "content": prompt.System})
+// buildRequestBody prepares the /api/chat payload, preferring explicit prompt parameters.
+func (o *OllamaAdapter) buildRequestBody(prompt Prompt, stream bool) map[string]interface{} {
+	finalMaxTokens := o.maxTokens
+	if prompt.Parameters.MaxTokens != nil && *prompt.Parameters.MaxTokens > 0 {
+		finalMaxTokens = int(*prompt.Parameters.MaxTokens)
 	}
-	if prompt.User != "" {
-		messages = append(messages, map[string]string{"role": "user", "content": prompt.User})
+
+	finalTemperature := o.temperature
+	if prompt.Parameters.Temperature != nil && *prompt.Parameters.Temperature > 0 {
+		finalTemperature = *prompt.Parameters.Temperature
 	}
 
-	// Prepare the request payload
 	requestBody := map[string]interface{}{
 		"model":       o.model,
-		"messages":    messages,
+		"messages":    o.buildMessages(prompt),
 		"max_tokens":  finalMaxTokens,
 		"temperature": finalTemperature,
-		"stream":      false,
+		"stream":      stream,
+	}
+	if tools := mapToolDefinitions(prompt.Tools); tools != nil {
+		requestBody["tools"] = tools
+	}
+	if options := ollamaOptions(prompt.Parameters); len(options) > 0 {
+		requestBody["options"] = options
+	}
+	if format := ollamaFormat(prompt.Parameters.ResponseFormat); format != nil {
+		requestBody["format"] = format
+	}
+	return requestBody
+}
+
+// ollamaOptions maps the optional sampling parameters to /api/chat "options".
+func ollamaOptions(params ModelParameters) map[string]interface{} {
+	options := make(map[string]interface{})
+	if params.TopP != nil {
+		options["top_p"] = *params.TopP
+	}
+	if len(params.StopSequences) > 0 {
+		options["stop"] = params.StopSequences
+	}
+	if params.PresencePenalty != nil {
+		options["presence_penalty"] = *params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		options["frequency_penalty"] = *params.FrequencyPenalty
+	}
+	if params.Seed != nil {
+		options["seed"] = *params.Seed
 	}
+	return options
+}
 
+// doChatRequest posts the payload to /api/chat and returns the response once the status is OK.
+// The caller must close the response body.
+func (o *OllamaAdapter) doChatRequest(ctx context.Context, client *http.Client, requestBody map[string]interface{}) (*http.Response, error) {
 	payload, err := json.Marshal(requestBody)
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to marshal request body: %w", err)
-	}
-	// Make the HTTP request with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second, // Add 30 second timeout
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/chat", o.baseURL), bytes.NewBuffer(payload))
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return Response{}, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		body, _ := ioutil.ReadAll(resp.Body)
-		return Response{}, fmt.Errorf("Ollama API error: %s", string(body))
+		return nil, fmt.Errorf("Ollama API error: %s", string(body))
 	}
+	return resp, nil
+}
 
-	var apiResp struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+// buildMessages converts the prompt into the /api/chat messages array,
+// including assistant tool calls and tool results from the conversation history.
+func (o *OllamaAdapter) buildMessages(prompt Prompt) []map[string]interface{} {
+	chat := chatMessages(prompt)
+	messages := make([]map[string]interface{}, 0, len(chat))
+	for _, msg := range chat {
+		message := map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Content,
+		}
+		if toolCalls := toOllamaToolCalls(msg.ToolCalls); toolCalls != nil {
+			message["tool_calls"] = toolCalls
+		}
+		messages = append(messages, message)
 	}
-
-	return Response{
-		Content: apiResp.Message.Content,
-	}, nil
+	return messages
 }
 
 // Stream implements the ModelProvider interface for streaming responses.
+// Ollama streams newline-delimited JSON objects; the final object has done=true
+// and carries the finish reason and token counts.
 func (o *OllamaAdapter) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
-	return nil, errors.New("streaming not supported by OllamaAdapter")
+	if prompt.System == "" && !hasUserInput(prompt) {
+		return nil, errors.New("both system and user prompts cannot be empty")
+	}
+
+	// No client timeout: long generations are bounded by ctx instead
+	resp, err := o.doChatRequest(ctx, &http.Client{}, o.buildRequestBody(prompt, true))
+	if err != nil {
+		return nil, err
+	}
+
+	tokenChan := make(chan Token)
+	go func() {
+		defer close(tokenChan)
+		defer resp.Body.Close()
+
+		index := 0
+		// Ollama sends each tool call whole, in a chunk of its own
+		var toolCalls []ollamaToolCall
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+
+			var chunk ollamaChatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("stream decode error: %w", err)})
+				return
+			}
+			if chunk.Error != "" {
+				sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("Ollama stream error: %s", chunk.Error)})
+				return
+			}
+
+			if chunk.Message.Content != "" {
+				if !sendToken(ctx, tokenChan, Token{Content: chunk.Message.Content, Index: index}) {
+					return
+				}
+				index++
+			}
+			toolCalls = append(toolCalls, chunk.Message.ToolCalls...)
+
+			if chunk.Done {
+				usage := chunk.usage()
+				sendToken(ctx, tokenChan, Token{
+					Index:        index,
+					FinishReason: chunk.DoneReason,
+					Usage:        &usage,
+					ToolCalls:    mapOllamaToolCalls(toolCalls),
+				})
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			sendToken(ctx, tokenChan, Token{Index: index, Error: fmt.Errorf("stream read error: %w", err)})
+		}
+	}()
+
+	return tokenChan, nil
 }
 
 // Embeddings implements the ModelProvider interface for generating embeddings.
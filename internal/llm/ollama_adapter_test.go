@@ -2,11 +2,13 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOllamaAdapter_Call(t *testing.T) {
@@ -158,4 +160,185 @@ func TestOllamaAdapter_SetEmbeddingModel(t *testing.T) {
 	// Test that empty string doesn't change the model
 	adapter.SetEmbeddingModel("")
 	assert.Equal(t, "all-minilm:latest", adapter.embeddingModel)
-}
\ No newline at end of file
+}
+func TestOllamaAdapter_Call_ToolCalls(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message": {"content": "", "tool_calls": [{"function": {"name": "get_weather", "arguments": {"city": "Paris"}}}]}}`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{
+		baseURL:     server.URL,
+		model:       "llama3.2:latest",
+		maxTokens:   100,
+		temperature: 0.7,
+	}
+
+	result, err := adapter.Call(context.Background(), Prompt{
+		User: "What's the weather in Paris?",
+		Tools: []ToolDefinition{{
+			Name:        "get_weather",
+			Description: "Get the current weather for a city",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	tools, ok := requestBody["tools"].([]interface{})
+	require.True(t, ok, "tools should be sent in the request")
+	require.Len(t, tools, 1)
+	function := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+	assert.Equal(t, "get_weather", function["name"])
+
+	require.Len(t, result.ToolCalls, 1)
+	assert.Equal(t, "call_0", result.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", result.ToolCalls[0].Name)
+	assert.Equal(t, "Paris", result.ToolCalls[0].Arguments["city"])
+}
+
+func TestOllamaAdapter_Call_MessageHistory(t *testing.T) {
+	var requestBody struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message": {"content": "Your name is Ada."}}`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{
+		baseURL:     server.URL,
+		model:       "llama3.2:latest",
+		maxTokens:   100,
+		temperature: 0.7,
+	}
+
+	result, err := adapter.Call(context.Background(), Prompt{
+		System: "System message",
+		Messages: []ChatMessage{
+			{Role: ChatRoleUser, Content: "My name is Ada."},
+			{Role: ChatRoleAssistant, Content: "Nice to meet you, Ada."},
+		},
+		User: "What is my name?",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Your name is Ada.", result.Content)
+
+	require.Len(t, requestBody.Messages, 4)
+	roles := []string{}
+	for _, msg := range requestBody.Messages {
+		roles = append(roles, msg.Role)
+	}
+	assert.Equal(t, []string{"system", "user", "assistant", "user"}, roles)
+	assert.Equal(t, "What is my name?", requestBody.Messages[3].Content)
+}
+
+func TestOllamaAdapter_Stream(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hello"},"done":false}
+{"message":{"role":"assistant","content":" there"},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":4}
+`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{
+		baseURL:     server.URL,
+		model:       "llama3.2:latest",
+		maxTokens:   100,
+		temperature: 0.7,
+	}
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{User: "Say hello"})
+	require.NoError(t, err)
+
+	var received []Token
+	for token := range tokens {
+		require.NoError(t, token.Error)
+		received = append(received, token)
+	}
+
+	assert.Equal(t, true, requestBody["stream"])
+	require.Len(t, received, 3)
+	assert.Equal(t, "Hello", received[0].Content)
+	assert.Equal(t, " there", received[1].Content)
+	assert.Equal(t, 1, received[1].Index)
+
+	final := received[2]
+	assert.Equal(t, "stop", final.FinishReason)
+	require.NotNil(t, final.Usage)
+	assert.Equal(t, 12, final.Usage.PromptTokens)
+	assert.Equal(t, 4, final.Usage.CompletionTokens)
+	assert.Equal(t, 16, final.Usage.TotalTokens)
+}
+
+func TestOllamaAdapter_Stream_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":false}
+{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_time","arguments":{"zone":"CET"}}}]},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":20,"eval_count":8}
+`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{baseURL: server.URL, model: "llama3.2:latest", maxTokens: 100, temperature: 0.7}
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{
+		User:  "What's the weather and time in Paris?",
+		Tools: []ToolDefinition{{Name: "get_weather"}, {Name: "get_time"}},
+	})
+	require.NoError(t, err)
+
+	var received []Token
+	for token := range tokens {
+		require.NoError(t, token.Error)
+		received = append(received, token)
+	}
+
+	require.Len(t, received, 1)
+	final := received[0]
+	assert.Equal(t, "stop", final.FinishReason)
+	require.Len(t, final.ToolCalls, 2)
+	assert.Equal(t, "call_0", final.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", final.ToolCalls[0].Name)
+	assert.Equal(t, "Paris", final.ToolCalls[0].Arguments["city"])
+	assert.Equal(t, "call_1", final.ToolCalls[1].ID)
+	assert.Equal(t, "get_time", final.ToolCalls[1].Name)
+	assert.Equal(t, "CET", final.ToolCalls[1].Arguments["zone"])
+}
+
+func TestOllamaAdapter_Stream_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"content":"partial"},"done":false}
+{"error":"model crashed"}
+`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{baseURL: server.URL, model: "llama3.2:latest", maxTokens: 100, temperature: 0.7}
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{User: "Say hello"})
+	require.NoError(t, err)
+
+	first := <-tokens
+	assert.Equal(t, "partial", first.Content)
+	last := <-tokens
+	require.Error(t, last.Error)
+	assert.Contains(t, last.Error.Error(), "model crashed")
+	_, open := <-tokens
+	assert.False(t, open)
+}
@@ -7,14 +7,37 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
-// OpenAIAdapter implements the ModelProvider interface for OpenAI's API.
+// OpenAIAdapter implements the ModelProvider interface for OpenAI's API
+// and for servers that expose an OpenAI-compatible Chat Completions API.
 type OpenAIAdapter struct {
-	apiKey      string
-	model       string
-	maxTokens   int
-	temperature float32
+	apiKey         string
+	model          string
+	embeddingModel string
+	maxTokens      int
+	temperature    float32
+	baseURL        string            // API root, e.g. https://api.openai.com/v1
+	headers        map[string]string // Extra headers sent with every request
+}
+
+// openAIDefaultBaseURL is the root of the public OpenAI API.
+const openAIDefaultBaseURL = "https://api.openai.com/v1"
+
+// openAIDefaultEmbeddingModel is used for Embeddings unless another model is configured.
+const openAIDefaultEmbeddingModel = "text-embedding-3-small"
+
+// OpenAICompatibleAdapterOptions configures an OpenAIAdapter for a server that implements
+// the OpenAI Chat Completions API, such as vLLM, LM Studio, llama.cpp server or OpenRouter.
+type OpenAICompatibleAdapterOptions struct {
+	BaseURL        string            // Required. API root including any version path, e.g. http://localhost:8000/v1
+	APIKey         string            // Optional. Sent as a Bearer token when set
+	Model          string            // Required. Model name as the server knows it
+	EmbeddingModel string            // Optional. Defaults to text-embedding-3-small
+	Headers        map[string]string // Optional. Extra headers, e.g. HTTP-Referer for OpenRouter
+	MaxTokens      int               // Optional. Defaults to 150
+	Temperature    float32           // Optional. Defaults to 0.7
 }
 
 // NewOpenAIAdapter creates a new OpenAIAdapter instance.
@@ -33,80 +56,83 @@ func NewOpenAIAdapter(apiKey, model string, maxTokens int, temperature float32)
 	}
 
 	return &OpenAIAdapter{
-		apiKey:      apiKey,
-		model:       model,
-		maxTokens:   maxTokens,
-		temperature: temperature,
+		apiKey:         apiKey,
+		model:          model,
+		embeddingModel: openAIDefaultEmbeddingModel,
+		maxTokens:      maxTokens,
+		temperature:    temperature,
+		baseURL:        openAIDefaultBaseURL,
 	}, nil
 }
 
-// Call implements the ModelProvider interface for a single request/response.
-func (o *OpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response, error) {
-	userPrompt := prompt.User
-	if userPrompt == "" {
-		return Response{}, errors.New("user prompt cannot be empty")
+// NewOpenAICompatibleAdapter creates an OpenAIAdapter that talks to a self-hosted or
+// third-party endpoint instead of api.openai.com.
+func NewOpenAICompatibleAdapter(options OpenAICompatibleAdapterOptions) (*OpenAIAdapter, error) {
+	if options.BaseURL == "" {
+		return nil, errors.New("base URL cannot be empty")
 	}
-
-	maxTokens := o.maxTokens
-	if prompt.Parameters.MaxTokens != nil {
-		maxTokens = int(*prompt.Parameters.MaxTokens)
+	if options.Model == "" {
+		return nil, errors.New("model cannot be empty")
 	}
-	temperature := o.temperature
-	if prompt.Parameters.Temperature != nil {
-		temperature = *prompt.Parameters.Temperature
+	if options.EmbeddingModel == "" {
+		options.EmbeddingModel = openAIDefaultEmbeddingModel
+	}
+	if options.MaxTokens == 0 {
+		options.MaxTokens = 150 // Default max tokens
+	}
+	if options.Temperature == 0 {
+		options.Temperature = 0.7 // Default temperature
 	}
 
-	// Build messages array for Chat Completions API
-	messages := []map[string]interface{}{
-		{
-			"role":    "user",
-			"content": userPrompt,
-		},
+	headers := make(map[string]string, len(options.Headers))
+	for key, value := range options.Headers {
+		headers[key] = value
+	}
+
+	return &OpenAIAdapter{
+		apiKey:         options.APIKey,
+		model:          options.Model,
+		embeddingModel: options.EmbeddingModel,
+		maxTokens:      options.MaxTokens,
+		temperature:    options.Temperature,
+		baseURL:        strings.TrimSuffix(options.BaseURL, "/"),
+		headers:        headers,
+	}, nil
+}
+
+// setHeaders applies the content type, authorization and any configured extra headers.
+func (o *OpenAIAdapter) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
 	}
-	
-	// Add system message if provided
-	if prompt.System != "" {
-		messages = append([]map[string]interface{}{
-			{
-				"role":    "system", 
-				"content": prompt.System,
-			},
-		}, messages...)
+	for key, value := range o.headers {
+		req.Header.Set(key, value)
 	}
+}
 
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"model":       o.model,
-		"messages":    messages,
-		"max_tokens":  maxTokens,
-		"temperature": temperature,
-	})
-	if err != nil {
-		return Response{}, err
+// Call implements the ModelProvider interface for a single request/response.
+func (o *OpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response, error) {
+	if !hasUserInput(prompt) {
+		return Response{}, errors.New("user prompt cannot be empty")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(requestBody))
+	body, err := o.buildRequestBody(prompt, false)
 	if err != nil {
 		return Response{}, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.doChatRequest(ctx, body)
 	if err != nil {
 		return Response{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return Response{}, errors.New("OpenAI API error: " + string(body))
-	}
-
 	var response struct {
 		Choices []struct {
 			Message struct {
-				Content string `json:"content"`
+				Content   string           `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
 			} `json:"message"`
 			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
@@ -124,8 +150,14 @@ func (o *OpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response, erro
 		return Response{}, errors.New("no completion choices returned")
 	}
 
+	toolCalls, err := mapOpenAIToolCalls(response.Choices[0].Message.ToolCalls)
+	if err != nil {
+		return Response{}, err
+	}
+
 	return Response{
-		Content: response.Choices[0].Message.Content,
+		Content:   response.Choices[0].Message.Content,
+		ToolCalls: toolCalls,
 		Usage: UsageStats{
 			PromptTokens:     response.Usage.PromptTokens,
 			CompletionTokens: response.Usage.CompletionTokens,
@@ -135,18 +167,117 @@ func (o *OpenAIAdapter) Call(ctx context.Context, prompt Prompt) (Response, erro
 	}, nil
 }
 
+// buildRequestBody prepares the Chat Completions payload, preferring explicit prompt parameters.
+func (o *OpenAIAdapter) buildRequestBody(prompt Prompt, stream bool) (map[string]interface{}, error) {
+	maxTokens := o.maxTokens
+	if prompt.Parameters.MaxTokens != nil {
+		maxTokens = int(*prompt.Parameters.MaxTokens)
+	}
+	temperature := o.temperature
+	if prompt.Parameters.Temperature != nil {
+		temperature = *prompt.Parameters.Temperature
+	}
+
+	// Build messages array for Chat Completions API
+	messages, err := o.buildMessages(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"model":       o.model,
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": temperature,
+	}
+	if tools := mapToolDefinitions(prompt.Tools); tools != nil {
+		body["tools"] = tools
+	}
+	applyOpenAIParameters(body, prompt.Parameters)
+	if stream {
+		body["stream"] = true
+		// Ask for a final usage chunk so streamed calls still report token counts
+		body["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	return body, nil
+}
+
+// doChatRequest posts the payload to /chat/completions and returns the response once the status is OK.
+// The caller must close the response body.
+func (o *OpenAIAdapter) doChatRequest(ctx context.Context, body map[string]interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	o.setHeaders(req)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.New("OpenAI API error: " + string(body))
+	}
+	return resp, nil
+}
+
+// buildMessages converts the prompt into the Chat Completions messages array,
+// including assistant tool calls and tool results from the conversation history.
+func (o *OpenAIAdapter) buildMessages(prompt Prompt) ([]map[string]interface{}, error) {
+	chat := chatMessages(prompt)
+	messages := make([]map[string]interface{}, 0, len(chat))
+	for _, msg := range chat {
+		message := map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Content,
+		}
+		if len(msg.ToolCalls) > 0 {
+			toolCalls, err := toOpenAIToolCalls(msg.ToolCalls)
+			if err != nil {
+				return nil, err
+			}
+			message["tool_calls"] = toolCalls
+		}
+		if msg.ToolCallID != "" {
+			message["tool_call_id"] = msg.ToolCallID
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
 // Stream implements the ModelProvider interface for streaming responses.
+// Tokens are read from the server-sent events stream as they arrive; the final
+// token carries the finish reason and usage.
 func (o *OpenAIAdapter) Stream(ctx context.Context, prompt Prompt) (<-chan Token, error) {
+	if !hasUserInput(prompt) {
+		return nil, errors.New("user prompt cannot be empty")
+	}
+
+	body, err := o.buildRequestBody(prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.doChatRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
 	ch := make(chan Token)
 	go func() {
 		defer close(ch)
-		// For now, just call Call and send the whole response as one token (streaming can be implemented later with SSE)
-		resp, err := o.Call(ctx, prompt)
-		if err != nil {
-			ch <- Token{Error: err}
-			return
-		}
-		ch <- Token{Content: resp.Content}
+		defer resp.Body.Close()
+		readChatCompletionsStream(ctx, resp.Body, ch)
 	}()
 	return ch, nil
 }
@@ -158,22 +289,19 @@ func (o *OpenAIAdapter) Embeddings(ctx context.Context, texts []string) ([][]flo
 	}
 
 	// Use appropriate embedding model instead of chat model
-	embeddingModel := "text-embedding-3-small"
-	
 	requestBody, err := json.Marshal(map[string]interface{}{
-		"model": embeddingModel,
+		"model": o.embeddingModel,
 		"input": texts,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/embeddings", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	o.setHeaders(req)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
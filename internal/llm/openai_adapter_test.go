@@ -2,6 +2,9 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -93,3 +96,196 @@ func TestOpenAIAdapter_Embeddings(t *testing.T) {
 		assert.Equal(t, 0, len(embeddings))
 	})
 }
+
+func TestOpenAIAdapter_Call_ToolCalls(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"choices": [{
+				"message": {
+					"content": "",
+					"tool_calls": [{"id": "call_abc", "type": "function", "function": {"name": "search", "arguments": "{\"query\":\"golang\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+		}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewOpenAIAdapter("test-key", "gpt-4o-mini", 50, 0.7)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	response, err := adapter.Call(context.Background(), Prompt{
+		User:  "Search for golang",
+		Tools: []ToolDefinition{{Name: "search", Description: "Search the web"}},
+	})
+	require.NoError(t, err)
+
+	tools, ok := requestBody["tools"].([]interface{})
+	require.True(t, ok, "tools should be sent in the request")
+	require.Len(t, tools, 1)
+	tool := tools[0].(map[string]interface{})
+	assert.Equal(t, "function", tool["type"])
+	assert.NotNil(t, tool["function"].(map[string]interface{})["parameters"], "parameters schema should default to an empty object")
+
+	assert.Equal(t, "tool_calls", response.FinishReason)
+	require.Len(t, response.ToolCalls, 1)
+	assert.Equal(t, "call_abc", response.ToolCalls[0].ID)
+	assert.Equal(t, "search", response.ToolCalls[0].Name)
+	assert.Equal(t, "golang", response.ToolCalls[0].Arguments["query"])
+}
+
+func TestOpenAIAdapter_Call_MessageHistory(t *testing.T) {
+	var requestBody struct {
+		Messages []struct {
+			Role       string           `json:"role"`
+			Content    string           `json:"content"`
+			ToolCalls  []openAIToolCall `json:"tool_calls"`
+			ToolCallID string           `json:"tool_call_id"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"content": "It is sunny in Paris."}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewOpenAIAdapter("test-key", "gpt-4o-mini", 50, 0.7)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	response, err := adapter.Call(context.Background(), Prompt{
+		System: "You are a weather assistant.",
+		Messages: []ChatMessage{
+			{Role: ChatRoleUser, Content: "What's the weather in Paris?"},
+			{Role: ChatRoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: map[string]interface{}{"city": "Paris"}}}},
+			{Role: ChatRoleTool, Content: "sunny", ToolCallID: "call_1"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "It is sunny in Paris.", response.Content)
+
+	require.Len(t, requestBody.Messages, 4)
+	assert.Equal(t, ChatRoleSystem, requestBody.Messages[0].Role)
+	assert.Equal(t, ChatRoleUser, requestBody.Messages[1].Role)
+	require.Len(t, requestBody.Messages[2].ToolCalls, 1)
+	assert.Equal(t, "call_1", requestBody.Messages[2].ToolCalls[0].ID)
+	assert.JSONEq(t, `{"city":"Paris"}`, requestBody.Messages[2].ToolCalls[0].Function.Arguments)
+	assert.Equal(t, ChatRoleTool, requestBody.Messages[3].Role)
+	assert.Equal(t, "call_1", requestBody.Messages[3].ToolCallID)
+}
+
+func TestOpenAIAdapter_Stream(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{"content":", world"}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: {"id":"1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewOpenAIAdapter("test-key", "gpt-4o-mini", 50, 0.7)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	tokens, err := adapter.Stream(context.Background(), Prompt{User: "Say hello"})
+	require.NoError(t, err)
+
+	var received []Token
+	for token := range tokens {
+		require.NoError(t, token.Error)
+		received = append(received, token)
+	}
+
+	assert.Equal(t, true, requestBody["stream"])
+	require.Len(t, received, 3)
+	assert.Equal(t, "Hello", received[0].Content)
+	assert.Equal(t, 0, received[0].Index)
+	assert.Equal(t, ", world", received[1].Content)
+	assert.Equal(t, 1, received[1].Index)
+
+	final := received[2]
+	assert.Empty(t, final.Content)
+	assert.Equal(t, "stop", final.FinishReason)
+	require.NotNil(t, final.Usage)
+	assert.Equal(t, 8, final.Usage.TotalTokens)
+}
+
+func TestOpenAIAdapter_Stream_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n\n"))
+		w.(http.Flusher).Flush()
+		// Hold the stream open until the test finishes
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	adapter, err := NewOpenAIAdapter("test-key", "gpt-4o-mini", 50, 0.7)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens, err := adapter.Stream(ctx, Prompt{User: "Say hello"})
+	require.NoError(t, err)
+
+	first := <-tokens
+	assert.Equal(t, "first", first.Content)
+	cancel()
+
+	// The channel must close once the context is cancelled
+	for token := range tokens {
+		assert.Empty(t, token.FinishReason, "no final token should be sent after cancellation")
+	}
+}
+
+func TestOpenAICompatibleAdapter_Call(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"), "no bearer token should be sent without an API key")
+		assert.Equal(t, "my-app", r.Header.Get("X-Title"))
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"content": "Hi from vLLM"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAICompatibleAdapter(OpenAICompatibleAdapterOptions{Model: "m"})
+	assert.Error(t, err, "a base URL is required")
+
+	adapter, err := NewOpenAICompatibleAdapter(OpenAICompatibleAdapterOptions{
+		BaseURL: server.URL + "/v1/",
+		Model:   "meta-llama/Llama-3.1-8B-Instruct",
+		Headers: map[string]string{"X-Title": "my-app"},
+	})
+	require.NoError(t, err)
+
+	response, err := adapter.Call(context.Background(), Prompt{User: "Hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi from vLLM", response.Content)
+	assert.Equal(t, "meta-llama/Llama-3.1-8B-Instruct", requestBody["model"])
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// openAIResponseFormat is the response_format object of OpenAI-style chat APIs.
+type openAIResponseFormat struct {
+	Type       string                `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *openAIJSONSchemaSpec `json:"json_schema,omitempty"`
+}
+
+// openAIJSONSchemaSpec names the schema a "json_schema" response must follow.
+type openAIJSONSchemaSpec struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"`
+}
+
+// toOpenAIResponseFormat converts a ResponseFormat to the OpenAI wire format.
+// It returns nil for free text so the field is omitted from requests.
+func toOpenAIResponseFormat(format *ResponseFormat) *openAIResponseFormat {
+	if format == nil || format.Type == "" || format.Type == ResponseFormatText {
+		return nil
+	}
+	if format.Type != ResponseFormatJSONSchema {
+		return &openAIResponseFormat{Type: string(format.Type)}
+	}
+	name := format.Name
+	if name == "" {
+		name = "response" // The API requires a name
+	}
+	return &openAIResponseFormat{
+		Type:       string(ResponseFormatJSONSchema),
+		JSONSchema: &openAIJSONSchemaSpec{Name: name, Schema: format.Schema, Strict: format.Strict},
+	}
+}
+
+// applyOpenAIParameters adds the optional sampling and format parameters shared by
+// OpenAI-style chat completion APIs to a request body.
+func applyOpenAIParameters(body map[string]interface{}, params ModelParameters) {
+	if params.TopP != nil {
+		body["top_p"] = *params.TopP
+	}
+	if len(params.StopSequences) > 0 {
+		body["stop"] = params.StopSequences
+	}
+	if params.PresencePenalty != nil {
+		body["presence_penalty"] = *params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		body["frequency_penalty"] = *params.FrequencyPenalty
+	}
+	if params.Seed != nil {
+		body["seed"] = *params.Seed
+	}
+	if format := toOpenAIResponseFormat(params.ResponseFormat); format != nil {
+		body["response_format"] = format
+	}
+}
+
+// ollamaFormat converts a ResponseFormat to the value of Ollama's "format" field:
+// "json" for JSON mode or the schema itself for structured outputs.
+func ollamaFormat(format *ResponseFormat) interface{} {
+	if format == nil {
+		return nil
+	}
+	switch format.Type {
+	case ResponseFormatJSONObject:
+		return "json"
+	case ResponseFormatJSONSchema:
+		if format.Schema == nil {
+			return "json"
+		}
+		return format.Schema
+	default:
+		return nil
+	}
+}
+
+// jsonFormatInstruction describes a ResponseFormat in words, for providers that have no
+// native JSON mode and must be asked through the system prompt. It returns "" for free text.
+func jsonFormatInstruction(format *ResponseFormat) (string, error) {
+	if format == nil {
+		return "", nil
+	}
+	switch format.Type {
+	case ResponseFormatJSONObject:
+		return "Respond with a single valid JSON object and nothing else: no prose and no code fences.", nil
+	case ResponseFormatJSONSchema:
+		schema, err := json.Marshal(format.Schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal response schema: %w", err)
+		}
+		return "Respond with a single valid JSON object that conforms to this JSON schema, and nothing else: no prose and no code fences.\nSchema: " + string(schema), nil
+	default:
+		return "", nil
+	}
+}
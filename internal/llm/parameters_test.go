@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fullParameters() ModelParameters {
+	seed := int64(42)
+	return ModelParameters{
+		TopP:             floatPtr(0.9),
+		StopSequences:    []string{"END"},
+		PresencePenalty:  floatPtr(0.5),
+		FrequencyPenalty: floatPtr(0.25),
+		Seed:             &seed,
+		ResponseFormat: &ResponseFormat{
+			Type:   ResponseFormatJSONSchema,
+			Name:   "answer",
+			Schema: map[string]interface{}{"type": "object"},
+			Strict: true,
+		},
+	}
+}
+
+// assertOpenAIParameters checks the optional parameters of an OpenAI-style request body.
+func assertOpenAIParameters(t *testing.T, requestBody map[string]interface{}) {
+	assert.InDelta(t, 0.9, requestBody["top_p"], 1e-6)
+	assert.Equal(t, []interface{}{"END"}, requestBody["stop"])
+	assert.InDelta(t, 0.5, requestBody["presence_penalty"], 1e-6)
+	assert.InDelta(t, 0.25, requestBody["frequency_penalty"], 1e-6)
+	assert.Equal(t, float64(42), requestBody["seed"])
+
+	format, ok := requestBody["response_format"].(map[string]interface{})
+	require.True(t, ok, "response_format should be sent in the request")
+	assert.Equal(t, "json_schema", format["type"])
+	schema := format["json_schema"].(map[string]interface{})
+	assert.Equal(t, "answer", schema["name"])
+	assert.Equal(t, true, schema["strict"])
+	assert.Equal(t, map[string]interface{}{"type": "object"}, schema["schema"])
+}
+
+func TestOpenAIAdapter_Call_Parameters(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBody = nil
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"content": "{}"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewOpenAIAdapter("test-key", "gpt-4o-mini", 50, 0.7)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	_, err = adapter.Call(context.Background(), Prompt{User: "Answer in JSON", Parameters: fullParameters()})
+	require.NoError(t, err)
+	assertOpenAIParameters(t, requestBody)
+
+	// Unset parameters are omitted so the provider's defaults apply
+	_, err = adapter.Call(context.Background(), Prompt{User: "Hello"})
+	require.NoError(t, err)
+	for _, key := range []string{"top_p", "stop", "presence_penalty", "frequency_penalty", "seed", "response_format"} {
+		assert.NotContains(t, requestBody, key)
+	}
+}
+
+func TestAzureOpenAIAdapter_Call_Parameters(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, azureAPIVersion, r.URL.Query().Get("api-version"))
+		requestBody = nil
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{}"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewAzureOpenAIAdapter(AzureOpenAIAdapterOptions{
+		Endpoint:            server.URL,
+		APIKey:              "test-key",
+		ChatDeployment:      "chat",
+		EmbeddingDeployment: "embed",
+	})
+	require.NoError(t, err)
+
+	_, err = adapter.Call(context.Background(), Prompt{User: "Answer in JSON", Parameters: fullParameters()})
+	require.NoError(t, err)
+	assertOpenAIParameters(t, requestBody)
+}
+
+func TestOllamaAdapter_Call_Parameters(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBody = nil
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message": {"content": "{}"}}`))
+	}))
+	defer server.Close()
+
+	adapter := &OllamaAdapter{
+		baseURL:     server.URL,
+		model:       "llama3.2:latest",
+		maxTokens:   100,
+		temperature: 0.7,
+	}
+
+	_, err := adapter.Call(context.Background(), Prompt{User: "Answer in JSON", Parameters: fullParameters()})
+	require.NoError(t, err)
+
+	options, ok := requestBody["options"].(map[string]interface{})
+	require.True(t, ok, "options should be sent in the request")
+	assert.InDelta(t, 0.9, options["top_p"], 1e-6)
+	assert.Equal(t, []interface{}{"END"}, options["stop"])
+	assert.InDelta(t, 0.5, options["presence_penalty"], 1e-6)
+	assert.InDelta(t, 0.25, options["frequency_penalty"], 1e-6)
+	assert.Equal(t, float64(42), options["seed"])
+	assert.Equal(t, map[string]interface{}{"type": "object"}, requestBody["format"], "the schema should be sent as the format")
+
+	_, err = adapter.Call(context.Background(), Prompt{
+		User:       "Answer in JSON",
+		Parameters: ModelParameters{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "json", requestBody["format"])
+}
+
+func TestAnthropicAdapter_Call_Parameters(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBody = nil
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content": [{"type": "text", "text": "{}"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewAnthropicAdapter("test-key", "claude-test", 256, 0.5)
+	require.NoError(t, err)
+	adapter.baseURL = server.URL
+
+	_, err = adapter.Call(context.Background(), Prompt{System: "Be terse.", User: "Answer in JSON", Parameters: fullParameters()})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.9, requestBody["top_p"], 1e-6)
+	assert.Equal(t, []interface{}{"END"}, requestBody["stop_sequences"])
+	for _, key := range []string{"presence_penalty", "frequency_penalty", "seed", "response_format"} {
+		assert.NotContains(t, requestBody, key, "Anthropic does not support %s", key)
+	}
+	system, _ := requestBody["system"].(string)
+	assert.Contains(t, system, "Be terse.")
+	assert.Contains(t, system, `{"type":"object"}`, "JSON mode should be requested through the system prompt")
+}
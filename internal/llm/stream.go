@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// chatCompletionsStreamChunk is a single SSE data payload from the OpenAI-style
+// Chat Completions API (OpenAI and Azure OpenAI).
+type chatCompletionsStreamChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role      string                  `json:"role,omitempty"`       // Usually only present in the first chunk for assistant
+			Content   string                  `json:"content,omitempty"`    // The token delta
+			ToolCalls []streamedToolCallDelta `json:"tool_calls,omitempty"` // Fragments of the tool calls being made
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason,omitempty"` // Present in the last chunk for a choice
+	} `json:"choices"`
+	Usage *struct { // Usually nil until the very end, sometimes in a separate final chunk
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// streamedToolCallDelta is a fragment of a streamed tool call. The first fragment of a call
+// carries its ID and function name; later fragments append to its arguments.
+type streamedToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// sendToken delivers a token unless ctx is cancelled first. It reports whether the token was sent.
+func sendToken(ctx context.Context, ch chan<- Token, token Token) bool {
+	select {
+	case ch <- token:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// readChatCompletionsStream consumes an OpenAI-style SSE body and forwards content deltas
+// to ch. Tool call fragments are accumulated by index. Once the stream ends it sends a final
+// token with the finish reason, the tool calls and, when the server reported it, usage. The
+// caller owns ch and body.
+func readChatCompletionsStream(ctx context.Context, body io.Reader, ch chan<- Token) {
+	index := 0
+	finishReason := ""
+	var usage *UsageStats
+	var toolCalls []openAIToolCall
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue // Skip blank separators and SSE comments
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !strings.HasPrefix(line, "data:") {
+			continue // Ignore other SSE fields such as event: or id:
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionsStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			sendToken(ctx, ch, Token{Index: index, Error: fmt.Errorf("stream decode error: %w", err)})
+			return
+		}
+
+		if chunk.Usage != nil {
+			usage = &UsageStats{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		choice := chunk.Choices[0]
+		if choice.FinishReason != nil {
+			finishReason = *choice.FinishReason
+		}
+		for _, delta := range choice.Delta.ToolCalls {
+			for len(toolCalls) <= delta.Index {
+				toolCalls = append(toolCalls, openAIToolCall{Type: "function"})
+			}
+			call := &toolCalls[delta.Index]
+			if delta.ID != "" {
+				call.ID = delta.ID
+			}
+			if delta.Type != "" {
+				call.Type = delta.Type
+			}
+			call.Function.Name += delta.Function.Name
+			call.Function.Arguments += delta.Function.Arguments
+		}
+		if choice.Delta.Content != "" {
+			if !sendToken(ctx, ch, Token{Content: choice.Delta.Content, Index: index}) {
+				return
+			}
+			index++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// Don't report read errors caused by cancellation
+		if ctx.Err() == nil {
+			sendToken(ctx, ch, Token{Index: index, Error: fmt.Errorf("stream read error: %w", err)})
+		}
+		return
+	}
+
+	calls, err := mapOpenAIToolCalls(toolCalls)
+	if err != nil {
+		sendToken(ctx, ch, Token{Index: index, Error: fmt.Errorf("stream decode error: %w", err)})
+		return
+	}
+	sendToken(ctx, ch, Token{Index: index, FinishReason: finishReason, ToolCalls: calls, Usage: usage})
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// --- Tool Calling Structs ---
+
+// openAITool is the function tool format shared by OpenAI, Azure OpenAI and Ollama chat APIs.
+type openAITool struct {
+	Type     string             `json:"type"` // Always "function"
+	Function openAIToolFunction `json:"function"`
+}
+
+// openAIToolFunction describes a callable function inside an openAITool.
+type openAIToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// openAIToolCall is a tool call returned by OpenAI-style chat APIs.
+// Arguments are encoded as a JSON string.
+type openAIToolCall struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Function openAIToolCallFunction `json:"function"`
+}
+
+// openAIToolCallFunction names the function and carries its JSON-encoded arguments.
+type openAIToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ollamaToolCall is a tool call returned by Ollama's /api/chat.
+// Unlike OpenAI, arguments are a JSON object and calls carry no ID.
+type ollamaToolCall struct {
+	Function ollamaToolCallFunction `json:"function"`
+}
+
+// ollamaToolCallFunction names the function and carries its decoded arguments.
+type ollamaToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// mapToolDefinitions converts tool definitions to the OpenAI-style tools array.
+// It returns nil when no tools are given so the field is omitted from requests.
+func mapToolDefinitions(tools []ToolDefinition) []openAITool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	apiTools := make([]openAITool, len(tools))
+	for i, tool := range tools {
+		params := tool.Parameters
+		if params == nil {
+			// The APIs require a parameters schema even for argument-less functions
+			params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		apiTools[i] = openAITool{
+			Type: "function",
+			Function: openAIToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  params,
+			},
+		}
+	}
+	return apiTools
+}
+
+// mapOpenAIToolCalls converts OpenAI-style tool calls into ToolCalls, decoding the JSON arguments.
+func mapOpenAIToolCalls(calls []openAIToolCall) ([]ToolCall, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	toolCalls := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		args := make(map[string]interface{})
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("failed to decode arguments for tool call %s: %w", call.Function.Name, err)
+			}
+		}
+		toolCalls[i] = ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: args,
+		}
+	}
+	return toolCalls, nil
+}
+
+// mapOllamaToolCalls converts Ollama tool calls into ToolCalls, assigning positional IDs.
+func mapOllamaToolCalls(calls []ollamaToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	toolCalls := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		args := call.Function.Arguments
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+		toolCalls[i] = ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: args,
+		}
+	}
+	return toolCalls
+}
+
+// toOpenAIToolCalls encodes ToolCalls from an assistant message back into the OpenAI wire format.
+func toOpenAIToolCalls(calls []ToolCall) ([]openAIToolCall, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	apiCalls := make([]openAIToolCall, len(calls))
+	for i, call := range calls {
+		args := call.Arguments
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode arguments for tool call %s: %w", call.Name, err)
+		}
+		apiCalls[i] = openAIToolCall{
+			ID:   call.ID,
+			Type: "function",
+			Function: openAIToolCallFunction{
+				Name:      call.Name,
+				Arguments: string(encoded),
+			},
+		}
+	}
+	return apiCalls, nil
+}
+
+// toOllamaToolCalls encodes ToolCalls from an assistant message into Ollama's wire format.
+func toOllamaToolCalls(calls []ToolCall) []ollamaToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	apiCalls := make([]ollamaToolCall, len(calls))
+	for i, call := range calls {
+		apiCalls[i] = ollamaToolCall{
+			Function: ollamaToolCallFunction{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		}
+	}
+	return apiCalls
+}
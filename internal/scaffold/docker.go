@@ -257,6 +257,13 @@ AZURE_OPENAI_DEPLOYMENT=your-deployment-name
 # OpenAI
 OPENAI_API_KEY=your-openai-api-key
 
+# Anthropic
+# ANTHROPIC_API_KEY=your-anthropic-api-key
+
+# OpenAI-compatible endpoint (vLLM, LM Studio, llama.cpp server, OpenRouter)
+# OPENAI_COMPATIBLE_BASE_URL=http://localhost:8000/v1
+# OPENAI_COMPATIBLE_API_KEY=your-api-key
+
 # Embedding Provider Configuration
 # For OpenAI embeddings (if using OpenAI embedding provider)
 # OPENAI_API_KEY=your-openai-api-key
@@ -286,6 +293,13 @@ AZURE_OPENAI_DEPLOYMENT=your-deployment-name
 # OpenAI
 OPENAI_API_KEY=your-openai-api-key
 
+# Anthropic
+# ANTHROPIC_API_KEY=your-anthropic-api-key
+
+# OpenAI-compatible endpoint (vLLM, LM Studio, llama.cpp server, OpenRouter)
+# OPENAI_COMPATIBLE_BASE_URL=http://localhost:8000/v1
+# OPENAI_COMPATIBLE_API_KEY=your-api-key
+
 # Embedding Provider Configuration
 # For OpenAI embeddings (if using OpenAI embedding provider)
 # OPENAI_API_KEY=your-openai-api-key
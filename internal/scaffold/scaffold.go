@@ -525,6 +525,17 @@ timeout_seconds = 30
 base_url = "http://localhost:11434"
 model = "llama2"
 
+[providers.anthropic]
+# API key will be read from ANTHROPIC_API_KEY environment variable
+model = "claude-3-5-haiku-latest"
+
+[providers.openai-compatible]
+# Any server implementing the OpenAI Chat Completions API (vLLM, LM Studio, llama.cpp server, OpenRouter)
+# API key (optional) will be read from OPENAI_COMPATIBLE_API_KEY environment variable
+base_url = "http://localhost:8000/v1"
+model = "your-model-name"
+# headers = { "HTTP-Referer" = "https://your-app.example" }
+
 [providers.mock]
 # Mock provider for testing - no configuration needed
 `, config.Name, config.Provider)
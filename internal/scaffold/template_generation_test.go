@@ -157,6 +157,22 @@ func TestCreateAgentProjectModular(t *testing.T) {
 				}
 			}
 
+			// Agents send MCP tools natively instead of describing them in the prompt
+			if tt.config.MCPEnabled {
+				agentContent, err := os.ReadFile(filepath.Join(projectPath, "agent1.go"))
+				if err != nil {
+					t.Errorf("Failed to read agent1.go: %v", err)
+				} else {
+					agentStr := string(agentContent)
+					if !strings.Contains(agentStr, "Tools:  toolDefinitions") || !strings.Contains(agentStr, "toolCalls := response.ToolCalls") {
+						t.Errorf("agent1.go does not use native tool calling")
+					}
+					if strings.Contains(agentStr, "FormatToolsPromptForLLM") {
+						t.Errorf("agent1.go still adds the text tool protocol to the prompt")
+					}
+				}
+			}
+
 			// Clean up for next test
 			os.RemoveAll(projectPath)
 		})
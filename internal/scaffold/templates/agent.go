@@ -80,13 +80,13 @@ func (a *{{.Agent.DisplayName}}Handler) Run(ctx context.Context, event agenticgo
 	systemPrompt = ` + "`{{.SystemPrompt}}`" + `
 	{{end}}
 	
-	// Get available MCP tools to include in prompt
-	var toolsPrompt string
+	// Get available MCP tools; they are sent to the LLM as native tool definitions
+	var toolDefinitions []agenticgokit.ToolDefinition
 	mcpManager := agenticgokit.GetMCPManager()
 	if mcpManager != nil {
 		availableTools := mcpManager.GetAvailableTools()
 		logger.Debug().Str("agent", "{{.Agent.Name}}").Int("tool_count", len(availableTools)).Msg("MCP Tools discovered")
-		toolsPrompt = agenticgokit.FormatToolsPromptForLLM(availableTools)
+		toolDefinitions = agenticgokit.ToolDefinitionsFromMCP(availableTools)
 	} else {
 		logger.Warn().Str("agent", "{{.Agent.Name}}").Msg("MCP Manager is not available")
 	}
@@ -157,9 +157,8 @@ func (a *{{.Agent.DisplayName}}Handler) Run(ctx context.Context, event agenticgo
 	}
 	{{end}}
 	
-	// Create initial LLM prompt with available tools information
+	// Create initial LLM prompt; available tools travel in prompt.Tools
 	userPrompt := fmt.Sprintf("User query: %v", inputToProcess)
-	userPrompt += toolsPrompt
 	{{if .Config.MemoryEnabled}}
 	userPrompt += memoryContext
 	{{end}}
@@ -167,6 +166,7 @@ func (a *{{.Agent.DisplayName}}Handler) Run(ctx context.Context, event agenticgo
 	prompt := agenticgokit.Prompt{
 		System: systemPrompt,
 		User:   userPrompt,
+		Tools:  toolDefinitions,
 	}
 	
 	// Debug: Log the full prompt being sent to LLM
@@ -180,8 +180,8 @@ func (a *{{.Agent.DisplayName}}Handler) Run(ctx context.Context, event agenticgo
 	
 	logger.Debug().Str("agent", "{{.Agent.Name}}").Str("response", response.Content).Msg("Initial LLM response received")
 	
-	// Parse LLM response for tool calls using core function
-	toolCalls := agenticgokit.ParseLLMToolCalls(response.Content)
+	// Tools were sent natively, so the LLM requests them in response.ToolCalls
+	toolCalls := response.ToolCalls
 	var mcpResults []string
 	
 	// Debug: Log the LLM response to see tool call format
@@ -193,42 +193,35 @@ func (a *{{.Agent.DisplayName}}Handler) Run(ctx context.Context, event agenticgo
 		logger.Info().Str("agent", "{{.Agent.Name}}").Int("tool_calls", len(toolCalls)).Msg("Executing LLM-requested tools")
 		
 		for _, toolCall := range toolCalls {
-			if toolName, ok := toolCall["name"].(string); ok {
-				var args map[string]interface{}
-				if toolArgs, exists := toolCall["args"]; exists {
-					if argsMap, ok := toolArgs.(map[string]interface{}); ok {
-						args = argsMap
+			toolName := toolCall.Name
+			args := toolCall.Arguments
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			
+			logger.Info().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Interface("args", args).Msg("Executing tool as requested by LLM")
+			
+			// Execute tool using the global ExecuteMCPTool function
+			result, err := agenticgokit.ExecuteMCPTool(ctx, toolName, args)
+			if err != nil {
+				logger.Error().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Err(err).Msg("Tool execution failed")
+				mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' failed: %v", toolName, err))
+			} else {
+				if result.Success {
+					logger.Info().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Msg("Tool execution successful")
+					
+					// Format the result content
+					var resultContent string
+					if len(result.Content) > 0 {
+						resultContent = result.Content[0].Text
 					} else {
-						args = make(map[string]interface{})
+						resultContent = "Tool executed successfully but returned no content"
 					}
+					
+					mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' result: %s", toolName, resultContent))
 				} else {
-					args = make(map[string]interface{})
-				}
-				
-				logger.Info().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Interface("args", args).Msg("Executing tool as requested by LLM")
-				
-				// Execute tool using the global ExecuteMCPTool function
-				result, err := agenticgokit.ExecuteMCPTool(ctx, toolName, args)
-				if err != nil {
-					logger.Error().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Err(err).Msg("Tool execution failed")
-					mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' failed: %v", toolName, err))
-				} else {
-					if result.Success {
-						logger.Info().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Msg("Tool execution successful")
-						
-						// Format the result content
-						var resultContent string
-						if len(result.Content) > 0 {
-							resultContent = result.Content[0].Text
-						} else {
-							resultContent = "Tool executed successfully but returned no content"
-						}
-						
-						mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' result: %s", toolName, resultContent))
-					} else {
-						logger.Error().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Msg("Tool execution was not successful")
-						mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' was not successful", toolName))
-					}
+					logger.Error().Str("agent", "{{.Agent.Name}}").Str("tool_name", toolName).Msg("Tool execution was not successful")
+					mcpResults = append(mcpResults, fmt.Sprintf("Tool '%s' was not successful", toolName))
 				}
 			}
 		}
@@ -28,6 +28,7 @@ func main() {
 	logger.Info().Msg("Starting {{.Config.Name}} multi-agent system...")
 
 	messageFlag := flag.String("m", "", "Message to process")
+	resumeFlag := flag.String("resume", "", "Resume the checkpointed workflow of a session")
 	flag.Parse()
 
 	// Read provider from config
@@ -50,6 +51,11 @@ func main() {
 			fmt.Printf("  OPENAI_API_KEY=your-api-key\n")
 		case "ollama":
 			fmt.Printf("  Ollama should be running on localhost:11434\n")
+		case "anthropic":
+			fmt.Printf("  ANTHROPIC_API_KEY=your-api-key\n")
+		case "openai-compatible":
+			fmt.Printf("  Set base_url and model under [providers.openai-compatible] in agentflow.toml\n")
+			fmt.Printf("  OPENAI_COMPATIBLE_API_KEY=your-api-key (if the server requires one)\n")
 		}
 		os.Exit(1)
 	}
@@ -249,6 +255,30 @@ func main() {
 	}
 
 
+	if *resumeFlag != "" {
+		// Continue a sequential or loop workflow from its last checkpoint ([orchestration].checkpoint_dir)
+		resumer, ok := runner.(core.Resumer)
+		if !ok {
+			fmt.Printf("Error: this runner cannot resume checkpointed workflows\n")
+			os.Exit(1)
+		}
+		runner.Start(ctx)
+		result, err := resumer.Resume(ctx, *resumeFlag)
+		runner.Stop()
+		if err != nil {
+			logger.Error().Err(err).Str("session", *resumeFlag).Msg("Resume failed")
+			fmt.Printf("Error resuming session %s: %v\n", *resumeFlag, err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n=== Workflow Resumed and Completed ===\n")
+		if result.OutputState != nil {
+			for _, key := range result.OutputState.Keys() {
+				value, _ := result.OutputState.Get(key)
+				fmt.Printf("%s: %v\n", key, value)
+			}
+		}
+		return
+	}
 
 	var message string
 	if *messageFlag != "" {
@@ -267,17 +297,24 @@ func main() {
 	// Start the runner (non-blocking)
 	runner.Start(ctx)
 
+	sessionID := core.GenerateSessionID()
+	if config.Orchestration.CheckpointDir != "" {
+		fmt.Printf("Session %s (if the workflow fails, continue it with: agentcli resume %s)\n", sessionID, sessionID)
+	}
+
 	{{if .Agents}}
 	event := core.NewEvent("{{(index .Agents 0).Name}}", core.EventData{
 		"message": message,
 	}, map[string]string{
-		"route": "{{(index .Agents 0).Name}}",
+		"route":           "{{(index .Agents 0).Name}}",
+		core.SessionIDKey: sessionID,
 	})
 	{{else}}
 	event := core.NewEvent("user_request", core.EventData{
 		"message": message,
 	}, map[string]string{
-		"route": "user_request",
+		"route":           "user_request",
+		core.SessionIDKey: sessionID,
 	})
 	{{end}}
 